@@ -0,0 +1,56 @@
+package Player_Logic
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"velvet/config"
+)
+
+// Account deletion. Deleting an account soft-deletes it and signs the player out
+// everywhere; it can be restored until the grace period (ACCOUNT_DELETION_GRACE_SECONDS)
+// runs out, after which the purger removes it and its data for good.
+
+const (
+	// accountPurgeInterval is how often accounts past their grace period are purged
+	accountPurgeInterval = 10 * time.Minute
+	// accountPurgeBatch caps how many accounts one purge pass removes
+	accountPurgeBatch = 100
+)
+
+// DeleteAccount soft-deletes a player's account, removes them from their room, closes their
+// connection, and ends their resumable session
+func DeleteAccount(ctx context.Context, playerID string) (*config.AccountDeletion, error) {
+	deletion, err := config.RequestAccountDeletion(ctx, playerID, settings.Accounts.DeletionGrace)
+	if err != nil {
+		return nil, err
+	}
+
+	evictPlayer(playerID, "account_deleted", "Your account has been deleted")
+	revokeResumeToken(playerID)
+	forgetDataExport(playerID)
+	return deletion, nil
+}
+
+// RestoreAccount undoes DeleteAccount while the grace period lasts
+func RestoreAccount(ctx context.Context, playerID string) error {
+	return config.CancelAccountDeletion(ctx, playerID)
+}
+
+// purgeDeletedAccounts removes accounts whose grace period has passed
+func purgeDeletedAccounts(ctx context.Context) {
+	if config.DB == nil {
+		return
+	}
+
+	userIDs, err := config.DueAccountDeletions(ctx, accountPurgeBatch)
+	if err != nil {
+		slog.Error("Could not list accounts to purge", "error", err)
+		return
+	}
+	for _, userID := range userIDs {
+		if err := config.PurgeAccount(ctx, userID); err != nil {
+			slog.Error("Could not purge account", "user_id", userID, "error", err)
+		}
+	}
+}
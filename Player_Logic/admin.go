@@ -0,0 +1,217 @@
+package Player_Logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// Admin operations on live rooms and connections, used by the /admin API
+
+var (
+	ErrRoomNotFound       = errors.New("room not found")
+	ErrCannotCloseMain    = errors.New("the main room cannot be closed")
+	ErrPlayerNotConnected = errors.New("player is not connected or in a room")
+)
+
+// ConnectionInfo describes an open WebSocket connection
+type ConnectionInfo struct {
+	PlayerID        string            `json:"player_id"`
+	RoomID          string            `json:"room_id"`
+	RemoteAddr      string            `json:"remote_addr"`
+	ConnectedAt     time.Time         `json:"connected_at"`
+	Codec           string            `json:"codec"`
+	ProtocolVersion int               `json:"protocol_version"`
+	DeltaPositions  bool              `json:"delta_positions"`
+	IsService       bool              `json:"is_service"`
+	QueuedMessages  int               `json:"queued_messages"` // Frames waiting in the send buffer
+	QueueCapacity   int               `json:"queue_capacity"`
+	RequestID       string            `json:"request_id"` // Matches request_id in the connection's log lines
+	RTTMs           int               `json:"rtt_ms"`     // Smoothed round-trip time, 0 until the first pong
+	Quality         ConnectionQuality `json:"quality"`
+}
+
+// info snapshots the connection's details
+func (c *Connection) info() ConnectionInfo {
+	c.mu.RLock()
+	roomID := c.roomID
+	c.mu.RUnlock()
+
+	return ConnectionInfo{
+		PlayerID:        c.playerID,
+		RoomID:          roomID,
+		RemoteAddr:      c.remoteAddr,
+		ConnectedAt:     c.connectedAt,
+		Codec:           c.codec.Name(),
+		ProtocolVersion: int(c.protocolVersion.Load()),
+		DeltaPositions:  c.deltaPositions.Load(),
+		IsService:       c.isService,
+		QueuedMessages:  len(c.send),
+		QueueCapacity:   cap(c.send),
+		RequestID:       c.requestID,
+		RTTMs:           c.rttMillis(),
+		Quality:         c.qualitySnapshot(),
+	}
+}
+
+// ListConnections returns every open connection, optionally only those in one room, by
+// player ID
+func ListConnections(roomID string) []ConnectionInfo {
+	connections := make([]ConnectionInfo, 0)
+	for _, conn := range connectionPool.snapshot() {
+		info := conn.info()
+		if roomID == "" || info.RoomID == roomID {
+			connections = append(connections, info)
+		}
+	}
+	sort.Slice(connections, func(i, j int) bool { return connections[i].PlayerID < connections[j].PlayerID })
+	return connections
+}
+
+// GetConnectionInfo returns a player's open connection
+func GetConnectionInfo(playerID string) (ConnectionInfo, bool) {
+	conn, exists := connectionPool.getConnection(playerID)
+	if !exists {
+		return ConnectionInfo{}, false
+	}
+	return conn.info(), true
+}
+
+// ListRoomPlayers returns everyone in a room, hidden service accounts included, by ID
+func (rm *RoomManager) ListRoomPlayers(roomID string) ([]Player, error) {
+	room := rm.getRoomByID(roomID)
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+
+	players := make([]Player, 0, len(room.Players))
+	for _, player := range room.Players {
+		players = append(players, Player{
+			ID:        player.ID,
+			Username:  player.Username,
+			RoomID:    player.RoomID,
+			Position:  player.Position,
+			IsActive:  player.IsActive,
+			LastSeen:  player.LastSeen,
+			IsService: player.IsService,
+			Language:  player.GetLanguage(),
+			Avatar:    player.GetAvatar(),
+		})
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].ID < players[j].ID })
+	return players, nil
+}
+
+// ForceRemovePlayer kicks a player out of their room and closes their connection
+func (rm *RoomManager) ForceRemovePlayer(actorID, playerID, reason string) error {
+	_, connected := connectionPool.getConnection(playerID)
+	if !connected && rm.GetPlayerRoom(playerID) == nil {
+		return ErrPlayerNotConnected
+	}
+	KickPlayer(actorID, playerID, reason)
+	slog.Info("Admin removed player", "player_id", playerID, "actor_id", actorID, "reason", reason)
+	return nil
+}
+
+// CloseRoom moves everyone in a room to the main room or one of its instances
+// (disconnecting anyone none of them can take), ends its game, and deletes it. Returns how
+// many players were moved.
+func (rm *RoomManager) CloseRoom(actorID, roomID, reason string) (int, error) {
+	room := rm.getRoomByID(roomID)
+	if room == nil {
+		return 0, ErrRoomNotFound
+	}
+	if room == rm.mainRoom {
+		return 0, ErrCannotCloseMain
+	}
+
+	if session := room.activeGame(); session != nil {
+		session.end("room_closed")
+	}
+
+	room.mu.RLock()
+	playerIDs := make([]string, 0, len(room.Players))
+	for id := range room.Players {
+		playerIDs = append(playerIDs, id)
+	}
+	room.mu.RUnlock()
+
+	moved := 0
+	for _, playerID := range playerIDs {
+		if conn, exists := connectionPool.getConnection(playerID); exists {
+			conn.sendMessage(WebSocketMessage{
+				Type:      "room_closed",
+				PlayerID:  "system",
+				RoomID:    roomID,
+				Text:      reason,
+				Timestamp: time.Now().UnixMilli(),
+			})
+		}
+
+		oldRoom, newRoom, err := rm.transferToMain(context.Background(), playerID)
+		if err != nil {
+			slog.Info("Could not move player out of closing room", "player_id", playerID, "room_id", roomID, "error", err)
+			KickPlayer(actorID, playerID, reason)
+			continue
+		}
+		moveConnectionToRoom(playerID, oldRoom, newRoom)
+		moved++
+	}
+
+	rm.mu.Lock()
+	if rm.rooms[roomID] == room {
+		delete(rm.rooms, roomID)
+		rm.forgetInstanceLocked(room)
+		room.stopHub()
+	}
+	rm.stats.mu.Lock()
+	rm.stats.currentActiveRooms = int32(len(rm.rooms))
+	rm.stats.mu.Unlock()
+	rm.mu.Unlock()
+
+	slog.Info("Admin closed room", "room_id", roomID, "actor_id", actorID, "moved", moved, "reason", reason)
+	return moved, nil
+}
+
+// AdminMutePlayer mutes a player in a room without the host/moderator check, for admins
+// acting on reports
+func (rm *RoomManager) AdminMutePlayer(actorID, roomID, targetID string, duration time.Duration) error {
+	room := rm.getRoomByID(roomID)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	if duration <= 0 || duration > MaxMuteDuration {
+		return fmt.Errorf("mute duration must be between 1 and %d minutes", int(MaxMuteDuration.Minutes()))
+	}
+
+	room.mu.Lock()
+	if room.Muted == nil {
+		room.Muted = make(map[string]time.Time)
+	}
+	room.Muted[targetID] = time.Now().Add(duration)
+	room.mu.Unlock()
+
+	slog.Info("Admin muted player", "player_id", targetID, "room_id", roomID, "duration", duration.String(), "actor_id", actorID)
+	recordMuteAudit(actorID, targetID, roomID, duration)
+	if current := rm.GetPlayerRoom(targetID); current == room {
+		NotifyMuteChanged(room, targetID)
+	}
+	return nil
+}
+
+// NotifyModerationWarning pushes a moderator's warning to a player if they're online
+func NotifyModerationWarning(playerID, text string) {
+	sendToPlayer(playerID, WebSocketMessage{
+		Type:      "moderation_warning",
+		PlayerID:  "system",
+		Text:      text,
+		System:    true,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
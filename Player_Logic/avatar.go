@@ -0,0 +1,55 @@
+package Player_Logic
+
+import (
+	"context"
+	"time"
+	"velvet/config"
+)
+
+// loadAccount reads the connecting player's account: its username, for private messages
+// addressed by name, and their saved avatar (or the default one) if they don't have one yet
+func (c *Connection) loadAccount(ctx context.Context, player *Player) {
+	avatar := config.DefaultAvatar()
+	user, err := config.GetUserStore().Get(ctx, player.ID)
+	if err != nil {
+		config.Logger(ctx).Debug("Using default avatar", "player_id", player.ID, "error", err)
+	} else {
+		c.accountName.Store(&user.Username)
+		if user.Avatar != nil {
+			avatar = *user.Avatar
+		}
+	}
+	if player.GetAvatar() == nil {
+		player.SetAvatar(avatar)
+	}
+}
+
+// SetAccountName records a renamed account's username on the player's live connection on
+// this instance, if any, so private messages find them by the new name
+func SetAccountName(playerID, username string) {
+	if conn, exists := connectionPool.getConnection(playerID); exists {
+		conn.accountName.Store(&username)
+	}
+}
+
+// UpdateAvatar changes an online player's avatar and shows it to their room. Saving it
+// is up to the caller.
+func UpdateAvatar(playerID string, avatar config.Avatar) {
+	rm := GetRoomManager()
+	player := rm.GetPlayer(playerID)
+	if player == nil {
+		return
+	}
+	player.SetAvatar(avatar)
+
+	room := rm.GetPlayerRoom(playerID)
+	if room == nil || player.Hidden {
+		return
+	}
+	go broadcastToRoomAsync(room, playerID, WebSocketMessage{
+		Type:      "avatar_updated",
+		PlayerID:  playerID,
+		Avatar:    &avatar,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
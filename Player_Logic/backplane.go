@@ -0,0 +1,156 @@
+package Player_Logic
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"velvet/config"
+)
+
+// Backplane for running several instances. Every room broadcast is also published on the
+// room's subject through the configured broker (see config.InitBroker) and each instance
+// delivers it to its own connected players in that room. Messages for a single player
+// (friend notifications) go to the player's subject and are delivered wherever they're
+// connected. Global chat has its own subject for every instance, and each guild has one
+// for its chat. With the in-memory broker the server runs as a single instance.
+//
+// Room state (positions, channels, mutes) stays per instance; remote broadcasts are
+// filtered with what the receiving instance knows (blocks, channel membership). Position
+// updates from other instances skip area-of-interest and delta encoding.
+const (
+	roomSubjectPrefix   = "velvet.room."
+	playerSubjectPrefix = "velvet.player."
+)
+
+// backplaneEnvelope is a broadcast published to other instances
+type backplaneEnvelope struct {
+	Origin  string           `json:"origin"`
+	RoomID  string           `json:"room_id,omitempty"`
+	Exclude string           `json:"exclude,omitempty"`
+	Message WebSocketMessage `json:"message"`
+}
+
+var (
+	instanceID     string
+	instanceIDOnce sync.Once
+)
+
+// getInstanceID identifies this process so it can ignore its own room broadcasts
+func getInstanceID() string {
+	instanceIDOnce.Do(func() {
+		raw := make([]byte, 8)
+		if _, err := rand.Read(raw); err != nil {
+			slog.Warn("Could not generate instance ID", "error", err)
+		}
+		instanceID = hex.EncodeToString(raw)
+	})
+	return instanceID
+}
+
+// StartBackplane subscribes to room and player subjects on the configured broker
+func StartBackplane() error {
+	broker := config.GetBroker()
+	if err := broker.SubscribePrefix(roomSubjectPrefix, handleRoomSubject); err != nil {
+		return err
+	}
+	if err := broker.SubscribePrefix(playerSubjectPrefix, handlePlayerSubject); err != nil {
+		return err
+	}
+	if err := broker.SubscribePrefix(globalChatSubject, handleGlobalChatSubject); err != nil {
+		return err
+	}
+	if err := broker.SubscribePrefix(guildChatSubjectPrefix, handleGuildChatSubject); err != nil {
+		return err
+	}
+	if err := broker.SubscribePrefix(guildMemberSubjectPrefix, handleGuildMemberSubject); err != nil {
+		return err
+	}
+	slog.Info("Backplane started", "broker", broker.Name(), "instance_id", getInstanceID())
+	return nil
+}
+
+// publishEnvelope marshals and publishes an envelope, logging failures
+func publishEnvelope(subject string, envelope backplaneEnvelope) {
+	envelope.Origin = getInstanceID()
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		slog.Error("Error marshaling backplane message", "error", err)
+		return
+	}
+	if err := config.GetBroker().Publish(subject, data); err != nil {
+		slog.Warn("Backplane publish failed", "subject", subject, "error", err)
+	}
+}
+
+// publishRoomBroadcast sends a local broadcast to the other instances
+func publishRoomBroadcast(roomID, excludePlayerID string, message WebSocketMessage) {
+	publishEnvelope(roomSubjectPrefix+roomID, backplaneEnvelope{
+		RoomID:  roomID,
+		Exclude: excludePlayerID,
+		Message: message,
+	})
+}
+
+// sendToPlayer delivers a message to a player on whichever instance they're connected to
+func sendToPlayer(playerID string, message WebSocketMessage) {
+	if conn, exists := connectionPool.getConnection(playerID); exists {
+		conn.sendMessage(message)
+		return
+	}
+	publishEnvelope(playerSubjectPrefix+playerID, backplaneEnvelope{Message: message})
+}
+
+// decodeEnvelope parses a broker message, skipping this instance's own publications
+func decodeEnvelope(subject string, data []byte) (backplaneEnvelope, bool) {
+	var envelope backplaneEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		slog.Warn("Malformed backplane message", "subject", subject, "error", err)
+		return envelope, false
+	}
+	return envelope, envelope.Origin != getInstanceID()
+}
+
+// handlePlayerSubject delivers a remote instance's message to a player connected here
+func handlePlayerSubject(subject string, data []byte) {
+	envelope, ok := decodeEnvelope(subject, data)
+	if !ok {
+		return
+	}
+	if conn, exists := connectionPool.getConnection(strings.TrimPrefix(subject, playerSubjectPrefix)); exists {
+		conn.sendMessage(envelope.Message)
+	}
+}
+
+// handleRoomSubject hands another instance's broadcast to players connected here
+func handleRoomSubject(subject string, data []byte) {
+	envelope, ok := decodeEnvelope(subject, data)
+	if !ok {
+		return
+	}
+	if envelope.RoomID == "" {
+		envelope.RoomID = strings.TrimPrefix(subject, roomSubjectPrefix)
+	}
+
+	room := GetRoomManager().getRoomByID(envelope.RoomID)
+	if room == nil {
+		return // Nobody in this room on this instance
+	}
+
+	message := envelope.Message
+	var skip func(string) bool
+	switch message.Type {
+	case "chat_message":
+		channel := message.Channel
+		if channel == "" {
+			channel = DefaultChannel
+		}
+		skip = combineSkips(room.channelAudience(channel), blockedBy(room, message.PlayerID))
+	case "typing_start", "typing_stop", "emote":
+		skip = blockedBy(room, message.PlayerID)
+	}
+
+	deliverToRoom(room, envelope.Exclude, message, skip)
+}
@@ -0,0 +1,59 @@
+package Player_Logic
+
+import (
+	"sync"
+	"time"
+)
+
+// When tick loops are off (ROOM_TICK_RATE=0), position updates are still coalesced: a
+// room's moves collect for up to BatchTimeout, or until BatchSize players have moved, and
+// then go out as one snapshot per client with only the newest position of each player.
+
+var messageBatcher = &MessageBatcher{batches: make(map[string]*MessageBatch)}
+
+// MessageBatch holds one room's players who moved since its batch was started
+type MessageBatch struct {
+	moved map[string]bool
+	timer *time.Timer
+}
+
+// MessageBatcher manages message batching per room
+type MessageBatcher struct {
+	batches map[string]*MessageBatch // By room ID
+	mu      sync.Mutex
+}
+
+// queuePosition adds a player's move to their room's batch, starting one if needed
+func (mb *MessageBatcher) queuePosition(room *Room, playerID string) {
+	mb.mu.Lock()
+	batch, exists := mb.batches[room.ID]
+	if !exists {
+		batch = &MessageBatch{moved: make(map[string]bool)}
+		mb.batches[room.ID] = batch
+		batch.timer = time.AfterFunc(BatchTimeout, func() { mb.flush(room, batch) })
+	}
+	batch.moved[playerID] = true
+	full := len(batch.moved) >= BatchSize
+	mb.mu.Unlock()
+
+	if full {
+		mb.flush(room, batch)
+	}
+}
+
+// flush sends a batch unless its timer or a full batch already did
+func (mb *MessageBatcher) flush(room *Room, batch *MessageBatch) {
+	mb.mu.Lock()
+	if mb.batches[room.ID] != batch {
+		mb.mu.Unlock()
+		return
+	}
+	delete(mb.batches, room.ID)
+	batch.timer.Stop()
+	mb.mu.Unlock()
+
+	room.mu.Lock()
+	frames := room.movedFramesLocked(batch.moved, 0, time.Now())
+	room.mu.Unlock()
+	room.sendSnapshot(frames)
+}
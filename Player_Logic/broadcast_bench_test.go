@@ -0,0 +1,133 @@
+package Player_Logic
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Benchmarks for the room broadcast path. Each compares the current code with the one it
+// replaced, kept here as a reference implementation:
+//
+//	go test ./Player_Logic -run '^$' -bench . -benchmem
+
+const (
+	benchRecipients = 20
+	benchSendBuffer = 1024
+)
+
+// benchConnections returns n connections with room in their send buffers for
+// benchSendBuffer frames
+func benchConnections(n int) []*Connection {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	conns := make([]*Connection, n)
+	for i := range conns {
+		conns[i] = &Connection{
+			playerID: "player-" + strconv.Itoa(i),
+			send:     make(chan []byte, benchSendBuffer),
+			codec:    JSONCodec,
+			logger:   logger,
+		}
+	}
+	return conns
+}
+
+// drainEvery empties the send buffers, off the clock, before they fill up, standing in for
+// writePumps that keep up with the room
+func drainEvery(b *testing.B, i int, conns []*Connection) {
+	if i == 0 || i%benchSendBuffer != 0 {
+		return
+	}
+	b.StopTimer()
+	for _, conn := range conns {
+		for len(conn.send) > 0 {
+			<-conn.send
+		}
+	}
+	b.StartTimer()
+}
+
+// BenchmarkRoomFanOut queues one encoded frame for every recipient in a room
+func BenchmarkRoomFanOut(b *testing.B) {
+	data := []byte(`{"type":"chat_message","player_id":"player-0","text":"hello"}`)
+
+	// Before synth-4349: a goroutine per recipient, joined with a WaitGroup
+	b.Run("goroutine_per_recipient", func(b *testing.B) {
+		conns := benchConnections(benchRecipients)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			drainEvery(b, i, conns)
+			var wg sync.WaitGroup
+			for _, conn := range conns {
+				wg.Add(1)
+				go func(c *Connection) {
+					defer wg.Done()
+					c.enqueue(data)
+				}(conn)
+			}
+			wg.Wait()
+		}
+	})
+
+	b.Run("direct", func(b *testing.B) {
+		conns := benchConnections(benchRecipients)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			drainEvery(b, i, conns)
+			for _, conn := range conns {
+				conn.enqueue(data)
+			}
+		}
+	})
+}
+
+// marshalMsgPackUnpooled is msgpackCodec.Marshal before synth-4350, with a new encoder and
+// buffer for every frame
+func marshalMsgPackUnpooled(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// BenchmarkMsgPackMarshal encodes a chat-sized message for msgpack clients
+func BenchmarkMsgPackMarshal(b *testing.B) {
+	message := WebSocketMessage{
+		Type:      "chat_message",
+		PlayerID:  "player-0",
+		Username:  "velvet",
+		Text:      "see you all at the fountain in five minutes",
+		Channel:   DefaultChannel,
+		Timestamp: time.Now().UnixMilli(),
+	}
+
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := marshalMsgPackUnpooled(message); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := MsgPackCodec.Marshal(message); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package Player_Logic
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	BroadcastJobKind       = "broadcast"
+	MaxBroadcastTextLength = 500
+	MaxBroadcastRooms      = 100
+)
+
+// BroadcastKinds are the message types admins can schedule
+var BroadcastKinds = map[string]bool{
+	"announcement":   true,
+	"event_reminder": true,
+}
+
+// ScheduledBroadcast is an admin message to deliver at a future time
+type ScheduledBroadcast struct {
+	Kind     string    `json:"kind"` // announcement or event_reminder
+	Text     string    `json:"text"`
+	RoomIDs  []string  `json:"room_ids,omitempty"`
+	Everyone bool      `json:"everyone"`
+	SendAt   time.Time `json:"send_at"`
+}
+
+// Validate checks the broadcast's target and content
+func (b *ScheduledBroadcast) Validate() error {
+	if !BroadcastKinds[b.Kind] {
+		return fmt.Errorf("kind must be announcement or event_reminder")
+	}
+	if b.Text == "" || len(b.Text) > MaxBroadcastTextLength {
+		return fmt.Errorf("text must be 1-%d characters", MaxBroadcastTextLength)
+	}
+	if b.Everyone == (len(b.RoomIDs) > 0) {
+		return fmt.Errorf("target either everyone or a list of room_ids")
+	}
+	if len(b.RoomIDs) > MaxBroadcastRooms {
+		return fmt.Errorf("too many rooms (max %d)", MaxBroadcastRooms)
+	}
+	return nil
+}
+
+// ScheduleBroadcast queues an admin broadcast on the scheduler
+func ScheduleBroadcast(b ScheduledBroadcast) (*ScheduledJob, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	return GetScheduler().Schedule(BroadcastJobKind, b.SendAt, b, func() {
+		deliverBroadcast(b)
+	}), nil
+}
+
+// deliverBroadcast sends a broadcast to its target rooms or to every connection
+func deliverBroadcast(b ScheduledBroadcast) {
+	message := WebSocketMessage{
+		Type:      b.Kind,
+		PlayerID:  "system",
+		Text:      b.Text,
+		System:    true,
+		Timestamp: time.Now().UnixMilli(),
+	}
+
+	if b.Everyone {
+		broadcastToAll(message)
+		slog.Info("Delivered broadcast to everyone", "kind", b.Kind)
+		return
+	}
+
+	rm := GetRoomManager()
+	for _, roomID := range b.RoomIDs {
+		room := rm.getRoomByID(roomID)
+		if room == nil {
+			slog.Info("Skipping broadcast for missing room", "kind", b.Kind, "room_id", roomID)
+			continue
+		}
+		broadcastToRoomAsync(room, "", message)
+	}
+	slog.Info("Delivered broadcast to rooms", "kind", b.Kind, "rooms", len(b.RoomIDs))
+}
+
+// broadcastToAll sends a message to every open connection
+func broadcastToAll(message WebSocketMessage) {
+	connectionPool.mu.RLock()
+	targets := make([]*Connection, 0, len(connectionPool.connections))
+	for _, conn := range connectionPool.connections {
+		targets = append(targets, conn)
+	}
+	connectionPool.mu.RUnlock()
+
+	for _, conn := range targets {
+		conn.sendMessage(message)
+	}
+}
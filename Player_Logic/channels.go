@@ -0,0 +1,374 @@
+package Player_Logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Chat channels
+const (
+	DefaultChannel     = "general"
+	HelpChannel        = "help"
+	MaxChannelsPerRoom = 10
+)
+
+var (
+	channelNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,23}$`)
+
+	ErrUnknownChannel  = errors.New("channel does not exist")
+	ErrNotInChannel    = errors.New("you have not joined this channel")
+	ErrChannelReadOnly = errors.New("only moderators can post in this channel")
+)
+
+// ChatChannel is a named chat stream within a room. Everyone in the room is implicitly in
+// the default channel; other channels must be joined.
+type ChatChannel struct {
+	Name           string          `json:"name"`
+	ModeratorsOnly bool            `json:"moderators_only"` // Everyone can read, only moderators post
+	InviteOnly     bool            `json:"invite_only"`     // Joining requires an invite from a moderator
+	Members        map[string]bool `json:"-"`
+	Invited        map[string]bool `json:"-"`
+}
+
+// ChannelInfo is the client view of a channel
+type ChannelInfo struct {
+	Name           string `json:"name"`
+	ModeratorsOnly bool   `json:"moderators_only"`
+	InviteOnly     bool   `json:"invite_only"`
+	Members        int    `json:"members"`
+	Joined         bool   `json:"joined"`
+}
+
+// channelSettings is the data payload of create_channel
+type channelSettings struct {
+	ModeratorsOnly bool `json:"moderators_only"`
+	InviteOnly     bool `json:"invite_only"`
+}
+
+// newChatChannel creates an empty channel
+func newChatChannel(name string, moderatorsOnly, inviteOnly bool) *ChatChannel {
+	return &ChatChannel{
+		Name:           name,
+		ModeratorsOnly: moderatorsOnly,
+		InviteOnly:     inviteOnly,
+		Members:        make(map[string]bool),
+		Invited:        make(map[string]bool),
+	}
+}
+
+// ensureChannelsLocked creates the default channels on first use (caller holds r.mu)
+func (r *Room) ensureChannelsLocked() {
+	if r.Channels != nil {
+		return
+	}
+	r.Channels = map[string]*ChatChannel{
+		DefaultChannel: newChatChannel(DefaultChannel, false, false),
+		HelpChannel:    newChatChannel(HelpChannel, false, false),
+	}
+}
+
+// isChannelMemberLocked reports whether a player receives a channel's messages (caller holds r.mu)
+func (r *Room) isChannelMemberLocked(channel *ChatChannel, playerID string) bool {
+	return channel.Name == DefaultChannel || channel.Members[playerID]
+}
+
+// ListChannels returns the room's channels from the player's point of view
+func (r *Room) ListChannels(playerID string) []ChannelInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ensureChannelsLocked()
+
+	channels := make([]ChannelInfo, 0, len(r.Channels))
+	for _, channel := range r.Channels {
+		members := len(channel.Members)
+		if channel.Name == DefaultChannel {
+			members = len(r.Players)
+		}
+		channels = append(channels, ChannelInfo{
+			Name:           channel.Name,
+			ModeratorsOnly: channel.ModeratorsOnly,
+			InviteOnly:     channel.InviteOnly,
+			Members:        members,
+			Joined:         r.isChannelMemberLocked(channel, playerID),
+		})
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Name < channels[j].Name })
+	return channels
+}
+
+// JoinChannel adds a player to a channel they're allowed into
+func (rm *RoomManager) JoinChannel(room *Room, playerID, name string) error {
+	isModerator := rm.canModerate(room, playerID)
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	room.ensureChannelsLocked()
+
+	channel, exists := room.Channels[name]
+	if !exists {
+		return ErrUnknownChannel
+	}
+	if channel.InviteOnly && !isModerator && !channel.Invited[playerID] {
+		return fmt.Errorf("channel %s is invite-only", name)
+	}
+	channel.Members[playerID] = true
+	return nil
+}
+
+// LeaveChannel removes a player from a channel (the default channel can't be left)
+func (rm *RoomManager) LeaveChannel(room *Room, playerID, name string) error {
+	if name == DefaultChannel {
+		return fmt.Errorf("cannot leave the %s channel", DefaultChannel)
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	room.ensureChannelsLocked()
+
+	channel, exists := room.Channels[name]
+	if !exists {
+		return ErrUnknownChannel
+	}
+	if !channel.Members[playerID] {
+		return ErrNotInChannel
+	}
+	delete(channel.Members, playerID)
+	return nil
+}
+
+// CreateChannel adds a channel to the room (host/moderators only); the creator joins it
+func (rm *RoomManager) CreateChannel(room *Room, actorID, name string, settings channelSettings) error {
+	if !rm.canModerate(room, actorID) {
+		return fmt.Errorf("only the room host or a moderator can create channels")
+	}
+	if !channelNamePattern.MatchString(name) {
+		return fmt.Errorf("channel names must be 1-24 lowercase letters, digits, or dashes")
+	}
+	if name == ZoneChannel {
+		return fmt.Errorf("channel name %s is reserved", name)
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	room.ensureChannelsLocked()
+
+	if _, exists := room.Channels[name]; exists {
+		return fmt.Errorf("channel %s already exists", name)
+	}
+	if len(room.Channels) >= MaxChannelsPerRoom {
+		return fmt.Errorf("rooms can have at most %d channels", MaxChannelsPerRoom)
+	}
+
+	channel := newChatChannel(name, settings.ModeratorsOnly, settings.InviteOnly)
+	channel.Members[actorID] = true
+	room.Channels[name] = channel
+
+	slog.Info("Channel created", "channel", name, "room_id", room.ID, "player_id", actorID)
+	return nil
+}
+
+// DeleteChannel removes a channel (host/moderators only); the default channels stay
+func (rm *RoomManager) DeleteChannel(room *Room, actorID, name string) error {
+	if !rm.canModerate(room, actorID) {
+		return fmt.Errorf("only the room host or a moderator can delete channels")
+	}
+	if name == DefaultChannel || name == HelpChannel {
+		return fmt.Errorf("cannot delete the %s channel", name)
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	room.ensureChannelsLocked()
+
+	if _, exists := room.Channels[name]; !exists {
+		return ErrUnknownChannel
+	}
+	delete(room.Channels, name)
+	return nil
+}
+
+// InviteToChannel lets a moderator invite a player into an invite-only channel
+func (rm *RoomManager) InviteToChannel(room *Room, actorID, targetID, name string) error {
+	if !rm.canModerate(room, actorID) {
+		return fmt.Errorf("only the room host or a moderator can invite to channels")
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	room.ensureChannelsLocked()
+
+	channel, exists := room.Channels[name]
+	if !exists {
+		return ErrUnknownChannel
+	}
+	channel.Invited[targetID] = true
+	return nil
+}
+
+// channelForPost checks that a player may post to a channel and returns a filter that
+// skips room members who aren't in it
+func (rm *RoomManager) channelForPost(room *Room, playerID, name string) (func(string) bool, error) {
+	isModerator := rm.canModerate(room, playerID)
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	room.ensureChannelsLocked()
+
+	channel, exists := room.Channels[name]
+	if !exists {
+		return nil, ErrUnknownChannel
+	}
+	if !room.isChannelMemberLocked(channel, playerID) {
+		return nil, ErrNotInChannel
+	}
+	if channel.ModeratorsOnly && !isModerator {
+		return nil, ErrChannelReadOnly
+	}
+
+	return channelAudienceLocked(channel), nil
+}
+
+// channelAudience returns a filter that skips players who can't read a channel. It works
+// on a snapshot of the members, so it's safe to run without the room lock.
+func (r *Room) channelAudience(name string) func(string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ensureChannelsLocked()
+
+	channel, exists := r.Channels[name]
+	if !exists {
+		return func(string) bool { return true }
+	}
+	return channelAudienceLocked(channel)
+}
+
+// channelAudienceLocked snapshots a channel's readers; nil means everyone in the room
+func channelAudienceLocked(channel *ChatChannel) func(string) bool {
+	if channel.Name == DefaultChannel {
+		return nil
+	}
+	members := make(map[string]bool, len(channel.Members))
+	for id := range channel.Members {
+		members[id] = true
+	}
+	return func(id string) bool { return !members[id] }
+}
+
+// handleChannelMessage processes join/leave/create/delete/invite/list channel requests
+func (c *Connection) handleChannelMessage(rm *RoomManager, message WebSocketMessage) {
+	room := rm.GetPlayerRoom(c.playerID)
+	if room == nil {
+		return
+	}
+
+	var err error
+	switch message.Type {
+	case "join_channel":
+		if err = rm.JoinChannel(room, c.playerID, message.Channel); err == nil {
+			c.notifyChannel(room, "channel_joined", message.Channel)
+		}
+	case "leave_channel":
+		if err = rm.LeaveChannel(room, c.playerID, message.Channel); err == nil {
+			c.notifyChannel(room, "channel_left", message.Channel)
+		}
+	case "create_channel":
+		var settings channelSettings
+		if len(message.Data) > 0 {
+			if err = json.Unmarshal(message.Data, &settings); err != nil {
+				err = fmt.Errorf("invalid channel settings")
+				break
+			}
+		}
+		err = rm.CreateChannel(room, c.playerID, message.Channel, settings)
+	case "delete_channel":
+		err = rm.DeleteChannel(room, c.playerID, message.Channel)
+	case "channel_invite":
+		if err = rm.InviteToChannel(room, c.playerID, message.TargetPlayerID, message.Channel); err == nil {
+			if conn, exists := connectionPool.getConnection(message.TargetPlayerID); exists {
+				conn.sendMessage(WebSocketMessage{
+					Type:      "channel_invited",
+					PlayerID:  c.playerID,
+					Channel:   message.Channel,
+					Timestamp: time.Now().UnixMilli(),
+				})
+			}
+		}
+	}
+
+	if err != nil {
+		c.sendMessage(WebSocketMessage{
+			Type:      "channel_error",
+			PlayerID:  "system",
+			Channel:   message.Channel,
+			Text:      err.Error(),
+			Timestamp: time.Now().UnixMilli(),
+		})
+		return
+	}
+
+	c.sendChannelList(room)
+}
+
+// notifyChannel tells a channel's members that this player joined or left it
+func (c *Connection) notifyChannel(room *Room, messageType, name string) {
+	room.mu.RLock()
+	var members map[string]bool
+	if channel, exists := room.Channels[name]; exists {
+		members = make(map[string]bool, len(channel.Members))
+		for id := range channel.Members {
+			members[id] = true
+		}
+	}
+	room.mu.RUnlock()
+
+	notice := WebSocketMessage{
+		Type:      messageType,
+		PlayerID:  c.playerID,
+		Channel:   name,
+		Timestamp: time.Now().UnixMilli(),
+	}
+	go broadcastToRoomFiltered(room, c.playerID, notice, func(id string) bool { return !members[id] })
+}
+
+// sendChannelList sends the room's channels to this connection
+func (c *Connection) sendChannelList(room *Room) {
+	data, err := json.Marshal(room.ListChannels(c.playerID))
+	if err != nil {
+		c.logger.Error("Error marshaling channel list", "error", err)
+		return
+	}
+	c.sendMessage(WebSocketMessage{
+		Type:      "channel_list",
+		PlayerID:  "system",
+		RoomID:    room.ID,
+		Data:      data,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
+
+// CanReadChannel reports whether a player may read a channel's messages (used for history)
+func (r *Room) CanReadChannel(playerID, name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ensureChannelsLocked()
+
+	channel, exists := r.Channels[name]
+	return exists && r.isChannelMemberLocked(channel, playerID)
+}
+
+// combineSkips returns a filter that skips a player if any of the filters does
+func combineSkips(skips ...func(string) bool) func(string) bool {
+	return func(playerID string) bool {
+		for _, skip := range skips {
+			if skip != nil && skip(playerID) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,129 @@
+package Player_Logic
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Subprotocols clients can request with Sec-WebSocket-Protocol
+const (
+	SubprotocolJSON    = "json"
+	SubprotocolMsgPack = "msgpack"
+)
+
+// Codec serializes WebSocketMessage/BatchedMessage frames for one wire format
+type Codec interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	FrameType() int // websocket.TextMessage or websocket.BinaryMessage
+}
+
+// jsonCodec is the default text protocol
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                               { return SubprotocolJSON }
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonCodec) FrameType() int                             { return websocket.TextMessage }
+
+// msgpackCodec is the compact binary protocol. It reuses the json struct tags so field
+// names match the JSON protocol; json.RawMessage payloads (data) arrive as binary JSON.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return SubprotocolMsgPack }
+
+// msgpackEncoder is a configured encoder with its scratch buffer, reused through
+// msgpackEncoders so marshaling doesn't regrow a buffer for every frame. (encoding/json
+// already pools its own scratch space inside json.Marshal.)
+type msgpackEncoder struct {
+	buf bytes.Buffer
+	enc *msgpack.Encoder
+}
+
+// Buffers that grew past this are left for the GC rather than pinned in the pool
+const maxPooledEncoderBytes = 64 << 10
+
+var msgpackEncoders = sync.Pool{
+	New: func() interface{} {
+		e := &msgpackEncoder{}
+		e.enc = msgpack.NewEncoder(&e.buf)
+		e.enc.SetCustomStructTag("json")
+		e.enc.UseCompactInts(true)
+		return e
+	},
+}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	e := msgpackEncoders.Get().(*msgpackEncoder)
+	defer func() {
+		if e.buf.Cap() <= maxPooledEncoderBytes {
+			e.buf.Reset()
+			msgpackEncoders.Put(e)
+		}
+	}()
+
+	if err := e.enc.Encode(v); err != nil {
+		return nil, err
+	}
+	// The frame outlives the encoder (it sits in send buffers), so it gets its own copy
+	return bytes.Clone(e.buf.Bytes()), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+func (msgpackCodec) FrameType() int { return websocket.BinaryMessage }
+
+var (
+	JSONCodec    Codec = jsonCodec{}
+	MsgPackCodec Codec = msgpackCodec{}
+)
+
+// codecFor picks the codec for the subprotocol negotiated during the upgrade
+func codecFor(subprotocol string) Codec {
+	if subprotocol == SubprotocolMsgPack {
+		return MsgPackCodec
+	}
+	return JSONCodec
+}
+
+// wireFormat identifies how a connection expects messages encoded
+type wireFormat struct {
+	codec   Codec
+	version int
+}
+
+func (c *Connection) wireFormat() wireFormat {
+	return wireFormat{codec: c.codec, version: c.version()}
+}
+
+// encodeForTargets marshals a message once per wire format in use among the targets. A
+// format gets a nil payload when its protocol version drops the message.
+func encodeForTargets(message WebSocketMessage, targets []*Connection) (map[wireFormat][]byte, error) {
+	payloads := make(map[wireFormat][]byte, 2)
+	for _, conn := range targets {
+		format := conn.wireFormat()
+		if _, done := payloads[format]; done {
+			continue
+		}
+		adapted, ok := conn.adaptOutgoing(message)
+		if !ok {
+			payloads[format] = nil
+			continue
+		}
+		data, err := conn.codec.Marshal(adapted)
+		if err != nil {
+			return nil, err
+		}
+		payloads[format] = data
+	}
+	return payloads, nil
+}
@@ -0,0 +1,152 @@
+package Player_Logic
+
+import (
+	"math"
+)
+
+// Collision. A layout's walls are indexed in a coarse grid when the layout is loaded, and
+// handlePositionUpdate stops any move whose path crosses a wall or a solid object (closed
+// doors) just short of it. The player gets a position_correction with violation
+// "collision". Players already standing inside a wall can walk out of it.
+const (
+	CollisionCellSize = 128.0 // World units per grid cell
+	SolidObjectRadius = 24.0  // Half the width of a solid object's footprint
+	MaxCollisionCells = 200000
+	// Distance kept between a stopped player and the obstacle
+	collisionBackoff = 0.5
+	// Beyond this many cells in a move's bounding box, every wall is tested instead
+	maxCollisionScan = 256
+)
+
+// collisionGrid maps each grid cell to the walls overlapping it
+type collisionGrid struct {
+	walls []Rect
+	cells map[[2]int][]int
+}
+
+// cellOf returns the grid cell containing a coordinate
+func cellOf(x, y float64) [2]int {
+	return [2]int{int(math.Floor(x / CollisionCellSize)), int(math.Floor(y / CollisionCellSize))}
+}
+
+// wallCells counts the grid cells a set of walls covers
+func wallCells(walls []Rect) int {
+	total := 0
+	for _, wall := range walls {
+		from, to := cellOf(wall.X, wall.Y), cellOf(wall.X+wall.Width, wall.Y+wall.Height)
+		total += (to[0] - from[0] + 1) * (to[1] - from[1] + 1)
+	}
+	return total
+}
+
+// newCollisionGrid indexes walls by cell (nil when there are none)
+func newCollisionGrid(walls []Rect) *collisionGrid {
+	if len(walls) == 0 {
+		return nil
+	}
+	grid := &collisionGrid{walls: walls, cells: make(map[[2]int][]int)}
+	for i, wall := range walls {
+		from, to := cellOf(wall.X, wall.Y), cellOf(wall.X+wall.Width, wall.Y+wall.Height)
+		for cx := from[0]; cx <= to[0]; cx++ {
+			for cy := from[1]; cy <= to[1]; cy++ {
+				cell := [2]int{cx, cy}
+				grid.cells[cell] = append(grid.cells[cell], i)
+			}
+		}
+	}
+	return grid
+}
+
+// candidates returns the walls that may intersect the segment from a to b
+func (g *collisionGrid) candidates(a, b Position) []Rect {
+	from := cellOf(math.Min(a.X, b.X), math.Min(a.Y, b.Y))
+	to := cellOf(math.Max(a.X, b.X), math.Max(a.Y, b.Y))
+	if (to[0]-from[0]+1)*(to[1]-from[1]+1) > maxCollisionScan {
+		return g.walls
+	}
+
+	seen := make(map[int]bool)
+	var walls []Rect
+	for cx := from[0]; cx <= to[0]; cx++ {
+		for cy := from[1]; cy <= to[1]; cy++ {
+			for _, i := range g.cells[[2]int{cx, cy}] {
+				if !seen[i] {
+					seen[i] = true
+					walls = append(walls, g.walls[i])
+				}
+			}
+		}
+	}
+	return walls
+}
+
+// segmentHit returns the fraction of the way from a to b where the segment first enters
+// the rectangle (slab method), or false if it doesn't
+func segmentHit(a, b Position, r Rect) (float64, bool) {
+	tMin, tMax := 0.0, 1.0
+	for _, axis := range [2]struct{ start, delta, min, max float64 }{
+		{a.X, b.X - a.X, r.X, r.X + r.Width},
+		{a.Y, b.Y - a.Y, r.Y, r.Y + r.Height},
+	} {
+		if axis.delta == 0 {
+			if axis.start < axis.min || axis.start > axis.max {
+				return 0, false
+			}
+			continue
+		}
+		t1, t2 := (axis.min-axis.start)/axis.delta, (axis.max-axis.start)/axis.delta
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		tMin, tMax = math.Max(tMin, t1), math.Min(tMax, t2)
+		if tMin > tMax {
+			return 0, false
+		}
+	}
+	return tMin, true
+}
+
+// solidObject reports whether an object blocks movement
+func solidObject(object *RoomObject) bool {
+	return object.Kind == ObjectDoor && object.State == "closed"
+}
+
+// collideLocked returns where a move from a to b stops, and whether it was blocked
+// (caller holds r.mu)
+func (r *Room) collideLocked(a, b Position) (Position, bool) {
+	if a == b {
+		return b, false
+	}
+
+	var obstacles []Rect
+	if grid := r.layoutLocked().collision; grid != nil {
+		obstacles = grid.candidates(a, b)
+	}
+	for _, object := range r.Objects {
+		if solidObject(object) {
+			obstacles = append(obstacles, Rect{
+				X:      object.Position.X - SolidObjectRadius,
+				Y:      object.Position.Y - SolidObjectRadius,
+				Width:  2 * SolidObjectRadius,
+				Height: 2 * SolidObjectRadius,
+			})
+		}
+	}
+
+	nearest, blocked := 1.0, false
+	for _, obstacle := range obstacles {
+		if obstacle.Contains(a) {
+			continue // Let players who are stuck inside walk out
+		}
+		if t, hit := segmentHit(a, b, obstacle); hit && t < nearest {
+			nearest, blocked = t, true
+		}
+	}
+	if !blocked {
+		return b, false
+	}
+
+	length := math.Hypot(b.X-a.X, b.Y-a.Y)
+	t := math.Max(0, nearest-collisionBackoff/length)
+	return Position{X: a.X + (b.X-a.X)*t, Y: a.Y + (b.Y-a.Y)*t}, true
+}
@@ -0,0 +1,34 @@
+package Player_Logic
+
+import (
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// Compression (permessage-deflate) is negotiated per client: it's offered unless the server
+// turns it off (WS_COMPRESSION=false) or the client connects with ?compression=off. Even
+// then only frames of at least WS_COMPRESSION_THRESHOLD_BYTES are compressed, since
+// deflating a small position update costs more CPU than it saves in bandwidth.
+
+// wantsCompression reports whether a connect request should negotiate compression
+func wantsCompression(r *http.Request) bool {
+	return settings.WebSocket.Compression && r.URL.Query().Get("compression") != "off"
+}
+
+// upgraderFor returns the upgrader for a connect request
+func upgraderFor(r *http.Request) *websocket.Upgrader {
+	if wantsCompression(r) {
+		return &upgrader
+	}
+	plain := upgrader
+	plain.EnableCompression = false
+	return &plain
+}
+
+// writeFrame writes a data frame, compressing it if it's big enough to be worth it (and
+// the client negotiated compression; otherwise the flag is ignored)
+func (c *Connection) writeFrame(data []byte) error {
+	c.ws.EnableWriteCompression(len(data) >= settings.WebSocket.CompressMinSize)
+	return c.ws.WriteMessage(c.codec.FrameType(), data)
+}
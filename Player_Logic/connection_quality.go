@@ -0,0 +1,211 @@
+package Player_Logic
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Every connection counts the frames and bytes it moves and the frames dropped because the
+// client couldn't keep up. A connection whose sends back up past its send buffer, that
+// drops WS_DEGRADED_DROPS frames within a minute, or whose round-trip time passes
+// WS_DEGRADED_RTT_MS, is degraded: the client gets a
+// connection_degraded warning (at most every 30 seconds) so it can tell its player, and
+// admins see it in /admin/connections.
+const (
+	qualityWindow          = time.Minute
+	degradedNoticeInterval = 30 * time.Second
+
+	DegradedReasonBacklog = "send_backlog"
+	DegradedReasonDrops   = "dropped_messages"
+	DegradedReasonLatency = "high_latency"
+)
+
+// connectionQuality holds a connection's traffic counters and degradation state
+type connectionQuality struct {
+	messagesIn  atomic.Int64
+	messagesOut atomic.Int64
+	bytesIn     atomic.Int64
+	bytesOut    atomic.Int64
+	dropped     atomic.Int64
+
+	mu          sync.Mutex
+	windowStart time.Time
+	windowDrops int
+	// Reason of a warning waiting to be written; writePump sends it once the send buffer
+	// has room, since a full buffer is usually why it was raised
+	pendingNotice string
+	lastNotice    time.Time
+	wake          chan struct{} // Signals writePump that a notice is pending
+}
+
+// ConnectionQuality is a snapshot of a connection's traffic and health
+type ConnectionQuality struct {
+	MessagesIn     int64   `json:"messages_in"`
+	MessagesOut    int64   `json:"messages_out"`
+	BytesIn        int64   `json:"bytes_in"`
+	BytesOut       int64   `json:"bytes_out"`
+	Dropped        int64   `json:"dropped"`       // Frames dropped because the client fell too far behind
+	RecentDrops    int     `json:"recent_drops"`  // Dropped within the last minute
+	BacklogBytes   int     `json:"backlog_bytes"` // Waiting behind the full send buffer
+	InPerSecond    float64 `json:"in_per_second"` // Messages per second since connecting
+	OutPerSecond   float64 `json:"out_per_second"`
+	Degraded       bool    `json:"degraded"`
+	DegradedReason string  `json:"degraded_reason,omitempty"`
+}
+
+// recordReceived counts an incoming frame of size bytes
+func (c *Connection) recordReceived(size int) {
+	c.quality.messagesIn.Add(1)
+	c.quality.bytesIn.Add(int64(size))
+	wsBytesReceived.Add(float64(size))
+}
+
+// recordSent counts an outgoing frame of size bytes
+func (c *Connection) recordSent(size int) {
+	c.quality.messagesOut.Add(1)
+	c.quality.bytesOut.Add(int64(size))
+	wsBytesSent.Add(float64(size))
+}
+
+// recordDrop counts a frame dropped because the client fell too far behind, and flags the
+// connection once drops pile up
+func (c *Connection) recordDrop() {
+	c.quality.dropped.Add(1)
+	now := time.Now()
+
+	q := &c.quality
+	q.mu.Lock()
+	if now.Sub(q.windowStart) >= qualityWindow {
+		q.windowStart = now
+		q.windowDrops = 0
+	}
+	q.windowDrops++
+	degraded := q.windowDrops >= settings.WebSocket.DegradedDrops
+	q.mu.Unlock()
+
+	if degraded {
+		c.flagDegraded(DegradedReasonDrops, now)
+	}
+}
+
+// checkLatency flags the connection when its smoothed round-trip time is too high
+func (c *Connection) checkLatency(now time.Time) {
+	if limit := settings.WebSocket.DegradedRTT; limit > 0 && time.Duration(c.rtt.Load()) > limit {
+		c.flagDegraded(DegradedReasonLatency, now)
+	}
+}
+
+// flagDegraded queues a connection_degraded warning unless one was sent recently
+func (c *Connection) flagDegraded(reason string, now time.Time) {
+	q := &c.quality
+	q.mu.Lock()
+	if q.pendingNotice != "" || now.Sub(q.lastNotice) < degradedNoticeInterval {
+		q.mu.Unlock()
+		return
+	}
+	q.pendingNotice = reason
+	q.lastNotice = now
+	q.mu.Unlock()
+
+	wsConnectionsDegraded.WithLabelValues(reason).Inc()
+	c.logger.Warn("Connection degraded", "reason", reason, "dropped", c.quality.dropped.Load(), "rtt_ms", c.rttMillis())
+	select {
+	case q.wake <- struct{}{}:
+	default: // Already signaled
+	}
+}
+
+// takeDegradedNotice returns the pending warning once the send buffer is at most half full
+func (c *Connection) takeDegradedNotice() (WebSocketMessage, bool) {
+	if len(c.send) > cap(c.send)/2 {
+		return WebSocketMessage{}, false
+	}
+
+	q := &c.quality
+	q.mu.Lock()
+	reason := q.pendingNotice
+	q.pendingNotice = ""
+	q.mu.Unlock()
+	if reason == "" {
+		return WebSocketMessage{}, false
+	}
+
+	var text string
+	switch reason {
+	case DegradedReasonBacklog:
+		text = "Your connection can't keep up; updates are arriving late"
+	case DegradedReasonLatency:
+		text = "Your connection is slow; other players may appear to lag"
+	default:
+		text = "Your connection can't keep up; some updates were skipped"
+	}
+	return WebSocketMessage{
+		Type:      "connection_degraded",
+		PlayerID:  "system",
+		Code:      reason,
+		Text:      text,
+		RTTMs:     c.rttMillis(),
+		Timestamp: time.Now().UnixMilli(),
+	}, true
+}
+
+// writeDegradedNotice writes a pending connection_degraded warning straight to the socket,
+// ahead of the queue. Called from writePump only; false means the write failed.
+func (c *Connection) writeDegradedNotice() bool {
+	message, ok := c.takeDegradedNotice()
+	if !ok {
+		return true
+	}
+	if message, ok = c.adaptOutgoing(message); !ok {
+		return true
+	}
+	data, err := c.codec.Marshal(message)
+	if err != nil {
+		c.logger.Error("Error marshaling message", "type", message.Type, "error", err)
+		return true
+	}
+
+	c.ws.SetWriteDeadline(time.Now().Add(settings.WebSocket.WriteTimeout))
+	if err := c.writeFrame(data); err != nil {
+		c.logger.Info("WebSocket write error", "error", err)
+		return false
+	}
+	c.recordSent(len(data))
+	wsMessagesSent.WithLabelValues(message.Type).Inc()
+	return true
+}
+
+// qualitySnapshot returns the connection's traffic and health
+func (c *Connection) qualitySnapshot() ConnectionQuality {
+	q := &c.quality
+	snapshot := ConnectionQuality{
+		MessagesIn:  q.messagesIn.Load(),
+		MessagesOut: q.messagesOut.Load(),
+		BytesIn:     q.bytesIn.Load(),
+		BytesOut:    q.bytesOut.Load(),
+		Dropped:     q.dropped.Load(),
+	}
+	snapshot.BacklogBytes = c.backlogBytes()
+	if seconds := time.Since(c.connectedAt).Seconds(); seconds > 0 {
+		snapshot.InPerSecond = float64(snapshot.MessagesIn) / seconds
+		snapshot.OutPerSecond = float64(snapshot.MessagesOut) / seconds
+	}
+
+	q.mu.Lock()
+	if time.Since(q.windowStart) < qualityWindow {
+		snapshot.RecentDrops = q.windowDrops
+	}
+	q.mu.Unlock()
+
+	switch {
+	case snapshot.RecentDrops >= settings.WebSocket.DegradedDrops:
+		snapshot.DegradedReason = DegradedReasonDrops
+	case snapshot.BacklogBytes > 0:
+		snapshot.DegradedReason = DegradedReasonBacklog
+	case settings.WebSocket.DegradedRTT > 0 && time.Duration(c.rtt.Load()) > settings.WebSocket.DegradedRTT:
+		snapshot.DegradedReason = DegradedReasonLatency
+	}
+	snapshot.Degraded = snapshot.DegradedReason != ""
+	return snapshot
+}
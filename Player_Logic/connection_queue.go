@@ -0,0 +1,146 @@
+package Player_Logic
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"velvet/config"
+
+	"github.com/gorilla/websocket"
+)
+
+// Queue length and admission timeout come from settings.WebSocket
+const QueueUpdateInterval = 5 * time.Second // How often queued clients get their position
+
+// admissionTicket is a client waiting on a holding connection for a free slot
+type admissionTicket struct {
+	playerID string
+	admitted chan struct{}
+	done     bool // Admitted or abandoned; guarded by ConnectionPool.mu
+}
+
+// canQueue reports whether another client may wait for admission
+func (cp *ConnectionPool) canQueue() bool {
+	cp.mu.RLock()
+	defer cp.mu.RUnlock()
+	return len(cp.waiting) < settings.WebSocket.MaxQueueLength
+}
+
+// enqueue adds a ticket to the back of the admission queue
+func (cp *ConnectionPool) enqueue(playerID string) *admissionTicket {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	ticket := &admissionTicket{playerID: playerID, admitted: make(chan struct{})}
+	cp.waiting = append(cp.waiting, ticket)
+	cp.admitNextLocked() // A slot may have freed up in the meantime
+	return ticket
+}
+
+// queuePosition returns the 1-based queue position of a ticket (0 once admitted)
+func (cp *ConnectionPool) queuePosition(ticket *admissionTicket) int {
+	cp.mu.RLock()
+	defer cp.mu.RUnlock()
+
+	for i, t := range cp.waiting {
+		if t == ticket {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// abandon removes a ticket whose client gave up, releasing its slot if it was already admitted
+func (cp *ConnectionPool) abandon(ticket *admissionTicket) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	for i, t := range cp.waiting {
+		if t == ticket {
+			cp.waiting = append(cp.waiting[:i], cp.waiting[i+1:]...)
+			return
+		}
+	}
+
+	// Not waiting anymore, so it was admitted and holds a reservation
+	if ticket.done {
+		cp.reserved--
+		cp.admitNextLocked()
+	}
+}
+
+// releaseReservation gives back a slot reserved for an admitted client that didn't connect
+func (cp *ConnectionPool) releaseReservation() {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.reserved--
+	cp.admitNextLocked()
+}
+
+// admitNextLocked admits queued clients FIFO while slots are free. Caller must hold cp.mu.
+func (cp *ConnectionPool) admitNextLocked() {
+	for len(cp.waiting) > 0 && cp.count+cp.reserved < settings.WebSocket.MaxConnections {
+		ticket := cp.waiting[0]
+		cp.waiting = cp.waiting[1:]
+		ticket.done = true
+		cp.reserved++
+		close(ticket.admitted)
+		slog.Info("Admitted queued connection", "player_id", ticket.playerID, "waiting", len(cp.waiting))
+	}
+}
+
+// waitForAdmission holds an upgraded connection in the queue, pushing position updates,
+// until a slot frees up. Returns false if the client went away or the wait timed out;
+// on true the caller owns a reservation that addConnection or releaseReservation consumes.
+func (cp *ConnectionPool) waitForAdmission(ctx context.Context, ws *websocket.Conn, playerID string) bool {
+	_, span := config.StartSpan(ctx, "ws.admission_queue")
+	defer span.End()
+
+	ticket := cp.enqueue(playerID)
+	ticker := time.NewTicker(QueueUpdateInterval)
+	defer ticker.Stop()
+	timeout := time.NewTimer(settings.WebSocket.QueueTimeout)
+	defer timeout.Stop()
+
+	slog.Info("Player queued for a connection slot", "player_id", playerID)
+
+	for {
+		position := cp.queuePosition(ticket)
+		if position > 0 && !sendQueuePosition(ws, position) {
+			cp.abandon(ticket)
+			return false
+		}
+
+		select {
+		case <-ticket.admitted:
+			return true
+		case <-timeout.C:
+			slog.Info("Player timed out waiting for a connection slot", "player_id", playerID)
+			cp.abandon(ticket)
+			return false
+		case <-drainCh:
+			cp.abandon(ticket)
+			ws.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(settings.WebSocket.WriteTimeout))
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendQueuePosition writes a queue update straight to the holding connection
+func sendQueuePosition(ws *websocket.Conn, position int) bool {
+	codec := codecFor(ws.Subprotocol())
+	data, err := codec.Marshal(WebSocketMessage{
+		Type:          "queue_position",
+		PlayerID:      "system",
+		QueuePosition: position,
+		Timestamp:     time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return false
+	}
+
+	ws.SetWriteDeadline(time.Now().Add(settings.WebSocket.WriteTimeout))
+	return ws.WriteMessage(codec.FrameType(), data) == nil
+}
@@ -0,0 +1,221 @@
+package Player_Logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// FilterSeverity decides what happens to a message containing a banned term
+type FilterSeverity int
+
+const (
+	SeverityNone   FilterSeverity = iota
+	SeverityMild                  // Term is masked with asterisks
+	SeveritySevere                // Whole message is rejected
+)
+
+func (s FilterSeverity) String() string {
+	switch s {
+	case SeverityMild:
+		return "mild"
+	case SeveritySevere:
+		return "severe"
+	}
+	return "none"
+}
+
+// defaultBannedTerms is the built-in mild list; deployments add terms (including severe
+// ones) with CHAT_BANNED_TERMS="term:mild,term:severe,..."
+var defaultBannedTerms = []string{"fuck", "fucking", "shit", "bitch", "asshole", "bastard", "dick", "cunt"}
+
+// leetReplacer undoes common character substitutions before matching
+var leetReplacer = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s", "!", "i")
+
+// FilterResult is the outcome of running a message through the content filter
+type FilterResult struct {
+	Text     string         // Text to deliver (masked if needed)
+	Severity FilterSeverity // Highest severity matched
+}
+
+// Rejected reports whether the message must not be delivered
+func (r FilterResult) Rejected() bool {
+	return r.Severity >= SeveritySevere
+}
+
+// ContentFilter masks or rejects chat containing banned terms
+type ContentFilter struct {
+	terms map[string]FilterSeverity
+	mu    sync.RWMutex
+}
+
+var (
+	contentFilter     *ContentFilter
+	contentFilterOnce sync.Once
+)
+
+// GetContentFilter returns the singleton filter loaded with the default and configured terms
+func GetContentFilter() *ContentFilter {
+	contentFilterOnce.Do(func() {
+		contentFilter = &ContentFilter{terms: make(map[string]FilterSeverity)}
+		for _, term := range defaultBannedTerms {
+			contentFilter.terms[term] = SeverityMild
+		}
+		for _, entry := range settings.Chat.BannedTerms {
+			term, level, _ := strings.Cut(entry, ":")
+			severity := SeverityMild
+			if strings.EqualFold(level, "severe") {
+				severity = SeveritySevere
+			}
+			contentFilter.SetTerm(term, severity)
+		}
+		slog.Info("Chat content filter loaded", "terms", len(contentFilter.terms))
+	})
+	return contentFilter
+}
+
+// SetTerm adds or updates a banned term; SeverityNone removes it
+func (f *ContentFilter) SetTerm(term string, severity FilterSeverity) {
+	term = normalizeWord(term)
+	if term == "" {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if severity == SeverityNone {
+		delete(f.terms, term)
+		return
+	}
+	f.terms[term] = severity
+}
+
+// Check matches each word of text against the banned terms and masks mild matches
+func (f *ContentFilter) Check(text string) FilterResult {
+	result := FilterResult{Text: text}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var out strings.Builder
+	out.Grow(len(text))
+	masked := false
+	for _, token := range splitWords(text) {
+		severity, banned := f.terms[normalizeWord(token)]
+		if !banned {
+			out.WriteString(token)
+			continue
+		}
+		if severity > result.Severity {
+			result.Severity = severity
+		}
+		out.WriteString(strings.Repeat("*", len([]rune(token))))
+		masked = true
+	}
+
+	if masked {
+		result.Text = out.String()
+	}
+	return result
+}
+
+// splitWords splits text into alternating word and separator tokens, so joining them
+// gives back the original text. Digits and leet symbols count as word characters.
+func splitWords(text string) []string {
+	var tokens []string
+	start := 0
+	inWord := false
+	for i, r := range text {
+		isWordChar := unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("@$!", r)
+		if i > 0 && isWordChar != inWord {
+			tokens = append(tokens, text[start:i])
+			start = i
+		}
+		inWord = isWordChar
+	}
+	if start < len(text) {
+		tokens = append(tokens, text[start:])
+	}
+	return tokens
+}
+
+// normalizeWord lowercases a word and undoes leetspeak
+func normalizeWord(word string) string {
+	return leetReplacer.Replace(strings.ToLower(strings.TrimSpace(word)))
+}
+
+// ChatRejection explains to a sender why their message wasn't delivered as sent
+type ChatRejection struct {
+	Reason           string `json:"reason"`             // e.g. "profanity", "muted"
+	Severity         string `json:"severity,omitempty"` // Filter severity for profanity
+	RemainingSeconds int    `json:"remaining_seconds,omitempty"`
+}
+
+// sendChatRejected tells the sender their message was rejected (or altered, for chat_filtered)
+func (c *Connection) sendChatRejected(messageType, text string, rejection ChatRejection) {
+	data, err := json.Marshal(rejection)
+	if err != nil {
+		c.logger.Error("Error marshaling chat rejection", "error", err)
+		return
+	}
+	c.sendMessage(WebSocketMessage{
+		Type:      messageType,
+		PlayerID:  "system",
+		Text:      text,
+		Data:      data,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
+
+// applyContentFilter filters outgoing chat text. Returns the text to deliver and false if
+// the message was rejected; the sender is notified either way when the filter acted.
+func (c *Connection) applyContentFilter(text string) (string, bool) {
+	if c.isService {
+		return text, true // Announcements from trusted bots aren't filtered
+	}
+
+	result := GetContentFilter().Check(text)
+	switch {
+	case result.Rejected():
+		c.sendChatRejected("chat_rejected", "Message blocked by the chat filter", ChatRejection{
+			Reason:   "profanity",
+			Severity: result.Severity.String(),
+		})
+		return "", false
+	case result.Severity != SeverityNone:
+		c.sendChatRejected("chat_filtered", "Some words in your message were masked", ChatRejection{
+			Reason:   "profanity",
+			Severity: result.Severity.String(),
+		})
+	}
+	return result.Text, true
+}
+
+// SetChatFilter enables or disables the content filter in a room (host/moderators only)
+func (rm *RoomManager) SetChatFilter(roomID, actorID string, enabled bool) error {
+	room := rm.getRoomByID(roomID)
+	if room == nil {
+		return fmt.Errorf("%w: %s", ErrRoomNotFound, roomID)
+	}
+	if !rm.canModerate(room, actorID) {
+		return fmt.Errorf("only the room host or a moderator can change the chat filter")
+	}
+
+	room.mu.Lock()
+	room.FilterDisabled = !enabled
+	room.mu.Unlock()
+
+	slog.Info("Chat filter toggled", "room_id", roomID, "enabled", enabled, "player_id", actorID)
+	return nil
+}
+
+// chatFilterEnabled reports whether chat in the room goes through the content filter
+func (r *Room) chatFilterEnabled() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return !r.FilterDisabled
+}
@@ -0,0 +1,110 @@
+package Player_Logic
+
+import (
+	"context"
+	"time"
+	"velvet/config"
+)
+
+// Daily login rewards. Claiming on consecutive days (midnight in DAILY_REWARD_TIMEZONE) builds
+// a streak; each day of the streak grants a bigger reward of coins and items, and from the last entry in
+// dailyRewards on the best reward repeats. Missing a day restarts at day 1.
+
+// DailyReward is what a given streak day grants
+type DailyReward struct {
+	Day      int    `json:"day"`
+	Coins    int64  `json:"coins"`
+	ItemID   string `json:"item_id,omitempty"`
+	Quantity int    `json:"quantity,omitempty"`
+}
+
+// dailyRewards is the reward for each day of a streak
+var dailyRewards = []DailyReward{
+	{Day: 1, Coins: 10, ItemID: "coffee", Quantity: 1},
+	{Day: 2, Coins: 15, ItemID: "coffee", Quantity: 2},
+	{Day: 3, Coins: 20, ItemID: "pizza", Quantity: 2},
+	{Day: 4, Coins: 30, ItemID: "balloon", Quantity: 3},
+	{Day: 5, Coins: 40, ItemID: "pizza", Quantity: 4},
+	{Day: 6, Coins: 50, ItemID: "rose", Quantity: 5},
+	{Day: 7, Coins: 100, ItemID: "plant", Quantity: 1},
+}
+
+// DailyRewardFor returns the reward for a streak day
+func DailyRewardFor(streak int) DailyReward {
+	if streak < 1 {
+		streak = 1
+	}
+	if streak > len(dailyRewards) {
+		streak = len(dailyRewards)
+	}
+	return dailyRewards[streak-1]
+}
+
+// DailyRewardStatus is a player's claim state for today
+type DailyRewardStatus struct {
+	Streak       int           `json:"streak"`        // Current streak (0 if it was broken)
+	ClaimedToday bool          `json:"claimed_today"` // Already claimed; come back after NextReset
+	NextReward   DailyReward   `json:"next_reward"`   // What the next claim grants
+	NextReset    int64         `json:"next_reset"`    // Unix ms when the next reward day starts
+	Timezone     string        `json:"timezone"`
+	Rewards      []DailyReward `json:"rewards"` // The whole schedule
+}
+
+// rewardDays returns today's and yesterday's dates and when tomorrow starts, in the reward timezone
+func rewardDays(now time.Time) (today, yesterday string, nextReset time.Time) {
+	location, err := time.LoadLocation(settings.Progression.RewardTimezone)
+	if err != nil {
+		location = time.UTC // Validated at startup; only reachable with a hand-built config
+	}
+	local := now.In(location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	return midnight.Format(time.DateOnly), midnight.AddDate(0, 0, -1).Format(time.DateOnly), midnight.AddDate(0, 0, 1)
+}
+
+// GetDailyRewardStatus reports whether a player can claim today and what they'd get
+func GetDailyRewardStatus(ctx context.Context, playerID string) (DailyRewardStatus, error) {
+	state, err := config.GetDailyRewardState(ctx, playerID)
+	if err != nil {
+		return DailyRewardStatus{}, err
+	}
+
+	today, yesterday, nextReset := rewardDays(time.Now())
+	status := DailyRewardStatus{
+		ClaimedToday: state.LastClaimDay == today,
+		NextReset:    nextReset.UnixMilli(),
+		Timezone:     settings.Progression.RewardTimezone,
+		Rewards:      dailyRewards,
+	}
+	if state.LastClaimDay == today || state.LastClaimDay == yesterday {
+		status.Streak = state.Streak
+		status.NextReward = DailyRewardFor(state.Streak + 1) // Tomorrow's, if already claimed
+	} else {
+		status.NextReward = DailyRewardFor(1)
+	}
+	return status, nil
+}
+
+// ClaimDailyReward grants today's reward, returning it and the new streak
+func ClaimDailyReward(ctx context.Context, playerID string) (DailyReward, int, error) {
+	today, yesterday, _ := rewardDays(time.Now())
+
+	var reward DailyReward
+	streak, err := config.ClaimDailyReward(ctx, playerID, today, yesterday, func(ctx context.Context, streak int) error {
+		reward = DailyRewardFor(streak)
+		if reward.Coins > 0 {
+			if _, err := CreditCoins(ctx, playerID, reward.Coins, "daily_reward", today); err != nil {
+				return err
+			}
+		}
+		if reward.ItemID != "" {
+			if _, err := GrantItem(ctx, playerID, reward.ItemID, reward.Quantity, "daily_reward"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return DailyReward{}, 0, err
+	}
+	return reward, streak, nil
+}
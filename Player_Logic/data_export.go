@@ -0,0 +1,185 @@
+package Player_Logic
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+	"velvet/config"
+)
+
+// Data exports. A player can request an archive of everything stored about them; it's
+// assembled in the background, uploaded to blob storage, and the player is pushed a
+// "data_export_ready" message with the download link.
+
+// Export statuses
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+const (
+	// DataExportCooldown is how long a player waits between export requests
+	DataExportCooldown = time.Hour
+	// dataExportTimeout bounds assembling and uploading one archive
+	dataExportTimeout = 2 * time.Minute
+)
+
+var (
+	ErrExportInProgress = errors.New("a data export is already being prepared")
+	ErrExportTooSoon    = errors.New("a data export was requested recently, try again later")
+	ErrNoDataExport     = errors.New("no data export has been requested")
+)
+
+// DataExport tracks a player's latest export request
+type DataExport struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	URL         string     `json:"download_url,omitempty"`
+	RequestedAt time.Time  `json:"requested_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+
+	key string // Blob key of the archive, deleted when the next export replaces it
+}
+
+// dataExports holds each player's latest export, by player ID
+var dataExports = struct {
+	exports map[string]*DataExport
+	mu      sync.Mutex
+}{exports: make(map[string]*DataExport)}
+
+// RequestDataExport starts building an archive for the player and returns the pending export
+func RequestDataExport(playerID string) (DataExport, error) {
+	if config.DB == nil {
+		return DataExport{}, errors.New("database not initialized")
+	}
+	if config.GetBlobStore() == nil {
+		return DataExport{}, config.ErrStorageUnavailable
+	}
+
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return DataExport{}, err
+	}
+	export := &DataExport{
+		ID:          hex.EncodeToString(raw),
+		Status:      ExportPending,
+		RequestedAt: time.Now(),
+	}
+
+	dataExports.mu.Lock()
+	previous := dataExports.exports[playerID]
+	if previous != nil {
+		if previous.Status == ExportPending {
+			dataExports.mu.Unlock()
+			return DataExport{}, ErrExportInProgress
+		}
+		if previous.Status == ExportReady && time.Since(previous.RequestedAt) < DataExportCooldown {
+			dataExports.mu.Unlock()
+			return DataExport{}, ErrExportTooSoon
+		}
+	}
+	dataExports.exports[playerID] = export
+	snapshot := *export
+	dataExports.mu.Unlock()
+
+	if previous != nil && previous.key != "" {
+		config.DeleteDataExport(previous.key)
+	}
+
+	go buildDataExport(playerID, export)
+	slog.Info("Data export requested", "player_id", playerID, "export_id", export.ID)
+	return snapshot, nil
+}
+
+// GetDataExport returns the player's latest export
+func GetDataExport(playerID string) (DataExport, error) {
+	dataExports.mu.Lock()
+	defer dataExports.mu.Unlock()
+	export, exists := dataExports.exports[playerID]
+	if !exists {
+		return DataExport{}, ErrNoDataExport
+	}
+	return *export, nil
+}
+
+// forgetDataExport drops the player's latest export and deletes its archive
+func forgetDataExport(playerID string) {
+	dataExports.mu.Lock()
+	export := dataExports.exports[playerID]
+	delete(dataExports.exports, playerID)
+	dataExports.mu.Unlock()
+
+	if export != nil && export.key != "" {
+		config.DeleteDataExport(export.key)
+	}
+}
+
+// buildDataExport assembles and uploads the archive, then tells the player it's ready
+func buildDataExport(playerID string, export *DataExport) {
+	ctx, cancel := context.WithTimeout(context.Background(), dataExportTimeout)
+	defer cancel()
+
+	url, key, err := assembleDataExport(ctx, playerID, export.ID)
+
+	now := time.Now()
+	dataExports.mu.Lock()
+	export.CompletedAt = &now
+	if err != nil {
+		export.Status = ExportFailed
+		export.Error = "The export could not be generated, please try again"
+	} else {
+		export.Status = ExportReady
+		export.URL = url
+		export.key = key
+	}
+	dataExports.mu.Unlock()
+
+	if err != nil {
+		slog.Error("Data export failed", "player_id", playerID, "export_id", export.ID, "error", err)
+		return
+	}
+
+	slog.Info("Data export ready", "player_id", playerID, "export_id", export.ID)
+	sendToPlayer(playerID, WebSocketMessage{
+		Type:      "data_export_ready",
+		PlayerID:  "system",
+		Text:      url,
+		System:    true,
+		Timestamp: now.UnixMilli(),
+	})
+}
+
+// assembleDataExport gathers stored and live data into one JSON document and uploads it
+func assembleDataExport(ctx context.Context, playerID, exportID string) (string, string, error) {
+	archive, err := config.ExportUserData(ctx, playerID)
+	if err != nil {
+		return "", "", err
+	}
+
+	sessions := make([]ConnectionInfo, 0, 1)
+	if info, connected := GetConnectionInfo(playerID); connected {
+		sessions = append(sessions, info)
+	}
+	archive["sessions"] = sessions
+	if room := GetRoomManager().GetPlayerRoom(playerID); room != nil {
+		archive["current_room"] = room.ID
+	}
+	archive["export"] = map[string]interface{}{
+		"id":           exportID,
+		"user_id":      playerID,
+		"generated_at": time.Now(),
+	}
+
+	data, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+	return config.SaveDataExport(ctx, data)
+}
@@ -0,0 +1,99 @@
+package Player_Logic
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Graceful shutdown of WebSocket clients. DrainConnections stops new upgrades, warns every
+// client with a server_shutdown countdown, then closes each connection with a going-away
+// close frame so clients can reconnect elsewhere instead of seeing an abrupt drop.
+const drainPollInterval = 250 * time.Millisecond
+
+var (
+	draining  atomic.Bool
+	drainCh   = make(chan struct{}) // Closed when draining starts, waking queued clients
+	drainOnce sync.Once
+)
+
+// IsDraining reports whether the server is shutting down and refusing new connections
+func IsDraining() bool {
+	return draining.Load()
+}
+
+// DrainConnections announces the shutdown, waits up to countdown for clients to leave, and
+// closes whoever is left. It returns early if ctx ends first.
+func DrainConnections(ctx context.Context, countdown time.Duration) {
+	drainOnce.Do(func() {
+		draining.Store(true)
+		close(drainCh)
+	})
+
+	connections := connectionPool.snapshot()
+	slog.Info("Draining WebSocket connections", "connections", len(connections), "countdown", countdown)
+
+	notice := WebSocketMessage{
+		Type:      "server_shutdown",
+		PlayerID:  "system",
+		Text:      "The server is restarting, please reconnect shortly",
+		Countdown: int(countdown.Seconds()),
+		Timestamp: time.Now().UnixMilli(),
+	}
+	for _, conn := range connections {
+		conn.sendMessage(notice)
+	}
+
+	// Give clients the countdown to wrap up and leave on their own
+	deadline := time.NewTimer(countdown)
+	defer deadline.Stop()
+	poll := time.NewTicker(drainPollInterval)
+	defer poll.Stop()
+wait:
+	for len(connectionPool.snapshot()) > 0 {
+		select {
+		case <-deadline.C:
+			break wait
+		case <-ctx.Done():
+			break wait
+		case <-poll.C:
+		}
+	}
+
+	remaining := connectionPool.snapshot()
+	for _, conn := range remaining {
+		conn.closeGoingAway("server shutting down")
+	}
+	slog.Info("WebSocket connections drained", "closed", len(remaining))
+}
+
+// closeGoingAway sends a going-away close frame and tears the connection down
+func (c *Connection) closeGoingAway(reason string) {
+	c.closeWithCode(websocket.CloseGoingAway, reason)
+}
+
+// closeWithCode sends a close frame with the given code and tears the connection down
+func (c *Connection) closeWithCode(code int, reason string) {
+	// WriteControl is safe to call alongside writePump's writes
+	frame := websocket.FormatCloseMessage(code, reason)
+	if err := c.ws.WriteControl(websocket.CloseMessage, frame, time.Now().Add(settings.WebSocket.WriteTimeout)); err != nil {
+		c.logger.Debug("Could not send close frame", "error", err)
+	}
+	c.cancel()
+}
+
+// snapshot returns the currently registered connections
+func (cp *ConnectionPool) snapshot() []*Connection {
+	cp.mu.RLock()
+	defer cp.mu.RUnlock()
+
+	connections := make([]*Connection, 0, len(cp.connections))
+	for _, conn := range cp.connections {
+		connections = append(connections, conn)
+	}
+	return connections
+}
@@ -0,0 +1,108 @@
+package Player_Logic
+
+import (
+	"sort"
+	"time"
+)
+
+// Emote rate limits: a short cooldown plus a cap per rolling window
+const (
+	EmoteCooldown      = 500 * time.Millisecond
+	EmoteWindow        = 10 * time.Second
+	MaxEmotesPerWindow = 5
+)
+
+// Emote is an avatar animation clients can trigger
+type Emote struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	DurationMs int    `json:"duration_ms"` // How long clients should play it (0 = loops until moved)
+}
+
+// emoteCatalog is the set of emotes the server accepts
+var emoteCatalog = map[string]Emote{
+	"wave":    {ID: "wave", Name: "Wave", DurationMs: 2000},
+	"dance":   {ID: "dance", Name: "Dance"},
+	"clap":    {ID: "clap", Name: "Clap", DurationMs: 2000},
+	"laugh":   {ID: "laugh", Name: "Laugh", DurationMs: 2500},
+	"heart":   {ID: "heart", Name: "Heart", DurationMs: 1500},
+	"thumbs":  {ID: "thumbs", Name: "Thumbs up", DurationMs: 1500},
+	"sit":     {ID: "sit", Name: "Sit"},
+	"shrug":   {ID: "shrug", Name: "Shrug", DurationMs: 1500},
+	"cry":     {ID: "cry", Name: "Cry", DurationMs: 2500},
+	"jump":    {ID: "jump", Name: "Jump", DurationMs: 1000},
+	"sleep":   {ID: "sleep", Name: "Sleep"},
+	"confuse": {ID: "confuse", Name: "Confused", DurationMs: 2000},
+}
+
+// EmoteCatalog returns all emotes sorted by ID
+func EmoteCatalog() []Emote {
+	emotes := make([]Emote, 0, len(emoteCatalog))
+	for _, emote := range emoteCatalog {
+		emotes = append(emotes, emote)
+	}
+	sort.Slice(emotes, func(i, j int) bool { return emotes[i].ID < emotes[j].ID })
+	return emotes
+}
+
+// emoteLimiter tracks recent emotes for one connection
+type emoteLimiter struct {
+	recent []time.Time
+}
+
+// allow records an emote at now if it is within the cooldown and window limits
+func (l *emoteLimiter) allow(now time.Time) bool {
+	if n := len(l.recent); n > 0 && now.Sub(l.recent[n-1]) < EmoteCooldown {
+		return false
+	}
+
+	kept := l.recent[:0]
+	for _, at := range l.recent {
+		if now.Sub(at) < EmoteWindow {
+			kept = append(kept, at)
+		}
+	}
+	l.recent = kept
+	if len(l.recent) >= MaxEmotesPerWindow {
+		return false
+	}
+
+	l.recent = append(l.recent, now)
+	return true
+}
+
+// handleEmote validates an emote against the catalog and broadcasts it to the room
+func (c *Connection) handleEmote(rm *RoomManager, message WebSocketMessage) {
+	emote, known := emoteCatalog[message.EmoteID]
+	if !known {
+		c.sendMessage(WebSocketMessage{
+			Type:      "emote_error",
+			PlayerID:  "system",
+			EmoteID:   message.EmoteID,
+			Text:      "Unknown emote",
+			Timestamp: time.Now().UnixMilli(),
+		})
+		return
+	}
+
+	now := time.Now()
+	if !c.emotes.allow(now) {
+		return // Silently drop spam
+	}
+
+	player := rm.GetPlayer(c.playerID)
+	room := rm.GetPlayerRoom(c.playerID)
+	if player == nil || room == nil || player.Hidden {
+		return
+	}
+
+	position := player.GetPosition()
+	emoteMessage := WebSocketMessage{
+		Type:      "emote",
+		PlayerID:  c.playerID,
+		EmoteID:   emote.ID,
+		Position:  &position,
+		Timestamp: now.UnixMilli(),
+	}
+	go broadcastToRoomAsync(room, c.playerID, emoteMessage)
+}
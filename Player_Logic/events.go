@@ -0,0 +1,233 @@
+package Player_Logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Server events. Admins schedule an event for a room with a start and end time; everyone
+// online gets an "event_reminder" at each EventReminderLeads before it starts, then
+// "event_started" and "event_ended" (Data is the event). While an event is live its room
+// carries it in the room directory. Events live on the scheduler, so like scheduled
+// broadcasts they don't survive a restart.
+const (
+	EventJobKind          = "event"
+	MaxEventTitleLength   = 100
+	MaxEventDuration      = 24 * time.Hour
+	MaxScheduledEvents    = 100
+	MaxEventScheduleAhead = 90 * 24 * time.Hour
+)
+
+// EventReminderLeads are how long before an event's start reminders go out
+var EventReminderLeads = []time.Duration{time.Hour, 15 * time.Minute}
+
+var (
+	ErrUnknownEvent     = errors.New("event not found or already over")
+	ErrTooManyEvents    = fmt.Errorf("at most %d events can be scheduled", MaxScheduledEvents)
+	ErrInvalidEventRoom = errors.New("room_id must be 1-10 characters")
+)
+
+// ScheduledEvent is an admin-scheduled event in a room
+type ScheduledEvent struct {
+	ID       string    `json:"id"`
+	Title    string    `json:"title"`
+	RoomID   string    `json:"room_id"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Live     bool      `json:"live"`
+
+	jobIDs []string
+}
+
+// Validate checks the event's title, room, and times
+func (e *ScheduledEvent) Validate() error {
+	if e.Title == "" || len(e.Title) > MaxEventTitleLength {
+		return fmt.Errorf("title must be 1-%d characters", MaxEventTitleLength)
+	}
+	if !validPortalTarget(e.RoomID) {
+		return ErrInvalidEventRoom
+	}
+	if !e.EndsAt.After(e.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	if e.EndsAt.Sub(e.StartsAt) > MaxEventDuration {
+		return fmt.Errorf("events can last at most %s", MaxEventDuration)
+	}
+	if !e.EndsAt.After(time.Now()) {
+		return fmt.Errorf("ends_at must be in the future")
+	}
+	if time.Until(e.StartsAt) > MaxEventScheduleAhead {
+		return fmt.Errorf("events can be scheduled at most %s ahead", MaxEventScheduleAhead)
+	}
+	return nil
+}
+
+var serverEvents = struct {
+	events map[string]*ScheduledEvent
+	nextID int64
+	mu     sync.Mutex
+}{events: make(map[string]*ScheduledEvent)}
+
+// ScheduleEvent validates an event and schedules its reminders, start, and end
+func ScheduleEvent(e ScheduledEvent) (ScheduledEvent, error) {
+	if err := e.Validate(); err != nil {
+		return ScheduledEvent{}, err
+	}
+	if e.RoomID == PortalMainRoom {
+		e.RoomID = GetRoomManager().MainRoomID()
+	}
+
+	serverEvents.mu.Lock()
+	defer serverEvents.mu.Unlock()
+
+	if len(serverEvents.events) >= MaxScheduledEvents {
+		return ScheduledEvent{}, ErrTooManyEvents
+	}
+	serverEvents.nextID++
+	event := &ScheduledEvent{
+		ID:       fmt.Sprintf("event-%d", serverEvents.nextID),
+		Title:    e.Title,
+		RoomID:   e.RoomID,
+		StartsAt: e.StartsAt,
+		EndsAt:   e.EndsAt,
+	}
+
+	scheduler := GetScheduler()
+	for _, lead := range EventReminderLeads {
+		lead := lead
+		remindAt := event.StartsAt.Add(-lead)
+		if remindAt.Before(time.Now()) {
+			continue
+		}
+		job := scheduler.Schedule(EventJobKind, remindAt, event.ID, func() {
+			announceEvent(event.ID, "event_reminder", fmt.Sprintf("%s starts in %d minutes", event.Title, int(lead.Minutes())))
+		})
+		event.jobIDs = append(event.jobIDs, job.ID)
+	}
+	start := scheduler.Schedule(EventJobKind, event.StartsAt, event.ID, func() {
+		startEvent(event.ID)
+	})
+	end := scheduler.Schedule(EventJobKind, event.EndsAt, event.ID, func() {
+		endEvent(event.ID)
+	})
+	event.jobIDs = append(event.jobIDs, start.ID, end.ID)
+	serverEvents.events[event.ID] = event
+
+	slog.Info("Scheduled event", "event_id", event.ID, "room_id", event.RoomID, "starts_at", event.StartsAt.Format(time.RFC3339))
+	return *event, nil
+}
+
+// CancelEvent removes a scheduled or live event and its pending reminders
+func CancelEvent(eventID string) error {
+	serverEvents.mu.Lock()
+	event, exists := serverEvents.events[eventID]
+	if !exists {
+		serverEvents.mu.Unlock()
+		return ErrUnknownEvent
+	}
+	delete(serverEvents.events, eventID)
+	serverEvents.mu.Unlock()
+
+	for _, jobID := range event.jobIDs {
+		GetScheduler().Cancel(jobID)
+	}
+	slog.Info("Cancelled event", "event_id", eventID)
+	return nil
+}
+
+// UpcomingEvents returns live and future events sorted by start time
+func UpcomingEvents() []ScheduledEvent {
+	serverEvents.mu.Lock()
+	defer serverEvents.mu.Unlock()
+
+	events := make([]ScheduledEvent, 0, len(serverEvents.events))
+	for _, event := range serverEvents.events {
+		events = append(events, *event)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].StartsAt.Before(events[j].StartsAt) })
+	return events
+}
+
+// liveEvents returns the live event in each room, for the room directory
+func liveEvents() map[string]ScheduledEvent {
+	serverEvents.mu.Lock()
+	defer serverEvents.mu.Unlock()
+
+	live := make(map[string]ScheduledEvent)
+	for _, event := range serverEvents.events {
+		if event.Live {
+			live[event.RoomID] = *event
+		}
+	}
+	return live
+}
+
+// startEvent marks the event live and announces it
+func startEvent(eventID string) {
+	serverEvents.mu.Lock()
+	event, exists := serverEvents.events[eventID]
+	if exists {
+		event.Live = true
+	}
+	serverEvents.mu.Unlock()
+	if !exists {
+		return
+	}
+	announceEvent(eventID, "event_started", fmt.Sprintf("%s has started", event.Title))
+}
+
+// endEvent announces the end of the event and forgets it
+func endEvent(eventID string) {
+	serverEvents.mu.Lock()
+	event, exists := serverEvents.events[eventID]
+	if exists {
+		delete(serverEvents.events, eventID)
+	}
+	serverEvents.mu.Unlock()
+	if !exists {
+		return
+	}
+
+	data, _ := json.Marshal(event)
+	broadcastToAll(WebSocketMessage{
+		Type:      "event_ended",
+		PlayerID:  "system",
+		RoomID:    event.RoomID,
+		Text:      fmt.Sprintf("%s has ended", event.Title),
+		Data:      data,
+		System:    true,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	slog.Info("Event ended", "event_id", eventID, "room_id", event.RoomID)
+}
+
+// announceEvent sends an event message to every connection
+func announceEvent(eventID, messageType, text string) {
+	serverEvents.mu.Lock()
+	event, exists := serverEvents.events[eventID]
+	var snapshot ScheduledEvent
+	if exists {
+		snapshot = *event
+	}
+	serverEvents.mu.Unlock()
+	if !exists {
+		return
+	}
+
+	data, _ := json.Marshal(snapshot)
+	broadcastToAll(WebSocketMessage{
+		Type:      messageType,
+		PlayerID:  "system",
+		RoomID:    snapshot.RoomID,
+		Text:      text,
+		Data:      data,
+		System:    true,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	slog.Info("Announced event", "event_id", eventID, "type", messageType)
+}
@@ -0,0 +1,54 @@
+package Player_Logic
+
+import (
+	"log/slog"
+	"time"
+	"velvet/config"
+)
+
+// NotifyFriendRequest pushes a friend request (or acceptance) to the other user if they're
+// online, and sends requests to their devices if they're not
+func NotifyFriendRequest(fromID, toID, status string) {
+	messageType := "friend_request"
+	if status == config.FriendshipAccepted {
+		messageType = "friend_accepted"
+	}
+
+	sendToPlayer(toID, WebSocketMessage{
+		Type:           messageType,
+		PlayerID:       "system",
+		TargetPlayerID: fromID,
+		Timestamp:      time.Now().UnixMilli(),
+	})
+
+	// Requests also reach offline players' devices
+	if messageType == "friend_request" && GetPresence().Get([]string{toID})[toID].Status == PresenceOffline {
+		config.SendPushAsync(toID, config.NotifyFriendRequest, config.PushNotification{
+			Title: "New friend request",
+			Body:  "Someone wants to be your friend",
+			Data:  map[string]string{"from_id": fromID},
+		})
+	}
+}
+
+// FriendsOfMembersPriority grants reserved-slot admission to friends of players already in the room
+func FriendsOfMembersPriority(playerID string, room *Room) AdmissionPriority {
+	if config.DB == nil {
+		return PriorityRegular
+	}
+
+	friendIDs, err := config.GetFriendIDs(playerID)
+	if err != nil {
+		slog.Warn("Could not load friends for admission priority", "player_id", playerID, "error", err)
+		return PriorityRegular
+	}
+
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	for _, friendID := range friendIDs {
+		if _, inRoom := room.Players[friendID]; inRoom {
+			return PriorityPrivileged
+		}
+	}
+	return PriorityRegular
+}
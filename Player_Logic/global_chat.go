@@ -0,0 +1,120 @@
+package Player_Logic
+
+import (
+	"context"
+	"strings"
+	"time"
+	"velvet/config"
+)
+
+// Global chat reaches every connected player on every instance, whatever room they're in.
+// Clients send {"type":"global_chat","text":...}; it has its own, much tighter rate budget
+// (WS_RATE_GLOBAL_*), always goes through the content filter, and isn't kept in history.
+// Players muted in their room can't post, blocks apply as in room chat, and players can
+// turn it off with set_global_chat, which is saved in their chat preferences.
+const (
+	globalChatSubject = "velvet.global.chat"
+	chatPrefsTimeout  = 5 * time.Second
+)
+
+// handleGlobalChat sends a player's message to everyone who hasn't turned global chat off
+func (c *Connection) handleGlobalChat(rm *RoomManager, message WebSocketMessage) {
+	room := rm.GetPlayerRoom(c.playerID)
+	if room == nil {
+		return
+	}
+	if remaining := room.MuteRemaining(c.playerID); remaining > 0 {
+		c.sendChatRejected("chat_rejected", "You are muted", ChatRejection{
+			Reason:           "muted",
+			RemainingSeconds: int(remaining.Round(time.Second).Seconds()),
+		})
+		return
+	}
+
+	text, allowed := c.applyContentFilter(message.Text)
+	if !allowed || strings.TrimSpace(text) == "" {
+		return
+	}
+
+	globalMessage := WebSocketMessage{
+		Type:      "global_chat",
+		PlayerID:  c.playerID,
+		Username:  message.Username,
+		Text:      text,
+		RoomID:    room.ID,
+		System:    c.isService,
+		Timestamp: time.Now().UnixMilli(),
+	}
+	go func() {
+		deliverGlobalChat(globalMessage)
+		publishEnvelope(globalChatSubject, backplaneEnvelope{Message: globalMessage})
+	}()
+}
+
+// deliverGlobalChat queues a global chat message for the players connected here, except
+// the sender, anyone who turned global chat off, and anyone who blocked the sender
+func deliverGlobalChat(message WebSocketMessage) {
+	blocks := config.GetBlockStore()
+	connections := connectionPool.snapshot()
+	targets := connections[:0]
+	for _, conn := range connections {
+		if conn.playerID != message.PlayerID && !conn.globalChatOff.Load() && !blocks.IsBlocked(conn.playerID, message.PlayerID) {
+			targets = append(targets, conn)
+		}
+	}
+	queueToTargets(message, targets)
+}
+
+// handleGlobalChatSubject delivers another instance's global chat message
+func handleGlobalChatSubject(subject string, data []byte) {
+	if envelope, ok := decodeEnvelope(subject, data); ok {
+		deliverGlobalChat(envelope.Message)
+	}
+}
+
+// handleSetGlobalChat turns global chat on or off for the player and saves the choice
+func (c *Connection) handleSetGlobalChat(message WebSocketMessage) {
+	if message.Enabled == nil {
+		return
+	}
+	enabled := *message.Enabled
+	c.globalChatOff.Store(!enabled)
+	c.sendMessage(WebSocketMessage{
+		Type:      "global_chat_changed",
+		PlayerID:  "system",
+		Enabled:   message.Enabled,
+		Timestamp: time.Now().UnixMilli(),
+	})
+
+	if config.DB == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), chatPrefsTimeout)
+		defer cancel()
+		if err := config.SetChatPrefs(ctx, c.playerID, config.ChatPrefs{GlobalChat: enabled}); err != nil {
+			c.logger.Warn("Could not save chat preferences", "error", err)
+		}
+	}()
+}
+
+// loadChatPrefs applies the player's saved chat preferences to a new connection
+func (c *Connection) loadChatPrefs(ctx context.Context) {
+	if config.DB == nil || c.isService {
+		return
+	}
+	prefs, err := config.GetChatPrefs(ctx, c.playerID)
+	if err != nil {
+		c.logger.Warn("Could not read chat preferences", "error", err)
+		return
+	}
+	c.globalChatOff.Store(!prefs.GlobalChat)
+}
+
+// SetGlobalChat applies a change to a player's saved global chat preference to their live
+// connection on this instance, if any
+func SetGlobalChat(playerID string, enabled bool) {
+	if conn, exists := connectionPool.getConnection(playerID); exists {
+		conn.globalChatOff.Store(!enabled)
+	}
+}
@@ -0,0 +1,161 @@
+package Player_Logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"velvet/config"
+)
+
+// Guild chat reaches every online member of the player's guild, on every instance and in
+// every room. Clients send {"type":"guild_chat","text":...}; it shares room chat's rate
+// budget and content filter. Membership is loaded when the socket connects and kept up to
+// date with GuildChanged, which also tells the player with a "guild_changed" message. The
+// guild's tag is shown in player_joined and room_state.
+const (
+	guildChatSubjectPrefix   = "velvet.guild.chat."
+	guildMemberSubjectPrefix = "velvet.guild.member."
+)
+
+// handleGuildChat sends a player's message to the rest of their guild
+func (c *Connection) handleGuildChat(rm *RoomManager, message WebSocketMessage) {
+	guildID := c.guildID.Load()
+	if guildID == 0 {
+		c.sendMessageError(message.Type, &MessageError{Code: ErrCodeNotInGuild, Message: "you are not in a guild"})
+		return
+	}
+	room := rm.GetPlayerRoom(c.playerID)
+	if room == nil {
+		return
+	}
+	if remaining := room.MuteRemaining(c.playerID); remaining > 0 {
+		c.sendChatRejected("chat_rejected", "You are muted", ChatRejection{
+			Reason:           "muted",
+			RemainingSeconds: int(remaining.Round(time.Second).Seconds()),
+		})
+		return
+	}
+
+	text, allowed := c.applyContentFilter(message.Text)
+	if !allowed || strings.TrimSpace(text) == "" {
+		return
+	}
+
+	var guildTag string
+	if player := rm.GetPlayer(c.playerID); player != nil {
+		guildTag = player.GetGuildTag()
+	}
+	guildMessage := WebSocketMessage{
+		Type:      "guild_chat",
+		PlayerID:  c.playerID,
+		Username:  message.Username,
+		Text:      text,
+		GuildTag:  guildTag,
+		System:    c.isService,
+		Timestamp: time.Now().UnixMilli(),
+	}
+	go func() {
+		deliverGuildChat(guildID, guildMessage)
+		publishEnvelope(guildChatSubject(guildID), backplaneEnvelope{Message: guildMessage})
+	}()
+}
+
+// guildChatSubject is the backplane subject of a guild's chat
+func guildChatSubject(guildID int64) string {
+	return guildChatSubjectPrefix + strconv.FormatInt(guildID, 10)
+}
+
+// deliverGuildChat queues a guild chat message for the guild's members connected here,
+// including the sender, except anyone who blocked the sender
+func deliverGuildChat(guildID int64, message WebSocketMessage) {
+	blocks := config.GetBlockStore()
+	connections := connectionPool.snapshot()
+	targets := connections[:0]
+	for _, conn := range connections {
+		if conn.guildID.Load() == guildID && !blocks.IsBlocked(conn.playerID, message.PlayerID) {
+			targets = append(targets, conn)
+		}
+	}
+	queueToTargets(message, targets)
+}
+
+// handleGuildChatSubject delivers another instance's guild chat message
+func handleGuildChatSubject(subject string, data []byte) {
+	envelope, ok := decodeEnvelope(subject, data)
+	if !ok {
+		return
+	}
+	guildID, err := strconv.ParseInt(strings.TrimPrefix(subject, guildChatSubjectPrefix), 10, 64)
+	if err != nil {
+		return
+	}
+	deliverGuildChat(guildID, envelope.Message)
+}
+
+// loadGuild applies the player's guild membership to a new connection
+func (c *Connection) loadGuild(ctx context.Context, player *Player) {
+	if config.DB == nil || c.isService {
+		return
+	}
+	membership, err := config.GetGuildMembership(ctx, c.playerID)
+	if errors.Is(err, config.ErrNotInGuild) {
+		return
+	}
+	if err != nil {
+		c.logger.Warn("Could not read guild membership", "error", err)
+		return
+	}
+	c.guildID.Store(membership.Guild.ID)
+	player.SetGuildTag(membership.Guild.Tag)
+}
+
+// GuildChanged applies a player's new guild (nil if they left or it was disbanded) to their
+// live connection, wherever it is, and tells them
+func GuildChanged(playerID string, guild *config.Guild) {
+	message := WebSocketMessage{
+		Type:      "guild_changed",
+		PlayerID:  "system",
+		Timestamp: time.Now().UnixMilli(),
+	}
+	if guild != nil {
+		data, err := json.Marshal(guild)
+		if err != nil {
+			return
+		}
+		message.Data = data
+		message.GuildTag = guild.Tag
+	}
+	applyGuildChange(playerID, message)
+	publishEnvelope(guildMemberSubjectPrefix+playerID, backplaneEnvelope{Message: message})
+}
+
+// handleGuildMemberSubject applies another instance's guild change to a player connected here
+func handleGuildMemberSubject(subject string, data []byte) {
+	if envelope, ok := decodeEnvelope(subject, data); ok {
+		applyGuildChange(strings.TrimPrefix(subject, guildMemberSubjectPrefix), envelope.Message)
+	}
+}
+
+// applyGuildChange updates the player's connection and tag from a guild_changed message and
+// forwards it to them
+func applyGuildChange(playerID string, message WebSocketMessage) {
+	conn, exists := connectionPool.getConnection(playerID)
+	if !exists {
+		return
+	}
+	var guild config.Guild
+	if len(message.Data) > 0 {
+		if err := json.Unmarshal(message.Data, &guild); err != nil {
+			conn.logger.Warn("Malformed guild change", "error", err)
+			return
+		}
+	}
+	conn.guildID.Store(guild.ID)
+	if player := GetRoomManager().GetPlayer(playerID); player != nil {
+		player.SetGuildTag(guild.Tag)
+	}
+	conn.sendMessage(message)
+}
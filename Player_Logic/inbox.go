@@ -0,0 +1,59 @@
+package Player_Logic
+
+import (
+	"velvet/config"
+)
+
+// deliverInbox sends queued offline private messages in a single "inbox" batch and marks
+// them delivered once they're on the connection's send queue
+func (c *Connection) deliverInbox() {
+	if config.DB == nil || c.isService {
+		return
+	}
+
+	queued, err := config.GetUndeliveredMessages(c.playerID)
+	if err != nil {
+		c.logger.Warn("Could not load offline messages", "error", err)
+		return
+	}
+	if len(queued) == 0 {
+		return
+	}
+
+	blocks := config.GetBlockStore()
+	ids := make([]int64, 0, len(queued))
+	messages := make([]WebSocketMessage, 0, len(queued))
+	for _, m := range queued {
+		ids = append(ids, m.ID)
+		if blocks.IsBlocked(c.playerID, m.SenderID) {
+			continue // Blocked after sending; drop it like a live message
+		}
+		messages = append(messages, WebSocketMessage{
+			Type:           "private_message",
+			PlayerID:       m.SenderID,
+			TargetPlayerID: c.playerID,
+			Text:           m.Text,
+			Username:       m.Username,
+			Timestamp:      m.CreatedAt.UnixMilli(),
+			System:         m.System,
+		})
+	}
+
+	if len(messages) > 0 {
+		batchType := batchTypeForVersion(c.version(), "inbox")
+		data, err := c.codec.Marshal(BatchedMessage{Type: batchType, Messages: messages, Count: len(messages)})
+		if err != nil {
+			c.logger.Error("Error marshaling inbox", "error", err)
+			return
+		}
+		if c.ctx.Err() != nil || !c.enqueue(data) {
+			return // Disconnected first; keep the messages for next time
+		}
+	}
+
+	if err := config.MarkMessagesDelivered(ids); err != nil {
+		c.logger.Warn("Could not mark offline messages delivered", "error", err)
+		return
+	}
+	c.logger.Info("Delivered offline messages", "count", len(messages))
+}
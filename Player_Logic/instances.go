@@ -0,0 +1,214 @@
+package Player_Logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"velvet/config"
+)
+
+// The main room is instanced. Once it's full, players headed for it go to a copy built
+// from the same layout, with the same capacity and reserved slots, named after the main
+// room with a number: if the main room is ABC123, its instances are ABC123-1, ABC123-2,
+// and so on. Joins go to whichever instance has the fewest players, so instances fill
+// evenly, and a new one opens only when all of them are full (up to
+// MAIN_ROOM_MAX_INSTANCES). Players can hop between instances with HopInstance. Empty
+// instances are removed like any other empty room.
+
+// ErrNotMainInstance is returned when hopping to or from a room that isn't the main room
+// or one of its instances
+var ErrNotMainInstance = errors.New("not a main room instance")
+
+// isMainInstance reports whether the room is the main room or one of its instances
+func (rm *RoomManager) isMainInstance(room *Room) bool {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	return room == rm.mainRoom || (room.instance > 0 && rm.instances[room.instance] == room)
+}
+
+// mainInstancesLocked returns the main room followed by its instances in order (caller
+// holds rm.mu)
+func (rm *RoomManager) mainInstancesLocked() []*Room {
+	rooms := make([]*Room, 0, len(rm.instances)+1)
+	rooms = append(rooms, rm.mainRoom)
+	for _, room := range rm.instances {
+		rooms = append(rooms, room)
+	}
+	sort.Slice(rooms[1:], func(i, j int) bool { return rooms[1+i].instance < rooms[1+j].instance })
+	return rooms
+}
+
+// MainInstances returns directory entries for the main room and its instances
+func (rm *RoomManager) MainInstances() []RoomInfo {
+	rm.mu.RLock()
+	rooms := rm.mainInstancesLocked()
+	rm.mu.RUnlock()
+
+	infos := make([]RoomInfo, 0, len(rooms))
+	for i, room := range rooms {
+		room.mu.RLock()
+		infos = append(infos, room.infoLocked(i == 0))
+		room.mu.RUnlock()
+	}
+	return infos
+}
+
+// placeInMain calls join with main room instances, least crowded first, until one takes
+// the player. If every instance is full it opens a new one.
+func (rm *RoomManager) placeInMain(playerID string, join func(roomID string) error) error {
+	if rm.IsBanned(rm.MainRoomID(), playerID) {
+		return ErrPlayerBanned // A ban from the main room covers its instances
+	}
+
+	tried := make(map[*Room]bool)
+	for {
+		room := rm.leastCrowdedInstance(tried)
+		if room == nil {
+			var err error
+			if room, err = rm.openInstance(); err != nil {
+				return err
+			}
+		}
+		err := join(room.ID)
+		if !errors.Is(err, ErrRoomFull) {
+			return err
+		}
+		tried[room] = true
+	}
+}
+
+// leastCrowdedInstance returns the main room instance with the fewest players, skipping
+// tried ones; nil if none are left
+func (rm *RoomManager) leastCrowdedInstance(tried map[*Room]bool) *Room {
+	rm.mu.RLock()
+	rooms := rm.mainInstancesLocked()
+	rm.mu.RUnlock()
+
+	var best *Room
+	bestCount := 0
+	for _, room := range rooms {
+		if tried[room] {
+			continue
+		}
+		room.mu.RLock()
+		count := len(room.Players)
+		room.mu.RUnlock()
+		if best == nil || count < bestCount {
+			best, bestCount = room, count
+		}
+	}
+	return best
+}
+
+// openInstance creates the lowest-numbered main room instance that doesn't exist yet
+func (rm *RoomManager) openInstance() (*Room, error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if len(rm.instances) >= settings.Rooms.MainRoomMaxInstances {
+		return nil, fmt.Errorf("%w: %s and all of its instances", ErrRoomFull, rm.mainRoom.ID)
+	}
+	number := 1
+	for rm.instances[number] != nil || rm.rooms[rm.instanceIDLocked(number)] != nil {
+		number++
+	}
+
+	primary := rm.mainRoom
+	primary.mu.RLock()
+	room := &Room{
+		ID:            rm.instanceIDLocked(number),
+		Players:       make(map[string]*Player),
+		CreatedAt:     time.Now(),
+		LastActivity:  time.Now(),
+		Capacity:      primary.Capacity,
+		ReservedSlots: primary.ReservedSlots,
+		Name:          primary.Name,
+		Theme:         primary.Theme,
+		Moderators:    make(map[string]bool),
+		Banned:        make(map[string]bool),
+		instance:      number,
+	}
+	layout := primary.layoutLocked()
+	primary.mu.RUnlock()
+
+	room.applyLayout(layout)
+	rm.rooms[room.ID] = room
+	rm.instances[number] = room
+	rm.stats.mu.Lock()
+	rm.stats.totalRoomsCreated++
+	rm.stats.currentActiveRooms = int32(len(rm.rooms))
+	rm.stats.mu.Unlock()
+
+	slog.Info("Opened main room instance", "room_id", room.ID, "instance", number)
+	emitRoomCreated(room, "")
+	return room, nil
+}
+
+// instanceIDLocked returns the room ID of a main room instance (caller holds rm.mu)
+func (rm *RoomManager) instanceIDLocked(number int) string {
+	return rm.mainRoom.ID + "-" + strconv.Itoa(number)
+}
+
+// forgetInstanceLocked drops a removed room from the main room's instances (caller holds
+// rm.mu for writing)
+func (rm *RoomManager) forgetInstanceLocked(room *Room) {
+	if room.instance > 0 && rm.instances[room.instance] == room {
+		delete(rm.instances, room.instance)
+	}
+}
+
+// adoptInstancesLocked registers restored rooms named after the main room as its
+// instances (caller holds rm.mu for writing)
+func (rm *RoomManager) adoptInstancesLocked() {
+	prefix := rm.mainRoom.ID + "-"
+	for roomID, room := range rm.rooms {
+		number, err := strconv.Atoi(strings.TrimPrefix(roomID, prefix))
+		if !strings.HasPrefix(roomID, prefix) || err != nil || number < 1 {
+			continue
+		}
+		room.instance = number
+		rm.instances[number] = room
+	}
+}
+
+// transferToMain moves a player into the least crowded main room instance that takes them
+func (rm *RoomManager) transferToMain(ctx context.Context, playerID string) (oldRoom, newRoom *Room, err error) {
+	err = rm.placeInMain(playerID, func(roomID string) (err error) {
+		oldRoom, newRoom, err = rm.TransferPlayer(ctx, playerID, roomID)
+		return err
+	})
+	return oldRoom, newRoom, err
+}
+
+// HopInstance moves a connected player from one main room instance to another
+func (rm *RoomManager) HopInstance(ctx context.Context, playerID, roomID string) (*Room, error) {
+	current := rm.GetPlayerRoom(playerID)
+	if current == nil {
+		return nil, ErrPlayerNotConnected
+	}
+	target := rm.getRoomByID(roomID)
+	if target == nil {
+		return nil, fmt.Errorf("%w: %s", ErrRoomNotFound, roomID)
+	}
+	if !rm.isMainInstance(current) || !rm.isMainInstance(target) {
+		return nil, ErrNotMainInstance
+	}
+	if rm.IsBanned(rm.MainRoomID(), playerID) {
+		return nil, ErrPlayerBanned
+	}
+
+	oldRoom, newRoom, err := rm.TransferPlayer(ctx, playerID, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	moveConnectionToRoom(playerID, oldRoom, newRoom)
+	config.UpdateLastRoomAsync(ctx, playerID, newRoom.ID)
+
+	slog.Info("Player hopped instances", "player_id", playerID, "from_room", oldRoom.ID, "to_room", newRoom.ID)
+	return newRoom, nil
+}
@@ -0,0 +1,252 @@
+package Player_Logic
+
+import (
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+// Area of interest: position updates only go to players within the interest radius
+// (AOI_RADIUS in world units, 0 sends them room-wide). Players see each other
+// symmetrically; "player_entered_view" and "player_left_view" tell clients when to start
+// and stop rendering someone. player_joined/player_left stay room-wide so rosters work.
+// A player leaves view only beyond radius * InterestExitFactor, so someone walking
+// along the edge doesn't flicker in and out
+const InterestExitFactor = 1.2
+
+var (
+	interestRadius     float64
+	interestRadiusOnce sync.Once
+)
+
+// getInterestRadius returns the configured radius (0 means AOI is off)
+func getInterestRadius() float64 {
+	interestRadiusOnce.Do(func() {
+		interestRadius = settings.Rooms.InterestRadius
+		slog.Info("Area of interest configured", "radius", interestRadius)
+	})
+	return interestRadius
+}
+
+type gridCell struct{ x, y int }
+
+// interestGrid buckets a room's players into cells the size of the exit radius, so
+// everyone a player could see is in the 3x3 block of cells around them
+type interestGrid struct {
+	radius   float64
+	cellSize float64
+	cells    map[gridCell]map[string]bool
+	cellOf   map[string]gridCell
+	views    map[string]map[string]bool // Player ID -> players currently in view
+}
+
+// interestChange is the result of moving a player through the grid
+type interestChange struct {
+	visible map[string]bool // Players who should receive the mover's position
+	entered []string        // Came into view of each other
+	exited  []string        // Went out of view of each other
+}
+
+func newInterestGrid(radius float64) *interestGrid {
+	return &interestGrid{
+		radius:   radius,
+		cellSize: radius * InterestExitFactor,
+		cells:    make(map[gridCell]map[string]bool),
+		cellOf:   make(map[string]gridCell),
+		views:    make(map[string]map[string]bool),
+	}
+}
+
+func (g *interestGrid) cellFor(position Position) gridCell {
+	return gridCell{x: int(math.Floor(position.X / g.cellSize)), y: int(math.Floor(position.Y / g.cellSize))}
+}
+
+// place moves a player into the cell for their position
+func (g *interestGrid) place(playerID string, position Position) {
+	cell := g.cellFor(position)
+	if old, exists := g.cellOf[playerID]; exists {
+		if old == cell {
+			return
+		}
+		g.removeFromCell(playerID, old)
+	}
+	if g.cells[cell] == nil {
+		g.cells[cell] = make(map[string]bool)
+	}
+	g.cells[cell][playerID] = true
+	g.cellOf[playerID] = cell
+}
+
+func (g *interestGrid) removeFromCell(playerID string, cell gridCell) {
+	delete(g.cells[cell], playerID)
+	if len(g.cells[cell]) == 0 {
+		delete(g.cells, cell)
+	}
+}
+
+// remove drops a player from the grid and from everyone's view
+func (g *interestGrid) remove(playerID string) {
+	if cell, exists := g.cellOf[playerID]; exists {
+		g.removeFromCell(playerID, cell)
+		delete(g.cellOf, playerID)
+	}
+	for other := range g.views[playerID] {
+		delete(g.views[other], playerID)
+	}
+	delete(g.views, playerID)
+}
+
+func (g *interestGrid) setVisible(a, b string, visible bool) {
+	for _, pair := range [][2]string{{a, b}, {b, a}} {
+		if visible {
+			if g.views[pair[0]] == nil {
+				g.views[pair[0]] = make(map[string]bool)
+			}
+			g.views[pair[0]][pair[1]] = true
+		} else {
+			delete(g.views[pair[0]], pair[1])
+		}
+	}
+}
+
+// ensureInterestLocked creates the room's grid on first use; nil when AOI is off (caller holds r.mu)
+func (r *Room) ensureInterestLocked() *interestGrid {
+	radius := getInterestRadius()
+	if radius == 0 {
+		return nil
+	}
+	if r.interest == nil {
+		r.interest = newInterestGrid(radius)
+	}
+	return r.interest
+}
+
+// updateInterestLocked re-places a player after they move and works out who can see them
+// now. Returns nil when AOI is off (caller holds r.mu).
+func (r *Room) updateInterestLocked(playerID string) *interestChange {
+	grid := r.ensureInterestLocked()
+	player, exists := r.Players[playerID]
+	if grid == nil || !exists {
+		return nil
+	}
+
+	position := player.Position
+	grid.place(playerID, position)
+	center := grid.cellFor(position)
+	change := &interestChange{visible: make(map[string]bool)}
+	seen := make(map[string]bool)
+
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			for otherID := range grid.cells[gridCell{x: center.x + dx, y: center.y + dy}] {
+				if otherID == playerID {
+					continue
+				}
+				other, exists := r.Players[otherID]
+				if !exists {
+					grid.remove(otherID) // Left without going through the normal removal path
+					continue
+				}
+				if other.Hidden {
+					continue
+				}
+				seen[otherID] = true
+
+				distance := math.Hypot(other.Position.X-position.X, other.Position.Y-position.Y)
+				inView := grid.views[playerID][otherID]
+				switch {
+				case distance <= grid.radius:
+					change.visible[otherID] = true
+					if !inView {
+						grid.setVisible(playerID, otherID, true)
+						change.entered = append(change.entered, otherID)
+					}
+				case inView && distance <= grid.radius*InterestExitFactor:
+					change.visible[otherID] = true
+				case inView:
+					grid.setVisible(playerID, otherID, false)
+					change.exited = append(change.exited, otherID)
+				}
+			}
+		}
+	}
+
+	// Anyone still marked in view but outside the neighborhood is out of range
+	for otherID := range grid.views[playerID] {
+		if !seen[otherID] {
+			grid.setVisible(playerID, otherID, false)
+			if _, exists := r.Players[otherID]; exists {
+				change.exited = append(change.exited, otherID)
+			}
+		}
+	}
+	return change
+}
+
+// removeInterestLocked takes a departing player out of the grid (caller holds r.mu)
+func (r *Room) removeInterestLocked(playerID string) {
+	if r.interest != nil {
+		r.interest.remove(playerID)
+	}
+}
+
+// skip returns a broadcast filter for players who can't see the mover
+func (change *interestChange) skip() func(string) bool {
+	if change == nil {
+		return nil
+	}
+	return func(playerID string) bool { return !change.visible[playerID] }
+}
+
+// notify sends entered/left view events to both sides of every change
+func (change *interestChange) notify(room *Room, playerID string) {
+	if change == nil || len(change.entered)+len(change.exited) == 0 {
+		return
+	}
+
+	room.mu.RLock()
+	positions := make(map[string]WebSocketMessage, len(change.entered)+1)
+	for _, id := range append([]string{playerID}, change.entered...) {
+		if p, exists := room.Players[id]; exists {
+			position := p.Position
+			positions[id] = WebSocketMessage{
+				Type:      "player_entered_view",
+				PlayerID:  id,
+				Position:  &position,
+				Username:  p.Username,
+				Avatar:    p.GetAvatar(),
+				Timestamp: time.Now().UnixMilli(),
+			}
+		}
+	}
+	room.mu.RUnlock()
+
+	send := func(to string, message WebSocketMessage) {
+		if conn, exists := connectionPool.getConnection(to); exists {
+			conn.sendMessage(message)
+		}
+	}
+	for _, otherID := range change.entered {
+		if entered, exists := positions[otherID]; exists {
+			send(playerID, entered)
+			send(otherID, positions[playerID])
+		}
+	}
+	for _, otherID := range change.exited {
+		now := time.Now().UnixMilli()
+		send(playerID, WebSocketMessage{Type: "player_left_view", PlayerID: otherID, Timestamp: now})
+		send(otherID, WebSocketMessage{Type: "player_left_view", PlayerID: playerID, Timestamp: now})
+	}
+}
+
+// refreshInterest computes a player's view after they join or connect
+func (r *Room) refreshInterest(playerID string) {
+	r.mu.Lock()
+	var change *interestChange
+	if player, exists := r.Players[playerID]; exists && !player.Hidden {
+		change = r.updateInterestLocked(playerID)
+	}
+	r.mu.Unlock()
+	change.notify(r, playerID)
+}
@@ -0,0 +1,74 @@
+package Player_Logic
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Room manager operations for other backend services (matchmaking, moderation bots),
+// exposed over the internal gRPC API
+
+// PlayerLocation is where a player is right now
+type PlayerLocation struct {
+	PlayerID  string   `json:"player_id"`
+	RoomID    string   `json:"room_id"`
+	Position  Position `json:"position"`
+	Connected bool     `json:"connected"` // False while held for session resume
+}
+
+// LocatePlayer returns the player's room and position
+func (rm *RoomManager) LocatePlayer(playerID string) (PlayerLocation, error) {
+	room := rm.GetPlayerRoom(playerID)
+	if room == nil {
+		return PlayerLocation{}, ErrPlayerNotConnected
+	}
+
+	room.mu.RLock()
+	player, inRoom := room.Players[playerID]
+	var position Position
+	if inRoom {
+		position = player.Position
+	}
+	room.mu.RUnlock()
+	if !inRoom {
+		return PlayerLocation{}, ErrPlayerNotConnected
+	}
+
+	_, connected := connectionPool.getConnection(playerID)
+	return PlayerLocation{PlayerID: playerID, RoomID: room.ID, Position: position, Connected: connected}, nil
+}
+
+// ForceMovePlayer moves a player into an existing room ("main" for the main room or one of
+// its instances), skipping portals and party rules. Returns the room they left.
+func (rm *RoomManager) ForceMovePlayer(playerID, roomID string) (string, error) {
+	if roomID != PortalMainRoom && rm.getRoomByID(roomID) == nil {
+		return "", ErrRoomNotFound
+	}
+	if rm.GetPlayerRoom(playerID) == nil {
+		return "", ErrPlayerNotConnected
+	}
+
+	var oldRoom, newRoom *Room
+	var err error
+	if roomID == PortalMainRoom {
+		oldRoom, newRoom, err = rm.transferToMain(context.Background(), playerID)
+	} else {
+		oldRoom, newRoom, err = rm.TransferPlayer(context.Background(), playerID, roomID)
+	}
+	if err != nil {
+		return "", err
+	}
+	moveConnectionToRoom(playerID, oldRoom, newRoom)
+
+	slog.Info("Service moved player", "player_id", playerID, "from_room", oldRoom.ID, "to_room", newRoom.ID)
+	return oldRoom.ID, nil
+}
+
+// SendBroadcast delivers a broadcast right away; SendAt is ignored
+func SendBroadcast(b ScheduledBroadcast) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	deliverBroadcast(b)
+	return nil
+}
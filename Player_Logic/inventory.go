@@ -0,0 +1,178 @@
+package Player_Logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+	"velvet/config"
+)
+
+// Inventory. Items are defined in itemCatalog and owned quantities are stored per player
+// (config/inventory.go). Grants push an "item_granted" message to the player; using a
+// consumable removes one and shows an "item_used" message to the room.
+const MaxGrantQuantity = 100 // Largest quantity a single grant may add
+
+// Item categories
+const (
+	ItemConsumable  = "consumable" // Used up one at a time
+	ItemWearable    = "wearable"
+	ItemDecor       = "decor"
+	ItemCollectible = "collectible"
+)
+
+var (
+	ErrUnknownItem     = errors.New("unknown item")
+	ErrInvalidQuantity = errors.New("quantity must be between 1 and 100")
+	ErrNotConsumable   = errors.New("item can't be used")
+)
+
+// ItemDef describes an item players can own
+type ItemDef struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	MaxStack int    `json:"max_stack"` // Most a player can hold at once
+}
+
+// itemCatalog is the set of items the server knows about
+var itemCatalog = map[string]ItemDef{
+	"coffee":     {ID: "coffee", Name: "Coffee", Category: ItemConsumable, MaxStack: 99},
+	"pizza":      {ID: "pizza", Name: "Pizza slice", Category: ItemConsumable, MaxStack: 99},
+	"balloon":    {ID: "balloon", Name: "Balloon", Category: ItemConsumable, MaxStack: 20},
+	"rose":       {ID: "rose", Name: "Rose", Category: ItemConsumable, MaxStack: 20},
+	"party_hat":  {ID: "party_hat", Name: "Party hat", Category: ItemWearable, MaxStack: 1},
+	"sunglasses": {ID: "sunglasses", Name: "Sunglasses", Category: ItemWearable, MaxStack: 1},
+	"plant":      {ID: "plant", Name: "Potted plant", Category: ItemDecor, MaxStack: 10},
+	"lamp":       {ID: "lamp", Name: "Floor lamp", Category: ItemDecor, MaxStack: 10},
+	"trophy":     {ID: "trophy", Name: "Trophy", Category: ItemCollectible, MaxStack: 1},
+}
+
+// ItemCatalog returns all item definitions sorted by ID
+func ItemCatalog() []ItemDef {
+	items := make([]ItemDef, 0, len(itemCatalog))
+	for _, item := range itemCatalog {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items
+}
+
+// InventoryEntry is an owned item with its definition, as shown to clients
+type InventoryEntry struct {
+	ItemDef
+	Quantity   int   `json:"quantity"`
+	AcquiredAt int64 `json:"acquired_at"` // Unix ms
+}
+
+// itemEvent is the data of item_granted and item_used messages
+type itemEvent struct {
+	ItemID   string `json:"item_id"`
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`         // How many were granted or used
+	Total    int    `json:"total"`            // How many the player holds now
+	Source   string `json:"source,omitempty"` // Why it was granted ("admin", "reward", ...)
+}
+
+// GetInventory returns a player's items with their definitions
+func GetInventory(ctx context.Context, playerID string) ([]InventoryEntry, error) {
+	items, err := config.GetInventory(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]InventoryEntry, 0, len(items))
+	for _, item := range items {
+		def, known := itemCatalog[item.ItemID]
+		if !known {
+			def = ItemDef{ID: item.ItemID, Name: item.ItemID} // Retired item, still owned
+		}
+		entries = append(entries, InventoryEntry{ItemDef: def, Quantity: item.Quantity, AcquiredAt: item.AcquiredAt.UnixMilli()})
+	}
+	return entries, nil
+}
+
+// GrantItem adds items to a player's inventory and tells them if they're online
+func GrantItem(ctx context.Context, playerID, itemID string, quantity int, source string) (config.InventoryItem, error) {
+	def, known := itemCatalog[itemID]
+	if !known {
+		return config.InventoryItem{}, ErrUnknownItem
+	}
+	if quantity < 1 || quantity > MaxGrantQuantity {
+		return config.InventoryItem{}, ErrInvalidQuantity
+	}
+
+	item, err := config.GrantInventoryItem(ctx, playerID, itemID, quantity, def.MaxStack)
+	if err != nil {
+		return item, err
+	}
+
+	// Inside a caller's transaction, only tell the player once the grant is committed
+	config.AfterCommit(ctx, func() {
+		config.Logger(ctx).Info("Item granted", "player_id", playerID, "item_id", itemID, "quantity", quantity, "source", source)
+		sendItemEvent(playerID, playerID, "item_granted", itemEvent{
+			ItemID: itemID, Name: def.Name, Quantity: quantity, Total: item.Quantity, Source: source,
+		})
+	})
+	return item, nil
+}
+
+// DiscardItem removes items from a player's inventory and returns how many are left
+func DiscardItem(ctx context.Context, playerID, itemID string, quantity int) (int, error) {
+	if quantity < 1 || quantity > MaxGrantQuantity {
+		return 0, ErrInvalidQuantity
+	}
+	return config.RemoveInventoryItem(ctx, playerID, itemID, quantity)
+}
+
+// UseItem consumes one of a consumable item and shows it to the player's room
+func UseItem(ctx context.Context, playerID, itemID string) (int, error) {
+	def, known := itemCatalog[itemID]
+	if !known {
+		return 0, ErrUnknownItem
+	}
+	if def.Category != ItemConsumable {
+		return 0, ErrNotConsumable
+	}
+
+	remaining, err := config.RemoveInventoryItem(ctx, playerID, itemID, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	rm := GetRoomManager()
+	player := rm.GetPlayer(playerID)
+	room := rm.GetPlayerRoom(playerID)
+	if player == nil || room == nil || player.Hidden {
+		return remaining, nil
+	}
+
+	data, err := json.Marshal(itemEvent{ItemID: itemID, Name: def.Name, Quantity: 1, Total: remaining})
+	if err != nil {
+		return remaining, nil
+	}
+	position := player.GetPosition()
+	go broadcastToRoomAsync(room, "", WebSocketMessage{
+		Type:      "item_used",
+		PlayerID:  playerID,
+		Position:  &position,
+		Data:      data,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	return remaining, nil
+}
+
+// sendItemEvent delivers an inventory message to one player
+func sendItemEvent(recipientID, playerID, messageType string, event itemEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	sendToPlayer(recipientID, WebSocketMessage{
+		Type:      messageType,
+		PlayerID:  playerID,
+		Data:      data,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
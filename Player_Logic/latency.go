@@ -0,0 +1,96 @@
+package Player_Logic
+
+import (
+	"strconv"
+	"time"
+)
+
+// Round-trip time is measured with the keepalive pings: each ping frame carries its send
+// time, which the client's pong echoes back. Samples are smoothed (like TCP's SRTT) so a
+// single slow pong doesn't make latency indicators jump. Browser clients can't see control
+// frames, so they send {"type":"ping","client_time":...} instead and time the "pong"
+// reply themselves; it echoes client_time and carries the server's rtt_ms.
+const rttSmoothing = 0.125 // Weight of each new sample
+
+// pingPayload returns the data for a ping frame sent at now
+func pingPayload(now time.Time) []byte {
+	return []byte(strconv.FormatInt(now.UnixNano(), 10))
+}
+
+// handlePong records the round trip of a pong echoing one of our pings. Called from
+// readPump only, so the smoothed value has a single writer.
+func (c *Connection) handlePong(payload string, now time.Time) {
+	sent, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		return // Unsolicited pong, not an echo of our ping
+	}
+	sample := now.Sub(time.Unix(0, sent))
+	if sample < 0 || sample > settings.WebSocket.PongTimeout {
+		return
+	}
+
+	smoothed := time.Duration(c.rtt.Load())
+	if smoothed == 0 {
+		smoothed = sample
+	} else {
+		smoothed += time.Duration(float64(sample-smoothed) * rttSmoothing)
+	}
+	c.rtt.Store(int64(smoothed))
+	wsRoundTrip.Observe(sample.Seconds())
+	c.checkLatency(now)
+}
+
+// rttMillis returns the connection's smoothed round-trip time in milliseconds, or 0 before
+// the first pong
+func (c *Connection) rttMillis() int {
+	return int(time.Duration(c.rtt.Load()).Milliseconds())
+}
+
+// playerRTTMillis returns a connected player's round-trip time in milliseconds (0 if unknown)
+func playerRTTMillis(playerID string) int {
+	if conn, exists := connectionPool.getConnection(playerID); exists {
+		return conn.rttMillis()
+	}
+	return 0
+}
+
+// handlePing answers a client's ping message so it can time the round trip
+func (c *Connection) handlePing(message WebSocketMessage) {
+	c.sendMessage(WebSocketMessage{
+		Type:       "pong",
+		PlayerID:   "system",
+		ClientTime: message.ClientTime,
+		RTTMs:      c.rttMillis(),
+		Timestamp:  time.Now().UnixMilli(),
+	})
+}
+
+// handleTimeSync answers a time_sync so the client can estimate its clock offset from the
+// server's: with t0 its client_time and t1 when the reply arrived, the offset is about
+// timestamp - (t0+t1)/2. Clients apply it to message timestamps when interpolating.
+func (c *Connection) handleTimeSync(message WebSocketMessage) {
+	c.sendMessage(WebSocketMessage{
+		Type:       "time_sync",
+		PlayerID:   "system",
+		ClientTime: message.ClientTime,
+		Timestamp:  time.Now().UnixMilli(),
+	})
+}
+
+// averageRTTMillis returns the mean round-trip time of connections that have one
+func (cp *ConnectionPool) averageRTTMillis() float64 {
+	cp.mu.RLock()
+	defer cp.mu.RUnlock()
+
+	var total, measured int
+	for _, conn := range cp.connections {
+		if rtt := conn.rttMillis(); rtt > 0 {
+			total += rtt
+			measured++
+		}
+	}
+	if measured == 0 {
+		return 0
+	}
+	return float64(total) / float64(measured)
+}
@@ -0,0 +1,370 @@
+package Player_Logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+	"velvet/config"
+)
+
+// Room layouts describe a room's map: its size, where players spawn, its walls and named
+// zones, and the objects it starts furnished with. Layouts are stored in the database (the
+// built-in "default" layout always exists) and joining clients get the room's layout as
+// "room_layout" before the player list. A room keeps the layout it was created with, so
+// editing a layout only changes rooms created afterwards.
+const (
+	DefaultLayoutID      = "default"
+	MaxLayoutNameLength  = 64
+	MaxLayoutSize        = 100000 // World units on each axis
+	MaxLayoutSpawnPoints = 32
+	MaxLayoutWalls       = 500
+	MaxLayoutZones       = 64
+)
+
+var (
+	ErrUnknownLayout = errors.New("unknown layout")
+	ErrBuiltinLayout = errors.New("built-in layouts can't be changed")
+)
+
+var layoutIDPattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// Rect is an axis-aligned rectangle with its origin at the top-left corner
+type Rect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Contains reports whether the position is inside the rectangle
+func (r Rect) Contains(p Position) bool {
+	return p.X >= r.X && p.X <= r.X+r.Width && p.Y >= r.Y && p.Y <= r.Y+r.Height
+}
+
+// LayoutZone is a named area of a layout (e.g. "stage", "lounge")
+type LayoutZone struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Area Rect   `json:"area"`
+}
+
+// RoomLayout is a map definition rooms are built from
+type RoomLayout struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Width       float64           `json:"width,omitempty"`  // 0 leaves the axis unbounded
+	Height      float64           `json:"height,omitempty"` // 0 leaves the axis unbounded
+	SpawnPoints []Position        `json:"spawn_points,omitempty"`
+	Walls       []Rect            `json:"walls,omitempty"`
+	Zones       []LayoutZone      `json:"zones,omitempty"`
+	Objects     []ObjectPlacement `json:"objects,omitempty"` // Placed when a room is created
+	NPCs        []NPCDefinition   `json:"npcs,omitempty"`    // Spawned when a room is created or restored
+	UpdatedAt   time.Time         `json:"updated_at,omitempty"`
+
+	collision *collisionGrid // Walls indexed for movement checks, built by prepare
+}
+
+// builtinLayouts always exist and can't be edited or deleted
+var builtinLayouts = map[string]*RoomLayout{
+	DefaultLayoutID: {
+		ID:          DefaultLayoutID,
+		Name:        "Open plaza",
+		SpawnPoints: []Position{{X: 0, Y: 0}},
+		Objects: []ObjectPlacement{
+			{Kind: ObjectDoor, Position: Position{X: 0, Y: -300}},
+			{Kind: ObjectLamp, Position: Position{X: -200, Y: -200}},
+			{Kind: ObjectChair, Position: Position{X: -60, Y: 100}},
+			{Kind: ObjectChair, Position: Position{X: 60, Y: 100}},
+			{Kind: ObjectSign, Position: Position{X: 200, Y: -200}},
+		},
+	},
+}
+
+// Validate checks a layout definition against the limits above
+func (l *RoomLayout) Validate() error {
+	if !layoutIDPattern.MatchString(l.ID) {
+		return fmt.Errorf("id must be 1-32 lowercase letters, digits, dashes, or underscores")
+	}
+	if l.Name == "" || len(l.Name) > MaxLayoutNameLength {
+		return fmt.Errorf("name must be 1-%d characters", MaxLayoutNameLength)
+	}
+	if l.Width < 0 || l.Height < 0 || l.Width > MaxLayoutSize || l.Height > MaxLayoutSize {
+		return fmt.Errorf("width and height must be between 0 and %d", MaxLayoutSize)
+	}
+	if len(l.SpawnPoints) > MaxLayoutSpawnPoints {
+		return fmt.Errorf("at most %d spawn points are allowed", MaxLayoutSpawnPoints)
+	}
+	if len(l.Walls) > MaxLayoutWalls {
+		return fmt.Errorf("at most %d walls are allowed", MaxLayoutWalls)
+	}
+	if len(l.Zones) > MaxLayoutZones {
+		return fmt.Errorf("at most %d zones are allowed", MaxLayoutZones)
+	}
+	if len(l.Objects) > MaxObjectsPerRoom {
+		return ErrTooManyObjects
+	}
+	if len(l.NPCs) > MaxNPCsPerRoom {
+		return ErrTooManyNPCs
+	}
+
+	for i, wall := range l.Walls {
+		if wall.Width <= 0 || wall.Height <= 0 {
+			return fmt.Errorf("walls[%d] must have a positive width and height", i)
+		}
+	}
+	if wallCells(l.Walls) > MaxCollisionCells {
+		return fmt.Errorf("walls cover too much of the map (max %d grid cells of %g units)", MaxCollisionCells, CollisionCellSize)
+	}
+	for i, spawn := range l.SpawnPoints {
+		if !l.inBounds(spawn) {
+			return fmt.Errorf("spawn_points[%d] is outside the map", i)
+		}
+		for _, wall := range l.Walls {
+			if wall.Contains(spawn) {
+				return fmt.Errorf("spawn_points[%d] is inside a wall", i)
+			}
+		}
+	}
+	zoneIDs := make(map[string]bool, len(l.Zones))
+	for i, zone := range l.Zones {
+		if !layoutIDPattern.MatchString(zone.ID) || zoneIDs[zone.ID] {
+			return fmt.Errorf("zones[%d] needs a unique id of lowercase letters, digits, dashes, or underscores", i)
+		}
+		zoneIDs[zone.ID] = true
+		if len(zone.Name) > MaxLayoutNameLength {
+			return fmt.Errorf("zones[%d].name too long (max %d characters)", i, MaxLayoutNameLength)
+		}
+		if zone.Area.Width <= 0 || zone.Area.Height <= 0 {
+			return fmt.Errorf("zones[%d] must have a positive width and height", i)
+		}
+	}
+	for i, object := range l.Objects {
+		if err := object.validate(); err != nil {
+			return fmt.Errorf("objects[%d]: %w", i, err)
+		}
+		if !l.inBounds(object.Position) {
+			return fmt.Errorf("objects[%d] is outside the map", i)
+		}
+	}
+	for i, npc := range l.NPCs {
+		if err := npc.validate(); err != nil {
+			return fmt.Errorf("npcs[%d]: %w", i, err)
+		}
+		if !l.inBounds(npc.Position) {
+			return fmt.Errorf("npcs[%d] is outside the map", i)
+		}
+		for _, point := range npc.Route {
+			if !l.inBounds(point) {
+				return fmt.Errorf("npcs[%d] has a route point outside the map", i)
+			}
+		}
+	}
+	return nil
+}
+
+// inBounds reports whether a position is inside the map (unbounded axes always are)
+func (l *RoomLayout) inBounds(p Position) bool {
+	if l.Width > 0 && (p.X < 0 || p.X > l.Width) {
+		return false
+	}
+	if l.Height > 0 && (p.Y < 0 || p.Y > l.Height) {
+		return false
+	}
+	return true
+}
+
+// prepare builds the layout's collision grid
+func (l *RoomLayout) prepare() *RoomLayout {
+	l.collision = newCollisionGrid(l.Walls)
+	return l
+}
+
+// SpawnPoint picks one of the layout's spawn points at random (the origin if it has none)
+func (l *RoomLayout) SpawnPoint() Position {
+	if len(l.SpawnPoints) == 0 {
+		return Position{}
+	}
+	return l.SpawnPoints[rand.Intn(len(l.SpawnPoints))]
+}
+
+// layoutCache holds stored layouts after their first use so room creation stays off the database
+type layoutCache struct {
+	layouts map[string]*RoomLayout
+	mu      sync.RWMutex
+}
+
+var storedLayouts = &layoutCache{layouts: make(map[string]*RoomLayout)}
+
+// decodeLayout parses a stored layout definition
+func decodeLayout(row config.RoomLayoutRow) (*RoomLayout, error) {
+	var layout RoomLayout
+	if err := json.Unmarshal(row.Definition, &layout); err != nil {
+		return nil, fmt.Errorf("failed to decode layout %s: %w", row.ID, err)
+	}
+	layout.ID, layout.Name, layout.UpdatedAt = row.ID, row.Name, row.UpdatedAt
+	return layout.prepare(), nil
+}
+
+// GetLayout returns the layout with the given ID; an empty ID is the default layout
+func GetLayout(ctx context.Context, id string) (*RoomLayout, error) {
+	if id == "" {
+		id = DefaultLayoutID
+	}
+	if layout, builtin := builtinLayouts[id]; builtin {
+		return layout, nil
+	}
+
+	storedLayouts.mu.RLock()
+	layout, cached := storedLayouts.layouts[id]
+	storedLayouts.mu.RUnlock()
+	if cached {
+		return layout, nil
+	}
+
+	if config.DB == nil || !layoutIDPattern.MatchString(id) {
+		return nil, ErrUnknownLayout
+	}
+	row, err := config.GetRoomLayout(ctx, id)
+	if errors.Is(err, config.ErrLayoutNotFound) {
+		return nil, ErrUnknownLayout
+	}
+	if err != nil {
+		return nil, err
+	}
+	if layout, err = decodeLayout(*row); err != nil {
+		return nil, err
+	}
+
+	storedLayouts.mu.Lock()
+	storedLayouts.layouts[id] = layout
+	storedLayouts.mu.Unlock()
+	return layout, nil
+}
+
+// ListLayouts returns the built-in layouts followed by the stored ones
+func ListLayouts(ctx context.Context) ([]*RoomLayout, error) {
+	layouts := make([]*RoomLayout, 0, len(builtinLayouts))
+	for _, layout := range builtinLayouts {
+		layouts = append(layouts, layout)
+	}
+	sort.Slice(layouts, func(i, j int) bool { return layouts[i].ID < layouts[j].ID })
+
+	if config.DB == nil {
+		return layouts, nil
+	}
+	rows, err := config.ListRoomLayouts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		layout, err := decodeLayout(row)
+		if err != nil {
+			slog.Warn("Skipping unreadable layout", "layout_id", row.ID, "error", err)
+			continue
+		}
+		layouts = append(layouts, layout)
+	}
+	return layouts, nil
+}
+
+// SaveLayout validates and stores a layout, replacing any layout with the same ID
+func SaveLayout(ctx context.Context, layout RoomLayout) (*RoomLayout, error) {
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+	if _, builtin := builtinLayouts[layout.ID]; builtin {
+		return nil, ErrBuiltinLayout
+	}
+
+	layout.UpdatedAt = time.Now()
+	definition, err := json.Marshal(layout)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.SaveRoomLayout(ctx, config.RoomLayoutRow{ID: layout.ID, Name: layout.Name, Definition: definition}); err != nil {
+		return nil, err
+	}
+
+	storedLayouts.mu.Lock()
+	storedLayouts.layouts[layout.ID] = layout.prepare()
+	storedLayouts.mu.Unlock()
+	return &layout, nil
+}
+
+// DeleteLayout removes a stored layout. Rooms built from it keep their copy.
+func DeleteLayout(ctx context.Context, id string) error {
+	if _, builtin := builtinLayouts[id]; builtin {
+		return ErrBuiltinLayout
+	}
+	if err := config.DeleteRoomLayout(ctx, id); err != nil {
+		if errors.Is(err, config.ErrLayoutNotFound) {
+			return ErrUnknownLayout
+		}
+		return err
+	}
+
+	storedLayouts.mu.Lock()
+	delete(storedLayouts.layouts, id)
+	storedLayouts.mu.Unlock()
+	return nil
+}
+
+// Layout returns the layout the room was built from
+func (r *Room) Layout() *RoomLayout {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.layoutLocked()
+}
+
+// layoutLocked returns the room's layout, falling back to the default (caller holds r.mu)
+func (r *Room) layoutLocked() *RoomLayout {
+	if r.layout == nil {
+		return builtinLayouts[DefaultLayoutID]
+	}
+	return r.layout
+}
+
+// applyLayout builds a new room from a layout: it keeps the layout, places its objects,
+// and spawns its NPCs
+func (r *Room) applyLayout(layout *RoomLayout) {
+	r.mu.Lock()
+	r.layout = layout
+	r.mu.Unlock()
+	r.furnishDefaults(r.Layout().Objects)
+	r.spawnLayoutNPCs()
+}
+
+// movementLimitsLocked narrows the configured map bounds to the room's layout (caller holds r.mu)
+func (r *Room) movementLimitsLocked() movementLimits {
+	limits := getMovementLimits()
+	layout := r.layoutLocked()
+	if layout.Width > 0 {
+		limits.width = layout.Width
+	}
+	if layout.Height > 0 {
+		limits.height = layout.Height
+	}
+	return limits
+}
+
+// sendRoomLayout sends the room's layout to a newly connected client
+func (c *Connection) sendRoomLayout(room *Room) {
+	data, err := json.Marshal(room.Layout())
+	if err != nil {
+		return
+	}
+	c.sendMessage(WebSocketMessage{
+		Type:      "room_layout",
+		PlayerID:  "system",
+		RoomID:    room.ID,
+		Data:      data,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
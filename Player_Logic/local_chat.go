@@ -0,0 +1,65 @@
+package Player_Logic
+
+import (
+	"errors"
+	"math"
+)
+
+// Local chat. A chat_message with mode "local" only reaches players within
+// LOCAL_CHAT_RADIUS of the sender's position; the default mode "room" reaches the whole
+// room (still subject to channels and blocks). The mode is echoed on the delivered message.
+// Candidates come from the room's interest grid when AOI is on, otherwise every player is
+// checked.
+const (
+	ChatModeRoom  = "room"
+	ChatModeLocal = "local"
+)
+
+var ErrUnknownChatMode = errors.New("chat mode must be \"room\" or \"local\"")
+
+// nearby returns the IDs of players in grid cells that could be within radius of position
+func (g *interestGrid) nearby(position Position, radius float64) []string {
+	center := g.cellFor(position)
+	reach := int(math.Ceil(radius / g.cellSize))
+
+	var ids []string
+	for dx := -reach; dx <= reach; dx++ {
+		for dy := -reach; dy <= reach; dy++ {
+			for id := range g.cells[gridCell{x: center.x + dx, y: center.y + dy}] {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// localAudience returns a skip function leaving out everyone farther than radius from the
+// sender
+func (r *Room) localAudience(senderID string, radius float64) func(string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sender, exists := r.Players[senderID]
+	if !exists {
+		return func(string) bool { return true }
+	}
+	origin := sender.Position
+
+	candidates := make([]string, 0, len(r.Players))
+	if r.interest != nil {
+		candidates = r.interest.nearby(origin, radius)
+	} else {
+		for id := range r.Players {
+			candidates = append(candidates, id)
+		}
+	}
+
+	audience := make(map[string]bool, len(candidates))
+	for _, id := range candidates {
+		if player, exists := r.Players[id]; exists &&
+			math.Hypot(player.Position.X-origin.X, player.Position.Y-origin.Y) <= radius {
+			audience[id] = true
+		}
+	}
+	return func(id string) bool { return !audience[id] }
+}
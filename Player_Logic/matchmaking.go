@@ -0,0 +1,214 @@
+package Player_Logic
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	MinMatchPartySize     = 2
+	MaxMatchPartySize     = 8
+	DefaultMatchRegion    = "global"
+	MatchmakingQueueTTL   = 10 * time.Minute // Waiting longer than this drops you from the queue
+	MatchResultRetention  = 2 * time.Minute  // How long a found match can be polled for
+	matchmakingPruneEvery = 30 * time.Second
+)
+
+var matchRegionPattern = regexp.MustCompile(`^[a-z0-9-]{1,16}$`)
+
+// matchKey groups queue entries that can be matched together
+type matchKey struct {
+	Region    string
+	PartySize int
+}
+
+// QueueEntry is a player waiting for a match
+type QueueEntry struct {
+	PlayerID  string    `json:"player_id"`
+	Region    string    `json:"region"`
+	PartySize int       `json:"party_size"`
+	QueuedAt  time.Time `json:"queued_at"`
+}
+
+// matchResult remembers a formed match so clients without a socket can poll for it
+type matchResult struct {
+	RoomID    string
+	MatchedAt time.Time
+}
+
+// Matchmaking groups queued players by region and party size into fresh rooms
+type Matchmaking struct {
+	buckets  map[matchKey][]*QueueEntry
+	byPlayer map[string]*QueueEntry
+	matches  map[string]matchResult
+	mu       sync.Mutex
+}
+
+var (
+	matchmaking     *Matchmaking
+	matchmakingOnce sync.Once
+)
+
+// GetMatchmaking returns the singleton matchmaking queue
+func GetMatchmaking() *Matchmaking {
+	matchmakingOnce.Do(func() {
+		matchmaking = &Matchmaking{
+			buckets:  make(map[matchKey][]*QueueEntry),
+			byPlayer: make(map[string]*QueueEntry),
+			matches:  make(map[string]matchResult),
+		}
+		go matchmaking.pruneLoop()
+	})
+	return matchmaking
+}
+
+// Enqueue adds a player to the queue and forms a match if their bucket is full
+func (mm *Matchmaking) Enqueue(playerID, region string, partySize int) (*QueueEntry, error) {
+	if region == "" {
+		region = DefaultMatchRegion
+	}
+	if !matchRegionPattern.MatchString(region) {
+		return nil, fmt.Errorf("invalid region %q", region)
+	}
+	if partySize < MinMatchPartySize || partySize > MaxMatchPartySize {
+		return nil, fmt.Errorf("party_size must be between %d and %d", MinMatchPartySize, MaxMatchPartySize)
+	}
+
+	mm.mu.Lock()
+	mm.removeLocked(playerID)
+	delete(mm.matches, playerID)
+
+	entry := &QueueEntry{PlayerID: playerID, Region: region, PartySize: partySize, QueuedAt: time.Now()}
+	key := matchKey{Region: region, PartySize: partySize}
+	mm.buckets[key] = append(mm.buckets[key], entry)
+	mm.byPlayer[playerID] = entry
+
+	var matched []*QueueEntry
+	if len(mm.buckets[key]) >= partySize {
+		matched = mm.buckets[key][:partySize]
+		mm.buckets[key] = append([]*QueueEntry(nil), mm.buckets[key][partySize:]...)
+		for _, e := range matched {
+			delete(mm.byPlayer, e.PlayerID)
+		}
+	}
+	mm.mu.Unlock()
+
+	slog.Info("Player queued for matchmaking", "player_id", playerID, "region", region, "party_size", partySize)
+
+	if matched != nil {
+		mm.formMatch(key, matched)
+	}
+	return entry, nil
+}
+
+// Dequeue removes a player from the queue; returns false if they weren't queued
+func (mm *Matchmaking) Dequeue(playerID string) bool {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return mm.removeLocked(playerID)
+}
+
+// Status returns the player's queue entry and position, or the room of a found match
+func (mm *Matchmaking) Status(playerID string) (entry *QueueEntry, position int, matchedRoomID string) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	if result, exists := mm.matches[playerID]; exists {
+		return nil, 0, result.RoomID
+	}
+
+	entry = mm.byPlayer[playerID]
+	if entry == nil {
+		return nil, 0, ""
+	}
+	for i, e := range mm.buckets[matchKey{Region: entry.Region, PartySize: entry.PartySize}] {
+		if e.PlayerID == playerID {
+			position = i + 1
+			break
+		}
+	}
+	return entry, position, ""
+}
+
+// removeLocked drops a player's queue entry. Caller must hold mm.mu.
+func (mm *Matchmaking) removeLocked(playerID string) bool {
+	entry, exists := mm.byPlayer[playerID]
+	if !exists {
+		return false
+	}
+	delete(mm.byPlayer, playerID)
+
+	key := matchKey{Region: entry.Region, PartySize: entry.PartySize}
+	bucket := mm.buckets[key]
+	for i, e := range bucket {
+		if e.PlayerID == playerID {
+			mm.buckets[key] = append(bucket[:i], bucket[i+1:]...)
+			break
+		}
+	}
+	if len(mm.buckets[key]) == 0 {
+		delete(mm.buckets, key)
+	}
+	return true
+}
+
+// formMatch creates a room sized for the group and tells every member where to go
+func (mm *Matchmaking) formMatch(key matchKey, entries []*QueueEntry) {
+	room, err := GetRoomManager().CreateRoom("", RoomOptions{Capacity: key.PartySize})
+	if err != nil {
+		slog.Error("Error creating match room", "error", err)
+		// Put everyone back at the front of the queue
+		mm.mu.Lock()
+		mm.buckets[key] = append(entries, mm.buckets[key]...)
+		for _, e := range entries {
+			mm.byPlayer[e.PlayerID] = e
+		}
+		mm.mu.Unlock()
+		return
+	}
+
+	mm.mu.Lock()
+	for _, e := range entries {
+		mm.matches[e.PlayerID] = matchResult{RoomID: room.ID, MatchedAt: time.Now()}
+	}
+	mm.mu.Unlock()
+
+	for _, e := range entries {
+		if conn, exists := connectionPool.getConnection(e.PlayerID); exists {
+			conn.sendMessage(WebSocketMessage{
+				Type:      "match_found",
+				PlayerID:  "system",
+				RoomID:    room.ID,
+				Timestamp: time.Now().UnixMilli(),
+			})
+		}
+	}
+
+	slog.Info("Match formed", "room_id", room.ID, "region", key.Region, "party_size", key.PartySize)
+}
+
+// pruneLoop drops stale queue entries and old match results
+func (mm *Matchmaking) pruneLoop() {
+	ticker := time.NewTicker(matchmakingPruneEvery)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		mm.mu.Lock()
+		for playerID, entry := range mm.byPlayer {
+			if now.Sub(entry.QueuedAt) > MatchmakingQueueTTL {
+				mm.removeLocked(playerID)
+				slog.Info("Player dropped from matchmaking queue after timeout", "player_id", playerID)
+			}
+		}
+		for playerID, result := range mm.matches {
+			if now.Sub(result.MatchedAt) > MatchResultRetention {
+				delete(mm.matches, playerID)
+			}
+		}
+		mm.mu.Unlock()
+	}
+}
@@ -0,0 +1,155 @@
+package Player_Logic
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"velvet/config"
+)
+
+// Per-connection message rate limits. Each incoming message type belongs to a class with
+// its own token bucket; messages over budget are dropped, and a client that keeps going over
+// (MaxRateViolations drops within RateViolationWindow) is disconnected. Budgets are messages
+// per minute plus a burst, set with WS_RATE_<CLASS>_PER_MINUTE and WS_RATE_<CLASS>_BURST.
+const (
+	RateViolationWindow = 10 * time.Second
+	MaxRateViolations   = 50
+)
+
+// messageClass groups message types that share a budget
+type messageClass string
+
+const (
+	classPosition messageClass = "position"
+	classChat     messageClass = "chat"
+	classPrivate  messageClass = "private"
+	classGlobal   messageClass = "global"
+	classControl  messageClass = "control"
+)
+
+// messageClasses maps message types to their class; unlisted types (except ack) are control
+var messageClasses = map[string]messageClass{
+	"position_update": classPosition,
+	"chat_message":    classChat,
+	"emote":           classChat,
+	"typing_start":    classChat,
+	"typing_stop":     classChat,
+	"private_message": classPrivate,
+	"global_chat":     classGlobal,
+	"guild_chat":      classChat,
+}
+
+// unmeteredMessageTypes are sent automatically by clients and never limited
+var unmeteredMessageTypes = map[string]bool{
+	"ack": true,
+}
+
+// messageBudget is a sustained rate and burst for one class
+type messageBudget struct {
+	perMinute int
+	burst     int
+}
+
+// defaultMessageBudgets allow smooth 30Hz movement and conversational chat; global chat
+// reaches everyone, so it gets a fraction of room chat's budget
+var defaultMessageBudgets = map[messageClass]messageBudget{
+	classPosition: {perMinute: 1800, burst: 60},
+	classChat:     {perMinute: 60, burst: 10},
+	classPrivate:  {perMinute: 20, burst: 5},
+	classGlobal:   {perMinute: 4, burst: 2},
+	classControl:  {perMinute: 120, burst: 20},
+}
+
+var (
+	messageBudgets     map[messageClass]messageBudget
+	messageBudgetsOnce sync.Once
+)
+
+// getMessageBudgets returns the default budgets overridden by the environment
+func getMessageBudgets() map[messageClass]messageBudget {
+	messageBudgetsOnce.Do(func() {
+		messageBudgets = make(map[messageClass]messageBudget, len(defaultMessageBudgets))
+		for class, budget := range defaultMessageBudgets {
+			prefix := "WS_RATE_" + strings.ToUpper(string(class))
+			messageBudgets[class] = messageBudget{
+				perMinute: config.GetEnvInt(prefix+"_PER_MINUTE", budget.perMinute),
+				burst:     config.GetEnvInt(prefix+"_BURST", budget.burst),
+			}
+		}
+	})
+	return messageBudgets
+}
+
+// messageLimiter holds one connection's buckets. It is only used from readPump, so it
+// needs no lock.
+type messageLimiter struct {
+	buckets      map[messageClass]*config.TokenBucket
+	windowStart  time.Time
+	violations   int
+	disconnected bool
+}
+
+// allow takes a token for the message type. exceeded is set once the client has gone over
+// budget MaxRateViolations times within RateViolationWindow.
+func (l *messageLimiter) allow(messageType string, now time.Time) (allowed, exceeded bool) {
+	if unmeteredMessageTypes[messageType] {
+		return true, false
+	}
+
+	class := classifyMessage(messageType)
+	if l.buckets == nil {
+		l.buckets = make(map[messageClass]*config.TokenBucket)
+	}
+	bucket, exists := l.buckets[class]
+	if !exists {
+		budget := getMessageBudgets()[class]
+		if budget.perMinute <= 0 {
+			return true, false
+		}
+		bucket = config.NewTokenBucket(float64(budget.perMinute)/60, budget.burst)
+		l.buckets[class] = bucket
+	}
+
+	if ok, _ := bucket.Take(1, now); ok {
+		return true, false
+	}
+
+	if now.Sub(l.windowStart) >= RateViolationWindow {
+		l.windowStart = now
+		l.violations = 0
+	}
+	l.violations++
+	return false, l.violations >= MaxRateViolations
+}
+
+// checkRateLimit reports whether the message may be handled, disconnecting clients that
+// persistently exceed their budgets. Service accounts are exempt.
+func (c *Connection) checkRateLimit(messageType string) bool {
+	if c.isService {
+		return true
+	}
+	if c.limiter.disconnected {
+		return false
+	}
+
+	allowed, exceeded := c.limiter.allow(messageType, time.Now())
+	if allowed {
+		return true
+	}
+
+	wsMessagesRateLimited.WithLabelValues(string(classifyMessage(messageType))).Inc()
+	if exceeded {
+		c.limiter.disconnected = true
+		c.logger.Warn("Disconnecting client for exceeding message rate limits", "type", messageType)
+		c.closeWithNotice("rate_limited", "Too many messages, please slow down")
+	}
+	return false
+}
+
+// classifyMessage returns the budget class for a message type
+func classifyMessage(messageType string) messageClass {
+	if class, ok := messageClasses[messageType]; ok {
+		return class
+	}
+	return classControl
+}
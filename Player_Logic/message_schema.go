@@ -0,0 +1,228 @@
+package Player_Logic
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Incoming messages are checked against a per-type schema before they're handled. A
+// message that fails gets an "error" reply with a machine-readable code, the offending
+// field, and the type of the message it's about (in_reply_to).
+const (
+	ErrCodeMalformedMessage = "malformed_message" // Couldn't be decoded at all
+	ErrCodeUnknownType      = "unknown_type"
+	ErrCodeMissingField     = "missing_field"
+	ErrCodeInvalidField     = "invalid_field"
+	ErrCodeInternal         = "internal_error"
+	ErrCodeRateLimited      = "rate_limited" // Sent too soon after the last one
+	ErrCodeNotInGuild       = "not_in_guild"
+)
+
+// Field bounds for incoming messages
+const (
+	MaxChatMessageLength = 500 // Characters in chat and private messages
+	MaxUsernameLength    = 32  // Same limit as /auth/update-user
+	MaxLanguageLength    = 10
+	MaxMessageIDLength   = 128 // Player, party, object, NPC, and emote IDs
+	MaxChannelNameLength = 24
+	MaxMessageDataBytes  = 16 * 1024
+)
+
+// MessageError describes why an incoming message was rejected
+type MessageError struct {
+	Code    string
+	Field   string // JSON name of the offending field, if there is one
+	Message string
+}
+
+func (e *MessageError) Error() string {
+	return e.Message
+}
+
+// fieldCheck validates one field of a message, returning nil if it's fine
+type fieldCheck func(message WebSocketMessage) *MessageError
+
+// messageSchemas lists the checks for every message type clients may send
+var messageSchemas = map[string][]fieldCheck{
+	"hello":           {required("version", func(m WebSocketMessage) bool { return m.Version != 0 })},
+	"ack":             {},
+	"ping":            {required("client_time", func(m WebSocketMessage) bool { return m.ClientTime != 0 })},
+	"time_sync":       {required("client_time", func(m WebSocketMessage) bool { return m.ClientTime != 0 })},
+	"position_update": {checkPosition, checkUsername},
+	"leave_room":      {},
+	"resync_request":  {},
+	"chat_message": {
+		requiredText("text", func(m WebSocketMessage) string { return m.Text }, MaxChatMessageLength),
+		checkUsername,
+		optionalText("channel", func(m WebSocketMessage) string { return m.Channel }, MaxChannelNameLength),
+		oneOf("mode", func(m WebSocketMessage) string { return m.Mode }, ChatModeRoom, ChatModeLocal),
+	},
+	"emote":          {requiredText("emote_id", func(m WebSocketMessage) string { return m.EmoteID }, MaxMessageIDLength)},
+	"join_channel":   {checkChannel},
+	"leave_channel":  {checkChannel},
+	"create_channel": {checkChannel},
+	"delete_channel": {checkChannel},
+	"channel_invite": {checkChannel, checkTargetPlayer},
+	"list_channels":  {},
+	"typing_start":   {},
+	"typing_stop":    {},
+	"private_message": {
+		checkWhisperTarget,
+		requiredText("text", func(m WebSocketMessage) string { return m.Text }, MaxChatMessageLength),
+		checkUsername,
+	},
+	"global_chat": {
+		requiredText("text", func(m WebSocketMessage) string { return m.Text }, MaxChatMessageLength),
+		checkUsername,
+	},
+	"guild_chat": {
+		requiredText("text", func(m WebSocketMessage) string { return m.Text }, MaxChatMessageLength),
+		checkUsername,
+	},
+	"set_language": {optionalText("language", func(m WebSocketMessage) string { return m.Language }, MaxLanguageLength)},
+	"party_invite": {checkTargetPlayer},
+	"party_accept": {requiredText("party_id", func(m WebSocketMessage) string { return m.PartyID }, MaxMessageIDLength)},
+	"party_leave":  {},
+	"mute": {
+		checkTargetPlayer,
+		intRange("minutes", func(m WebSocketMessage) int { return m.Minutes }, 1, int(MaxMuteDuration/time.Minute)),
+	},
+	"unmute":              {checkTargetPlayer},
+	"set_chat_filter":     {checkEnabled},
+	"set_delta_positions": {checkEnabled},
+	"set_global_chat":     {checkEnabled},
+	"ban":                 {checkTargetPlayer},
+	"unban":               {checkTargetPlayer},
+	"interact":            {checkObjectID},
+	"place_object":        {required("data", func(m WebSocketMessage) bool { return len(m.Data) > 0 })},
+	"remove_object":       {checkObjectID},
+	"npc_interact":        {requiredText("npc_id", func(m WebSocketMessage) string { return m.NPCID }, MaxMessageIDLength)},
+	"game_start":          {required("data", func(m WebSocketMessage) bool { return len(m.Data) > 0 })},
+	"game_input":          {},
+	"game_stop":           {},
+}
+
+// Checks shared by several message types
+var (
+	checkUsername     = optionalText("username", func(m WebSocketMessage) string { return m.Username }, MaxUsernameLength)
+	checkTargetPlayer = requiredText("target_player_id", func(m WebSocketMessage) string { return m.TargetPlayerID }, MaxMessageIDLength)
+	checkChannel      = requiredText("channel", func(m WebSocketMessage) string { return m.Channel }, MaxChannelNameLength)
+	checkObjectID     = requiredText("object_id", func(m WebSocketMessage) string { return m.ObjectID }, MaxMessageIDLength)
+	checkEnabled      = required("enabled", func(m WebSocketMessage) bool { return m.Enabled != nil })
+)
+
+// checkWhisperTarget requires a private message to name its target by player ID or by
+// username; the ID wins if both are given
+func checkWhisperTarget(message WebSocketMessage) *MessageError {
+	if strings.TrimSpace(message.TargetUsername) != "" && message.TargetPlayerID == "" {
+		return checkLength("target_username", message.TargetUsername, MaxUsernameLength)
+	}
+	return checkTargetPlayer(message)
+}
+
+// checkPosition requires a position with finite coordinates
+func checkPosition(message WebSocketMessage) *MessageError {
+	if message.Position == nil {
+		return &MessageError{Code: ErrCodeMissingField, Field: "position", Message: "position is required"}
+	}
+	if !message.Position.finite() {
+		return &MessageError{Code: ErrCodeInvalidField, Field: "position", Message: "position must be finite numbers"}
+	}
+	return nil
+}
+
+// validateMessage checks a message against the schema for its type
+func validateMessage(message WebSocketMessage) *MessageError {
+	if message.Type == "" {
+		return &MessageError{Code: ErrCodeMissingField, Field: "type", Message: "type is required"}
+	}
+	checks, known := messageSchemas[message.Type]
+	if !known {
+		return &MessageError{Code: ErrCodeUnknownType, Field: "type", Message: fmt.Sprintf("unknown message type %q", message.Type)}
+	}
+	if len(message.Data) > MaxMessageDataBytes {
+		return &MessageError{Code: ErrCodeInvalidField, Field: "data", Message: fmt.Sprintf("data must be at most %d bytes", MaxMessageDataBytes)}
+	}
+	for _, check := range checks {
+		if err := check(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// required fails when present reports the field is missing
+func required(field string, present func(WebSocketMessage) bool) fieldCheck {
+	return func(message WebSocketMessage) *MessageError {
+		if !present(message) {
+			return &MessageError{Code: ErrCodeMissingField, Field: field, Message: field + " is required"}
+		}
+		return nil
+	}
+}
+
+// requiredText fails when the field is blank or longer than max characters
+func requiredText(field string, get func(WebSocketMessage) string, max int) fieldCheck {
+	return func(message WebSocketMessage) *MessageError {
+		if strings.TrimSpace(get(message)) == "" {
+			return &MessageError{Code: ErrCodeMissingField, Field: field, Message: field + " is required"}
+		}
+		return checkLength(field, get(message), max)
+	}
+}
+
+// optionalText fails when the field is longer than max characters
+func optionalText(field string, get func(WebSocketMessage) string, max int) fieldCheck {
+	return func(message WebSocketMessage) *MessageError {
+		return checkLength(field, get(message), max)
+	}
+}
+
+func checkLength(field, value string, max int) *MessageError {
+	if utf8.RuneCountInString(value) > max {
+		return &MessageError{Code: ErrCodeInvalidField, Field: field, Message: fmt.Sprintf("%s must be at most %d characters", field, max)}
+	}
+	return nil
+}
+
+// oneOf fails when the field is set to anything but one of options
+func oneOf(field string, get func(WebSocketMessage) string, options ...string) fieldCheck {
+	return func(message WebSocketMessage) *MessageError {
+		value := get(message)
+		if value == "" {
+			return nil
+		}
+		for _, option := range options {
+			if value == option {
+				return nil
+			}
+		}
+		return &MessageError{Code: ErrCodeInvalidField, Field: field, Message: fmt.Sprintf("%s must be one of %s", field, strings.Join(options, ", "))}
+	}
+}
+
+// intRange fails when the field is outside [min, max]
+func intRange(field string, get func(WebSocketMessage) int, min, max int) fieldCheck {
+	return func(message WebSocketMessage) *MessageError {
+		if value := get(message); value < min || value > max {
+			return &MessageError{Code: ErrCodeInvalidField, Field: field, Message: fmt.Sprintf("%s must be between %d and %d", field, min, max)}
+		}
+		return nil
+	}
+}
+
+// sendMessageError replies to a rejected message; inReplyTo is its type ("" if unknown)
+func (c *Connection) sendMessageError(inReplyTo string, err *MessageError) {
+	c.sendMessage(WebSocketMessage{
+		Type:      "error",
+		PlayerID:  "system",
+		Code:      err.Code,
+		Field:     err.Field,
+		InReplyTo: inReplyTo,
+		Text:      err.Message,
+		RequestID: c.requestID,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
@@ -0,0 +1,155 @@
+package Player_Logic
+
+import (
+	"time"
+	"velvet/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// WebSocket and room metrics, exported on /metrics (see config.MetricsHandler)
+var (
+	wsMessagesReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "ws_messages_received_total",
+		Help:      "WebSocket messages received from clients, by type (unrecognized types count as \"unknown\").",
+	}, []string{"type"})
+
+	wsMessagesSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "ws_messages_sent_total",
+		Help:      "WebSocket frames queued for clients, by message or batch type.",
+	}, []string{"type"})
+
+	wsMessagesDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "ws_messages_dropped_total",
+		Help:      "WebSocket frames dropped because the client fell too far behind to keep them.",
+	}, []string{"type"})
+
+	wsSendBacklogged = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "ws_send_backlogged_total",
+		Help:      "WebSocket frames that waited in a backlog because the client's send channel was full.",
+	})
+
+	wsSlowConsumers = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "ws_slow_consumer_disconnects_total",
+		Help:      "Clients disconnected for falling further behind than WS_MAX_SEND_BACKLOG_BYTES.",
+	})
+
+	wsMessagesRateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "ws_messages_rate_limited_total",
+		Help:      "WebSocket messages from clients dropped for exceeding their rate budget, by class.",
+	}, []string{"class"})
+
+	wsBytesReceived = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "ws_received_bytes_total",
+		Help:      "Bytes of WebSocket frames received from clients.",
+	})
+
+	wsBytesSent = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "ws_sent_bytes_total",
+		Help:      "Bytes of WebSocket frames written to clients.",
+	})
+
+	wsConnectionsDegraded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "ws_connections_degraded_total",
+		Help:      "connection_degraded warnings sent to clients, by reason.",
+	}, []string{"reason"})
+
+	wsRoundTrip = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "ws_round_trip_seconds",
+		Help:      "Round-trip time of WebSocket keepalive pings.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 10), // 5ms to ~2.5s
+	})
+
+	broadcastDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "broadcast_duration_seconds",
+		Help:      "Time to fan a broadcast out to a room's local connections, by message type.",
+		Buckets:   prometheus.ExponentialBuckets(0.00005, 2, 14), // 50µs to ~400ms
+	}, []string{"type"})
+
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "ws_connections",
+		Help:      "Open WebSocket connections.",
+	}, func() float64 {
+		connectionPool.mu.RLock()
+		defer connectionPool.mu.RUnlock()
+		return float64(connectionPool.count)
+	})
+
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "ws_queued_connections",
+		Help:      "Connections waiting for a free slot.",
+	}, func() float64 {
+		connectionPool.mu.RLock()
+		defer connectionPool.mu.RUnlock()
+		return float64(len(connectionPool.waiting))
+	})
+
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "ws_max_connections",
+		Help:      "Connection limit of this instance.",
+	}, func() float64 { return float64(settings.WebSocket.MaxConnections) })
+
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "rooms",
+		Help:      "Active rooms on this instance.",
+	}, func() float64 {
+		rm := GetRoomManager()
+		rm.mu.RLock()
+		defer rm.mu.RUnlock()
+		return float64(len(rm.rooms))
+	})
+
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "room_players",
+		Help:      "Players assigned to a room on this instance.",
+	}, func() float64 {
+		rm := GetRoomManager()
+		rm.playerMu.RLock()
+		defer rm.playerMu.RUnlock()
+		return float64(len(rm.playerToRoom))
+	})
+
+	_ = promauto.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "rooms_created_total",
+		Help:      "Rooms created since startup.",
+	}, func() float64 {
+		rm := GetRoomManager()
+		rm.stats.mu.RLock()
+		defer rm.stats.mu.RUnlock()
+		return float64(rm.stats.totalRoomsCreated)
+	})
+
+	_ = promauto.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "players_served_total",
+		Help:      "Players placed into rooms since startup.",
+	}, func() float64 {
+		rm := GetRoomManager()
+		rm.stats.mu.RLock()
+		defer rm.stats.mu.RUnlock()
+		return float64(rm.stats.totalPlayersServed)
+	})
+)
+
+// observeBroadcast records how long a broadcast of the given type took to fan out
+func observeBroadcast(messageType string, started time.Time) {
+	broadcastDuration.WithLabelValues(messageType).Observe(time.Since(started).Seconds())
+}
@@ -0,0 +1,93 @@
+package Player_Logic
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// Reaction is the reference mini-game. Each round the room gets "round_ready", then "go"
+// after a random delay; the first player to send {"action": "press"} after "go" wins the
+// round (+1), and pressing before "go" is a false start (-1). After ReactionRounds rounds
+// the highest score wins.
+const (
+	ReactionGameName     = "reaction"
+	ReactionRounds       = 5
+	ReactionMinDelay     = 2 * time.Second
+	ReactionMaxDelay     = 5 * time.Second
+	ReactionRoundTimeout = 5 * time.Second // A round nobody wins ends after this long
+)
+
+var errUnknownReactionAction = errors.New("reaction input must be {\"action\": \"press\"}")
+
+// reactionGame is one session's state
+type reactionGame struct {
+	round     int
+	live      bool      // "go" has been sent and nobody has pressed yet
+	goAt      time.Time // When the current round goes live
+	roundEnds time.Time // When a live round times out
+	scores    map[string]int
+}
+
+func newReactionGame() MiniGame {
+	return &reactionGame{scores: make(map[string]int)}
+}
+
+func (g *reactionGame) Start(session *GameSession) error {
+	g.nextRound(session, time.Now())
+	return nil
+}
+
+// nextRound arms the next round, or finishes the game after the last one
+func (g *reactionGame) nextRound(session *GameSession, now time.Time) {
+	g.live = false
+	if g.round >= ReactionRounds {
+		session.Finish()
+		return
+	}
+	g.round++
+	g.goAt = now.Add(ReactionMinDelay + time.Duration(rand.Int63n(int64(ReactionMaxDelay-ReactionMinDelay))))
+	session.Broadcast("round_ready", map[string]int{"round": g.round, "rounds": ReactionRounds})
+}
+
+func (g *reactionGame) Tick(session *GameSession, now time.Time) {
+	switch {
+	case !g.live && now.After(g.goAt):
+		g.live = true
+		g.roundEnds = now.Add(ReactionRoundTimeout)
+		session.Broadcast("go", map[string]int{"round": g.round})
+	case g.live && now.After(g.roundEnds):
+		session.Broadcast("round_over", map[string]interface{}{"round": g.round, "winner": ""})
+		g.nextRound(session, now)
+	}
+}
+
+func (g *reactionGame) HandleInput(session *GameSession, playerID string, input json.RawMessage) error {
+	var press struct {
+		Action string `json:"action"`
+	}
+	if json.Unmarshal(input, &press) != nil || press.Action != "press" {
+		return errUnknownReactionAction
+	}
+
+	now := time.Now()
+	if !g.live {
+		g.scores[playerID]--
+		session.Send(playerID, "false_start", map[string]int{"round": g.round, "score": g.scores[playerID]})
+		return nil
+	}
+
+	g.scores[playerID]++
+	session.Broadcast("round_over", map[string]interface{}{
+		"round":       g.round,
+		"winner":      playerID,
+		"reaction_ms": now.Sub(g.goAt).Milliseconds(),
+	})
+	g.nextRound(session, now)
+	return nil
+}
+
+func (g *reactionGame) End(session *GameSession) map[string]int {
+	return g.scores
+}
@@ -0,0 +1,383 @@
+package Player_Logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+	"velvet/config"
+)
+
+// Mini-games. A room host or moderator starts one with "game_start" (Data {"game": name});
+// players send "game_input" (Data is game-specific) and the host can end it early with
+// "game_stop". A room runs one game at a time. The room gets "game_started", game-specific
+// "game_event" messages (Text is the event name), and "game_ended" with the final scores,
+// which are added to the per-game leaderboard; winners earn MiniGameWinXP.
+const (
+	GameTickInterval = 100 * time.Millisecond
+	MaxGameDuration  = 10 * time.Minute
+	MiniGameWinXP    = 25
+)
+
+var (
+	ErrUnknownGame      = errors.New("unknown game")
+	ErrGameInProgress   = errors.New("a game is already running in this room")
+	ErrNoGame           = errors.New("no game is running in this room")
+	ErrCannotManageGame = errors.New("only the room host or a moderator can start or stop games")
+)
+
+// MiniGame is a game that runs inside a room. Calls are serialized per session: Start once,
+// HandleInput for each game_input, Tick every GameTickInterval, and End once the game calls
+// session.Finish, is stopped, or times out. End returns each player's final score.
+type MiniGame interface {
+	Start(session *GameSession) error
+	HandleInput(session *GameSession, playerID string, input json.RawMessage) error
+	Tick(session *GameSession, now time.Time)
+	End(session *GameSession) map[string]int
+}
+
+// MiniGameFactory creates a fresh game for each session
+type MiniGameFactory func() MiniGame
+
+var miniGames = struct {
+	factories map[string]MiniGameFactory
+	mu        sync.RWMutex
+}{factories: map[string]MiniGameFactory{
+	ReactionGameName: newReactionGame,
+}}
+
+// RegisterMiniGame makes a game available to game_start under name
+func RegisterMiniGame(name string, factory MiniGameFactory) {
+	miniGames.mu.Lock()
+	miniGames.factories[name] = factory
+	miniGames.mu.Unlock()
+}
+
+// MiniGameNames lists the games that can be started
+func MiniGameNames() []string {
+	miniGames.mu.RLock()
+	defer miniGames.mu.RUnlock()
+
+	names := make([]string, 0, len(miniGames.factories))
+	for name := range miniGames.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GameSession is one run of a mini-game in a room
+type GameSession struct {
+	ID        string    `json:"id"`
+	Game      string    `json:"game"`
+	RoomID    string    `json:"room_id"`
+	HostID    string    `json:"host_id"`
+	StartedAt time.Time `json:"started_at"`
+
+	room      *Room
+	game      MiniGame
+	finishing bool // Set by Finish; the session ends after the current call
+	ended     bool
+	done      chan struct{}
+	mu        sync.Mutex
+}
+
+// GameScore is a player's final score in a session
+type GameScore struct {
+	PlayerID string `json:"player_id"`
+	Score    int    `json:"score"`
+	Won      bool   `json:"won"`
+}
+
+// Broadcast sends a game event to everyone in the room
+func (s *GameSession) Broadcast(event string, data interface{}) {
+	go broadcastToRoomAsync(s.room, "", s.eventMessage(event, data))
+}
+
+// Send sends a game event to one player
+func (s *GameSession) Send(playerID, event string, data interface{}) {
+	if conn, exists := connectionPool.getConnection(playerID); exists {
+		conn.sendMessage(s.eventMessage(event, data))
+	}
+}
+
+// Players returns the IDs of the visible players in the room
+func (s *GameSession) Players() []string {
+	s.room.mu.RLock()
+	defer s.room.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.room.Players))
+	for id, player := range s.room.Players {
+		if !player.Hidden {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Finish ends the game once the current Start/HandleInput/Tick call returns
+func (s *GameSession) Finish() {
+	s.finishing = true
+}
+
+func (s *GameSession) eventMessage(event string, data interface{}) WebSocketMessage {
+	encoded, _ := json.Marshal(data)
+	return WebSocketMessage{
+		Type:      "game_event",
+		PlayerID:  "system",
+		RoomID:    s.RoomID,
+		GameID:    s.ID,
+		Text:      event,
+		Data:      encoded,
+		Timestamp: time.Now().UnixMilli(),
+	}
+}
+
+// StartMiniGame launches a game in the room on behalf of its host or a moderator
+func (rm *RoomManager) StartMiniGame(room *Room, actorID, name string) (*GameSession, error) {
+	if !rm.canModerate(room, actorID) {
+		return nil, ErrCannotManageGame
+	}
+	miniGames.mu.RLock()
+	factory, exists := miniGames.factories[name]
+	miniGames.mu.RUnlock()
+	if !exists {
+		return nil, ErrUnknownGame
+	}
+
+	session := &GameSession{
+		ID:        fmt.Sprintf("%s-%d", name, time.Now().UnixNano()),
+		Game:      name,
+		RoomID:    room.ID,
+		HostID:    actorID,
+		StartedAt: time.Now(),
+		room:      room,
+		game:      factory(),
+		done:      make(chan struct{}),
+	}
+
+	room.mu.Lock()
+	if room.game != nil {
+		room.mu.Unlock()
+		return nil, ErrGameInProgress
+	}
+	room.game = session
+	room.mu.Unlock()
+
+	session.mu.Lock()
+	err := session.game.Start(session)
+	session.mu.Unlock()
+	if err != nil {
+		room.mu.Lock()
+		room.game = nil
+		room.mu.Unlock()
+		return nil, err
+	}
+
+	data, _ := json.Marshal(session)
+	go broadcastToRoomAsync(room, "", WebSocketMessage{
+		Type:      "game_started",
+		PlayerID:  actorID,
+		RoomID:    room.ID,
+		GameID:    session.ID,
+		Data:      data,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	slog.Info("Mini-game started", "game", name, "game_id", session.ID, "room_id", room.ID, "player_id", actorID)
+
+	go session.run()
+	return session, nil
+}
+
+// StopMiniGame ends the room's game early on behalf of its host or a moderator
+func (rm *RoomManager) StopMiniGame(room *Room, actorID string) error {
+	if !rm.canModerate(room, actorID) {
+		return ErrCannotManageGame
+	}
+	session := room.activeGame()
+	if session == nil {
+		return ErrNoGame
+	}
+	session.end("stopped")
+	return nil
+}
+
+// activeGame returns the room's running game, if any
+func (r *Room) activeGame() *GameSession {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.game
+}
+
+// run ticks the game until it finishes, times out, or everyone leaves
+func (s *GameSession) run() {
+	ticker := time.NewTicker(GameTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			if now.Sub(s.StartedAt) > MaxGameDuration {
+				s.end("timeout")
+				return
+			}
+			s.room.mu.RLock()
+			empty := len(s.room.Players) == 0
+			s.room.mu.RUnlock()
+			if empty {
+				s.end("abandoned")
+				return
+			}
+
+			s.mu.Lock()
+			if s.ended {
+				s.mu.Unlock()
+				return
+			}
+			s.game.Tick(s, now)
+			finishing := s.finishing
+			s.mu.Unlock()
+			if finishing {
+				s.end("finished")
+				return
+			}
+		}
+	}
+}
+
+// handleInput passes a player's input to the game
+func (s *GameSession) handleInput(playerID string, input json.RawMessage) error {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return ErrNoGame
+	}
+	err := s.game.HandleInput(s, playerID, input)
+	finishing := s.finishing
+	s.mu.Unlock()
+
+	if finishing {
+		s.end("finished")
+	}
+	return err
+}
+
+// end collects the final scores, announces them, and records them on the leaderboard
+func (s *GameSession) end(reason string) {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	scores := s.game.End(s)
+	s.mu.Unlock()
+	close(s.done)
+
+	s.room.mu.Lock()
+	if s.room.game == s {
+		s.room.game = nil
+	}
+	s.room.mu.Unlock()
+
+	best := 0
+	for _, score := range scores {
+		best = max(best, score)
+	}
+	results := make([]GameScore, 0, len(scores))
+	records := make([]config.GameResult, 0, len(scores))
+	for playerID, score := range scores {
+		won := best > 0 && score == best
+		results = append(results, GameScore{PlayerID: playerID, Score: score, Won: won})
+		if _, isService := config.GetServiceAccount(playerID); !isService {
+			records = append(records, config.GameResult{UserID: playerID, Score: score, Won: won})
+		}
+		if won {
+			GetProgression().Award(playerID, MiniGameWinXP, "minigame")
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	config.RecordGameResultsAsync(s.Game, records)
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"game":   s.Game,
+		"reason": reason,
+		"scores": results,
+	})
+	go broadcastToRoomAsync(s.room, "", WebSocketMessage{
+		Type:      "game_ended",
+		PlayerID:  "system",
+		RoomID:    s.RoomID,
+		GameID:    s.ID,
+		Data:      data,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	slog.Info("Mini-game ended", "game", s.Game, "game_id", s.ID, "room_id", s.RoomID, "reason", reason, "players", len(results))
+}
+
+// handleGameMessage handles game_start, game_input, and game_stop
+func (c *Connection) handleGameMessage(rm *RoomManager, message WebSocketMessage) {
+	room := rm.GetPlayerRoom(c.playerID)
+	if room == nil {
+		return
+	}
+
+	var err error
+	switch message.Type {
+	case "game_start":
+		var request struct {
+			Game string `json:"game"`
+		}
+		if len(message.Data) == 0 || json.Unmarshal(message.Data, &request) != nil {
+			err = ErrUnknownGame
+			break
+		}
+		_, err = rm.StartMiniGame(room, c.playerID, request.Game)
+
+	case "game_input":
+		session := room.activeGame()
+		if session == nil {
+			err = ErrNoGame
+			break
+		}
+		err = session.handleInput(c.playerID, message.Data)
+
+	case "game_stop":
+		err = rm.StopMiniGame(room, c.playerID)
+	}
+
+	if err != nil {
+		c.sendMessage(WebSocketMessage{
+			Type:      "game_error",
+			PlayerID:  "system",
+			Text:      err.Error(),
+			Timestamp: time.Now().UnixMilli(),
+		})
+	}
+}
+
+// sendActiveGame tells a newly connected client about the room's running game
+func (c *Connection) sendActiveGame(room *Room) {
+	session := room.activeGame()
+	if session == nil {
+		return
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return
+	}
+	c.sendMessage(WebSocketMessage{
+		Type:      "game_started",
+		PlayerID:  session.HostID,
+		RoomID:    room.ID,
+		GameID:    session.ID,
+		Data:      data,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
@@ -0,0 +1,187 @@
+package Player_Logic
+
+import (
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Movement validation. Position updates faster than MOVE_MAX_SPEED (world units per second)
+// are clamped along their direction, and positions outside MAP_WIDTH x MAP_HEIGHT (0 leaves
+// that axis unbounded) are clamped to the map. The client gets a position_correction with
+// the authoritative position. Service accounts move freely. Coordinates that aren't finite
+// numbers (NaN, ±Inf) are never accepted from anyone.
+const (
+	// Extra distance allowed per update for network jitter and client frame timing
+	MoveSpeedSlack = 64
+	// Players with this many violations within ViolationWindow are flagged for moderators
+	ViolationWindow        = time.Minute
+	ViolationFlagThreshold = 10
+)
+
+// Kinds of movement violations
+const (
+	ViolationSpeed     = "speed"
+	ViolationBounds    = "bounds"
+	ViolationCollision = "collision" // Path crossed a wall or solid object (see collision.go)
+	ViolationInvalid   = "invalid"   // Coordinates weren't finite numbers
+)
+
+// movementLimits are the configured speed and map bounds
+type movementLimits struct {
+	maxSpeed      float64
+	width, height float64
+}
+
+var (
+	limits     movementLimits
+	limitsOnce sync.Once
+)
+
+func getMovementLimits() movementLimits {
+	limitsOnce.Do(func() {
+		limits = movementLimits{
+			maxSpeed: settings.Rooms.MaxMoveSpeed,
+			width:    settings.Rooms.MapWidth,
+			height:   settings.Rooms.MapHeight,
+		}
+	})
+	return limits
+}
+
+// finite reports whether both coordinates are real numbers (not NaN or infinite)
+func (pos Position) finite() bool {
+	return !math.IsNaN(pos.X) && !math.IsInf(pos.X, 0) && !math.IsNaN(pos.Y) && !math.IsInf(pos.Y, 0)
+}
+
+// validateMoveLocked returns the position to accept for a move and the violation, if any
+// (caller holds the room lock)
+func (p *Player) validateMoveLocked(target Position, now time.Time, limits movementLimits) (Position, string) {
+	accepted := target
+	violation := ""
+
+	// The first move after joining has nothing to measure speed against
+	if !p.lastMoveAt.IsZero() && limits.maxSpeed > 0 {
+		dx, dy := target.X-p.Position.X, target.Y-p.Position.Y
+		distance := math.Hypot(dx, dy)
+		allowed := limits.maxSpeed*now.Sub(p.lastMoveAt).Seconds() + MoveSpeedSlack
+		if distance > allowed {
+			scale := allowed / distance
+			accepted = Position{X: p.Position.X + dx*scale, Y: p.Position.Y + dy*scale}
+			violation = ViolationSpeed
+		}
+	}
+
+	clamped := accepted
+	if limits.width > 0 {
+		clamped.X = math.Max(0, math.Min(limits.width, clamped.X))
+	}
+	if limits.height > 0 {
+		clamped.Y = math.Max(0, math.Min(limits.height, clamped.Y))
+	}
+	if clamped != accepted && violation == "" {
+		violation = ViolationBounds
+	}
+	return clamped, violation
+}
+
+// sendPositionCorrection tells a client where the server actually put it
+func sendPositionCorrection(playerID string, position Position, inputSeq uint64, violation string) {
+	GetMovementMonitor().Record(playerID, violation)
+
+	if conn, exists := connectionPool.getConnection(playerID); exists {
+		conn.sendMessage(WebSocketMessage{
+			Type:      "position_correction",
+			PlayerID:  playerID,
+			Position:  &position,
+			InputSeq:  inputSeq,
+			Text:      violation,
+			Timestamp: time.Now().UnixMilli(),
+		})
+	}
+}
+
+// MovementViolations summarizes one player's movement violations
+type MovementViolations struct {
+	PlayerID string    `json:"player_id"`
+	Recent   int       `json:"recent"` // Within ViolationWindow
+	Total    int       `json:"total"`
+	LastKind string    `json:"last_kind"`
+	LastAt   time.Time `json:"last_at"`
+	Flagged  bool      `json:"flagged"`
+}
+
+// MovementMonitor counts movement violations per player so repeat offenders can be reviewed
+type MovementMonitor struct {
+	recent  map[string][]time.Time
+	summary map[string]*MovementViolations
+	mu      sync.Mutex
+}
+
+var (
+	movementMonitor     *MovementMonitor
+	movementMonitorOnce sync.Once
+)
+
+// GetMovementMonitor returns the singleton movement violation tracker
+func GetMovementMonitor() *MovementMonitor {
+	movementMonitorOnce.Do(func() {
+		movementMonitor = &MovementMonitor{
+			recent:  make(map[string][]time.Time),
+			summary: make(map[string]*MovementViolations),
+		}
+	})
+	return movementMonitor
+}
+
+// Record adds a violation and logs when the player crosses the flag threshold
+func (m *MovementMonitor) Record(playerID, kind string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	recent := m.recent[playerID][:0]
+	for _, at := range m.recent[playerID] {
+		if now.Sub(at) < ViolationWindow {
+			recent = append(recent, at)
+		}
+	}
+	recent = append(recent, now)
+	m.recent[playerID] = recent
+
+	summary, exists := m.summary[playerID]
+	if !exists {
+		summary = &MovementViolations{PlayerID: playerID}
+		m.summary[playerID] = summary
+	}
+	summary.Total++
+	summary.LastKind = kind
+	summary.LastAt = now
+	if len(recent) == ViolationFlagThreshold {
+		summary.Flagged = true
+		slog.Warn("Player flagged for movement violations", "player_id", playerID, "violations", len(recent), "window", ViolationWindow.String(), "last", kind)
+	}
+}
+
+// List returns every player with violations, most recent offenders first
+func (m *MovementMonitor) List() []MovementViolations {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	list := make([]MovementViolations, 0, len(m.summary))
+	for playerID, summary := range m.summary {
+		entry := *summary
+		entry.Recent = 0
+		for _, at := range m.recent[playerID] {
+			if now.Sub(at) < ViolationWindow {
+				entry.Recent++
+			}
+		}
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].LastAt.After(list[j].LastAt) })
+	return list
+}
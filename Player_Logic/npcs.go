@@ -0,0 +1,345 @@
+package Player_Logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// NPCs are server-controlled characters. They come from the room's layout (or SpawnNPC)
+// and are not players: they don't take slots or show up in player lists. Patrolling NPCs
+// walk their route on the room tick loop and move as "position_update"/"position_delta"
+// entries in the regular snapshot batches, so clients render them like players. Joining
+// clients get "room_npcs"; "npc_interact" (NPCID set) from a player within InteractRange
+// pauses the NPC and answers with its next dialogue line as "npc_dialogue".
+const (
+	MaxNPCsPerRoom      = 20
+	MaxNPCRoutePoints   = 32
+	MaxNPCDialogueLines = 16
+	MaxNPCLineLength    = 280
+	MaxNPCSpeed         = 600.0 // World units per second
+	DefaultNPCSpeed     = 80.0
+	NPCInteractPause    = 3 * time.Second // Patrols stop this long when someone talks to the NPC
+	// Tick interval for NPC movement when ROOM_TICK_RATE is 0
+	NPCTickInterval = 100 * time.Millisecond
+)
+
+// NPC behaviors
+const (
+	BehaviorIdle   = "idle"   // Stands at its position
+	BehaviorPatrol = "patrol" // Walks its route in a loop
+)
+
+var (
+	ErrUnknownNPC  = errors.New("npc does not exist")
+	ErrTooManyNPCs = fmt.Errorf("rooms can have at most %d NPCs", MaxNPCsPerRoom)
+)
+
+// NPCDefinition describes an NPC in a layout
+type NPCDefinition struct {
+	Name     string     `json:"name"`
+	Behavior string     `json:"behavior"` // idle (default) or patrol
+	Position Position   `json:"position"`
+	Route    []Position `json:"route,omitempty"` // Patrol waypoints, walked in order and looped
+	Speed    float64    `json:"speed,omitempty"` // World units per second (patrol)
+	Dialogue []string   `json:"dialogue,omitempty"`
+}
+
+// validate checks an NPC definition against the limits above
+func (d NPCDefinition) validate() error {
+	if d.Name == "" || len(d.Name) > MaxLayoutNameLength {
+		return fmt.Errorf("name must be 1-%d characters", MaxLayoutNameLength)
+	}
+	switch d.Behavior {
+	case "", BehaviorIdle:
+	case BehaviorPatrol:
+		if len(d.Route) == 0 {
+			return fmt.Errorf("patrolling NPCs need a route")
+		}
+	default:
+		return fmt.Errorf("behavior must be %q or %q", BehaviorIdle, BehaviorPatrol)
+	}
+	if len(d.Route) > MaxNPCRoutePoints {
+		return fmt.Errorf("routes can have at most %d points", MaxNPCRoutePoints)
+	}
+	if d.Speed < 0 || d.Speed > MaxNPCSpeed {
+		return fmt.Errorf("speed must be between 0 and %g", MaxNPCSpeed)
+	}
+	if len(d.Dialogue) > MaxNPCDialogueLines {
+		return fmt.Errorf("at most %d dialogue lines are allowed", MaxNPCDialogueLines)
+	}
+	for _, line := range d.Dialogue {
+		if len(line) > MaxNPCLineLength {
+			return fmt.Errorf("dialogue lines are limited to %d characters", MaxNPCLineLength)
+		}
+	}
+	return nil
+}
+
+// NPC is a server-controlled character in a room
+type NPC struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Behavior string   `json:"behavior"`
+	Position Position `json:"position"`
+
+	route       []Position
+	speed       float64
+	dialogue    []string
+	waypoint    int // Next route point
+	line        int // Next dialogue line
+	pausedUntil time.Time
+	deltaBase   positionBase
+}
+
+// patrolling reports whether the NPC moves on its own
+func (n *NPC) patrolling() bool {
+	return n.Behavior == BehaviorPatrol && len(n.route) > 0
+}
+
+// step walks a patrolling NPC toward its next waypoint and reports whether it moved
+func (n *NPC) step(now time.Time, elapsed time.Duration) bool {
+	if !n.patrolling() || now.Before(n.pausedUntil) {
+		return false
+	}
+
+	budget := n.speed * elapsed.Seconds()
+	moved := false
+	for budget > 0 {
+		target := n.route[n.waypoint]
+		dx, dy := target.X-n.Position.X, target.Y-n.Position.Y
+		distance := math.Hypot(dx, dy)
+		if distance <= budget {
+			n.Position = target
+			n.waypoint = (n.waypoint + 1) % len(n.route)
+			budget -= distance
+			moved = moved || distance > 0
+			if len(n.route) == 1 {
+				break // Nowhere else to go
+			}
+			continue
+		}
+		n.Position.X += dx / distance * budget
+		n.Position.Y += dy / distance * budget
+		return true
+	}
+	return moved
+}
+
+// SpawnNPC adds an NPC to the room, tells the room, and starts the tick loop if it patrols
+func (r *Room) SpawnNPC(definition NPCDefinition) (NPC, error) {
+	if err := definition.validate(); err != nil {
+		return NPC{}, err
+	}
+	npc := r.addNPC(definition)
+	if npc == nil {
+		return NPC{}, ErrTooManyNPCs
+	}
+
+	data, _ := json.Marshal(npc)
+	go broadcastToRoomAsync(r, "", WebSocketMessage{
+		Type:      "npc_spawned",
+		PlayerID:  npc.ID,
+		Username:  npc.Name,
+		Position:  &npc.Position,
+		Data:      data,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	GetRoomManager().wakeRoom(r)
+	return *npc, nil
+}
+
+// addNPC creates an NPC from a validated definition; nil if the room is full of NPCs
+func (r *Room) addNPC(definition NPCDefinition) *NPC {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.NPCs == nil {
+		r.NPCs = make(map[string]*NPC)
+	}
+	if len(r.NPCs) >= MaxNPCsPerRoom {
+		return nil
+	}
+
+	r.npcSeq++
+	npc := &NPC{
+		ID:       fmt.Sprintf("npc-%d", r.npcSeq),
+		Name:     definition.Name,
+		Behavior: definition.Behavior,
+		Position: definition.Position,
+		route:    definition.Route,
+		speed:    definition.Speed,
+		dialogue: definition.Dialogue,
+	}
+	if npc.Behavior == "" {
+		npc.Behavior = BehaviorIdle
+	}
+	if npc.speed == 0 {
+		npc.speed = DefaultNPCSpeed
+	}
+	r.NPCs[npc.ID] = npc
+	copied := *npc
+	return &copied
+}
+
+// spawnLayoutNPCs places the layout's NPCs in a new or restored room
+func (r *Room) spawnLayoutNPCs() {
+	for _, definition := range r.Layout().NPCs {
+		r.addNPC(definition)
+	}
+}
+
+// RemoveNPC deletes an NPC and tells the room
+func (r *Room) RemoveNPC(npcID string) error {
+	r.mu.Lock()
+	if _, exists := r.NPCs[npcID]; !exists {
+		r.mu.Unlock()
+		return ErrUnknownNPC
+	}
+	delete(r.NPCs, npcID)
+	r.mu.Unlock()
+
+	go broadcastToRoomAsync(r, "", WebSocketMessage{
+		Type:      "npc_removed",
+		PlayerID:  npcID,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	return nil
+}
+
+// ListNPCs returns the room's NPCs sorted by ID
+func (r *Room) ListNPCs() []NPC {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	npcs := make([]NPC, 0, len(r.NPCs))
+	for _, npc := range r.NPCs {
+		npcs = append(npcs, NPC{ID: npc.ID, Name: npc.Name, Behavior: npc.Behavior, Position: npc.Position})
+	}
+	sort.Slice(npcs, func(i, j int) bool { return npcs[i].ID < npcs[j].ID })
+	return npcs
+}
+
+// hasActiveNPCsLocked reports whether the tick loop has NPCs to move: someone is in the
+// room to see them and at least one patrols (caller holds r.mu)
+func (r *Room) hasActiveNPCsLocked() bool {
+	if len(r.Players) == 0 {
+		return false
+	}
+	for _, npc := range r.NPCs {
+		if npc.patrolling() {
+			return true
+		}
+	}
+	return false
+}
+
+// stepNPCsLocked advances every patrolling NPC and returns position frames for the ones
+// that moved (caller holds r.mu)
+func (r *Room) stepNPCsLocked(now time.Time) []positionFrame {
+	elapsed := now.Sub(r.npcSteppedAt)
+	if r.npcSteppedAt.IsZero() || elapsed > time.Second {
+		elapsed = roomTickInterval() // First step, or the loop was asleep
+	}
+	r.npcSteppedAt = now
+
+	var frames []positionFrame
+	for _, npc := range r.NPCs {
+		if !npc.step(now, elapsed) {
+			continue
+		}
+		quantized, delta := npc.deltaBase.next(npc.Position, now)
+		frames = append(frames, newPositionFrame(npc.ID, npc.Name, npc.Position, quantized, delta, nil, 0))
+	}
+	return frames
+}
+
+// wakeRoom starts the room's tick loop if it has NPCs to move and isn't ticking already
+func (rm *RoomManager) wakeRoom(room *Room) {
+	room.mu.Lock()
+	if room.ticking || !room.hasActiveNPCsLocked() {
+		room.mu.Unlock()
+		return
+	}
+	room.ticking = true
+	room.mu.Unlock()
+
+	rm.cleanupWG.Add(1)
+	go rm.runRoomTicker(room)
+}
+
+// InteractNPC pauses an NPC for a nearby player and returns its next dialogue line
+func (r *Room) InteractNPC(playerID, npcID string) (NPC, string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	npc, exists := r.NPCs[npcID]
+	if !exists {
+		return NPC{}, "", ErrUnknownNPC
+	}
+	player, exists := r.Players[playerID]
+	if !exists {
+		return NPC{}, "", ErrUnknownNPC
+	}
+	if math.Hypot(player.Position.X-npc.Position.X, player.Position.Y-npc.Position.Y) > InteractRange {
+		return NPC{}, "", ErrObjectOutOfRange
+	}
+
+	npc.pausedUntil = time.Now().Add(NPCInteractPause)
+	line := ""
+	if len(npc.dialogue) > 0 {
+		line = npc.dialogue[npc.line%len(npc.dialogue)]
+		npc.line++
+	}
+	return NPC{ID: npc.ID, Name: npc.Name, Behavior: npc.Behavior, Position: npc.Position}, line, nil
+}
+
+// handleNPCInteract answers npc_interact with the NPC's next line
+func (c *Connection) handleNPCInteract(rm *RoomManager, message WebSocketMessage) {
+	room := rm.GetPlayerRoom(c.playerID)
+	if room == nil {
+		return
+	}
+
+	npc, line, err := room.InteractNPC(c.playerID, message.NPCID)
+	if err != nil {
+		c.sendMessage(WebSocketMessage{
+			Type:      "npc_error",
+			PlayerID:  "system",
+			NPCID:     message.NPCID,
+			Text:      err.Error(),
+			Timestamp: time.Now().UnixMilli(),
+		})
+		return
+	}
+	c.sendMessage(WebSocketMessage{
+		Type:      "npc_dialogue",
+		PlayerID:  npc.ID,
+		NPCID:     npc.ID,
+		Username:  npc.Name,
+		Text:      line,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
+
+// sendRoomNPCs sends the room's NPCs to a newly connected client
+func (c *Connection) sendRoomNPCs(room *Room) {
+	npcs := room.ListNPCs()
+	if len(npcs) == 0 {
+		return
+	}
+	data, err := json.Marshal(npcs)
+	if err != nil {
+		return
+	}
+	c.sendMessage(WebSocketMessage{
+		Type:      "room_npcs",
+		PlayerID:  "system",
+		RoomID:    room.ID,
+		Data:      data,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
@@ -0,0 +1,262 @@
+package Player_Logic
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	MaxPartySize      = 8
+	PartyInviteExpiry = 5 * time.Minute
+)
+
+// ErrNotInParty is returned for party operations by players without a party
+var ErrNotInParty = errors.New("player is not in a party")
+
+// Party is a group of players that travel between rooms together
+type Party struct {
+	ID        string               `json:"party_id"`
+	LeaderID  string               `json:"leader_id"`
+	Members   map[string]bool      `json:"-"`
+	Invites   map[string]time.Time `json:"-"` // Invited player -> invite expiry
+	CreatedAt time.Time            `json:"created_at"`
+	mu        sync.RWMutex
+}
+
+// PartyInfo is a snapshot of a party for API responses
+type PartyInfo struct {
+	ID        string    `json:"party_id"`
+	LeaderID  string    `json:"leader_id"`
+	Members   []string  `json:"members"`
+	Invited   []string  `json:"invited"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Info returns a snapshot of the party
+func (p *Party) Info() PartyInfo {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	info := PartyInfo{ID: p.ID, LeaderID: p.LeaderID, CreatedAt: p.CreatedAt, Members: []string{}, Invited: []string{}}
+	for id := range p.Members {
+		info.Members = append(info.Members, id)
+	}
+	for id, expiry := range p.Invites {
+		if time.Now().Before(expiry) {
+			info.Invited = append(info.Invited, id)
+		}
+	}
+	return info
+}
+
+// MemberIDsExcept returns the party's members other than playerID
+func (p *Party) MemberIDsExcept(playerID string) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ids := make([]string, 0, len(p.Members))
+	for id := range p.Members {
+		if id != playerID {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// PartyManager tracks all parties, analogous to RoomManager
+type PartyManager struct {
+	parties       map[string]*Party
+	playerToParty map[string]string // playerID -> partyID
+	nextID        int64
+	mu            sync.RWMutex
+}
+
+var (
+	partyManager     *PartyManager
+	partyManagerOnce sync.Once
+)
+
+// GetPartyManager returns the singleton party manager
+func GetPartyManager() *PartyManager {
+	partyManagerOnce.Do(func() {
+		partyManager = &PartyManager{
+			parties:       make(map[string]*Party),
+			playerToParty: make(map[string]string),
+		}
+	})
+	return partyManager
+}
+
+// GetPlayerParty returns the party the player belongs to, or nil
+func (pm *PartyManager) GetPlayerParty(playerID string) *Party {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.parties[pm.playerToParty[playerID]]
+}
+
+// Invite invites targetID to the inviter's party, creating a party led by the inviter if needed
+func (pm *PartyManager) Invite(inviterID, targetID string) (*Party, error) {
+	if inviterID == targetID {
+		return nil, fmt.Errorf("cannot invite yourself")
+	}
+
+	pm.mu.Lock()
+	party := pm.parties[pm.playerToParty[inviterID]]
+	if party == nil {
+		pm.nextID++
+		party = &Party{
+			ID:        fmt.Sprintf("P%d", pm.nextID),
+			LeaderID:  inviterID,
+			Members:   map[string]bool{inviterID: true},
+			Invites:   make(map[string]time.Time),
+			CreatedAt: time.Now(),
+		}
+		pm.parties[party.ID] = party
+		pm.playerToParty[inviterID] = party.ID
+		slog.Info("Party created", "party_id", party.ID, "player_id", inviterID)
+	}
+	pm.mu.Unlock()
+
+	party.mu.Lock()
+	defer party.mu.Unlock()
+
+	if party.LeaderID != inviterID {
+		return nil, fmt.Errorf("only the party leader can invite")
+	}
+	if party.Members[targetID] {
+		return nil, fmt.Errorf("player is already in the party")
+	}
+	if len(party.Members) >= MaxPartySize {
+		return nil, fmt.Errorf("party is full (max %d)", MaxPartySize)
+	}
+	party.Invites[targetID] = time.Now().Add(PartyInviteExpiry)
+
+	slog.Info("Party invite sent", "party_id", party.ID, "player_id", inviterID, "target_id", targetID)
+	return party, nil
+}
+
+// Accept joins the party the player was invited to, leaving any current party
+func (pm *PartyManager) Accept(playerID, partyID string) (*Party, error) {
+	pm.mu.RLock()
+	party := pm.parties[partyID]
+	pm.mu.RUnlock()
+	if party == nil {
+		return nil, fmt.Errorf("party %s not found", partyID)
+	}
+
+	party.mu.Lock()
+	expiry, invited := party.Invites[playerID]
+	if !invited || time.Now().After(expiry) {
+		party.mu.Unlock()
+		return nil, fmt.Errorf("no pending invite to party %s", partyID)
+	}
+	if len(party.Members) >= MaxPartySize {
+		party.mu.Unlock()
+		return nil, fmt.Errorf("party is full (max %d)", MaxPartySize)
+	}
+	party.mu.Unlock()
+
+	if current := pm.GetPlayerParty(playerID); current != nil && current.ID != partyID {
+		if oldParty, released, err := pm.Leave(playerID); err == nil {
+			NotifyPartyChanged(oldParty, released...)
+		}
+	}
+
+	party.mu.Lock()
+	delete(party.Invites, playerID)
+	party.Members[playerID] = true
+	party.mu.Unlock()
+
+	pm.mu.Lock()
+	pm.playerToParty[playerID] = partyID
+	pm.mu.Unlock()
+
+	slog.Info("Player joined party", "party_id", partyID, "player_id", playerID)
+	return party, nil
+}
+
+// Leave removes the player from their party, promoting a new leader or disbanding as needed.
+// Returns the party (nil if it was disbanded) and any members released by a disband, so
+// everyone affected can be notified.
+func (pm *PartyManager) Leave(playerID string) (*Party, []string, error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	partyID, exists := pm.playerToParty[playerID]
+	if !exists {
+		return nil, nil, ErrNotInParty
+	}
+	delete(pm.playerToParty, playerID)
+	party := pm.parties[partyID]
+
+	party.mu.Lock()
+	defer party.mu.Unlock()
+
+	delete(party.Members, playerID)
+	if len(party.Members) <= 1 {
+		// A party of one is no party; disband it
+		var released []string
+		for id := range party.Members {
+			delete(pm.playerToParty, id)
+			released = append(released, id)
+		}
+		delete(pm.parties, partyID)
+		slog.Info("Party disbanded", "party_id", partyID)
+		return nil, released, nil
+	}
+
+	if party.LeaderID == playerID {
+		for id := range party.Members {
+			party.LeaderID = id
+			break
+		}
+		slog.Info("Party leadership passed", "party_id", partyID, "leader_id", party.LeaderID)
+	}
+	slog.Info("Player left party", "party_id", partyID, "player_id", playerID)
+	return party, nil, nil
+}
+
+// notifyPartyUpdate sends the current party state to every member
+func notifyPartyUpdate(party *Party) {
+	info := party.Info()
+	for _, memberID := range info.Members {
+		if conn, exists := connectionPool.getConnection(memberID); exists {
+			conn.sendPartyInfo("party_updated", info)
+		}
+	}
+}
+
+// NotifyPartyInvite tells an invited player about their invite
+func NotifyPartyInvite(party *Party, targetID string) {
+	if conn, exists := connectionPool.getConnection(targetID); exists {
+		conn.sendPartyInfo("party_invite", party.Info())
+	}
+	notifyPartyUpdate(party)
+}
+
+// NotifyPartyChanged tells remaining members about a change and confirms to players who
+// are no longer in the party (party may be nil if disbanded)
+func NotifyPartyChanged(party *Party, leftPlayerIDs ...string) {
+	for _, playerID := range leftPlayerIDs {
+		if conn, exists := connectionPool.getConnection(playerID); exists {
+			conn.sendPartyInfo("party_left", PartyInfo{})
+		}
+	}
+	if party != nil {
+		notifyPartyUpdate(party)
+	}
+}
+
+// MovePartyConnections moves the sockets of group members that changed rooms, telling
+// their old rooms they left and sending them the new room's state
+func MovePartyConnections(room *Room, previousRooms map[string]*Room, leaderID string) {
+	for playerID, oldRoom := range previousRooms {
+		if playerID == leaderID {
+			continue // The leader's client drives its own join
+		}
+		moveConnectionToRoom(playerID, oldRoom, room)
+	}
+}
@@ -3,6 +3,7 @@ package Player_Logic
 import (
 	"sync"
 	"time"
+	"velvet/config"
 
 	"github.com/gorilla/websocket"
 )
@@ -15,7 +16,26 @@ type Player struct {
 	IsActive bool            `json:"is_active"`
 	LastSeen time.Time       `json:"last_seen"`
 	WS       *websocket.Conn `json:"-"`
-	mu       sync.RWMutex
+	// Service accounts (bots) are non-human principals; hidden ones stay out of player lists
+	IsService bool `json:"is_service"`
+	Hidden    bool `json:"-"`
+	// Preferred chat language (e.g. "en", "es"); empty means no translation
+	Language string `json:"language,omitempty"`
+	// How the player looks; loaded from their account when the socket connects
+	Avatar *config.Avatar `json:"avatar,omitempty"`
+	// Tag of the player's guild, if any (see guilds.go)
+	GuildTag string `json:"guild_tag,omitempty"`
+	// Last broadcast position for delta encoding (guarded by the room lock)
+	deltaBase positionBase
+	// Newest client input applied to Position, echoed for prediction reconciliation (room lock)
+	lastInputSeq uint64
+	// When the last position update was accepted, for speed validation (room lock)
+	lastMoveAt time.Time
+	// When the player arrived through a portal; portals are ignored for PortalCooldown (room lock)
+	arrivedAt time.Time
+	// Layout zones the player is standing in, for zone_entered/zone_left (room lock)
+	zones []string
+	mu    sync.RWMutex
 }
 
 type Position struct {
@@ -37,6 +57,48 @@ func (p *Player) GetPosition() Position {
 	return p.Position
 }
 
+// SetLanguage updates the player's preferred chat language
+func (p *Player) SetLanguage(lang string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Language = lang
+}
+
+// GetLanguage returns the player's preferred chat language
+func (p *Player) GetLanguage() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.Language
+}
+
+// SetAvatar updates how the player looks
+func (p *Player) SetAvatar(avatar config.Avatar) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Avatar = &avatar
+}
+
+// SetGuildTag updates the tag shown next to the player's name; empty when not in a guild
+func (p *Player) SetGuildTag(tag string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.GuildTag = tag
+}
+
+// GetGuildTag returns the player's guild tag
+func (p *Player) GetGuildTag() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.GuildTag
+}
+
+// GetAvatar returns the player's avatar, or nil if it hasn't been loaded
+func (p *Player) GetAvatar() *config.Avatar {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.Avatar
+}
+
 // MarkDisconnected marks the player as disconnected
 func (p *Player) MarkDisconnected() {
 	p.mu.Lock()
@@ -0,0 +1,146 @@
+package Player_Logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+	"velvet/config"
+)
+
+// Portals. A position update that ends within PortalRadius of a portal object moves the
+// player to the portal's target room in one step: they are admitted to the target (bans and
+// capacity checked) before leaving the current room, the connection is re-bound, and the
+// client gets "room_changed" followed by the new room's layout, players, and objects. If
+// the move is refused the client gets "portal_failed" and stays put. Players arrive at a
+// spawn point and ignore portals for PortalCooldown so they don't bounce straight back.
+const (
+	PortalRadius   = 40.0 // World units from a portal's position that trigger it
+	PortalCooldown = 2 * time.Second
+	PortalMainRoom = "main" // Target meaning the main room, or the least crowded of its instances
+)
+
+var (
+	ErrInvalidPortalTarget = errors.New("portals need a target room ID or \"main\"")
+	ErrSameRoom            = errors.New("already in the target room")
+)
+
+// validPortalTarget reports whether target is "main" or looks like a room ID
+func validPortalTarget(target string) bool {
+	return target != "" && len(target) <= 10
+}
+
+// portalAtLocked returns the portal the player has walked into, if any (caller holds r.mu)
+func (r *Room) portalAtLocked(player *Player, now time.Time) *RoomObject {
+	if now.Sub(player.arrivedAt) < PortalCooldown {
+		return nil
+	}
+	for _, object := range r.Objects {
+		if object.Kind == ObjectPortal &&
+			math.Hypot(player.Position.X-object.Position.X, player.Position.Y-object.Position.Y) <= PortalRadius {
+			return object
+		}
+	}
+	return nil
+}
+
+// usePortal moves a player through a portal and tells them if it didn't work
+func (rm *RoomManager) usePortal(playerID string, portal RoomObject) {
+	target := portal.Target
+	var oldRoom, newRoom *Room
+	var err error
+	if target == PortalMainRoom {
+		oldRoom, newRoom, err = rm.transferToMain(context.Background(), playerID)
+	} else {
+		oldRoom, newRoom, err = rm.TransferPlayer(context.Background(), playerID, target)
+	}
+	if err != nil {
+		slog.Info("Portal refused", "player_id", playerID, "object_id", portal.ID, "target", target, "error", err)
+		if conn, exists := connectionPool.getConnection(playerID); exists {
+			conn.sendMessage(WebSocketMessage{
+				Type:      "portal_failed",
+				PlayerID:  "system",
+				ObjectID:  portal.ID,
+				RoomID:    target,
+				Text:      err.Error(),
+				Timestamp: time.Now().UnixMilli(),
+			})
+		}
+		return
+	}
+
+	moveConnectionToRoom(playerID, oldRoom, newRoom)
+	GetProgression().awardRoomVisit(playerID, newRoom.ID)
+	config.UpdateLastRoomAsync(context.Background(), playerID, newRoom.ID)
+}
+
+// TransferPlayer moves a player from their room into an existing room. They are admitted to
+// the target before leaving, so a refused move (banned, full, unknown room) leaves them
+// where they were. Returns the room they left and the room they are now in.
+func (rm *RoomManager) TransferPlayer(ctx context.Context, playerID, targetRoomID string) (*Room, *Room, error) {
+	oldRoom := rm.GetPlayerRoom(playerID)
+	if oldRoom == nil {
+		return nil, nil, fmt.Errorf("player %s is not in a room", playerID)
+	}
+	newRoom := rm.getRoomByID(targetRoomID)
+	if newRoom == nil {
+		return nil, nil, fmt.Errorf("room %s not found", targetRoomID)
+	}
+	if newRoom == oldRoom {
+		return nil, nil, ErrSameRoom
+	}
+
+	// Carry the player's identity over; position and per-room state start fresh
+	oldRoom.mu.RLock()
+	previous, exists := oldRoom.Players[playerID]
+	if !exists {
+		oldRoom.mu.RUnlock()
+		return nil, nil, fmt.Errorf("player %s is not in room %s", playerID, oldRoom.ID)
+	}
+	player := newPlayer(playerID)
+	player.Username = previous.Username
+	player.WS = previous.WS
+	player.Language = previous.GetLanguage()
+	player.Avatar = previous.GetAvatar()
+	oldRoom.mu.RUnlock()
+
+	priority := rm.admissionPriority(ctx, playerID, newRoom)
+
+	newRoom.mu.Lock()
+	if newRoom.Banned[playerID] {
+		newRoom.mu.Unlock()
+		return nil, nil, ErrPlayerBanned
+	}
+	if err := newRoom.checkCapacity(priority, 1); err != nil {
+		newRoom.mu.Unlock()
+		return nil, nil, err
+	}
+	player.RoomID = newRoom.ID
+	player.Position = newRoom.layoutLocked().SpawnPoint()
+	player.arrivedAt = time.Now()
+	newRoom.Players[playerID] = player
+	newRoom.LastActivity = time.Now()
+	newRoom.playerCount = int32(len(newRoom.Players))
+	newRoom.mu.Unlock()
+
+	oldRoom.mu.Lock()
+	freedSeat := oldRoom.removePlayerLocked(playerID)
+	oldRoom.mu.Unlock()
+	if freedSeat != nil {
+		broadcastObjectUpdate(oldRoom, *freedSeat)
+	}
+
+	rm.playerMu.Lock()
+	rm.playerToRoom[playerID] = newRoom.ID
+	rm.playerMu.Unlock()
+
+	rm.stats.mu.Lock()
+	rm.stats.totalPlayersServed++
+	rm.stats.mu.Unlock()
+
+	slog.Info("Transferred player", "player_id", playerID, "from_room", oldRoom.ID, "to_room", newRoom.ID)
+	emitPlayerJoined(playerID, newRoom.ID)
+	return oldRoom, newRoom, nil
+}
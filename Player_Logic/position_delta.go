@@ -0,0 +1,82 @@
+package Player_Logic
+
+import (
+	"math"
+	"time"
+)
+
+// Delta-compressed position updates. Clients that opt in (?position_encoding=delta or a
+// set_delta_positions message) receive "position_delta" messages holding the change since
+// the last broadcast, in quantized units, instead of absolute floats. A full
+// "position_update" keyframe is sent periodically so dropped deltas can't drift forever.
+// Clients get their base for each player by quantizing any absolute position they receive
+// (initial room state, keyframes, or updates from before they opted in).
+const (
+	PositionScale        = 10              // Quantized units per world unit (0.1 precision)
+	KeyframeEveryUpdates = 30              // Keyframe after this many deltas
+	KeyframeInterval     = 3 * time.Second // ...or after this long
+	MaxPositionDelta     = math.MaxInt16   // Larger jumps (teleports) are sent as keyframes
+)
+
+// PositionDelta is a position change in quantized units (divide by PositionScale)
+type PositionDelta struct {
+	DX int32 `json:"dx"`
+	DY int32 `json:"dy"`
+}
+
+// positionBase is the last position broadcast for a player, in quantized units
+type positionBase struct {
+	x, y         int64
+	sinceKey     int
+	lastKeyframe time.Time
+	valid        bool
+}
+
+// quantize converts a world coordinate to quantized units
+func quantize(v float64) int64 {
+	return int64(math.Round(v * PositionScale))
+}
+
+// nextPositionFrame advances the player's broadcast base to a new position. It returns the
+// quantized absolute position and, unless a keyframe is due, the delta from the previous
+// base. Caller holds the room lock.
+func (p *Player) nextPositionFrame(position Position, now time.Time) (Position, *PositionDelta) {
+	return p.deltaBase.next(position, now)
+}
+
+// next records position as the new base and returns it quantized, with the delta from the
+// previous base unless a keyframe is due
+func (base *positionBase) next(position Position, now time.Time) (Position, *PositionDelta) {
+	x, y := quantize(position.X), quantize(position.Y)
+	dx, dy := x-base.x, y-base.y
+
+	keyframe := !base.valid ||
+		base.sinceKey >= KeyframeEveryUpdates ||
+		now.Sub(base.lastKeyframe) >= KeyframeInterval ||
+		dx > MaxPositionDelta || dx < -MaxPositionDelta ||
+		dy > MaxPositionDelta || dy < -MaxPositionDelta
+
+	base.x, base.y, base.valid = x, y, true
+	quantized := Position{X: float64(x) / PositionScale, Y: float64(y) / PositionScale}
+	if keyframe {
+		base.sinceKey = 0
+		base.lastKeyframe = now
+		return quantized, nil
+	}
+	base.sinceKey++
+	return quantized, &PositionDelta{DX: int32(dx), DY: int32(dy)}
+}
+
+// handleSetDeltaPositions toggles delta encoding for this connection
+func (c *Connection) handleSetDeltaPositions(message WebSocketMessage) {
+	if message.Enabled == nil {
+		return
+	}
+	c.deltaPositions.Store(*message.Enabled)
+	c.sendMessage(WebSocketMessage{
+		Type:      "delta_positions_changed",
+		PlayerID:  "system",
+		Enabled:   message.Enabled,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
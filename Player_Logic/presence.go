@@ -0,0 +1,169 @@
+package Player_Logic
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+	"velvet/config"
+)
+
+// Presence statuses
+const (
+	PresenceOnline  = "online"
+	PresenceAway    = "away"
+	PresenceOffline = "offline"
+)
+
+const (
+	presenceSweepInterval = 15 * time.Second
+	MaxPresenceLookup     = 100 // Max IDs per GET /player/presence
+)
+
+// PresenceInfo is a player's current presence
+type PresenceInfo struct {
+	Status     string `json:"status"`
+	LastActive int64  `json:"last_active,omitempty"` // Unix ms of the last input, 0 if unknown
+}
+
+// presenceEntry tracks a connected player
+type presenceEntry struct {
+	status     string
+	lastActive time.Time
+}
+
+// PresenceService tracks online/away status for connected players. Players without
+// an entry are offline.
+type PresenceService struct {
+	entries   map[string]*presenceEntry
+	awayAfter time.Duration
+	mu        sync.Mutex
+}
+
+var (
+	presence     *PresenceService
+	presenceOnce sync.Once
+)
+
+// GetPresence returns the singleton presence service (AFK timeout from PRESENCE_AWAY_AFTER_SECONDS)
+func GetPresence() *PresenceService {
+	presenceOnce.Do(func() {
+		presence = &PresenceService{
+			entries:   make(map[string]*presenceEntry),
+			awayAfter: settings.Rooms.AwayAfter,
+		}
+		go presence.sweepLoop()
+	})
+	return presence
+}
+
+// Connected marks a player online when their WebSocket registers
+func (ps *PresenceService) Connected(playerID string) {
+	ps.mu.Lock()
+	ps.entries[playerID] = &presenceEntry{status: PresenceOnline, lastActive: time.Now()}
+	ps.mu.Unlock()
+
+	go publishPresence(playerID, PresenceOnline)
+}
+
+// Disconnected marks a player offline when their WebSocket closes
+func (ps *PresenceService) Disconnected(playerID string) {
+	ps.mu.Lock()
+	_, tracked := ps.entries[playerID]
+	delete(ps.entries, playerID)
+	ps.mu.Unlock()
+
+	if tracked {
+		go publishPresence(playerID, PresenceOffline)
+	}
+}
+
+// Touch records player input, bringing an away player back online
+func (ps *PresenceService) Touch(playerID string) {
+	ps.mu.Lock()
+	entry, tracked := ps.entries[playerID]
+	if !tracked {
+		ps.mu.Unlock()
+		return
+	}
+	entry.lastActive = time.Now()
+	wasAway := entry.status == PresenceAway
+	entry.status = PresenceOnline
+	ps.mu.Unlock()
+
+	if wasAway {
+		go publishPresence(playerID, PresenceOnline)
+	}
+}
+
+// Get returns presence for each requested player
+func (ps *PresenceService) Get(playerIDs []string) map[string]PresenceInfo {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	result := make(map[string]PresenceInfo, len(playerIDs))
+	for _, id := range playerIDs {
+		if entry, tracked := ps.entries[id]; tracked {
+			result[id] = PresenceInfo{Status: entry.status, LastActive: entry.lastActive.UnixMilli()}
+		} else {
+			result[id] = PresenceInfo{Status: PresenceOffline}
+		}
+	}
+	return result
+}
+
+// sweepLoop flips idle players to away
+func (ps *PresenceService) sweepLoop() {
+	ticker := time.NewTicker(presenceSweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		var nowAway []string
+		ps.mu.Lock()
+		for playerID, entry := range ps.entries {
+			if entry.status == PresenceOnline && time.Since(entry.lastActive) >= ps.awayAfter {
+				entry.status = PresenceAway
+				nowAway = append(nowAway, playerID)
+			}
+		}
+		ps.mu.Unlock()
+
+		for _, playerID := range nowAway {
+			publishPresence(playerID, PresenceAway)
+		}
+	}
+}
+
+// publishPresence sends presence_changed to the player's online friends. Coming online or
+// going offline also sends the friend_online/friend_offline events.
+func publishPresence(playerID, status string) {
+	if config.DB == nil {
+		return
+	}
+
+	friendIDs, err := config.GetFriendIDs(playerID)
+	if err != nil {
+		slog.Warn("Could not load friends for presence update", "player_id", playerID, "error", err)
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	messages := []WebSocketMessage{{
+		Type:           "presence_changed",
+		PlayerID:       "system",
+		TargetPlayerID: playerID,
+		Status:         status,
+		Timestamp:      now,
+	}}
+	switch status {
+	case PresenceOnline:
+		messages = append(messages, WebSocketMessage{Type: "friend_online", PlayerID: "system", TargetPlayerID: playerID, Timestamp: now})
+	case PresenceOffline:
+		messages = append(messages, WebSocketMessage{Type: "friend_offline", PlayerID: "system", TargetPlayerID: playerID, Timestamp: now})
+	}
+
+	for _, friendID := range friendIDs {
+		for _, message := range messages {
+			sendToPlayer(friendID, message)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package Player_Logic
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+	"velvet/config"
+)
+
+// XP and levels. Connected players earn XP for time online (not away), chatting, and the
+// first visit to each room per UTC day. Gains are kept in memory and written in batches
+// every XP_FLUSH_SECONDS; a level_up event goes to the room as soon as a player levels.
+const onlineXPInterval = time.Minute
+
+// XPForLevel is the total XP needed to reach a level (100 for level 2, then 200 more for
+// level 3, 300 more for level 4, ...)
+func XPForLevel(level int) int64 {
+	if level <= 1 {
+		return 0
+	}
+	n := int64(level - 1)
+	return 50 * n * (n + 1)
+}
+
+// LevelForXP is the level a player with xp total XP has reached
+func LevelForXP(xp int64) int {
+	level := 1
+	for XPForLevel(level+1) <= xp {
+		level++
+	}
+	return level
+}
+
+// ProgressInfo is a player's XP and level as shown to clients
+type ProgressInfo struct {
+	XP          int64 `json:"xp"`
+	Level       int   `json:"level"`
+	LevelXP     int64 `json:"level_xp"`      // Total XP at the start of the current level
+	NextLevelXP int64 `json:"next_level_xp"` // Total XP needed for the next level
+}
+
+func newProgressInfo(xp int64) ProgressInfo {
+	level := LevelForXP(xp)
+	return ProgressInfo{XP: xp, Level: level, LevelXP: XPForLevel(level), NextLevelXP: XPForLevel(level + 1)}
+}
+
+// progressEntry is a connected player's XP
+type progressEntry struct {
+	xp         int64 // Stored XP plus pending
+	level      int
+	pending    int64 // Earned since the last flush
+	lastChatXP time.Time
+	connected  bool // Disconnected entries are dropped once their XP is flushed
+}
+
+// ProgressionService tracks XP for connected players
+type ProgressionService struct {
+	players   map[string]*progressEntry
+	visits    map[string]bool // "playerID|roomID" visited today
+	visitsDay string
+	mu        sync.Mutex
+}
+
+var (
+	progression     *ProgressionService
+	progressionOnce sync.Once
+)
+
+// GetProgression returns the singleton progression service
+func GetProgression() *ProgressionService {
+	progressionOnce.Do(func() {
+		progression = &ProgressionService{
+			players: make(map[string]*progressEntry),
+			visits:  make(map[string]bool),
+		}
+		go progression.onlineLoop()
+		go progression.flushLoop()
+	})
+	return progression
+}
+
+// Connected starts tracking a player's XP and credits their visit to the room
+func (ps *ProgressionService) Connected(ctx context.Context, playerID, roomID string) {
+	if config.DB == nil {
+		return
+	}
+
+	ps.mu.Lock()
+	entry, tracked := ps.players[playerID]
+	if tracked {
+		entry.connected = true // Reconnected before the last flush; memory is ahead of the DB
+	}
+	ps.mu.Unlock()
+
+	if !tracked {
+		stored, err := config.GetProgress(ctx, playerID)
+		if err != nil {
+			// Without the stored total, levels can't be tracked; don't award XP this session
+			config.Logger(ctx).Warn("Could not load progress, XP disabled for session", "player_id", playerID, "error", err)
+			return
+		}
+		ps.mu.Lock()
+		if entry, tracked = ps.players[playerID]; tracked {
+			entry.connected = true
+		} else {
+			ps.players[playerID] = &progressEntry{xp: stored.XP, level: LevelForXP(stored.XP), connected: true}
+		}
+		ps.mu.Unlock()
+	}
+
+	ps.awardRoomVisit(playerID, roomID)
+}
+
+// Disconnected stops awarding online XP; pending XP is still written on the next flush
+func (ps *ProgressionService) Disconnected(playerID string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if entry, tracked := ps.players[playerID]; tracked {
+		entry.connected = false
+	}
+}
+
+// Award adds XP to a connected player and announces a level up
+func (ps *ProgressionService) Award(playerID string, amount int, reason string) {
+	if amount <= 0 {
+		return
+	}
+
+	ps.mu.Lock()
+	entry, tracked := ps.players[playerID]
+	if !tracked || !entry.connected {
+		ps.mu.Unlock()
+		return
+	}
+	entry.xp += int64(amount)
+	entry.pending += int64(amount)
+	previous := entry.level
+	entry.level = LevelForXP(entry.xp)
+	info := newProgressInfo(entry.xp)
+	ps.mu.Unlock()
+
+	slog.Debug("XP awarded", "player_id", playerID, "amount", amount, "reason", reason)
+	if info.Level > previous {
+		announceLevelUp(playerID, info)
+	}
+}
+
+// awardChatXP credits a chat message, at most once per XP_CHAT_COOLDOWN_SECONDS
+func (ps *ProgressionService) awardChatXP(playerID string) {
+	now := time.Now()
+	ps.mu.Lock()
+	entry, tracked := ps.players[playerID]
+	if !tracked || now.Sub(entry.lastChatXP) < settings.Progression.ChatXPCooldown {
+		ps.mu.Unlock()
+		return
+	}
+	entry.lastChatXP = now
+	ps.mu.Unlock()
+
+	ps.Award(playerID, settings.Progression.XPPerChat, "chat")
+}
+
+// awardRoomVisit credits the first visit to a room each UTC day
+func (ps *ProgressionService) awardRoomVisit(playerID, roomID string) {
+	today := time.Now().UTC().Format(time.DateOnly)
+	key := playerID + "|" + roomID
+
+	ps.mu.Lock()
+	if ps.visitsDay != today {
+		ps.visits = make(map[string]bool)
+		ps.visitsDay = today
+	}
+	firstVisit := !ps.visits[key]
+	ps.visits[key] = true
+	ps.mu.Unlock()
+
+	if firstVisit {
+		ps.Award(playerID, settings.Progression.XPPerRoomVisit, "room_visit")
+	}
+}
+
+// Get returns a player's progress, from memory while they're tracked and the database otherwise
+func (ps *ProgressionService) Get(ctx context.Context, playerID string) (ProgressInfo, error) {
+	ps.mu.Lock()
+	entry, tracked := ps.players[playerID]
+	var xp int64
+	if tracked {
+		xp = entry.xp
+	}
+	ps.mu.Unlock()
+
+	if !tracked {
+		stored, err := config.GetProgress(ctx, playerID)
+		if err != nil {
+			return ProgressInfo{}, err
+		}
+		xp = stored.XP
+	}
+	return newProgressInfo(xp), nil
+}
+
+// Flush queues a write of all pending XP and forgets disconnected players once written
+func (ps *ProgressionService) Flush() {
+	ps.mu.Lock()
+	var gains []config.XPGain
+	for playerID, entry := range ps.players {
+		if entry.pending > 0 {
+			gains = append(gains, config.XPGain{UserID: playerID, XP: entry.pending, Level: entry.level})
+		}
+	}
+	if !config.SaveXPGainsAsync(gains) {
+		ps.mu.Unlock()
+		return // Queue full; everything stays pending
+	}
+	for playerID, entry := range ps.players {
+		entry.pending = 0
+		if !entry.connected {
+			delete(ps.players, playerID)
+		}
+	}
+	ps.mu.Unlock()
+}
+
+// onlineLoop credits connected players who aren't away every minute
+func (ps *ProgressionService) onlineLoop() {
+	ticker := time.NewTicker(onlineXPInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ps.mu.Lock()
+		var connected []string
+		for playerID, entry := range ps.players {
+			if entry.connected {
+				connected = append(connected, playerID)
+			}
+		}
+		ps.mu.Unlock()
+
+		for playerID, info := range GetPresence().Get(connected) {
+			if info.Status == PresenceOnline {
+				ps.Award(playerID, settings.Progression.XPPerMinuteOnline, "online")
+			}
+		}
+	}
+}
+
+// flushLoop writes pending XP every XP_FLUSH_SECONDS
+func (ps *ProgressionService) flushLoop() {
+	ticker := time.NewTicker(settings.Progression.FlushInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ps.Flush()
+	}
+}
+
+// announceLevelUp tells the player's room they reached a new level
+func announceLevelUp(playerID string, info ProgressInfo) {
+	rm := GetRoomManager()
+	player := rm.GetPlayer(playerID)
+	room := rm.GetPlayerRoom(playerID)
+	if player == nil || room == nil || player.Hidden {
+		return
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return
+	}
+	go broadcastToRoomAsync(room, "", WebSocketMessage{
+		Type:      "level_up",
+		PlayerID:  playerID,
+		Username:  player.Username,
+		Data:      data,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
@@ -0,0 +1,122 @@
+package Player_Logic
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Protocol versions. Clients announce theirs with ?protocol_version=N on the WebSocket URL
+// or a "hello" message; clients that announce nothing are treated as version 1.
+//
+//	1: original protocol (no sequence numbers, acks, or chat channels; offline messages arrive as a "batch")
+//	2: reliable chat with seq/ack and retransmit, typed batches ("inbox", "snapshot")
+const (
+	ProtocolVersion1       = 1
+	ProtocolVersion2       = 2
+	CurrentProtocolVersion = ProtocolVersion2
+	MinProtocolVersion     = ProtocolVersion1
+	// Versions below this still work but get a protocol_deprecated notice on connect
+	DeprecatedBelowVersion = ProtocolVersion2
+)
+
+// protocolAdapter downgrades outgoing messages for clients on an older version.
+// Returning false drops the message for that client.
+type protocolAdapter func(message WebSocketMessage) (WebSocketMessage, bool)
+
+// protocolAdapters maps a version to the translation applied before encoding
+var protocolAdapters = map[int]protocolAdapter{
+	ProtocolVersion1: func(message WebSocketMessage) (WebSocketMessage, bool) {
+		// v1 clients don't ack, so sequence numbers are meaningless to them
+		message.Seq = 0
+		// ...and have no notion of channels, so side-channel chat would look like room chat
+		if message.Type == "chat_message" && message.Channel != "" && message.Channel != DefaultChannel {
+			return message, false
+		}
+		return message, true
+	},
+}
+
+// batchTypeForVersion returns the batch type a client understands; v1 only knows "batch"
+func batchTypeForVersion(version int, batchType string) string {
+	if version < ProtocolVersion2 {
+		return "batch"
+	}
+	return batchType
+}
+
+// parseProtocolVersion validates a client-supplied version; empty means version 1
+func parseProtocolVersion(raw string) (int, error) {
+	if raw == "" {
+		return ProtocolVersion1, nil
+	}
+	version, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid protocol version %q", raw)
+	}
+	if version < MinProtocolVersion || version > CurrentProtocolVersion {
+		return 0, fmt.Errorf("unsupported protocol version %d (supported %d-%d)", version, MinProtocolVersion, CurrentProtocolVersion)
+	}
+	return version, nil
+}
+
+// version returns the protocol version negotiated for this connection
+func (c *Connection) version() int {
+	return int(c.protocolVersion.Load())
+}
+
+// reliableDelivery reports whether this client acks sequenced messages
+func (c *Connection) reliableDelivery() bool {
+	return c.version() >= ProtocolVersion2
+}
+
+// adaptOutgoing translates a message for the connection's protocol version
+func (c *Connection) adaptOutgoing(message WebSocketMessage) (WebSocketMessage, bool) {
+	if adapter, exists := protocolAdapters[c.version()]; exists {
+		return adapter(message)
+	}
+	return message, true
+}
+
+// sendProtocolInfo tells the client which version is in effect, and warns if it's deprecated
+func (c *Connection) sendProtocolInfo(messageType string) {
+	version := c.version()
+	c.sendMessage(WebSocketMessage{
+		Type:      messageType,
+		PlayerID:  "system",
+		Version:   version,
+		RequestID: c.requestID,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	if version < DeprecatedBelowVersion {
+		c.sendMessage(WebSocketMessage{
+			Type:      "protocol_deprecated",
+			PlayerID:  "system",
+			Version:   CurrentProtocolVersion,
+			Text:      fmt.Sprintf("Protocol version %d is deprecated, please update to version %d", version, CurrentProtocolVersion),
+			Timestamp: time.Now().UnixMilli(),
+		})
+	}
+}
+
+// handleHello switches the connection to the version the client announced. Clients that
+// can set the URL should prefer ?protocol_version, since the initial room state and
+// offline messages are sent before a hello can arrive.
+func (c *Connection) handleHello(message WebSocketMessage) {
+	version, err := parseProtocolVersion(strconv.Itoa(message.Version))
+	if err != nil {
+		c.closeWithNotice("protocol_unsupported", err.Error())
+		return
+	}
+
+	previous := int(c.protocolVersion.Swap(int32(version)))
+	if previous != version {
+		c.logger.Info("Protocol version switched", "from", previous, "to", version)
+	}
+	c.sendProtocolInfo("hello_ack")
+
+	// Messages a previous connection left unacked were held back until the client proved it acks
+	if previous < ProtocolVersion2 && version >= ProtocolVersion2 {
+		c.retransmitPending()
+	}
+}
@@ -0,0 +1,361 @@
+package Player_Logic
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ReconcileInterval is how often room state is checked for divergence
+const ReconcileInterval = time.Minute
+
+// Kinds of divergence between playerToRoom, room.Players, and the connection pool
+const (
+	issueStaleMapping    = "stale_mapping"    // playerToRoom points at a room that doesn't hold the player
+	issueUnmappedPlayer  = "unmapped_player"  // Player is in room.Players but has no playerToRoom entry
+	issueDuplicatePlayer = "duplicate_player" // Player is in more than one room
+	issuePlayerCount     = "player_count"     // room.playerCount disagrees with len(room.Players)
+	issueStaleActive     = "stale_active"     // Player marked active with no live connection
+	issueOrphanConn      = "orphan_connection"
+	issueConnRoom        = "connection_room_mismatch"
+	issueOversizedRoom   = "oversized_room"
+)
+
+// consistencyIssue is one detected divergence
+type consistencyIssue struct {
+	kind     string
+	playerID string
+	roomID   string
+}
+
+func (i consistencyIssue) key() string {
+	return fmt.Sprintf("%s:%s:%s", i.kind, i.playerID, i.roomID)
+}
+
+// reconcileState tracks issues seen on the previous pass. Joins and moves update the maps
+// one at a time, so an issue is only repaired once it has survived two consecutive passes.
+type reconcileState struct {
+	suspects map[string]bool
+	repairs  map[string]int64 // kind -> repairs made
+	checks   int64
+	mu       sync.Mutex
+}
+
+// startReconciler runs the consistency check on ReconcileInterval
+func (rm *RoomManager) startReconciler() {
+	rm.cleanupWG.Add(1)
+	go func() {
+		defer rm.cleanupWG.Done()
+		ticker := time.NewTicker(ReconcileInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				rm.reconcile()
+			case <-rm.cleanupCtx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// reconcile detects divergence and repairs issues that persisted since the last pass
+func (rm *RoomManager) reconcile() {
+	issues := rm.checkInvariants()
+
+	rm.consistency.mu.Lock()
+	previous := rm.consistency.suspects
+	rm.consistency.suspects = make(map[string]bool)
+	rm.consistency.checks++
+	rm.consistency.mu.Unlock()
+
+	repaired := 0
+	for _, issue := range issues {
+		// Counter drift and oversized rooms are safe to fix immediately
+		immediate := issue.kind == issuePlayerCount || issue.kind == issueOversizedRoom
+		if !immediate && !previous[issue.key()] {
+			rm.consistency.mu.Lock()
+			rm.consistency.suspects[issue.key()] = true
+			rm.consistency.mu.Unlock()
+			continue
+		}
+
+		if rm.repair(issue) {
+			repaired++
+			rm.consistency.mu.Lock()
+			rm.consistency.repairs[issue.kind]++
+			rm.consistency.mu.Unlock()
+			slog.Warn("State repair", "kind", issue.kind, "player_id", issue.playerID, "room_id", issue.roomID)
+		}
+	}
+
+	if repaired > 0 {
+		slog.Info("Reconciliation completed", "issues", len(issues), "repaired", repaired)
+	}
+}
+
+// checkInvariants compares snapshots of the room maps and connection pool
+func (rm *RoomManager) checkInvariants() []consistencyIssue {
+	rm.mu.RLock()
+	rooms := make(map[string]*Room, len(rm.rooms))
+	for id, room := range rm.rooms {
+		rooms[id] = room
+	}
+	rm.mu.RUnlock()
+
+	rm.playerMu.RLock()
+	mapping := make(map[string]string, len(rm.playerToRoom))
+	for playerID, roomID := range rm.playerToRoom {
+		mapping[playerID] = roomID
+	}
+	rm.playerMu.RUnlock()
+
+	connectionPool.mu.RLock()
+	conns := make(map[string]*Connection, len(connectionPool.connections))
+	for playerID, conn := range connectionPool.connections {
+		conns[playerID] = conn
+	}
+	connectionPool.mu.RUnlock()
+
+	var issues []consistencyIssue
+	memberOf := make(map[string][]string) // playerID -> rooms listing them
+
+	for roomID, room := range rooms {
+		room.mu.RLock()
+		if int(room.playerCount) != len(room.Players) {
+			issues = append(issues, consistencyIssue{kind: issuePlayerCount, roomID: roomID})
+		}
+		if len(room.Players) > room.Capacity {
+			issues = append(issues, consistencyIssue{kind: issueOversizedRoom, roomID: roomID})
+		}
+		for playerID, player := range room.Players {
+			memberOf[playerID] = append(memberOf[playerID], roomID)
+			if _, connected := conns[playerID]; player.IsActive && !connected {
+				issues = append(issues, consistencyIssue{kind: issueStaleActive, playerID: playerID, roomID: roomID})
+			}
+		}
+		room.mu.RUnlock()
+	}
+
+	for playerID, roomIDs := range memberOf {
+		mapped := mapping[playerID]
+		for _, roomID := range roomIDs {
+			switch {
+			case mapped == "":
+				issues = append(issues, consistencyIssue{kind: issueUnmappedPlayer, playerID: playerID, roomID: roomID})
+			case mapped != roomID:
+				issues = append(issues, consistencyIssue{kind: issueDuplicatePlayer, playerID: playerID, roomID: roomID})
+			}
+		}
+	}
+
+	for playerID, roomID := range mapping {
+		listed := false
+		for _, id := range memberOf[playerID] {
+			listed = listed || id == roomID
+		}
+		if !listed {
+			issues = append(issues, consistencyIssue{kind: issueStaleMapping, playerID: playerID, roomID: roomID})
+		}
+	}
+
+	for playerID, conn := range conns {
+		conn.mu.RLock()
+		connRoomID := conn.roomID
+		conn.mu.RUnlock()
+
+		mapped, exists := mapping[playerID]
+		switch {
+		case !exists:
+			issues = append(issues, consistencyIssue{kind: issueOrphanConn, playerID: playerID})
+		case connRoomID != mapped:
+			issues = append(issues, consistencyIssue{kind: issueConnRoom, playerID: playerID, roomID: mapped})
+		}
+	}
+
+	return issues
+}
+
+// repair re-verifies an issue under the relevant locks and fixes it. Returns true if
+// something was changed.
+func (rm *RoomManager) repair(issue consistencyIssue) bool {
+	switch issue.kind {
+	case issuePlayerCount:
+		room := rm.getRoomByID(issue.roomID)
+		if room == nil {
+			return false
+		}
+		room.mu.Lock()
+		defer room.mu.Unlock()
+		if int(room.playerCount) == len(room.Players) {
+			return false
+		}
+		room.playerCount = int32(len(room.Players))
+		return true
+
+	case issueOversizedRoom:
+		return rm.shrinkOversizedRoom(issue.roomID)
+
+	case issueStaleActive:
+		if _, connected := connectionPool.getConnection(issue.playerID); connected {
+			return false
+		}
+		room := rm.getRoomByID(issue.roomID)
+		if room == nil {
+			return false
+		}
+		room.mu.RLock()
+		player, exists := room.Players[issue.playerID]
+		room.mu.RUnlock()
+		if !exists || !player.IsActive {
+			return false
+		}
+		// Hand the player to the normal grace-period cleanup
+		player.MarkDisconnected()
+		return true
+
+	case issueUnmappedPlayer:
+		rm.playerMu.Lock()
+		defer rm.playerMu.Unlock()
+		if _, mapped := rm.playerToRoom[issue.playerID]; mapped || !rm.roomHasPlayer(issue.roomID, issue.playerID) {
+			return false
+		}
+		rm.playerToRoom[issue.playerID] = issue.roomID
+		return true
+
+	case issueDuplicatePlayer:
+		// playerToRoom is the source of truth; drop the extra copy
+		mapped := rm.getPlayerRoomID(issue.playerID)
+		if mapped == "" || mapped == issue.roomID || !rm.roomHasPlayer(mapped, issue.playerID) {
+			return false
+		}
+		return rm.deleteFromRoom(issue.roomID, issue.playerID)
+
+	case issueStaleMapping:
+		rm.playerMu.Lock()
+		defer rm.playerMu.Unlock()
+		if rm.playerToRoom[issue.playerID] != issue.roomID || rm.roomHasPlayer(issue.roomID, issue.playerID) {
+			return false
+		}
+		delete(rm.playerToRoom, issue.playerID)
+		// Repoint to whichever room still lists the player, if any
+		rm.mu.RLock()
+		for roomID, room := range rm.rooms {
+			room.mu.RLock()
+			_, listed := room.Players[issue.playerID]
+			room.mu.RUnlock()
+			if listed {
+				rm.playerToRoom[issue.playerID] = roomID
+				break
+			}
+		}
+		rm.mu.RUnlock()
+		return true
+
+	case issueConnRoom:
+		conn, exists := connectionPool.getConnection(issue.playerID)
+		room := rm.getRoomByID(issue.roomID)
+		if !exists || room == nil || rm.getPlayerRoomID(issue.playerID) != issue.roomID {
+			return false
+		}
+		conn.bindRoom(room)
+		return true
+
+	case issueOrphanConn:
+		conn, exists := connectionPool.getConnection(issue.playerID)
+		if !exists || rm.getPlayerRoomID(issue.playerID) != "" {
+			return false
+		}
+		conn.closeWithNotice("session_reset", "Your session was out of sync, please reconnect")
+		return true
+	}
+	return false
+}
+
+// shrinkOversizedRoom evicts disconnected players from a room that ended up above
+// capacity. Connected players are never kicked; the room just stays flagged until
+// enough of them leave.
+func (rm *RoomManager) shrinkOversizedRoom(roomID string) bool {
+	room := rm.getRoomByID(roomID)
+	if room == nil {
+		return false
+	}
+
+	room.mu.Lock()
+	var evicted []string
+	for playerID, player := range room.Players {
+		if len(room.Players) <= room.Capacity {
+			break
+		}
+		if !player.IsActive {
+			delete(room.Players, playerID)
+			evicted = append(evicted, playerID)
+		}
+	}
+	room.playerCount = int32(len(room.Players))
+	room.mu.Unlock()
+
+	if len(evicted) == 0 {
+		return false
+	}
+
+	rm.playerMu.Lock()
+	for _, playerID := range evicted {
+		if rm.playerToRoom[playerID] == roomID {
+			delete(rm.playerToRoom, playerID)
+		}
+	}
+	rm.playerMu.Unlock()
+
+	slog.Info("Evicted disconnected players from oversized room", "count", len(evicted), "room_id", roomID)
+	return true
+}
+
+// roomHasPlayer reports whether roomID currently lists playerID
+func (rm *RoomManager) roomHasPlayer(roomID, playerID string) bool {
+	room := rm.getRoomByID(roomID)
+	if room == nil {
+		return false
+	}
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	_, exists := room.Players[playerID]
+	return exists
+}
+
+// deleteFromRoom removes a player from one room's player map only
+func (rm *RoomManager) deleteFromRoom(roomID, playerID string) bool {
+	room := rm.getRoomByID(roomID)
+	if room == nil {
+		return false
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if _, exists := room.Players[playerID]; !exists {
+		return false
+	}
+	delete(room.Players, playerID)
+	room.playerCount = int32(len(room.Players))
+	return true
+}
+
+// getConsistencyStats reports reconciliation counters for monitoring
+func (rm *RoomManager) getConsistencyStats() map[string]interface{} {
+	rm.consistency.mu.Lock()
+	defer rm.consistency.mu.Unlock()
+
+	repairs := make(map[string]int64, len(rm.consistency.repairs))
+	var total int64
+	for kind, count := range rm.consistency.repairs {
+		repairs[kind] = count
+		total += count
+	}
+	return map[string]interface{}{
+		"checks":          rm.consistency.checks,
+		"pending_issues":  len(rm.consistency.suspects),
+		"repairs_total":   total,
+		"repairs_by_kind": repairs,
+	}
+}
@@ -0,0 +1,31 @@
+package Player_Logic
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// recoverPanic logs a panic that escaped one of the connection's goroutines. Deferred
+// in readPump and writePump so the connection is cleaned up instead of crashing the process.
+func (c *Connection) recoverPanic(goroutine string) {
+	if recovered := recover(); recovered != nil {
+		c.logger.Error("Panic in WebSocket "+goroutine,
+			"panic", fmt.Sprint(recovered),
+			"stack", string(debug.Stack()))
+	}
+}
+
+// handleMessageSafely handles one client message, turning a panic into a logged stack
+// trace and an error message so the connection survives it
+func (c *Connection) handleMessageSafely(rm *RoomManager, message WebSocketMessage) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			c.logger.Error("Panic handling WebSocket message",
+				"type", message.Type,
+				"panic", fmt.Sprint(recovered),
+				"stack", string(debug.Stack()))
+			c.sendMessageError(message.Type, &MessageError{Code: ErrCodeInternal, Message: "Internal server error"})
+		}
+	}()
+	c.handlePlayerAction(rm, message)
+}
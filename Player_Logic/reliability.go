@@ -0,0 +1,132 @@
+package Player_Logic
+
+import (
+	"sync"
+	"time"
+)
+
+// MaxUnackedMessages bounds the reliability buffer; the oldest unacked message is dropped beyond it
+const MaxUnackedMessages = 256
+
+// reliableTypes are the outgoing message types that carry a sequence number, are kept until
+// the client acks them, and are retransmitted when the player reconnects
+var reliableTypes = map[string]bool{
+	"chat_message":    true,
+	"private_message": true,
+}
+
+// pendingMessage is a sent but unacknowledged reliable message. The message is kept
+// unencoded since the next connection may negotiate a different codec.
+type pendingMessage struct {
+	seq     uint64
+	message WebSocketMessage
+}
+
+// reliableBuffer numbers a player's reliable messages and keeps them until acked. It moves
+// from connection to connection so a reconnecting client gets what it missed.
+type reliableBuffer struct {
+	nextSeq uint64
+	pending []pendingMessage
+	mu      sync.Mutex
+}
+
+// stashedBuffers holds buffers of disconnected players until their grace period ends
+var stashedBuffers = struct {
+	buffers map[string]*reliableBuffer
+	mu      sync.Mutex
+}{buffers: make(map[string]*reliableBuffer)}
+
+// stashReliableBuffer keeps a disconnected player's buffer for DisconnectedPlayerTTL
+func stashReliableBuffer(playerID string, buffer *reliableBuffer) {
+	stashedBuffers.mu.Lock()
+	stashedBuffers.buffers[playerID] = buffer
+	stashedBuffers.mu.Unlock()
+
+	time.AfterFunc(DisconnectedPlayerTTL, func() {
+		stashedBuffers.mu.Lock()
+		if stashedBuffers.buffers[playerID] == buffer {
+			delete(stashedBuffers.buffers, playerID)
+		}
+		stashedBuffers.mu.Unlock()
+	})
+}
+
+// takeReliableBuffer returns the buffer a new connection should use: the live connection's
+// (when replacing it), the stashed one from a recent disconnect, or a fresh one
+func takeReliableBuffer(playerID string) *reliableBuffer {
+	if existing, exists := connectionPool.getConnection(playerID); exists {
+		return existing.reliable
+	}
+
+	stashedBuffers.mu.Lock()
+	defer stashedBuffers.mu.Unlock()
+	if buffer, stashed := stashedBuffers.buffers[playerID]; stashed {
+		delete(stashedBuffers.buffers, playerID)
+		return buffer
+	}
+	return &reliableBuffer{nextSeq: 1}
+}
+
+// sendReliable assigns the next sequence number, buffers the message, and queues it. The
+// buffer lock is held across the queue so sequence numbers reach the socket in order.
+func (c *Connection) sendReliable(message WebSocketMessage) {
+	buffer := c.reliable
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+
+	message.Seq = buffer.nextSeq
+	data, err := c.codec.Marshal(message)
+	if err != nil {
+		c.logger.Error("Error marshaling message", "type", message.Type, "error", err)
+		return
+	}
+	buffer.nextSeq++
+
+	buffer.pending = append(buffer.pending, pendingMessage{seq: message.Seq, message: message})
+	if len(buffer.pending) > MaxUnackedMessages {
+		c.logger.Warn("Reliability buffer full, dropping oldest message", "seq", buffer.pending[0].seq)
+		buffer.pending = buffer.pending[1:]
+	}
+
+	if !c.enqueue(data) {
+		// Stays pending and goes out again on reconnect
+		c.logger.Debug("Connection cut off, message will be retransmitted", "seq", message.Seq)
+	}
+}
+
+// handleAck drops every pending message up to and including the acked sequence number
+func (c *Connection) handleAck(seq uint64) {
+	buffer := c.reliable
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+
+	acked := 0
+	for acked < len(buffer.pending) && buffer.pending[acked].seq <= seq {
+		acked++
+	}
+	buffer.pending = buffer.pending[acked:]
+}
+
+// retransmitPending resends unacked messages with their original sequence numbers;
+// clients drop any seq they've already seen
+func (c *Connection) retransmitPending() {
+	buffer := c.reliable
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+
+	if len(buffer.pending) == 0 {
+		return
+	}
+	for _, pending := range buffer.pending {
+		data, err := c.codec.Marshal(pending.message)
+		if err != nil {
+			c.logger.Error("Error marshaling retransmit", "error", err)
+			continue
+		}
+		if !c.enqueue(data) {
+			c.logger.Warn("Connection cut off, stopping retransmit", "seq", pending.seq)
+			return
+		}
+	}
+	c.logger.Info("Retransmitted unacked messages", "count", len(buffer.pending))
+}
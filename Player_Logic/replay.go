@@ -0,0 +1,126 @@
+package Player_Logic
+
+import (
+	"strconv"
+	"sync"
+	"time"
+	"velvet/config"
+)
+
+// Each room keeps its recent chat, joins, and leaves. A client reconnecting within the
+// grace period passes ?since=<timestamp of the last message it saw> and gets what it missed
+// as one "replay" batch. Chat for clients with reliable delivery is left to the retransmit
+// of unacked messages, which is exact, so it isn't sent twice.
+//
+// The ring also keeps the room's state changes, stamped with their state version, so a
+// client that finds a gap in the versions can get just the changes it missed.
+const (
+	ReplayBufferSize = 200
+	ReplayMaxAge     = DisconnectedPlayerTTL
+)
+
+// replayableTypes are the broadcasts recorded for replay
+var replayableTypes = map[string]bool{
+	"chat_message":  true,
+	"player_joined": true,
+	"player_left":   true,
+}
+
+// eventRing is a fixed-size ring buffer of a room's recent events
+type eventRing struct {
+	events []WebSocketMessage
+	next   int
+	mu     sync.Mutex
+}
+
+// record adds a broadcast to the ring if it's a replayable type or a state change
+func (ring *eventRing) record(message WebSocketMessage) {
+	if !replayableTypes[message.Type] && !versionedTypes[message.Type] {
+		return
+	}
+	if message.Timestamp == 0 {
+		message.Timestamp = time.Now().UnixMilli()
+	}
+
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+	if len(ring.events) < ReplayBufferSize {
+		ring.events = append(ring.events, message)
+		return
+	}
+	ring.events[ring.next] = message
+	ring.next = (ring.next + 1) % ReplayBufferSize
+}
+
+// since returns events newer than the timestamp (and within ReplayMaxAge), oldest first
+func (ring *eventRing) since(timestamp int64) []WebSocketMessage {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	cutoff := time.Now().Add(-ReplayMaxAge).UnixMilli()
+	if timestamp < cutoff {
+		timestamp = cutoff
+	}
+
+	var events []WebSocketMessage
+	for i := range ring.events {
+		event := ring.events[(ring.next+i)%len(ring.events)]
+		if replayableTypes[event.Type] && event.Timestamp > timestamp {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// sinceVersion returns the state changes after the given version, oldest first. ok is
+// false if the ring no longer holds all of them.
+func (ring *eventRing) sinceVersion(version uint64) (events []WebSocketMessage, ok bool) {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	next := version + 1
+	for i := range ring.events {
+		event := ring.events[(ring.next+i)%len(ring.events)]
+		if !versionedTypes[event.Type] || event.StateVersion <= version {
+			continue
+		}
+		if event.StateVersion != next {
+			return nil, false
+		}
+		events = append(events, event)
+		next++
+	}
+	return events, true
+}
+
+// replayMissed sends the room events the player missed since the given timestamp, minus
+// anything they couldn't have seen live (other channels, blocked senders, their own events)
+func (c *Connection) replayMissed(room *Room, since int64) {
+	blocks := config.GetBlockStore()
+	var missed []WebSocketMessage
+	for _, event := range room.replay.since(since) {
+		if event.PlayerID == c.playerID {
+			continue
+		}
+		if event.Type == "chat_message" {
+			channel := event.Channel
+			if channel == "" {
+				channel = DefaultChannel
+			}
+			if c.reliableDelivery() || !room.CanReadChannel(c.playerID, channel) || blocks.IsBlocked(c.playerID, event.PlayerID) {
+				continue
+			}
+		}
+		missed = append(missed, event)
+	}
+	c.sendBatch("replay", missed)
+}
+
+// parseReplaySince reads the ?since= timestamp (milliseconds); 0 means no replay
+func parseReplaySince(raw string) int64 {
+	since, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || since < 0 {
+		return 0
+	}
+	return since
+}
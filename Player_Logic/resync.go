@@ -0,0 +1,113 @@
+package Player_Logic
+
+import (
+	"encoding/json"
+	"time"
+	"velvet/config"
+)
+
+// A client that detects it's out of sync (a delta for a player it doesn't know, a missed
+// sequence number, ...) sends {"type":"resync_request"} and gets one "room_state" message
+// with everything it needs to rebuild the room, instead of reconnecting. Other players'
+// positions are the last ones broadcast, so position deltas that follow apply on top of
+// them; the requester's own position is the authoritative current one.
+//
+// A client that sends the last state_version it saw gets just the changes since then, as
+// a "replay" batch, while the room's replay ring still holds them all.
+const ResyncCooldown = 5 * time.Second
+
+// RoomState is a complete snapshot of a room as one client sees it
+type RoomState struct {
+	RoomID  string        `json:"room_id"`
+	Players []PlayerState `json:"players"`
+	Objects []RoomObject  `json:"objects"`
+	NPCs    []NPC         `json:"npcs"`
+	Game    *GameSession  `json:"game,omitempty"`
+}
+
+// PlayerState is one player's entry in a RoomState
+type PlayerState struct {
+	ID       string         `json:"player_id"`
+	Username string         `json:"username,omitempty"`
+	Position Position       `json:"position"`
+	Avatar   *config.Avatar `json:"avatar,omitempty"`
+	Zones    []string       `json:"zones,omitempty"` // Layout zones the player is standing in
+	GuildTag string         `json:"guild_tag,omitempty"`
+}
+
+// stateFor snapshots the room for a player. Hidden players are left out, except the
+// player themself.
+func (r *Room) stateFor(playerID string) RoomState {
+	r.mu.RLock()
+	players := make([]PlayerState, 0, len(r.Players))
+	for id, player := range r.Players {
+		if player.Hidden && id != playerID {
+			continue
+		}
+		position := player.Position
+		if base := player.deltaBase; id != playerID && base.valid {
+			position = Position{X: float64(base.x) / PositionScale, Y: float64(base.y) / PositionScale}
+		}
+		players = append(players, PlayerState{
+			ID:       id,
+			Username: player.Username,
+			Position: position,
+			Avatar:   player.GetAvatar(),
+			Zones:    append([]string(nil), player.zones...),
+			GuildTag: player.GetGuildTag(),
+		})
+	}
+	r.mu.RUnlock()
+
+	return RoomState{
+		RoomID:  r.ID,
+		Players: players,
+		Objects: r.ListObjects(),
+		NPCs:    r.ListNPCs(),
+		Game:    r.activeGame(),
+	}
+}
+
+// handleResyncRequest sends the client a full room_state, at most once per ResyncCooldown
+func (c *Connection) handleResyncRequest(rm *RoomManager, message WebSocketMessage) {
+	now := time.Now()
+	if now.Sub(c.lastResync) < ResyncCooldown {
+		c.sendMessageError(message.Type, &MessageError{Code: ErrCodeRateLimited, Message: "resync requested too often"})
+		return
+	}
+	c.lastResync = now
+
+	room := rm.GetPlayerRoom(c.playerID)
+	if room == nil {
+		return
+	}
+	// Read the version before the state: the state may then include a change numbered after
+	// it, which the client applies twice, but never misses one
+	version := room.StateVersion()
+	if seen := message.StateVersion; seen > 0 && seen <= version {
+		if missed, ok := room.replay.sinceVersion(seen); ok {
+			c.logger.Debug("Replaying state changes for resync", "room_id", room.ID, "since_version", seen, "count", len(missed))
+			if len(missed) == 0 {
+				c.sendMessage(versionNotice(version)) // Nothing was missed
+			} else {
+				c.sendBatch("replay", missed)
+			}
+			return
+		}
+	}
+
+	data, err := json.Marshal(room.stateFor(c.playerID))
+	if err != nil {
+		c.logger.Error("Error marshaling room state", "error", err)
+		return
+	}
+	c.logger.Debug("Sending room state for resync", "room_id", room.ID)
+	c.sendMessage(WebSocketMessage{
+		Type:         "room_state",
+		PlayerID:     "system",
+		RoomID:       room.ID,
+		Data:         data,
+		StateVersion: version,
+		Timestamp:    now.UnixMilli(),
+	})
+}
@@ -2,29 +2,91 @@ package Player_Logic
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
 	"sync"
 	"time"
+	"velvet/config"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
-	MaxPlayersPerRoom     = 20
 	RoomCodeLength        = 6
 	RoomCodeChars         = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	CleanupInterval       = 5 * time.Minute  // Cleanup every 5 minutes
-	InactiveRoomTimeout   = 30 * time.Minute // Remove empty rooms after 30 minutes
 	DisconnectedPlayerTTL = 80 * time.Second // Grace period for reconnection
+	MaxMuteDuration       = 24 * time.Hour
+)
+
+var (
+	// ErrPlayerBanned is returned when a banned player tries to (re)join a room
+	ErrPlayerBanned = errors.New("player is banned from this room")
+	// ErrRoomFull is returned when a room has no slot left for the joining player
+	ErrRoomFull = errors.New("room is full")
+	// ErrInvalidRoomOptions is returned when the options for a new room are out of range
+	ErrInvalidRoomOptions = errors.New("invalid room options")
+)
+
+// AdmissionPriority describes which room slots a join may use
+type AdmissionPriority int
+
+const (
+	PriorityRegular    AdmissionPriority = iota // Only unreserved slots
+	PriorityPrivileged                          // Reserved slots as well (friends, moderators, staff)
 )
 
+// PriorityResolver decides the admission priority of a player joining a room
+type PriorityResolver func(playerID string, room *Room) AdmissionPriority
+
 // Room represents a game room with optimized concurrency
 type Room struct {
 	ID           string
 	Players      map[string]*Player
 	CreatedAt    time.Time
 	LastActivity time.Time
-	mu           sync.RWMutex
+	// Max players, chosen by the creator (bounded by settings.Rooms.MaxPlayers)
+	Capacity int
+	// Slots held back for privileged joins; regular joins see Capacity - ReservedSlots
+	ReservedSlots int
+	// Number of a main room instance (see instances.go); 0 for every other room
+	instance int
+	// Presentation set by the host (exported/imported with the room layout)
+	Name  string
+	Theme string
+	// Moderation: the creator hosts the room; banned IDs can't rejoin for the room's lifetime
+	HostID     string
+	Moderators map[string]bool
+	Banned     map[string]bool
+	Muted      map[string]time.Time // Player ID -> mute expiry
+	// Chat content filtering is on unless the host turns it off
+	FilterDisabled bool
+	// Chat channels, created with the defaults on first use
+	Channels map[string]*ChatChannel
+	// Map the room was built from (spawn points, walls, zones); nil is the default layout
+	layout *RoomLayout
+	// Furniture and props, created on first use; objectSeq numbers new object IDs
+	Objects   map[string]*RoomObject
+	objectSeq int
+	// Server-controlled characters; npcSeq numbers new NPC IDs
+	NPCs         map[string]*NPC
+	npcSeq       int
+	npcSteppedAt time.Time
+	// Running mini-game, if any
+	game *GameSession
+	// Spatial grid for area-of-interest broadcasts, created on first use
+	interest *interestGrid
+	// Players who moved since the last tick, and whether the tick loop is running
+	pendingMoves map[string]bool
+	ticking      bool
+	// Recent chat/join/leave broadcasts for reconnecting clients
+	replay eventRing
+	// Goroutine fanning broadcasts out to the room's connections (see room_hub.go)
+	hubOnce  sync.Once
+	localHub *roomHub
+	mu       sync.RWMutex
 	// Performance optimizations
 	playerCount int32 // Atomic counter to avoid map len() calls
 }
@@ -32,19 +94,28 @@ type Room struct {
 // RoomManager manages all game rooms with optimized lookups
 type RoomManager struct {
 	// Core data structures
-	mainRoom *Room
-	rooms    map[string]*Room // Map of room ID to room
-	mu       sync.RWMutex
+	mainRoom  *Room
+	instances map[int]*Room    // Overflow copies of the main room by number (see instances.go)
+	rooms     map[string]*Room // Map of room ID to room
+	mu        sync.RWMutex
 
 	// Optimization: Player-to-room mapping for O(1) lookups
 	playerToRoom map[string]string // playerID -> roomID
 	playerMu     sync.RWMutex      // Separate lock for player mapping
 
+	// Priority admission
+	privilegedPlayers map[string]bool // Staff/moderator IDs that may use reserved slots
+	priorityResolvers []PriorityResolver
+	priorityMu        sync.RWMutex
+
 	// Cleanup management
 	cleanupCtx    context.Context
 	cleanupCancel context.CancelFunc
 	cleanupWG     sync.WaitGroup
 
+	// Divergence detection between playerToRoom, room.Players, and the connection pool
+	consistency reconcileState
+
 	// Statistics and monitoring
 	stats struct {
 		totalRoomsCreated  int64
@@ -77,6 +148,9 @@ func GetRoomManager() *RoomManager {
 		mainRoom := &Room{
 			ID:           mainRoomID,
 			Players:      make(map[string]*Player),
+			Capacity:     settings.Rooms.MaxPlayers,
+			Moderators:   make(map[string]bool),
+			Banned:       make(map[string]bool),
 			CreatedAt:    time.Now(),
 			LastActivity: time.Now(),
 			playerCount:  0,
@@ -84,20 +158,37 @@ func GetRoomManager() *RoomManager {
 
 		ctx, cancel := context.WithCancel(context.Background())
 		manager = &RoomManager{
-			mainRoom:      mainRoom,
-			rooms:         make(map[string]*Room),
-			playerToRoom:  make(map[string]string),
-			cleanupCtx:    ctx,
-			cleanupCancel: cancel,
+			mainRoom:          mainRoom,
+			instances:         make(map[int]*Room),
+			rooms:             make(map[string]*Room),
+			playerToRoom:      make(map[string]string),
+			privilegedPlayers: make(map[string]bool),
+			cleanupCtx:        ctx,
+			cleanupCancel:     cancel,
+			consistency: reconcileState{
+				suspects: make(map[string]bool),
+				repairs:  make(map[string]int64),
+			},
 		}
 
 		// Add main room to rooms map
 		manager.rooms[mainRoomID] = mainRoom
 
+		mainLayout, err := GetLayout(context.Background(), settings.Rooms.MainRoomLayout)
+		if err != nil {
+			slog.Warn("Main room layout unavailable, using the default", "layout_id", settings.Rooms.MainRoomLayout, "error", err)
+			mainLayout = builtinLayouts[DefaultLayoutID]
+		}
+		mainRoom.layout = mainLayout
+
+		// Bring back rooms from before the last restart (may replace the main room)
+		manager.restoreSnapshots()
+		manager.mainRoom.furnishDefaults(manager.mainRoom.Layout().Objects)
+
 		// Start cleanup routines
 		manager.startCleanupRoutines()
 
-		log.Printf("Room manager initialized with main room: %s", mainRoomID)
+		slog.Info("Room manager initialized", "main_room_id", manager.mainRoom.ID)
 	})
 	return manager
 }
@@ -138,7 +229,27 @@ func (rm *RoomManager) startCleanupRoutines() {
 		}
 	}()
 
-	log.Println("Room cleanup routines started")
+	// Purge accounts past their deletion grace period
+	rm.cleanupWG.Add(1)
+	go func() {
+		defer rm.cleanupWG.Done()
+		ticker := time.NewTicker(accountPurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				purgeDeletedAccounts(rm.cleanupCtx)
+			case <-rm.cleanupCtx.Done():
+				return
+			}
+		}
+	}()
+
+	// State consistency checks
+	rm.startReconciler()
+
+	slog.Info("Room cleanup routines started")
 }
 
 // performCleanup removes empty rooms and inactive players
@@ -159,7 +270,7 @@ func (rm *RoomManager) performCleanup() {
 
 		room.mu.RLock()
 		isEmpty := len(room.Players) == 0
-		isInactive := now.Sub(room.LastActivity) > InactiveRoomTimeout
+		isInactive := now.Sub(room.LastActivity) > settings.Rooms.InactiveTimeout
 		room.mu.RUnlock()
 
 		if isEmpty && isInactive {
@@ -172,15 +283,19 @@ func (rm *RoomManager) performCleanup() {
 	if len(roomsToDelete) > 0 {
 		rm.mu.Lock()
 		for _, roomID := range roomsToDelete {
+			if room := rm.rooms[roomID]; room != nil {
+				room.stopHub()
+				rm.forgetInstanceLocked(room)
+			}
 			delete(rm.rooms, roomID)
-			log.Printf("Cleaned up empty room: %s", roomID)
+			slog.Debug("Cleaned up empty room", "room_id", roomID)
 		}
 		rm.stats.mu.Lock()
 		rm.stats.currentActiveRooms = int32(len(rm.rooms))
 		rm.stats.mu.Unlock()
 		rm.mu.Unlock()
 
-		log.Printf("Cleanup completed: removed %d empty rooms", len(roomsToDelete))
+		slog.Info("Removed empty rooms", "count", len(roomsToDelete))
 	}
 }
 
@@ -210,11 +325,11 @@ func (rm *RoomManager) cleanupInactivePlayers() {
 	// Remove inactive players
 	for _, playerID := range playersToRemove {
 		rm.RemovePlayerOptimized(playerID)
-		log.Printf("Cleaned up inactive player: %s", playerID)
+		slog.Debug("Cleaned up inactive player", "player_id", playerID)
 	}
 
 	if len(playersToRemove) > 0 {
-		log.Printf("Cleanup completed: removed %d inactive players", len(playersToRemove))
+		slog.Info("Removed inactive players", "count", len(playersToRemove))
 	}
 }
 
@@ -225,90 +340,287 @@ func (rm *RoomManager) getRoomByID(roomID string) *Room {
 	return rm.rooms[roomID]
 }
 
-// AddPlayer adds a player to the main room (optimized)
-func (rm *RoomManager) AddPlayer(playerID string) (*Room, error) {
+// AddPlayer adds a player to the main room, or the least crowded of its instances
+func (rm *RoomManager) AddPlayer(ctx context.Context, playerID string) (room *Room, err error) {
+	ctx, span := config.StartSpan(ctx, "RoomManager.AddPlayer", attribute.String("player_id", playerID))
+	defer func() { config.EndSpan(span, err) }()
+
 	// Fast path: check if player already exists using O(1) lookup
-	if existingRoomID := rm.getPlayerRoomID(playerID); existingRoomID != "" {
-		if existingRoomID == rm.mainRoom.ID {
-			log.Printf("Player %s already exists in main room", playerID)
-			return rm.mainRoom, nil
+	if existing := rm.GetPlayerRoom(playerID); existing != nil {
+		if rm.isMainInstance(existing) {
+			slog.Debug("Player already in main room", "player_id", playerID, "room_id", existing.ID)
+			return existing, nil
 		}
 		// Remove from current room first
 		rm.RemovePlayerOptimized(playerID)
 	}
 
-	return rm.addPlayerToRoom(playerID, rm.mainRoom.ID)
+	err = rm.placeInMain(playerID, func(roomID string) (err error) {
+		room, err = rm.addPlayerToRoom(ctx, playerID, roomID)
+		return err
+	})
+	return room, err
+}
+
+// RoomOptions holds settings applied when a join creates a new room
+type RoomOptions struct {
+	Capacity      int    // Max players; 0 means settings.Rooms.MaxPlayers
+	ReservedSlots int    // Slots held back for privileged joins
+	LayoutID      string // Map to build the room from; empty means the default layout
+
+	layout *RoomLayout // Resolved from LayoutID by validate
+}
+
+// validate checks the options against server limits and fills in defaults
+func (opts *RoomOptions) validate() error {
+	if opts.Capacity == 0 {
+		opts.Capacity = settings.Rooms.MaxPlayers
+	}
+	if opts.Capacity < config.MinRoomCapacity || opts.Capacity > settings.Rooms.MaxPlayers {
+		return fmt.Errorf("%w: capacity must be between %d and %d", ErrInvalidRoomOptions, config.MinRoomCapacity, settings.Rooms.MaxPlayers)
+	}
+	if opts.ReservedSlots < 0 || opts.ReservedSlots >= opts.Capacity {
+		return fmt.Errorf("%w: reserved slots must be between 0 and %d", ErrInvalidRoomOptions, opts.Capacity-1)
+	}
+	layout, err := GetLayout(context.Background(), opts.LayoutID)
+	if err != nil {
+		return fmt.Errorf("layout %q: %w", opts.LayoutID, err)
+	}
+	opts.layout = layout
+	return nil
 }
 
 // AddPlayerToSpecificRoom adds a player to a specific room (optimized)
-func (rm *RoomManager) AddPlayerToSpecificRoom(playerID, roomID string) (*Room, error) {
-	log.Printf("Attempting to add player %s to specific room %s", playerID, roomID)
+func (rm *RoomManager) AddPlayerToSpecificRoom(ctx context.Context, playerID, roomID string) (*Room, error) {
+	return rm.AddPlayerToSpecificRoomWithOptions(ctx, playerID, roomID, RoomOptions{})
+}
+
+// AddPlayerToSpecificRoomWithOptions adds a player to a specific room, creating it with opts if needed
+func (rm *RoomManager) AddPlayerToSpecificRoomWithOptions(ctx context.Context, playerID, roomID string, opts RoomOptions) (room *Room, err error) {
+	ctx, span := config.StartSpan(ctx, "RoomManager.AddPlayerToSpecificRoom",
+		attribute.String("player_id", playerID), attribute.String("room_id", roomID))
+	defer func() { config.EndSpan(span, err) }()
+
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	slog.Debug("Adding player to specific room", "player_id", playerID, "room_id", roomID)
 
 	// Fast path: check if player already in target room
 	if existingRoomID := rm.getPlayerRoomID(playerID); existingRoomID == roomID {
-		log.Printf("Player %s already exists in room %s", playerID, roomID)
+		slog.Debug("Player already in room", "player_id", playerID, "room_id", roomID)
 		return rm.getRoomByID(roomID), nil
 	}
 
+	// Banned players keep their current room
+	if rm.IsBanned(roomID, playerID) {
+		slog.Info("Player is banned from room", "player_id", playerID, "room_id", roomID)
+		return nil, ErrPlayerBanned
+	}
+
 	// Remove from current room if exists
 	if existingRoomID := rm.getPlayerRoomID(playerID); existingRoomID != "" {
 		rm.RemovePlayerOptimized(playerID)
 	}
 
 	// Create room if it doesn't exist
+	rm.getOrCreateRoom(roomID, playerID, opts)
+
+	return rm.addPlayerToRoom(ctx, playerID, roomID)
+}
+
+// getOrCreateRoom returns the room, creating it with hostID and opts if it doesn't exist
+func (rm *RoomManager) getOrCreateRoom(roomID, hostID string, opts RoomOptions) *Room {
 	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
 	room, exists := rm.rooms[roomID]
-	if !exists {
-		log.Printf("Room %s doesn't exist, creating new room", roomID)
-		room = &Room{
-			ID:           roomID,
-			Players:      make(map[string]*Player),
-			CreatedAt:    time.Now(),
-			LastActivity: time.Now(),
-			playerCount:  0,
+	if exists {
+		return room
+	}
+
+	slog.Info("Room doesn't exist, creating it", "room_id", roomID)
+	room = &Room{
+		ID:            roomID,
+		Players:       make(map[string]*Player),
+		CreatedAt:     time.Now(),
+		LastActivity:  time.Now(),
+		Capacity:      opts.Capacity,
+		ReservedSlots: opts.ReservedSlots,
+		HostID:        hostID,
+		Moderators:    make(map[string]bool),
+		Banned:        make(map[string]bool),
+		playerCount:   0,
+	}
+	room.applyLayout(opts.layout)
+	rm.rooms[roomID] = room
+	rm.stats.mu.Lock()
+	rm.stats.totalRoomsCreated++
+	rm.stats.currentActiveRooms = int32(len(rm.rooms))
+	rm.stats.mu.Unlock()
+	emitRoomCreated(room, hostID)
+	return room
+}
+
+// AddGroupToSpecificRoom moves a leader and their group into a room all-or-nothing:
+// either every player fits (capacity and bans) or nobody moves. Returns the room and,
+// for each player that changed rooms, the room they left (nil if they weren't in one).
+func (rm *RoomManager) AddGroupToSpecificRoom(ctx context.Context, leaderID string, memberIDs []string, roomID string, opts RoomOptions) (_ *Room, _ map[string]*Room, err error) {
+	ctx, span := config.StartSpan(ctx, "RoomManager.AddGroupToSpecificRoom",
+		attribute.String("player_id", leaderID), attribute.String("room_id", roomID), attribute.Int("group_size", len(memberIDs)+1))
+	defer func() { config.EndSpan(span, err) }()
+
+	if err := opts.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	// Leader first, without duplicates
+	playerIDs := []string{leaderID}
+	seen := map[string]bool{leaderID: true}
+	for _, id := range memberIDs {
+		if !seen[id] {
+			seen[id] = true
+			playerIDs = append(playerIDs, id)
+		}
+	}
+	for _, id := range playerIDs {
+		if rm.IsBanned(roomID, id) {
+			return nil, nil, fmt.Errorf("%w: %s", ErrPlayerBanned, id)
 		}
-		rm.rooms[roomID] = room
-		rm.stats.mu.Lock()
-		rm.stats.totalRoomsCreated++
-		rm.stats.currentActiveRooms = int32(len(rm.rooms))
-		rm.stats.mu.Unlock()
 	}
-	rm.mu.Unlock()
 
-	return rm.addPlayerToRoom(playerID, roomID)
+	room := rm.getOrCreateRoom(roomID, leaderID, opts)
+	priority := rm.admissionPriority(ctx, leaderID, room)
+
+	// Insert everyone under one lock so the group can't be split by a concurrent join
+	room.mu.Lock()
+	var joining []string
+	for _, id := range playerIDs {
+		if room.Banned[id] {
+			room.mu.Unlock()
+			return nil, nil, fmt.Errorf("%w: %s", ErrPlayerBanned, id)
+		}
+		if _, already := room.Players[id]; !already {
+			joining = append(joining, id)
+		}
+	}
+	if err := room.checkCapacity(priority, len(joining)); err != nil {
+		room.mu.Unlock()
+		return nil, nil, err
+	}
+	for _, id := range joining {
+		player := newPlayer(id)
+		player.Position = room.layoutLocked().SpawnPoint()
+		room.Players[id] = player
+	}
+	room.LastActivity = time.Now()
+	room.playerCount = int32(len(room.Players))
+	room.mu.Unlock()
+
+	// Take joined players out of their previous rooms and repoint the mapping
+	previousRooms := make(map[string]*Room, len(joining))
+	for _, id := range joining {
+		rm.playerMu.Lock()
+		oldRoomID := rm.playerToRoom[id]
+		rm.playerToRoom[id] = roomID
+		rm.playerMu.Unlock()
+
+		var oldRoom *Room
+		if oldRoomID != "" && oldRoomID != roomID {
+			if oldRoom = rm.getRoomByID(oldRoomID); oldRoom != nil {
+				oldRoom.mu.Lock()
+				freedSeat := oldRoom.removePlayerLocked(id)
+				oldRoom.mu.Unlock()
+				if freedSeat != nil {
+					broadcastObjectUpdate(oldRoom, *freedSeat)
+				}
+			}
+		}
+		previousRooms[id] = oldRoom
+	}
+
+	rm.stats.mu.Lock()
+	rm.stats.totalPlayersServed += int64(len(joining))
+	rm.stats.mu.Unlock()
+
+	slog.Info("Added group to room", "count", len(joining), "leader_id", leaderID, "room_id", roomID)
+	for _, id := range joining {
+		emitPlayerJoined(id, roomID)
+	}
+	return room, previousRooms, nil
+}
+
+// CreateRoom creates an empty room with a freshly generated code
+func (rm *RoomManager) CreateRoom(hostID string, opts RoomOptions) (*Room, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	roomID := generateRoomCode()
+	for rm.rooms[roomID] != nil {
+		roomID = generateRoomCode()
+	}
+
+	room := &Room{
+		ID:            roomID,
+		Players:       make(map[string]*Player),
+		CreatedAt:     time.Now(),
+		LastActivity:  time.Now(),
+		Capacity:      opts.Capacity,
+		ReservedSlots: opts.ReservedSlots,
+		HostID:        hostID,
+		Moderators:    make(map[string]bool),
+		Banned:        make(map[string]bool),
+	}
+	room.applyLayout(opts.layout)
+	rm.rooms[roomID] = room
+
+	rm.stats.mu.Lock()
+	rm.stats.totalRoomsCreated++
+	rm.stats.currentActiveRooms = int32(len(rm.rooms))
+	rm.stats.mu.Unlock()
+
+	slog.Info("Created room", "room_id", roomID)
+	emitRoomCreated(room, hostID)
+	return room, nil
 }
 
 // addPlayerToRoom adds a player to a specific room (internal optimized helper)
-func (rm *RoomManager) addPlayerToRoom(playerID, roomID string) (*Room, error) {
+func (rm *RoomManager) addPlayerToRoom(ctx context.Context, playerID, roomID string) (*Room, error) {
 	room := rm.getRoomByID(roomID)
 	if room == nil {
-		return nil, fmt.Errorf("room %s not found", roomID)
+		return nil, fmt.Errorf("%w: %s", ErrRoomNotFound, roomID)
 	}
 
+	priority := rm.admissionPriority(ctx, playerID, room)
+
 	// Check room capacity with minimal locking
 	room.mu.RLock()
-	if len(room.Players) >= MaxPlayersPerRoom {
+	if err := room.checkCapacity(priority, 1); err != nil {
 		room.mu.RUnlock()
-		log.Printf("Room %s is full, cannot add player %s", roomID, playerID)
-		return nil, fmt.Errorf("room %s is full", roomID)
+		slog.Info("Room cannot admit player", "room_id", roomID, "player_id", playerID, "error", err)
+		return nil, err
 	}
 	room.mu.RUnlock()
 
-	// Create player
-	player := &Player{
-		ID:       playerID,
-		Username: "",
-		Position: Position{X: 0, Y: 0},
-		IsActive: true,
-		LastSeen: time.Now(),
-	}
+	// Create player at one of the layout's spawn points
+	player := newPlayer(playerID)
+	player.Position = room.Layout().SpawnPoint()
 
 	// Add player with minimal lock scope
 	room.mu.Lock()
+	if room.Banned[playerID] {
+		room.mu.Unlock()
+		return nil, ErrPlayerBanned
+	}
 	// Double-check capacity after acquiring lock
-	if len(room.Players) >= MaxPlayersPerRoom {
+	if err := room.checkCapacity(priority, 1); err != nil {
 		room.mu.Unlock()
-		return nil, fmt.Errorf("room %s is full", roomID)
+		return nil, err
 	}
 
 	room.Players[playerID] = player
@@ -325,10 +637,263 @@ func (rm *RoomManager) addPlayerToRoom(playerID, roomID string) (*Room, error) {
 	rm.stats.totalPlayersServed++
 	rm.stats.mu.Unlock()
 
-	log.Printf("Added player %s to room %s", playerID, roomID)
+	slog.Info("Added player to room", "player_id", playerID, "room_id", roomID)
+	emitPlayerJoined(playerID, roomID)
 	return room, nil
 }
 
+// newPlayer creates a freshly joined player at the origin
+func newPlayer(playerID string) *Player {
+	player := &Player{
+		ID:       playerID,
+		Username: "",
+		Position: Position{X: 0, Y: 0},
+		IsActive: true,
+		LastSeen: time.Now(),
+	}
+	if account, isService := config.GetServiceAccount(playerID); isService {
+		player.IsService = true
+		player.Hidden = account.Hidden
+		player.Username = account.Name
+	}
+	return player
+}
+
+// checkCapacity reports whether `joining` players with the given priority fit in the room.
+// Caller must hold room.mu.
+func (r *Room) checkCapacity(priority AdmissionPriority, joining int) error {
+	if len(r.Players)+joining > r.Capacity {
+		return fmt.Errorf("%w: %s", ErrRoomFull, r.ID)
+	}
+	if priority == PriorityRegular && len(r.Players)+joining > r.Capacity-r.ReservedSlots {
+		return fmt.Errorf("%w: %s (remaining slots are reserved)", ErrRoomFull, r.ID)
+	}
+	return nil
+}
+
+// canModerate reports whether a player may moderate a room (host, room moderator, or privileged staff)
+func (rm *RoomManager) canModerate(room *Room, playerID string) bool {
+	room.mu.RLock()
+	isHost := room.HostID != "" && room.HostID == playerID
+	isModerator := room.Moderators[playerID]
+	room.mu.RUnlock()
+	if isHost || isModerator {
+		return true
+	}
+
+	rm.priorityMu.RLock()
+	defer rm.priorityMu.RUnlock()
+	return rm.privilegedPlayers[playerID]
+}
+
+// IsBanned reports whether a player is banned from a room
+func (rm *RoomManager) IsBanned(roomID, playerID string) bool {
+	room := rm.getRoomByID(roomID)
+	if room == nil {
+		return false
+	}
+
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	return room.Banned[playerID]
+}
+
+// BanPlayer bans a player from a room on behalf of its host/moderator and removes them if present.
+// Returns true if the target was in the room at the time of the ban.
+func (rm *RoomManager) BanPlayer(roomID, actorID, targetID string) (bool, error) {
+	room := rm.getRoomByID(roomID)
+	if room == nil {
+		return false, fmt.Errorf("%w: %s", ErrRoomNotFound, roomID)
+	}
+	if !rm.canModerate(room, actorID) {
+		return false, fmt.Errorf("only the room host or a moderator can ban players")
+	}
+	if targetID == actorID {
+		return false, fmt.Errorf("cannot ban yourself")
+	}
+
+	room.mu.Lock()
+	if targetID == room.HostID {
+		room.mu.Unlock()
+		return false, fmt.Errorf("cannot ban the room host")
+	}
+	room.Banned[targetID] = true
+	_, wasPresent := room.Players[targetID]
+	room.mu.Unlock()
+
+	if wasPresent {
+		rm.RemovePlayerOptimized(targetID)
+	}
+
+	slog.Info("Player banned from room", "player_id", targetID, "room_id", roomID, "actor_id", actorID)
+	config.RecordAudit(context.Background(), actorID, config.AuditRoomBan, targetID, map[string]interface{}{"room_id": roomID})
+	return wasPresent, nil
+}
+
+// MutePlayer stops a player from chatting in a room for the given duration (host/moderators only)
+func (rm *RoomManager) MutePlayer(roomID, actorID, targetID string, duration time.Duration) error {
+	room := rm.getRoomByID(roomID)
+	if room == nil {
+		return fmt.Errorf("%w: %s", ErrRoomNotFound, roomID)
+	}
+	if !rm.canModerate(room, actorID) {
+		return fmt.Errorf("only the room host or a moderator can mute players")
+	}
+	if targetID == actorID {
+		return fmt.Errorf("cannot mute yourself")
+	}
+	if duration <= 0 || duration > MaxMuteDuration {
+		return fmt.Errorf("mute duration must be between 1 and %d minutes", int(MaxMuteDuration.Minutes()))
+	}
+
+	room.mu.Lock()
+	if targetID == room.HostID {
+		room.mu.Unlock()
+		return fmt.Errorf("cannot mute the room host")
+	}
+	if room.Muted == nil {
+		room.Muted = make(map[string]time.Time)
+	}
+	room.Muted[targetID] = time.Now().Add(duration)
+	room.mu.Unlock()
+
+	slog.Info("Player muted", "player_id", targetID, "room_id", roomID, "duration", duration.String(), "actor_id", actorID)
+	recordMuteAudit(actorID, targetID, roomID, duration)
+	return nil
+}
+
+// recordMuteAudit writes a room mute to the audit log
+func recordMuteAudit(actorID, targetID, roomID string, duration time.Duration) {
+	config.RecordAudit(context.Background(), actorID, config.AuditMute, targetID, map[string]interface{}{
+		"room_id":          roomID,
+		"duration_minutes": int(duration.Minutes()),
+	})
+}
+
+// UnmutePlayer lifts a room mute on behalf of its host/moderator
+func (rm *RoomManager) UnmutePlayer(roomID, actorID, targetID string) error {
+	room := rm.getRoomByID(roomID)
+	if room == nil {
+		return fmt.Errorf("%w: %s", ErrRoomNotFound, roomID)
+	}
+	if !rm.canModerate(room, actorID) {
+		return fmt.Errorf("only the room host or a moderator can unmute players")
+	}
+
+	room.mu.Lock()
+	delete(room.Muted, targetID)
+	room.mu.Unlock()
+
+	slog.Info("Player unmuted", "player_id", targetID, "room_id", roomID, "actor_id", actorID)
+	config.RecordAudit(context.Background(), actorID, config.AuditUnmute, targetID, map[string]interface{}{"room_id": roomID})
+	return nil
+}
+
+// MuteRemaining returns how long a player stays muted in the room (0 if not muted)
+func (r *Room) MuteRemaining(playerID string) time.Duration {
+	r.mu.RLock()
+	expiresAt, muted := r.Muted[playerID]
+	r.mu.RUnlock()
+	if !muted {
+		return 0
+	}
+
+	remaining := time.Until(expiresAt)
+	if remaining <= 0 {
+		r.mu.Lock()
+		if r.Muted[playerID] == expiresAt {
+			delete(r.Muted, playerID)
+		}
+		r.mu.Unlock()
+		return 0
+	}
+	return remaining
+}
+
+// UnbanPlayer lifts a room ban on behalf of its host/moderator
+func (rm *RoomManager) UnbanPlayer(roomID, actorID, targetID string) error {
+	room := rm.getRoomByID(roomID)
+	if room == nil {
+		return fmt.Errorf("%w: %s", ErrRoomNotFound, roomID)
+	}
+	if !rm.canModerate(room, actorID) {
+		return fmt.Errorf("only the room host or a moderator can unban players")
+	}
+
+	room.mu.Lock()
+	delete(room.Banned, targetID)
+	room.mu.Unlock()
+
+	slog.Info("Player unbanned from room", "player_id", targetID, "room_id", roomID, "actor_id", actorID)
+	config.RecordAudit(context.Background(), actorID, config.AuditRoomUnban, targetID, map[string]interface{}{"room_id": roomID})
+	return nil
+}
+
+// SetReservedSlots sets how many slots of a room are held back for privileged joins
+func (rm *RoomManager) SetReservedSlots(roomID string, slots int) error {
+	room := rm.getRoomByID(roomID)
+	if room == nil {
+		return fmt.Errorf("%w: %s", ErrRoomNotFound, roomID)
+	}
+
+	room.mu.Lock()
+	if slots < 0 || slots >= room.Capacity {
+		room.mu.Unlock()
+		return fmt.Errorf("reserved slots must be between 0 and %d", room.Capacity-1)
+	}
+	room.ReservedSlots = slots
+	room.mu.Unlock()
+
+	slog.Info("Reserved slots configured", "room_id", roomID, "slots", slots)
+	return nil
+}
+
+// SetPrivilegedPlayers replaces the set of staff/moderator IDs that may use reserved slots
+func (rm *RoomManager) SetPrivilegedPlayers(playerIDs []string) {
+	privileged := make(map[string]bool, len(playerIDs))
+	for _, id := range playerIDs {
+		privileged[id] = true
+	}
+
+	rm.priorityMu.Lock()
+	rm.privilegedPlayers = privileged
+	rm.priorityMu.Unlock()
+}
+
+// AddPriorityResolver registers an extra rule that can grant privileged admission
+// (e.g. friends of current room members)
+func (rm *RoomManager) AddPriorityResolver(resolver PriorityResolver) {
+	rm.priorityMu.Lock()
+	rm.priorityResolvers = append(rm.priorityResolvers, resolver)
+	rm.priorityMu.Unlock()
+}
+
+// admissionPriority resolves the priority a player joins a room with
+func (rm *RoomManager) admissionPriority(ctx context.Context, playerID string, room *Room) AdmissionPriority {
+	_, span := config.StartSpan(ctx, "RoomManager.admissionPriority")
+	defer span.End()
+
+	rm.priorityMu.RLock()
+	isPrivileged := rm.privilegedPlayers[playerID]
+	resolvers := rm.priorityResolvers
+	rm.priorityMu.RUnlock()
+
+	if isPrivileged {
+		return PriorityPrivileged
+	}
+	for _, resolver := range resolvers {
+		if resolver(playerID, room) == PriorityPrivileged {
+			return PriorityPrivileged
+		}
+	}
+	return PriorityRegular
+}
+
+// MainRoomID returns the ID of the main room
+func (rm *RoomManager) MainRoomID() string {
+	return rm.mainRoom.ID
+}
+
 // getPlayerRoomID gets the room ID for a player using O(1) lookup
 func (rm *RoomManager) getPlayerRoomID(playerID string) string {
 	rm.playerMu.RLock()
@@ -382,24 +947,38 @@ func (rm *RoomManager) RemovePlayerOptimized(playerID string) {
 		return
 	}
 
+	var freedSeat *RoomObject
 	room.mu.Lock()
 	if player, exists := room.Players[playerID]; exists {
 		player.IsActive = false
 		player.LastSeen = time.Now()
-		delete(room.Players, playerID)
-		room.LastActivity = time.Now()
-		room.playerCount = int32(len(room.Players))
-		log.Printf("Removed player %s from room %s. Remaining players: %d",
-			playerID, room.ID, len(room.Players))
+		freedSeat = room.removePlayerLocked(playerID)
+		slog.Info("Removed player from room", "player_id", playerID, "room_id", room.ID, "remaining", len(room.Players))
 	}
 	room.mu.Unlock()
 
+	if freedSeat != nil {
+		broadcastObjectUpdate(room, *freedSeat)
+	}
+
 	// Remove from player-to-room mapping
 	rm.playerMu.Lock()
 	delete(rm.playerToRoom, playerID)
 	rm.playerMu.Unlock()
 }
 
+// removePlayerLocked takes a player out of the room along with their interest-grid cell
+// and any seat they held, returning the freed seat for the caller to broadcast once the
+// lock is released. Caller must hold r.mu.
+func (r *Room) removePlayerLocked(playerID string) *RoomObject {
+	delete(r.Players, playerID)
+	r.removeInterestLocked(playerID)
+	freedSeat := r.vacateSeatLocked(playerID)
+	r.LastActivity = time.Now()
+	r.playerCount = int32(len(r.Players))
+	return freedSeat
+}
+
 // RemovePlayer removes a player from all rooms (legacy compatibility)
 func (rm *RoomManager) RemovePlayer(playerID string) {
 	rm.RemovePlayerOptimized(playerID)
@@ -419,6 +998,104 @@ func (rm *RoomManager) GetRoomStats() map[string]int {
 	return stats
 }
 
+// GetCapacity returns the room's player capacity
+func (r *Room) GetCapacity() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.Capacity
+}
+
+// VisiblePlayers returns the players in the room that should appear in player lists
+func (r *Room) VisiblePlayers() []*Player {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	players := make([]*Player, 0, len(r.Players))
+	for _, player := range r.Players {
+		if !player.Hidden {
+			players = append(players, player)
+		}
+	}
+	return players
+}
+
+// RoomInfo is a room's entry in the public room directory
+type RoomInfo struct {
+	ID            string    `json:"room_id"`
+	PlayerCount   int       `json:"player_count"`
+	Capacity      int       `json:"capacity"`
+	ReservedSlots int       `json:"reserved_slots"`
+	IsMain        bool      `json:"is_main"`
+	Instance      int       `json:"instance,omitempty"` // Number of a main room instance
+	CreatedAt     time.Time `json:"created_at"`
+
+	Event *ScheduledEvent `json:"event,omitempty"` // Event live in the room right now
+}
+
+// ListRooms returns directory entries for all rooms
+func (rm *RoomManager) ListRooms() []RoomInfo {
+	live := liveEvents()
+
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	rooms := make([]RoomInfo, 0, len(rm.rooms))
+	for roomID, room := range rm.rooms {
+		room.mu.RLock()
+		rooms = append(rooms, room.infoLocked(room == rm.mainRoom))
+		room.mu.RUnlock()
+		if event, exists := live[roomID]; exists {
+			rooms[len(rooms)-1].Event = &event
+		}
+	}
+	return rooms
+}
+
+// GetRoomInfo returns one room's directory entry
+func (rm *RoomManager) GetRoomInfo(roomID string) (RoomInfo, bool) {
+	room := rm.getRoomByID(roomID)
+	if room == nil {
+		return RoomInfo{}, false
+	}
+
+	isMain := roomID == rm.MainRoomID()
+	room.mu.RLock()
+	info := room.infoLocked(isMain)
+	room.mu.RUnlock()
+	if event, exists := liveEvents()[roomID]; exists {
+		info.Event = &event
+	}
+	return info, true
+}
+
+// infoLocked builds the room's directory entry (caller holds r.mu)
+func (r *Room) infoLocked(isMain bool) RoomInfo {
+	return RoomInfo{
+		ID:            r.ID,
+		PlayerCount:   len(r.Players),
+		Capacity:      r.Capacity,
+		ReservedSlots: r.ReservedSlots,
+		IsMain:        isMain,
+		Instance:      r.instance,
+		CreatedAt:     r.CreatedAt,
+	}
+}
+
+// VisiblePlayerIDs returns the IDs of the players listed in a room, or nil if it doesn't exist
+func (rm *RoomManager) VisiblePlayerIDs(roomID string) []string {
+	room := rm.getRoomByID(roomID)
+	if room == nil {
+		return nil
+	}
+
+	players := room.VisiblePlayers()
+	ids := make([]string, 0, len(players))
+	for _, player := range players {
+		ids = append(ids, player.ID)
+	}
+	return ids
+}
+
 // GetRoomPlayers returns all players in the main room
 func (rm *RoomManager) GetRoomPlayers() []*Player {
 	rm.mainRoom.mu.RLock()
@@ -431,40 +1108,86 @@ func (rm *RoomManager) GetRoomPlayers() []*Player {
 	return players
 }
 
-// handlePositionUpdate updates a player's position with O(1) lookup
-func (rm *RoomManager) handlePositionUpdate(playerID string, position Position, username string) {
+// handlePositionUpdate updates a player's position with O(1) lookup. inputSeq is the client's
+// input number (0 if it doesn't predict movement).
+func (rm *RoomManager) handlePositionUpdate(playerID string, position Position, username string, inputSeq uint64) {
 	// O(1) room lookup instead of linear search
 	room := rm.GetPlayerRoom(playerID)
 	if room == nil {
-		log.Printf("Player %s not found in any room for position update", playerID)
+		slog.Debug("Player not found in any room for position update", "player_id", playerID)
 		return
 	}
 
 	// Minimal lock scope for position update
 	room.mu.Lock()
-	if player, exists := room.Players[playerID]; exists {
-		player.Position = position
-		player.LastSeen = time.Now()
-		if username != "" {
-			player.Username = username
+	player, exists := room.Players[playerID]
+	if !exists {
+		room.mu.Unlock()
+		return
+	}
+	// NaN would get past every check below and stick, and can't be encoded as JSON; send
+	// the client back to its last good position instead
+	if !position.finite() {
+		current := player.Position
+		room.mu.Unlock()
+		go sendPositionCorrection(playerID, current, inputSeq, ViolationInvalid)
+		return
+	}
+
+	// Clamp impossible moves; the client is corrected once the lock is released
+	now := time.Now()
+	violation := ""
+	if !player.IsService {
+		position, violation = player.validateMoveLocked(position, now, room.movementLimitsLocked())
+		if stop, blocked := room.collideLocked(player.Position, position); blocked {
+			position = stop
+			if violation == "" {
+				violation = ViolationCollision
+			}
 		}
-		room.LastActivity = time.Now()
-	} else {
+	}
+	player.Position = position
+	player.lastMoveAt = now
+	player.LastSeen = now
+	if username != "" {
+		player.Username = username
+	}
+	if inputSeq > 0 {
+		player.lastInputSeq = inputSeq
+	}
+	room.LastActivity = now
+	if violation != "" {
+		go sendPositionCorrection(playerID, position, inputSeq, violation)
+	}
+	if changes := room.updateZonesLocked(player); len(changes) > 0 {
+		go notifyZoneChanges(room, playerID, player.Hidden, changes)
+	}
+	if portal := room.portalAtLocked(player, now); portal != nil {
+		player.arrivedAt = now // Ignore further moves onto the portal while the transfer runs
+		go rm.usePortal(playerID, *portal)
+	}
+
+	// Hidden service accounts never show up on other clients
+	if player.Hidden {
 		room.mu.Unlock()
 		return
 	}
-	room.mu.Unlock()
 
-	// Broadcast position asynchronously
-	message := WebSocketMessage{
-		Type:      "position_update",
-		PlayerID:  playerID,
-		Position:  &position,
-		Username:  username,
-		Timestamp: time.Now().UnixMilli(),
+	// With a tick rate, the room's loop sends the newest position on the next tick
+	if getTickInterval() > 0 {
+		start := room.queuePositionLocked(playerID)
+		room.mu.Unlock()
+		if start {
+			rm.cleanupWG.Add(1)
+			go rm.runRoomTicker(room)
+		}
+		return
 	}
 
-	go broadcastToRoomAsync(room, playerID, message)
+	room.mu.Unlock()
+
+	// Otherwise moves are coalesced for up to BatchTimeout
+	messageBatcher.queuePosition(room, playerID)
 }
 
 // GetManagerStats returns comprehensive room manager statistics
@@ -474,6 +1197,7 @@ func (rm *RoomManager) GetManagerStats() map[string]interface{} {
 
 	rm.mu.RLock()
 	roomCount := len(rm.rooms)
+	instanceCount := len(rm.instances)
 	rm.mu.RUnlock()
 
 	rm.playerMu.RLock()
@@ -485,7 +1209,9 @@ func (rm *RoomManager) GetManagerStats() map[string]interface{} {
 		"total_players_served":   rm.stats.totalPlayersServed,
 		"current_active_rooms":   roomCount,
 		"current_active_players": playerCount,
+		"main_room_instances":    instanceCount,
 		"cleanup_operations":     rm.stats.cleanupOperations,
+		"consistency":            rm.getConsistencyStats(),
 		"optimization_features": map[string]bool{
 			"o1_player_lookup":        true,
 			"reduced_lock_contention": true,
@@ -497,8 +1223,11 @@ func (rm *RoomManager) GetManagerStats() map[string]interface{} {
 
 // Shutdown gracefully shuts down the room manager
 func (rm *RoomManager) Shutdown() {
-	log.Println("Shutting down room manager...")
+	slog.Info("Shutting down room manager")
 	rm.cleanupCancel()
 	rm.cleanupWG.Wait()
-	log.Println("Room manager shutdown complete")
+
+	// Persist rooms so their codes survive the restart
+	rm.saveSnapshots()
+	slog.Info("Room manager shutdown complete")
 }
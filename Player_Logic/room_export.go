@@ -0,0 +1,180 @@
+package Player_Logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"time"
+)
+
+const (
+	RoomExportVersion     = 1
+	MaxRoomExportBytes    = 64 * 1024 // Size limit for uploaded room documents
+	MaxRoomNameLength     = 64
+	MaxRoomExportIDs      = 500 // Max entries in each permission list
+	MaxExportedPlayerID   = 128
+	DefaultRoomExportName = "Untitled room"
+)
+
+// ErrRoomExists is returned when importing into a room code that is already taken
+var ErrRoomExists = errors.New("room already exists")
+
+var roomThemePattern = regexp.MustCompile(`^[a-z0-9-]{0,32}$`)
+
+// RoomExport is the portable JSON document describing a room's layout and settings
+type RoomExport struct {
+	Version     int                   `json:"version"`
+	ExportedAt  time.Time             `json:"exported_at"`
+	Metadata    RoomExportMetadata    `json:"metadata"`
+	Theme       string                `json:"theme"`
+	Layout      string                `json:"layout,omitempty"` // Layout ID; empty is the default layout
+	Permissions RoomExportPermissions `json:"permissions"`
+}
+
+// RoomExportMetadata holds the descriptive and sizing settings of a room
+type RoomExportMetadata struct {
+	Name          string `json:"name"`
+	Capacity      int    `json:"capacity"`
+	ReservedSlots int    `json:"reserved_slots"`
+}
+
+// RoomExportPermissions holds the room's moderator and ban lists
+type RoomExportPermissions struct {
+	Moderators []string `json:"moderators"`
+	Banned     []string `json:"banned"`
+}
+
+// Validate checks an imported document against the schema and server limits
+func (e *RoomExport) Validate() error {
+	if e.Version != RoomExportVersion {
+		return fmt.Errorf("unsupported export version %d (expected %d)", e.Version, RoomExportVersion)
+	}
+	if len(e.Metadata.Name) > MaxRoomNameLength {
+		return fmt.Errorf("metadata.name too long (max %d characters)", MaxRoomNameLength)
+	}
+	if !roomThemePattern.MatchString(e.Theme) {
+		return fmt.Errorf("theme must be lowercase letters, digits, or dashes (max 32)")
+	}
+
+	opts := RoomOptions{Capacity: e.Metadata.Capacity, ReservedSlots: e.Metadata.ReservedSlots, LayoutID: e.Layout}
+	if err := opts.validate(); err != nil {
+		return fmt.Errorf("metadata: %w", err)
+	}
+
+	for name, ids := range map[string][]string{"moderators": e.Permissions.Moderators, "banned": e.Permissions.Banned} {
+		if len(ids) > MaxRoomExportIDs {
+			return fmt.Errorf("permissions.%s has too many entries (max %d)", name, MaxRoomExportIDs)
+		}
+		for _, id := range ids {
+			if id == "" || len(id) > MaxExportedPlayerID {
+				return fmt.Errorf("permissions.%s contains an invalid player ID", name)
+			}
+		}
+	}
+	return nil
+}
+
+// ExportRoom builds the export document for a room; only its moderators may export it
+func (rm *RoomManager) ExportRoom(roomID, actorID string) (*RoomExport, error) {
+	room := rm.getRoomByID(roomID)
+	if room == nil {
+		return nil, fmt.Errorf("%w: %s", ErrRoomNotFound, roomID)
+	}
+	if !rm.canModerate(room, actorID) {
+		return nil, fmt.Errorf("only the room host or a moderator can export the room")
+	}
+
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+
+	export := &RoomExport{
+		Version:    RoomExportVersion,
+		ExportedAt: time.Now(),
+		Metadata: RoomExportMetadata{
+			Name:          room.Name,
+			Capacity:      room.Capacity,
+			ReservedSlots: room.ReservedSlots,
+		},
+		Theme:  room.Theme,
+		Layout: room.layoutLocked().ID,
+		Permissions: RoomExportPermissions{
+			Moderators: make([]string, 0, len(room.Moderators)),
+			Banned:     make([]string, 0, len(room.Banned)),
+		},
+	}
+	for id := range room.Moderators {
+		export.Permissions.Moderators = append(export.Permissions.Moderators, id)
+	}
+	for id := range room.Banned {
+		export.Permissions.Banned = append(export.Permissions.Banned, id)
+	}
+	return export, nil
+}
+
+// ImportRoom creates a new room from an export document with the importer as host.
+// An empty roomID generates a fresh room code.
+func (rm *RoomManager) ImportRoom(hostID, roomID string, export *RoomExport) (*Room, error) {
+	if err := export.Validate(); err != nil {
+		return nil, err
+	}
+
+	layout, err := GetLayout(context.Background(), export.Layout)
+	if err != nil {
+		return nil, err
+	}
+
+	name := export.Metadata.Name
+	if name == "" {
+		name = DefaultRoomExportName
+	}
+
+	room := &Room{
+		Players:       make(map[string]*Player),
+		CreatedAt:     time.Now(),
+		LastActivity:  time.Now(),
+		Capacity:      export.Metadata.Capacity,
+		ReservedSlots: export.Metadata.ReservedSlots,
+		Name:          name,
+		Theme:         export.Theme,
+		HostID:        hostID,
+		Moderators:    make(map[string]bool, len(export.Permissions.Moderators)),
+		Banned:        make(map[string]bool, len(export.Permissions.Banned)),
+	}
+	if room.Capacity == 0 {
+		room.Capacity = settings.Rooms.MaxPlayers
+	}
+	for _, id := range export.Permissions.Moderators {
+		room.Moderators[id] = true
+	}
+	for _, id := range export.Permissions.Banned {
+		if id != hostID {
+			room.Banned[id] = true
+		}
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if roomID == "" {
+		roomID = generateRoomCode()
+		for rm.rooms[roomID] != nil {
+			roomID = generateRoomCode()
+		}
+	} else if rm.rooms[roomID] != nil {
+		return nil, ErrRoomExists
+	}
+	room.ID = roomID
+	room.applyLayout(layout)
+	rm.rooms[roomID] = room
+
+	rm.stats.mu.Lock()
+	rm.stats.totalRoomsCreated++
+	rm.stats.currentActiveRooms = int32(len(rm.rooms))
+	rm.stats.mu.Unlock()
+
+	slog.Info("Room imported", "room_id", roomID, "player_id", hostID)
+	emitRoomCreated(room, hostID)
+	return room, nil
+}
@@ -0,0 +1,237 @@
+package Player_Logic
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Every room has a hub goroutine that owns the room's connections on this instance and
+// does all of its fan-out. Broadcasting never takes room.mu or looks players up in the
+// connection pool, so there's no lock ordering between rooms and connections to get
+// wrong, and a room's broadcasts reach each client in the order they were sent.
+//
+// Connections join the hub of the room they're bound to (see Connection.bindRoom) and
+// leave it when they move or close. Registration is synchronous, so a broadcast sent
+// after join returns reaches the new member. The hub also numbers the room's state changes
+// (see state_version.go) and records broadcasts for replay.
+const hubQueueSize = 256
+
+// roomHub delivers one room's broadcasts to its local connections
+type roomHub struct {
+	roomID     string
+	register   chan *Connection
+	unregister chan *Connection
+	broadcast  chan hubBroadcast
+	done       chan struct{}
+	stopOnce   sync.Once
+	replay     *eventRing
+	version    atomic.Uint64 // Written by run only
+
+	// Owned by run
+	members map[string]*Connection
+	targets []*Connection // Scratch list reused across broadcasts
+	passed  []*Connection // Members a versioned change skipped
+}
+
+// hubBroadcast is one delivery to a room's members, skipping exclude and anyone skip
+// rejects. skip runs on the hub goroutine, so it must be quick.
+type hubBroadcast struct {
+	message WebSocketMessage
+	exclude string
+	skip    func(playerID string) bool
+	// deliver, if set, replaces sending message with a per-connection send (e.g. tick
+	// snapshots), passed the room's state version. A message set alongside it is still
+	// stamped and recorded for replay.
+	deliver func(conn *Connection, version uint64)
+}
+
+func newRoomHub(roomID string, replay *eventRing) *roomHub {
+	h := &roomHub{
+		roomID:     roomID,
+		replay:     replay,
+		register:   make(chan *Connection),
+		unregister: make(chan *Connection),
+		broadcast:  make(chan hubBroadcast, hubQueueSize),
+		done:       make(chan struct{}),
+		members:    make(map[string]*Connection),
+	}
+	go h.run()
+	return h
+}
+
+// hub returns the room's hub, starting it on first use
+func (r *Room) hub() *roomHub {
+	r.hubOnce.Do(func() { r.localHub = newRoomHub(r.ID, &r.replay) })
+	return r.localHub
+}
+
+// stopHub shuts the room's hub down once the room is gone; later sends to it are no-ops
+func (r *Room) stopHub() {
+	r.hub().stop()
+}
+
+func (h *roomHub) run() {
+	for {
+		select {
+		case conn := <-h.register:
+			h.members[conn.playerID] = conn // Replaces the player's previous connection
+		case conn := <-h.unregister:
+			if h.members[conn.playerID] == conn {
+				delete(h.members, conn.playerID)
+			}
+		case b := <-h.broadcast:
+			h.fanOut(b)
+		case <-h.done:
+			return
+		}
+	}
+}
+
+func (h *roomHub) stop() {
+	h.stopOnce.Do(func() { close(h.done) })
+}
+
+// join adds a connection to the hub
+func (h *roomHub) join(conn *Connection) {
+	select {
+	case h.register <- conn:
+	case <-h.done:
+	}
+}
+
+// leave removes a connection from the hub unless a newer one for the player replaced it
+func (h *roomHub) leave(conn *Connection) {
+	select {
+	case h.unregister <- conn:
+	case <-h.done:
+	}
+}
+
+// send queues a broadcast, waiting if the hub is backed up
+func (h *roomHub) send(b hubBroadcast) {
+	select {
+	case h.broadcast <- b:
+	case <-h.done:
+	}
+}
+
+// fanOut delivers a broadcast to the hub's members. A panic is logged and costs only
+// this broadcast, so the room keeps its hub.
+func (h *roomHub) fanOut(b hubBroadcast) {
+	messageType := b.message.Type
+	if messageType == "" {
+		messageType = "snapshot"
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			slog.Error("Panic in room hub",
+				"room_id", h.roomID,
+				"type", messageType,
+				"panic", fmt.Sprint(recovered),
+				"stack", string(debug.Stack()))
+		}
+	}()
+	defer observeBroadcast(messageType, time.Now())
+
+	version := h.version.Load()
+	changed := versionedTypes[b.message.Type]
+	if changed {
+		version = h.version.Add(1)
+	}
+	if b.message.Type != "" {
+		b.message.StateVersion = version
+		h.replay.record(b.message)
+	}
+
+	targets, passed := h.targets[:0], h.passed[:0]
+	for playerID, conn := range h.members {
+		if playerID != b.exclude && (b.skip == nil || !b.skip(playerID)) {
+			targets = append(targets, conn)
+		} else if changed {
+			passed = append(passed, conn)
+		}
+	}
+	defer func() {
+		clear(targets) // Don't keep closed connections reachable between broadcasts
+		clear(passed)
+		h.targets, h.passed = targets[:0], passed[:0]
+	}()
+
+	switch {
+	case b.deliver != nil:
+		for _, conn := range targets {
+			b.deliver(conn, version)
+		}
+		queueToTargets(versionNotice(version), passed)
+	default:
+		queueToTargets(b.message, targets)
+		queueToTargets(versionNotice(version), passed)
+	}
+}
+
+// queueToTargets encodes a message once per wire format and queues it for each target.
+// Queueing never blocks (a full send buffer spills into the connection's backlog), so one
+// pass over the targets is cheaper than fanning out to goroutines.
+func queueToTargets(message WebSocketMessage, targets []*Connection) {
+	if len(targets) == 0 {
+		return
+	}
+
+	// Reliable messages get a per-connection sequence number, so they can't share one payload
+	if reliableTypes[message.Type] {
+		for _, conn := range targets {
+			conn.sendMessage(message)
+		}
+		return
+	}
+
+	payloads, err := encodeForTargets(message, targets)
+	if err != nil {
+		slog.Error("Error marshaling message", "type", message.Type, "error", err)
+		return
+	}
+
+	sent := wsMessagesSent.WithLabelValues(message.Type)
+	dropped := wsMessagesDropped.WithLabelValues(message.Type)
+	for _, c := range targets {
+		data := payloads[c.wireFormat()]
+		if data == nil {
+			continue // Dropped for this client's protocol version
+		}
+		if c.enqueue(data) {
+			sent.Inc()
+		} else {
+			dropped.Inc()
+		}
+	}
+}
+
+// bindRoom moves the connection to a room's hub, leaving the hub of the room it was in
+func (c *Connection) bindRoom(room *Room) {
+	c.mu.Lock()
+	previous := c.roomID
+	c.roomID = room.ID
+	c.mu.Unlock()
+
+	if previous != room.ID {
+		if old := GetRoomManager().getRoomByID(previous); old != nil {
+			old.hub().leave(c)
+		}
+	}
+	room.hub().join(c)
+}
+
+// unbindRoom takes a closing connection out of its room's hub
+func (c *Connection) unbindRoom() {
+	c.mu.RLock()
+	roomID := c.roomID
+	c.mu.RUnlock()
+
+	if room := GetRoomManager().getRoomByID(roomID); room != nil {
+		room.hub().leave(c)
+	}
+}
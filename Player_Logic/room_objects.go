@@ -0,0 +1,309 @@
+package Player_Logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Room objects are furniture and props the server tracks per room. Players change them
+// with "interact" (doors open/close, lamps switch, chairs seat one player at a time) and
+// moderators add or remove them with "place_object"/"remove_object". Portals move whoever
+// walks into them to their target room (see portals.go). Every change is broadcast to the
+// room as "object_updated"/"object_removed"; joining clients get the full list as
+// "room_objects".
+const (
+	MaxObjectsPerRoom = 100
+	InteractRange     = 120.0 // World units between a player and an object they can use
+)
+
+// Object kinds
+const (
+	ObjectDoor   = "door"
+	ObjectChair  = "chair"
+	ObjectLamp   = "lamp"
+	ObjectSign   = "sign"   // Decorative; can't be interacted with
+	ObjectPortal = "portal" // Walking into it moves the player to Target
+)
+
+var (
+	ErrUnknownObject     = errors.New("object does not exist")
+	ErrUnknownObjectKind = errors.New("unknown object kind")
+	ErrObjectOutOfRange  = errors.New("too far away to interact")
+	ErrObjectOccupied    = errors.New("someone is already using this")
+	ErrNotInteractable   = errors.New("this object can't be used")
+	ErrTooManyObjects    = fmt.Errorf("rooms can have at most %d objects", MaxObjectsPerRoom)
+	ErrCannotEditObjects = errors.New("only the room host or a moderator can place or remove objects")
+)
+
+// objectKinds maps each kind to the state new objects start in
+var objectKinds = map[string]string{
+	ObjectDoor:   "closed",
+	ObjectChair:  "free",
+	ObjectLamp:   "off",
+	ObjectSign:   "",
+	ObjectPortal: "",
+}
+
+// RoomObject is an entity placed in a room
+type RoomObject struct {
+	ID         string   `json:"id"`
+	Kind       string   `json:"kind"`
+	Position   Position `json:"position"`
+	State      string   `json:"state,omitempty"`       // "open"/"closed", "on"/"off", "free"/"occupied"
+	OccupiedBy string   `json:"occupied_by,omitempty"` // Seated player (chairs)
+	Target     string   `json:"target,omitempty"`      // Destination room ID or "main" (portals)
+	UpdatedAt  int64    `json:"updated_at"`            // Unix ms of the last change
+}
+
+// ObjectPlacement is the data payload of place_object, and a layout's starting object
+type ObjectPlacement struct {
+	Kind     string   `json:"kind"`
+	Position Position `json:"position"`
+	Target   string   `json:"target,omitempty"` // Portals only
+}
+
+// validate checks the kind and, for portals, the target
+func (p ObjectPlacement) validate() error {
+	if _, known := objectKinds[p.Kind]; !known {
+		return ErrUnknownObjectKind
+	}
+	if p.Kind == ObjectPortal && !validPortalTarget(p.Target) {
+		return ErrInvalidPortalTarget
+	}
+	if p.Kind != ObjectPortal && p.Target != "" {
+		return fmt.Errorf("only portals have a target")
+	}
+	return nil
+}
+
+// ensureObjectsLocked creates the object map on first use (caller holds r.mu)
+func (r *Room) ensureObjectsLocked() {
+	if r.Objects == nil {
+		r.Objects = make(map[string]*RoomObject)
+	}
+}
+
+// furnishDefaults places the default objects in a room that has none
+func (r *Room) furnishDefaults(defaults []ObjectPlacement) {
+	r.mu.RLock()
+	empty := len(r.Objects) == 0
+	r.mu.RUnlock()
+	if !empty {
+		return
+	}
+	for _, object := range defaults {
+		r.PlaceObject(object)
+	}
+}
+
+// ListObjects returns the room's objects sorted by ID
+func (r *Room) ListObjects() []RoomObject {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	objects := make([]RoomObject, 0, len(r.Objects))
+	for _, object := range r.Objects {
+		objects = append(objects, *object)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].ID < objects[j].ID })
+	return objects
+}
+
+// PlaceObject adds an object and returns it
+func (r *Room) PlaceObject(placement ObjectPlacement) (RoomObject, error) {
+	if err := placement.validate(); err != nil {
+		return RoomObject{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ensureObjectsLocked()
+	if len(r.Objects) >= MaxObjectsPerRoom {
+		return RoomObject{}, ErrTooManyObjects
+	}
+
+	r.objectSeq++
+	object := &RoomObject{
+		ID:        fmt.Sprintf("%s-%d", placement.Kind, r.objectSeq),
+		Kind:      placement.Kind,
+		Position:  placement.Position,
+		State:     objectKinds[placement.Kind],
+		Target:    placement.Target,
+		UpdatedAt: time.Now().UnixMilli(),
+	}
+	r.Objects[object.ID] = object
+	return *object, nil
+}
+
+// RemoveObject deletes an object
+func (r *Room) RemoveObject(objectID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.Objects[objectID]; !exists {
+		return ErrUnknownObject
+	}
+	delete(r.Objects, objectID)
+	return nil
+}
+
+// Interact applies a player's use of an object and returns its new state. A seated player
+// interacting with another chair moves to it; the returned slice holds every changed object.
+func (r *Room) Interact(playerID, objectID string) ([]RoomObject, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	object, exists := r.Objects[objectID]
+	if !exists {
+		return nil, ErrUnknownObject
+	}
+	player, inRoom := r.Players[playerID]
+	if !inRoom {
+		return nil, ErrUnknownObject
+	}
+	position := player.GetPosition()
+	if math.Hypot(position.X-object.Position.X, position.Y-object.Position.Y) > InteractRange {
+		return nil, ErrObjectOutOfRange
+	}
+
+	now := time.Now().UnixMilli()
+	var changed []RoomObject
+	switch object.Kind {
+	case ObjectDoor:
+		object.State = toggleState(object.State, "open", "closed")
+	case ObjectLamp:
+		object.State = toggleState(object.State, "on", "off")
+	case ObjectChair:
+		switch {
+		case object.OccupiedBy == playerID:
+			object.OccupiedBy, object.State = "", "free" // Stand up
+		case object.OccupiedBy != "" && r.Players[object.OccupiedBy] != nil:
+			return nil, ErrObjectOccupied
+		default:
+			if previous := r.vacateSeatLocked(playerID); previous != nil {
+				changed = append(changed, *previous)
+			}
+			object.OccupiedBy, object.State = playerID, "occupied"
+		}
+	default:
+		return nil, ErrNotInteractable
+	}
+	object.UpdatedAt = now
+	return append(changed, *object), nil
+}
+
+// vacateSeatLocked frees any chair the player is sitting on and returns it (caller holds r.mu)
+func (r *Room) vacateSeatLocked(playerID string) *RoomObject {
+	for _, object := range r.Objects {
+		if object.Kind == ObjectChair && object.OccupiedBy == playerID {
+			object.OccupiedBy, object.State = "", "free"
+			object.UpdatedAt = time.Now().UnixMilli()
+			freed := *object
+			return &freed
+		}
+	}
+	return nil
+}
+
+// toggleState flips between two states
+func toggleState(current, on, off string) string {
+	if current == on {
+		return off
+	}
+	return on
+}
+
+// handleObjectMessage handles interact, place_object, and remove_object
+func (c *Connection) handleObjectMessage(rm *RoomManager, message WebSocketMessage) {
+	room := rm.GetPlayerRoom(c.playerID)
+	if room == nil {
+		return
+	}
+
+	var err error
+	switch message.Type {
+	case "interact":
+		var changed []RoomObject
+		if changed, err = room.Interact(c.playerID, message.ObjectID); err == nil {
+			for _, object := range changed {
+				broadcastObjectUpdate(room, object)
+			}
+		}
+
+	case "place_object":
+		if !rm.canModerate(room, c.playerID) {
+			err = ErrCannotEditObjects
+			break
+		}
+		var placement ObjectPlacement
+		if len(message.Data) == 0 || json.Unmarshal(message.Data, &placement) != nil {
+			err = ErrUnknownObjectKind
+			break
+		}
+		var object RoomObject
+		if object, err = room.PlaceObject(placement); err == nil {
+			broadcastObjectUpdate(room, object)
+		}
+
+	case "remove_object":
+		if !rm.canModerate(room, c.playerID) {
+			err = ErrCannotEditObjects
+			break
+		}
+		if err = room.RemoveObject(message.ObjectID); err == nil {
+			go broadcastToRoomAsync(room, "", WebSocketMessage{
+				Type:      "object_removed",
+				PlayerID:  c.playerID,
+				ObjectID:  message.ObjectID,
+				Timestamp: time.Now().UnixMilli(),
+			})
+		}
+	}
+
+	if err != nil {
+		c.sendMessage(WebSocketMessage{
+			Type:      "object_error",
+			PlayerID:  "system",
+			ObjectID:  message.ObjectID,
+			Text:      err.Error(),
+			Timestamp: time.Now().UnixMilli(),
+		})
+	}
+}
+
+// sendRoomObjects sends the room's objects to a newly connected client
+func (c *Connection) sendRoomObjects(room *Room) {
+	objects := room.ListObjects()
+	if len(objects) == 0 {
+		return
+	}
+	data, err := json.Marshal(objects)
+	if err != nil {
+		return
+	}
+	c.sendMessage(WebSocketMessage{
+		Type:      "room_objects",
+		PlayerID:  "system",
+		RoomID:    room.ID,
+		Data:      data,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
+
+// broadcastObjectUpdate tells the room an object changed
+func broadcastObjectUpdate(room *Room, object RoomObject) {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return
+	}
+	go broadcastToRoomAsync(room, "", WebSocketMessage{
+		Type:      "object_updated",
+		PlayerID:  "system",
+		ObjectID:  object.ID,
+		Data:      data,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
@@ -0,0 +1,229 @@
+package Player_Logic
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+	"velvet/config"
+)
+
+// RoomSnapshotTTL limits restores to rooms that were active recently before shutdown
+const RoomSnapshotTTL = time.Hour
+
+// RoomSnapshot is the serialized state of a room kept across restarts
+type RoomSnapshot struct {
+	ID            string               `json:"id"`
+	Name          string               `json:"name,omitempty"`
+	Theme         string               `json:"theme,omitempty"`
+	LayoutID      string               `json:"layout_id,omitempty"`
+	Capacity      int                  `json:"capacity"`
+	ReservedSlots int                  `json:"reserved_slots"`
+	HostID        string               `json:"host_id,omitempty"`
+	Moderators    []string             `json:"moderators,omitempty"`
+	Banned        []string             `json:"banned,omitempty"`
+	FilterOff     bool                 `json:"filter_off,omitempty"`
+	Muted         map[string]time.Time `json:"muted,omitempty"`
+	Objects       []RoomObject         `json:"objects,omitempty"`
+	ObjectSeq     int                  `json:"object_seq,omitempty"`
+	CreatedAt     time.Time            `json:"created_at"`
+	LastActivity  time.Time            `json:"last_activity"`
+	Players       []PlayerSnapshot     `json:"players"`
+}
+
+// PlayerSnapshot is the persisted part of a player
+type PlayerSnapshot struct {
+	ID       string   `json:"id"`
+	Username string   `json:"username,omitempty"`
+	Position Position `json:"position"`
+	Language string   `json:"language,omitempty"`
+}
+
+// snapshot captures the room's persistent state
+func (r *Room) snapshot() RoomSnapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snap := RoomSnapshot{
+		ID:            r.ID,
+		Name:          r.Name,
+		Theme:         r.Theme,
+		LayoutID:      r.layoutLocked().ID,
+		Capacity:      r.Capacity,
+		ReservedSlots: r.ReservedSlots,
+		HostID:        r.HostID,
+		FilterOff:     r.FilterDisabled,
+		CreatedAt:     r.CreatedAt,
+		LastActivity:  r.LastActivity,
+		Players:       make([]PlayerSnapshot, 0, len(r.Players)),
+	}
+	for id := range r.Moderators {
+		snap.Moderators = append(snap.Moderators, id)
+	}
+	for id := range r.Banned {
+		snap.Banned = append(snap.Banned, id)
+	}
+	for id, expiresAt := range r.Muted {
+		if time.Now().Before(expiresAt) {
+			if snap.Muted == nil {
+				snap.Muted = make(map[string]time.Time)
+			}
+			snap.Muted[id] = expiresAt
+		}
+	}
+	for _, object := range r.Objects {
+		snap.Objects = append(snap.Objects, *object)
+	}
+	snap.ObjectSeq = r.objectSeq
+	for _, player := range r.Players {
+		if player.IsService {
+			continue // Bots reconnect on their own
+		}
+		snap.Players = append(snap.Players, PlayerSnapshot{
+			ID:       player.ID,
+			Username: player.Username,
+			Position: player.Position,
+			Language: player.GetLanguage(),
+		})
+	}
+	return snap
+}
+
+// restore builds a room from a snapshot. Players come back disconnected so they get the
+// usual reconnection grace period.
+func (snap RoomSnapshot) restore() *Room {
+	room := &Room{
+		ID:             snap.ID,
+		Players:        make(map[string]*Player, len(snap.Players)),
+		CreatedAt:      snap.CreatedAt,
+		LastActivity:   snap.LastActivity,
+		Capacity:       snap.Capacity,
+		ReservedSlots:  snap.ReservedSlots,
+		Name:           snap.Name,
+		Theme:          snap.Theme,
+		HostID:         snap.HostID,
+		FilterDisabled: snap.FilterOff,
+		Moderators:     make(map[string]bool, len(snap.Moderators)),
+		Banned:         make(map[string]bool, len(snap.Banned)),
+		Muted:          snap.Muted,
+	}
+	if room.Capacity == 0 {
+		room.Capacity = settings.Rooms.MaxPlayers
+	}
+	layout, err := GetLayout(context.Background(), snap.LayoutID)
+	if err != nil {
+		slog.Warn("Restored room's layout is gone, using the default", "room_id", snap.ID, "layout_id", snap.LayoutID, "error", err)
+		layout = builtinLayouts[DefaultLayoutID]
+	}
+	room.layout = layout
+	for _, id := range snap.Moderators {
+		room.Moderators[id] = true
+	}
+	for _, id := range snap.Banned {
+		room.Banned[id] = true
+	}
+	if len(snap.Objects) > 0 {
+		room.Objects = make(map[string]*RoomObject, len(snap.Objects))
+		for _, object := range snap.Objects {
+			if object.Kind == ObjectChair {
+				object.OccupiedBy, object.State = "", "free" // Nobody is seated after a restart
+			}
+			object := object
+			room.Objects[object.ID] = &object
+		}
+	}
+	room.objectSeq = snap.ObjectSeq
+	for _, p := range snap.Players {
+		room.Players[p.ID] = &Player{
+			ID:       p.ID,
+			Username: p.Username,
+			RoomID:   snap.ID,
+			Position: p.Position,
+			Language: p.Language,
+			IsActive: false,
+			LastSeen: time.Now(),
+		}
+	}
+	room.playerCount = int32(len(room.Players))
+	room.spawnLayoutNPCs() // NPCs aren't persisted; they start over from the layout
+	return room
+}
+
+// saveSnapshots persists every room so shared room codes survive a deploy
+func (rm *RoomManager) saveSnapshots() {
+	rm.mu.RLock()
+	rooms := make([]*Room, 0, len(rm.rooms))
+	for _, room := range rm.rooms {
+		rooms = append(rooms, room)
+	}
+	mainRoomID := rm.mainRoom.ID
+	rm.mu.RUnlock()
+
+	rows := make([]config.RoomSnapshotRow, 0, len(rooms))
+	for _, room := range rooms {
+		snap := room.snapshot()
+		data, err := json.Marshal(snap)
+		if err != nil {
+			slog.Error("Error marshaling room snapshot", "room_id", snap.ID, "error", err)
+			continue
+		}
+		rows = append(rows, config.RoomSnapshotRow{
+			RoomID:       snap.ID,
+			IsMain:       snap.ID == mainRoomID,
+			Snapshot:     data,
+			LastActivity: snap.LastActivity,
+		})
+	}
+
+	if err := config.SaveRoomSnapshots(rows); err != nil {
+		slog.Warn("Failed to persist rooms on shutdown", "error", err)
+	}
+}
+
+// restoreSnapshots reloads rooms saved by the previous process (if the database is up)
+func (rm *RoomManager) restoreSnapshots() {
+	if config.DB == nil {
+		slog.Info("Database not initialized, skipping room restore")
+		return
+	}
+
+	rows, err := config.LoadRoomSnapshots(RoomSnapshotTTL)
+	if err != nil {
+		slog.Warn("Failed to restore rooms", "error", err)
+		return
+	}
+
+	restoredPlayers := 0
+	rm.mu.Lock()
+	rm.playerMu.Lock()
+	for _, row := range rows {
+		var snap RoomSnapshot
+		if err := json.Unmarshal(row.Snapshot, &snap); err != nil {
+			slog.Error("Error decoding room snapshot", "room_id", row.RoomID, "error", err)
+			continue
+		}
+
+		room := snap.restore()
+		if row.IsMain {
+			// Keep the main room's code stable across restarts
+			delete(rm.rooms, rm.mainRoom.ID)
+			rm.mainRoom.stopHub()
+			rm.mainRoom = room
+		}
+		rm.rooms[room.ID] = room
+		for playerID := range room.Players {
+			rm.playerToRoom[playerID] = room.ID
+			restoredPlayers++
+		}
+	}
+	rm.adoptInstancesLocked()
+	rm.playerMu.Unlock()
+	rm.stats.mu.Lock()
+	rm.stats.currentActiveRooms = int32(len(rm.rooms))
+	rm.stats.mu.Unlock()
+	rm.mu.Unlock()
+
+	if len(rows) > 0 {
+		slog.Info("Restored rooms from snapshots", "rooms", len(rows), "players", restoredPlayers)
+	}
+}
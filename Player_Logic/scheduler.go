@@ -0,0 +1,107 @@
+package Player_Logic
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ScheduledJob describes a pending job in the scheduler
+type ScheduledJob struct {
+	ID          string      `json:"id"`
+	Kind        string      `json:"kind"`
+	RunAt       time.Time   `json:"run_at"`
+	Description interface{} `json:"description,omitempty"` // Job-specific details for listings
+	timer       *time.Timer
+}
+
+// Scheduler runs one-off jobs at a future time with cancellation support
+type Scheduler struct {
+	jobs   map[string]*ScheduledJob
+	nextID int64
+	mu     sync.Mutex
+}
+
+var (
+	scheduler     *Scheduler
+	schedulerOnce sync.Once
+)
+
+// GetScheduler returns the singleton scheduler
+func GetScheduler() *Scheduler {
+	schedulerOnce.Do(func() {
+		scheduler = &Scheduler{jobs: make(map[string]*ScheduledJob)}
+	})
+	return scheduler
+}
+
+// Schedule runs fn at runAt (immediately if runAt is in the past) and returns the job
+func (s *Scheduler) Schedule(kind string, runAt time.Time, description interface{}, fn func()) *ScheduledJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	job := &ScheduledJob{
+		ID:          fmt.Sprintf("%s-%d", kind, s.nextID),
+		Kind:        kind,
+		RunAt:       runAt,
+		Description: description,
+	}
+	job.timer = time.AfterFunc(time.Until(runAt), func() {
+		s.mu.Lock()
+		_, pending := s.jobs[job.ID]
+		delete(s.jobs, job.ID)
+		s.mu.Unlock()
+
+		if pending {
+			slog.Info("Running scheduled job", "job_id", job.ID)
+			fn()
+		}
+	})
+	s.jobs[job.ID] = job
+
+	slog.Info("Scheduled job", "job_id", job.ID, "run_at", runAt.Format(time.RFC3339))
+	return job
+}
+
+// Cancel stops a pending job; returns false if it already ran or doesn't exist
+func (s *Scheduler) Cancel(jobID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, exists := s.jobs[jobID]
+	if !exists {
+		return false
+	}
+	job.timer.Stop()
+	delete(s.jobs, jobID)
+
+	slog.Info("Cancelled scheduled job", "job_id", jobID)
+	return true
+}
+
+// List returns pending jobs of the given kind ("" for all)
+func (s *Scheduler) List(kind string) []*ScheduledJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]*ScheduledJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		if kind == "" || job.Kind == kind {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+// Shutdown cancels every pending job
+func (s *Scheduler) Shutdown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, job := range s.jobs {
+		job.timer.Stop()
+		delete(s.jobs, id)
+	}
+}
@@ -0,0 +1,105 @@
+package Player_Logic
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Session resume. Every connection gets a resume token; when the socket drops, the player
+// stays in the room (inactive) for the resume window (SESSION_RESUME_SECONDS, 0 removes
+// them immediately). Reconnecting with ?resume=<token> inside the window re-binds the
+// socket to the same Player with its position intact and no player_left/player_joined churn.
+var (
+	resumeWindow     time.Duration
+	resumeWindowOnce sync.Once
+
+	// resumeTokens maps player ID -> the token issued to their latest connection
+	resumeTokens = struct {
+		tokens map[string]string
+		mu     sync.Mutex
+	}{tokens: make(map[string]string)}
+)
+
+// getResumeWindow returns how long a dropped player is held for resume (capped at DisconnectedPlayerTTL)
+func getResumeWindow() time.Duration {
+	resumeWindowOnce.Do(func() {
+		resumeWindow = settings.WebSocket.ResumeWindow
+		if resumeWindow > DisconnectedPlayerTTL {
+			resumeWindow = DisconnectedPlayerTTL
+		}
+	})
+	return resumeWindow
+}
+
+// issueResumeToken creates a new token for the player, replacing any earlier one
+func issueResumeToken(playerID string) (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(raw)
+
+	resumeTokens.mu.Lock()
+	resumeTokens.tokens[playerID] = token
+	resumeTokens.mu.Unlock()
+	return token, nil
+}
+
+// checkResumeToken reports whether token is the player's current resume token
+func checkResumeToken(playerID, token string) bool {
+	if token == "" {
+		return false
+	}
+	resumeTokens.mu.Lock()
+	defer resumeTokens.mu.Unlock()
+	current, exists := resumeTokens.tokens[playerID]
+	return exists && subtle.ConstantTimeCompare([]byte(current), []byte(token)) == 1
+}
+
+// revokeResumeToken forgets the player's token once they've left for good
+func revokeResumeToken(playerID string) {
+	resumeTokens.mu.Lock()
+	delete(resumeTokens.tokens, playerID)
+	resumeTokens.mu.Unlock()
+}
+
+// sendResumeToken hands the client the token to use if this connection drops
+func (c *Connection) sendResumeToken() {
+	token, err := issueResumeToken(c.playerID)
+	if err != nil {
+		c.logger.Warn("Could not issue resume token", "error", err)
+		return
+	}
+	c.sendMessage(WebSocketMessage{
+		Type:        "session",
+		PlayerID:    c.playerID,
+		ResumeToken: token,
+		Timestamp:   time.Now().UnixMilli(),
+	})
+}
+
+// holdForResume keeps a dropped player in the room for the resume window, then removes
+// them (with the usual player_left) unless they reconnected in the meantime
+func (rm *RoomManager) holdForResume(room *Room, playerID string) {
+	room.mu.Lock()
+	if player, exists := room.Players[playerID]; exists {
+		player.IsActive = false
+		player.LastSeen = time.Now()
+	}
+	room.mu.Unlock()
+
+	time.AfterFunc(getResumeWindow(), func() {
+		if _, reconnected := connectionPool.getConnection(playerID); reconnected {
+			return
+		}
+		if rm.GetPlayerRoom(playerID) != room {
+			return // Moved or removed through another path
+		}
+		slog.Info("Resume window expired", "player_id", playerID, "room_id", room.ID)
+		rm.removeDisconnectedPlayer(room, playerID)
+	})
+}
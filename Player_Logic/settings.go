@@ -0,0 +1,15 @@
+package Player_Logic
+
+import "velvet/config"
+
+// settings is the configuration injected by Configure; the defaults apply until then
+var settings = config.DefaultAppConfig()
+
+// Configure injects the validated application config. Call it once at startup, before
+// GetRoomManager or serving connections.
+func Configure(cfg *config.AppConfig) {
+	settings = cfg
+	upgrader.ReadBufferSize = cfg.WebSocket.ReadBufferSize
+	upgrader.WriteBufferSize = cfg.WebSocket.WriteBufferSize
+	upgrader.EnableCompression = cfg.WebSocket.Compression
+}
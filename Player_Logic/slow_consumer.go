@@ -0,0 +1,95 @@
+package Player_Logic
+
+import (
+	"sync"
+	"time"
+)
+
+// Frames that don't fit in a connection's send buffer wait in a backlog instead of being
+// dropped, so a client that falls briefly behind still gets every chat message and
+// join/leave. The backlog is capped at WS_MAX_SEND_BACKLOG_BYTES: a client that outgrows
+// it is disconnected with close code 4008 (slow_consumer), and can resume and resync
+// rather than carry on with a silently desynced view of the room.
+
+// CloseSlowConsumer is the close code for clients cut off for not reading fast enough
+const CloseSlowConsumer = 4008
+
+// sendBacklog holds frames waiting for room in a connection's send buffer
+type sendBacklog struct {
+	mu     sync.Mutex
+	frames [][]byte
+	bytes  int
+	cutOff bool // Disconnected for falling behind; later frames are dropped
+}
+
+// enqueue queues a frame for writePump without blocking. It reports false if the frame
+// was dropped because the connection was cut off for falling too far behind.
+func (c *Connection) enqueue(data []byte) bool {
+	b := &c.backlog
+	b.mu.Lock()
+	if b.cutOff {
+		b.mu.Unlock()
+		c.recordDrop()
+		return false
+	}
+	// Frames only skip the backlog when it's empty, so they stay in order
+	if len(b.frames) == 0 {
+		select {
+		case c.send <- data:
+			b.mu.Unlock()
+			return true
+		default:
+		}
+	}
+
+	if b.bytes+len(data) > settings.WebSocket.MaxSendBacklog {
+		backlogged := b.bytes
+		b.cutOff = true
+		b.frames, b.bytes = nil, 0
+		b.mu.Unlock()
+
+		wsSlowConsumers.Inc()
+		c.recordDrop()
+		c.logger.Warn("Disconnecting slow consumer", "backlog_bytes", backlogged, "queued", len(c.send))
+		go c.closeWithCode(CloseSlowConsumer, "slow_consumer")
+		return false
+	}
+
+	started := len(b.frames) == 0
+	b.frames = append(b.frames, data)
+	b.bytes += len(data)
+	b.mu.Unlock()
+
+	wsSendBacklogged.Inc()
+	if started {
+		c.flagDegraded(DegradedReasonBacklog, time.Now())
+	}
+	return true
+}
+
+// drainBacklog moves backlogged frames into the send buffer as it frees up. Called by
+// writePump after each write.
+func (c *Connection) drainBacklog() {
+	b := &c.backlog
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for len(b.frames) > 0 {
+		select {
+		case c.send <- b.frames[0]:
+			b.bytes -= len(b.frames[0])
+			b.frames[0] = nil
+			b.frames = b.frames[1:]
+		default:
+			return
+		}
+	}
+	b.frames = nil // Let the grown array go once the client has caught up
+}
+
+// backlogBytes returns how much is waiting behind the full send buffer
+func (c *Connection) backlogBytes() int {
+	c.backlog.mu.Lock()
+	defer c.backlog.mu.Unlock()
+	return c.backlog.bytes
+}
@@ -0,0 +1,45 @@
+package Player_Logic
+
+import "time"
+
+// Every room counts its discrete state changes (joins, leaves, avatars, objects, NPCs,
+// zones, games) in a state version. The room's hub stamps it on each broadcast as it fans
+// out, so versions reach every client in order: a change carries the version it produced,
+// and anything else (chat, snapshot batches) the version it was sent at. Clients take the
+// first version they see in a room as their baseline. A change numbered past last+1, or
+// any other message newer than the last change applied, means updates were missed, and
+// the client should send resync_request with the last version it saw.
+//
+// Members a change isn't delivered to (its sender, say) get a "state_version" notice
+// instead, so leaving them out doesn't look like a gap. Versions are per instance: a
+// client that reconnects elsewhere starts over from the new baseline.
+
+// versionedTypes are the broadcasts that change room state
+var versionedTypes = map[string]bool{
+	"player_joined":  true,
+	"player_left":    true,
+	"avatar_updated": true,
+	"object_updated": true,
+	"object_removed": true,
+	"npc_spawned":    true,
+	"npc_removed":    true,
+	"zone_entered":   true,
+	"zone_left":      true,
+	"game_started":   true,
+	"game_ended":     true,
+}
+
+// StateVersion returns the room's current state version on this instance
+func (r *Room) StateVersion() uint64 {
+	return r.hub().version.Load()
+}
+
+// versionNotice tells members left out of a change which version it was
+func versionNotice(version uint64) WebSocketMessage {
+	return WebSocketMessage{
+		Type:         "state_version",
+		PlayerID:     "system",
+		StateVersion: version,
+		Timestamp:    time.Now().UnixMilli(),
+	}
+}
@@ -0,0 +1,197 @@
+package Player_Logic
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Position updates are aggregated per room and sent at a fixed tick rate (ROOM_TICK_RATE
+// ticks per second; at 0, MessageBatcher coalesces them instead). Each tick sends every
+// client one "snapshot" batch with the latest position of each player that moved since the
+// last tick. A room's loop starts on the first movement and stops once the room goes quiet.
+// Rooms with patrolling NPCs (see npcs.go) keep ticking while anyone is in them.
+// Ticks without movement before a room's loop exits
+const TickIdleLimit = 40
+
+var (
+	tickInterval     time.Duration
+	tickIntervalOnce sync.Once
+)
+
+// getTickInterval returns the time between room ticks (0 means tick loops are off)
+func getTickInterval() time.Duration {
+	tickIntervalOnce.Do(func() {
+		rate := settings.Rooms.TickRate
+		if rate > 0 {
+			tickInterval = time.Second / time.Duration(rate)
+		}
+		slog.Info("Room tick rate configured", "hz", rate)
+	})
+	return tickInterval
+}
+
+// roomTickInterval is how often a room's loop runs: the tick rate, or NPCTickInterval when
+// tick loops are off and only NPCs need them
+func roomTickInterval() time.Duration {
+	if interval := getTickInterval(); interval > 0 {
+		return interval
+	}
+	return NPCTickInterval
+}
+
+// positionFrame is one player's movement as each kind of client receives it
+type positionFrame struct {
+	playerID string
+	absolute WebSocketMessage  // Regular clients
+	compact  WebSocketMessage  // Delta-encoding clients (keyframe or delta)
+	ack      *WebSocketMessage // The mover's own authoritative position, when it sent an input_seq
+	view     *interestChange
+}
+
+// newPositionFrame builds the outgoing messages for a player's new position. inputSeq is
+// the newest input applied, echoed so the mover can reconcile its predicted position.
+func newPositionFrame(playerID, username string, position, quantized Position, delta *PositionDelta, view *interestChange, inputSeq uint64) positionFrame {
+	frame := positionFrame{
+		playerID: playerID,
+		absolute: WebSocketMessage{
+			Type:      "position_update",
+			PlayerID:  playerID,
+			Position:  &position,
+			Username:  username,
+			Timestamp: time.Now().UnixMilli(),
+			InputSeq:  inputSeq,
+		},
+		compact: WebSocketMessage{Type: "position_update", PlayerID: playerID, Position: &quantized, Username: username, InputSeq: inputSeq},
+		view:    view,
+	}
+	if delta != nil {
+		frame.compact = WebSocketMessage{Type: "position_delta", PlayerID: playerID, Delta: delta, InputSeq: inputSeq}
+	}
+	if inputSeq > 0 {
+		frame.ack = &WebSocketMessage{
+			Type:      "position_ack",
+			PlayerID:  playerID,
+			Position:  &position,
+			InputSeq:  inputSeq,
+			Timestamp: time.Now().UnixMilli(),
+		}
+	}
+	return frame
+}
+
+// queuePositionLocked marks a player as moved for the next tick and reports whether the
+// caller must start the room's loop (caller holds r.mu)
+func (r *Room) queuePositionLocked(playerID string) bool {
+	if r.pendingMoves == nil {
+		r.pendingMoves = make(map[string]bool)
+	}
+	r.pendingMoves[playerID] = true
+	if r.ticking {
+		return false
+	}
+	r.ticking = true
+	return true
+}
+
+// runRoomTicker flushes a room's movement every tick until it has been idle for
+// TickIdleLimit ticks (caller adds it to cleanupWG)
+func (rm *RoomManager) runRoomTicker(room *Room) {
+	defer rm.cleanupWG.Done()
+
+	ticker := time.NewTicker(roomTickInterval())
+	defer ticker.Stop()
+
+	idle := 0
+	for {
+		select {
+		case <-ticker.C:
+		case <-rm.cleanupCtx.Done():
+			return
+		}
+
+		if room.flushPositions() {
+			idle = 0
+			continue
+		}
+		if idle++; idle >= TickIdleLimit {
+			room.mu.Lock()
+			if len(room.pendingMoves) == 0 && !room.hasActiveNPCsLocked() {
+				room.ticking = false
+				room.mu.Unlock()
+				return
+			}
+			room.mu.Unlock()
+		}
+	}
+}
+
+// flushPositions sends one snapshot per client with everything that moved since the last
+// tick. Returns false if nothing moved.
+func (r *Room) flushPositions() bool {
+	r.mu.Lock()
+	now := time.Now()
+	npcFrames := r.stepNPCsLocked(now)
+	if len(r.pendingMoves) == 0 && len(npcFrames) == 0 {
+		r.mu.Unlock()
+		return false
+	}
+
+	frames := r.movedFramesLocked(r.pendingMoves, len(npcFrames), now)
+	r.pendingMoves = make(map[string]bool)
+	frames = append(frames, npcFrames...)
+	r.mu.Unlock()
+
+	r.sendSnapshot(frames)
+	return true
+}
+
+// movedFramesLocked builds frames with the current position of each moved player, with
+// capacity for extra more frames (caller holds r.mu)
+func (r *Room) movedFramesLocked(moved map[string]bool, extra int, now time.Time) []positionFrame {
+	frames := make([]positionFrame, 0, len(moved)+extra)
+	for playerID := range moved {
+		player, exists := r.Players[playerID]
+		if !exists || player.Hidden {
+			continue
+		}
+		quantized, delta := player.nextPositionFrame(player.Position, now)
+		view := r.updateInterestLocked(playerID)
+		frames = append(frames, newPositionFrame(playerID, player.Username, player.Position, quantized, delta, view, player.lastInputSeq))
+	}
+	return frames
+}
+
+// sendSnapshot sends each client one "snapshot" batch with the frames it can see, and its
+// own position_ack if it moved
+func (r *Room) sendSnapshot(frames []positionFrame) {
+	if len(frames) == 0 {
+		return
+	}
+	for _, frame := range frames {
+		frame.view.notify(r, frame.playerID)
+		publishRoomBroadcast(r.ID, frame.playerID, frame.absolute)
+	}
+
+	r.hub().send(hubBroadcast{deliver: func(conn *Connection, version uint64) {
+		useDelta := conn.deltaPositions.Load()
+		messages := make([]WebSocketMessage, 0, len(frames))
+		for _, frame := range frames {
+			if frame.playerID == conn.playerID {
+				if frame.ack != nil {
+					messages = append(messages, *frame.ack)
+				}
+				continue
+			}
+			if frame.view != nil && !frame.view.visible[conn.playerID] {
+				continue
+			}
+			if useDelta {
+				messages = append(messages, frame.compact)
+			} else {
+				messages = append(messages, frame.absolute)
+			}
+		}
+		conn.sendBatchAt("snapshot", messages, version)
+	}})
+}
@@ -0,0 +1,145 @@
+package Player_Logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TranslationTimeout bounds how long a chat broadcast waits on the translation provider
+const TranslationTimeout = 2 * time.Second
+
+// Translator is a pluggable provider that translates chat text into a target language
+type Translator interface {
+	Translate(ctx context.Context, text, targetLang string) (string, error)
+}
+
+var (
+	translator   Translator
+	translatorMu sync.RWMutex
+)
+
+// SetTranslator installs the chat translation provider (nil disables translation)
+func SetTranslator(t Translator) {
+	translatorMu.Lock()
+	defer translatorMu.Unlock()
+	translator = t
+}
+
+// getTranslator returns the current translation provider, if any
+func getTranslator() Translator {
+	translatorMu.RLock()
+	defer translatorMu.RUnlock()
+	return translator
+}
+
+// HTTPTranslator talks to a LibreTranslate-compatible /translate endpoint
+type HTTPTranslator struct {
+	URL    string
+	APIKey string
+	client *http.Client
+}
+
+// NewHTTPTranslator creates a translator for a LibreTranslate-compatible API
+func NewHTTPTranslator(url, apiKey string) *HTTPTranslator {
+	return &HTTPTranslator{
+		URL:    url,
+		APIKey: apiKey,
+		client: &http.Client{Timeout: TranslationTimeout},
+	}
+}
+
+// Translate implements Translator
+func (t *HTTPTranslator) Translate(ctx context.Context, text, targetLang string) (string, error) {
+	payload, err := json.Marshal(map[string]string{
+		"q":       text,
+		"source":  "auto",
+		"target":  targetLang,
+		"format":  "text",
+		"api_key": t.APIKey,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("translation provider returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		TranslatedText string `json:"translatedText"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.TranslatedText, nil
+}
+
+// broadcastChatTranslated delivers a chat message to the room, translating it once per
+// recipient language that differs from the sender's. Recipients without a preferred
+// language (or when translation fails) receive the original text only. Translations are
+// made before the message goes to the room's hub, which hands each recipient theirs.
+func broadcastChatTranslated(t Translator, room *Room, senderLang string, message WebSocketMessage, skip func(playerID string) bool) {
+	publishRoomBroadcast(room.ID, message.PlayerID, message)
+
+	langs := make(map[string]string)
+	room.mu.RLock()
+	for playerID, player := range room.Players {
+		if playerID != message.PlayerID {
+			langs[playerID] = player.GetLanguage()
+		}
+	}
+	room.mu.RUnlock()
+
+	translations := make(map[string]WebSocketMessage)
+	for playerID, lang := range langs {
+		if lang == "" || lang == senderLang || skip(playerID) {
+			continue
+		}
+		if _, done := translations[lang]; done {
+			continue
+		}
+		delivered := message
+		ctx, cancel := context.WithTimeout(context.Background(), TranslationTimeout)
+		translated, err := t.Translate(ctx, message.Text, lang)
+		cancel()
+		if err != nil {
+			slog.Warn("Failed to translate chat message", "language", lang, "error", err)
+		} else {
+			delivered.TranslatedText = translated
+			delivered.Language = lang
+		}
+		translations[lang] = delivered
+	}
+
+	room.hub().send(hubBroadcast{
+		message: message,
+		exclude: message.PlayerID,
+		skip:    skip,
+		deliver: func(conn *Connection, version uint64) {
+			delivered, exists := translations[langs[conn.playerID]]
+			if !exists {
+				delivered = message
+			}
+			delivered.StateVersion = version
+			conn.sendMessage(delivered)
+		},
+	})
+}
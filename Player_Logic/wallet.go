@@ -0,0 +1,80 @@
+package Player_Logic
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+	"velvet/config"
+)
+
+// walletEvent is the data of a wallet_updated message
+type walletEvent struct {
+	Balance int64  `json:"balance"`
+	Amount  int64  `json:"amount"` // Signed change
+	Reason  string `json:"reason"`
+}
+
+// CreditCoins adds coins to a player's wallet and tells them once it's committed
+func CreditCoins(ctx context.Context, playerID string, amount int64, reason, reference string) (int64, error) {
+	balance, err := config.CreditCoins(ctx, playerID, amount, reason, reference)
+	if err != nil {
+		return 0, err
+	}
+	config.AfterCommit(ctx, func() { notifyWallet(playerID, walletEvent{Balance: balance, Amount: amount, Reason: reason}) })
+	return balance, nil
+}
+
+// referralRewardTimeout bounds crediting a referral reward, which runs after signup
+const referralRewardTimeout = 10 * time.Second
+
+// RewardReferral credits REFERRAL_REWARD_COINS to both players of an accepted referral. It's
+// installed as the config referral reward hook; the referee's ID is the reference, so a
+// retry never pays either player twice.
+func RewardReferral(referrerID, refereeID string) error {
+	amount := int64(settings.Accounts.ReferralRewardCoins)
+	if amount <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), referralRewardTimeout)
+	defer cancel()
+	return config.WithTx(ctx, func(ctx context.Context) error {
+		for _, playerID := range []string{referrerID, refereeID} {
+			playerID := playerID
+			balance, credited, err := config.CreditCoinsOnce(ctx, playerID, amount, "referral", refereeID)
+			if err != nil {
+				return err
+			}
+			if credited {
+				config.AfterCommit(ctx, func() {
+					notifyWallet(playerID, walletEvent{Balance: balance, Amount: amount, Reason: "referral"})
+				})
+			}
+		}
+		return nil
+	})
+}
+
+// DebitCoins spends coins from a player's wallet and tells them once it's committed
+func DebitCoins(ctx context.Context, playerID string, amount int64, reason, reference string) (int64, error) {
+	balance, err := config.DebitCoins(ctx, playerID, amount, reason, reference)
+	if err != nil {
+		return 0, err
+	}
+	config.AfterCommit(ctx, func() { notifyWallet(playerID, walletEvent{Balance: balance, Amount: -amount, Reason: reason}) })
+	return balance, nil
+}
+
+// notifyWallet sends the new balance to the player if they're online
+func notifyWallet(playerID string, event walletEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	sendToPlayer(playerID, WebSocketMessage{
+		Type:      "wallet_updated",
+		PlayerID:  "system",
+		Data:      data,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
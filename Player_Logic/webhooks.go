@@ -0,0 +1,23 @@
+package Player_Logic
+
+import "velvet/config"
+
+// emitRoomCreated sends the room_created webhook
+func emitRoomCreated(room *Room, hostID string) {
+	config.EmitWebhook(config.WebhookRoomCreated, map[string]interface{}{
+		"room_id":  room.ID,
+		"host_id":  hostID,
+		"capacity": room.Capacity,
+	})
+}
+
+// emitPlayerJoined sends the player_joined webhook; hidden service accounts are left out
+func emitPlayerJoined(playerID, roomID string) {
+	if account, isService := config.GetServiceAccount(playerID); isService && account.Hidden {
+		return
+	}
+	config.EmitWebhook(config.WebhookPlayerJoined, map[string]interface{}{
+		"player_id": playerID,
+		"room_id":   roomID,
+	})
+}
@@ -3,44 +3,41 @@ package Player_Logic
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"velvet/config"
 
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // WebSocket performance configuration
+// Buffer sizes, connection limits, and timeouts come from settings.WebSocket
 const (
-	// Buffer sizes optimized for multiplayer games
-	ReadBufferSize  = 8192 // 8KB for incoming messages
-	WriteBufferSize = 8192 // 8KB for outgoing messages
-
-	// Message batching configuration
-	BatchSize    = 10                    // Max messages per batch
+	// Position batching when tick loops are off (see batcher.go)
+	BatchSize    = 10                    // Movers per batch before it's sent early
 	BatchTimeout = 50 * time.Millisecond // Max wait time before sending batch
 
-	// Connection limits
-	MaxConcurrentConnections = 1000
+	// Typing indicators: at most one typing_start per player per interval
+	TypingThrottle = time.Second
 
-	// Timeouts
-	WriteTimeout = 10 * time.Second
-	ReadTimeout  = 60 * time.Second
-	PongTimeout  = 60 * time.Second
-	PingPeriod   = 54 * time.Second
+	// Max wait for the account lookup of a private message sent by username
+	UsernameLookupTimeout = 3 * time.Second
 )
 
 var (
 	upgrader = websocket.Upgrader{
-		ReadBufferSize:    ReadBufferSize,
-		WriteBufferSize:   WriteBufferSize,
-		EnableCompression: true,
-		CheckOrigin: func(r *http.Request) bool {
-			return true // Allow all origins for development
-		},
-		Subprotocols: []string{"json"},
+		ReadBufferSize:    settings.WebSocket.ReadBufferSize,
+		WriteBufferSize:   settings.WebSocket.WriteBufferSize,
+		EnableCompression: settings.WebSocket.Compression,
+		CheckOrigin:       config.CheckWebSocketOrigin,
+		Subprotocols:      []string{SubprotocolJSON, SubprotocolMsgPack},
 	}
 
 	// Connection pool management
@@ -48,12 +45,6 @@ var (
 		connections: make(map[string]*Connection),
 		mu:          sync.RWMutex{},
 	}
-
-	// Message batching
-	messageBatcher = &MessageBatcher{
-		batches: make(map[string]*MessageBatch),
-		mu:      sync.RWMutex{},
-	}
 )
 
 // Connection represents an optimized WebSocket connection
@@ -65,9 +56,42 @@ type Connection struct {
 	ctx      context.Context
 	cancel   context.CancelFunc
 	mu       sync.RWMutex
-	// Rate limiting
-	lastMessageTime time.Time
-	messageCount    int
+	// Service accounts are tagged as system senders and skip player rate limits
+	isService bool
+	// Per-class message budgets (see message_limits.go)
+	limiter messageLimiter
+	// Typing indicator state (throttled to TypingThrottle)
+	lastTypingStart time.Time
+	typing          bool
+	emotes          emoteLimiter
+	lastResync      time.Time // Last resync_request served (readPump only)
+	// Unacked reliable messages, carried over to the player's next connection
+	reliable *reliableBuffer
+	// Wire format negotiated via subprotocol (JSON unless the client asked for msgpack)
+	codec Codec
+	// Message format version announced by the client (see protocol.go)
+	protocolVersion atomic.Int32
+	// Receive position_delta messages instead of absolute position updates
+	deltaPositions atomic.Bool
+	// Turned global chat off (see global_chat.go)
+	globalChatOff atomic.Bool
+	// ID of the player's guild, 0 if none (see guilds.go)
+	guildID atomic.Int64
+	// Username of the player's account, for private messages addressed by name
+	accountName atomic.Pointer[string]
+	// Smoothed round-trip time in nanoseconds from ping/pong (see latency.go)
+	rtt atomic.Int64
+	// Traffic counters and connection_degraded state (see connection_quality.go)
+	quality connectionQuality
+	// Frames waiting for room in send (see slow_consumer.go)
+	backlog sendBacklog
+	// ID of the upgrade request, shared by every log line of the connection
+	requestID string
+	// Logger carrying request_id, player_id, and room_id
+	logger *slog.Logger
+	// Client address and connect time, for the admin connection listing
+	remoteAddr  string
+	connectedAt time.Time
 }
 
 // ConnectionPool manages all WebSocket connections
@@ -75,19 +99,9 @@ type ConnectionPool struct {
 	connections map[string]*Connection
 	mu          sync.RWMutex
 	count       int
-}
-
-// MessageBatch holds batched messages for efficient sending
-type MessageBatch struct {
-	messages []WebSocketMessage
-	timer    *time.Timer
-	mu       sync.Mutex
-}
-
-// MessageBatcher manages message batching per room
-type MessageBatcher struct {
-	batches map[string]*MessageBatch
-	mu      sync.RWMutex
+	// Admission queue for clients that opted to wait when the server is full
+	waiting  []*admissionTicket
+	reserved int // Slots promised to admitted clients that haven't registered yet
 }
 
 // WebSocketMessage represents a message sent over WebSocket
@@ -95,11 +109,44 @@ type WebSocketMessage struct {
 	Type           string          `json:"type"`
 	PlayerID       string          `json:"player_id"`
 	TargetPlayerID string          `json:"target_player_id,omitempty"`
+	TargetUsername string          `json:"target_username,omitempty"` // private_message by username instead of ID
 	Position       *Position       `json:"position,omitempty"`
 	Data           json.RawMessage `json:"data,omitempty"`
 	Text           string          `json:"text,omitempty"`
 	Username       string          `json:"username,omitempty"`
 	Timestamp      int64           `json:"timestamp,omitempty"`
+	System         bool            `json:"system,omitempty"` // Sent by a service account
+	TranslatedText string          `json:"translated_text,omitempty"`
+	Language       string          `json:"language,omitempty"` // Language of TranslatedText, or requested language
+	RoomID         string          `json:"room_id,omitempty"`
+	QueuePosition  int             `json:"queue_position,omitempty"`
+	PartyID        string          `json:"party_id,omitempty"`
+	Status         string          `json:"status,omitempty"`  // Presence status for presence_changed
+	Enabled        *bool           `json:"enabled,omitempty"` // Toggle value for settings messages
+	Minutes        int             `json:"minutes,omitempty"` // Duration for timed moderation (mute)
+	EmoteID        string          `json:"emote_id,omitempty"`
+	Channel        string          `json:"channel,omitempty"`       // Chat channel (defaults to "general")
+	Seq            uint64          `json:"seq,omitempty"`           // Sequence number of a reliable outgoing message
+	Ack            uint64          `json:"ack,omitempty"`           // Highest seq the client has received (ack messages)
+	Version        int             `json:"version,omitempty"`       // Protocol version (hello/hello_ack/protocol)
+	Delta          *PositionDelta  `json:"delta,omitempty"`         // Quantized position change (position_delta)
+	InputSeq       uint64          `json:"input_seq,omitempty"`     // Client input number on position_update, echoed once processed
+	ResumeToken    string          `json:"resume_token,omitempty"`  // Token for ?resume= after a dropped connection
+	Countdown      int             `json:"countdown,omitempty"`     // Seconds until the server shuts down (server_shutdown)
+	Avatar         *config.Avatar  `json:"avatar,omitempty"`        // Player's look (player_joined, avatar_updated)
+	ObjectID       string          `json:"object_id,omitempty"`     // Room object (interact, object_updated, ...)
+	Zone           string          `json:"zone,omitempty"`          // Layout zone ID (zone_entered, zone_left)
+	NPCID          string          `json:"npc_id,omitempty"`        // NPC (npc_interact, npc_dialogue)
+	Mode           string          `json:"mode,omitempty"`          // Chat reach: "room" (default) or "local"
+	GameID         string          `json:"game_id,omitempty"`       // Mini-game session (game_started, game_event, game_ended)
+	RequestID      string          `json:"request_id,omitempty"`    // Connection's request ID (protocol, error)
+	Code           string          `json:"code,omitempty"`          // Machine-readable error code (error) or reason (connection_degraded)
+	Field          string          `json:"field,omitempty"`         // Field an error is about (error)
+	InReplyTo      string          `json:"in_reply_to,omitempty"`   // Type of the client message an error is about (error)
+	ClientTime     int64           `json:"client_time,omitempty"`   // Client's clock on ping/time_sync, echoed in the reply
+	RTTMs          int             `json:"rtt_ms,omitempty"`        // Smoothed round-trip time (pong; player_joined with WS_SHARE_LATENCY)
+	StateVersion   uint64          `json:"state_version,omitempty"` // Room state version (room broadcasts, room_state; last one seen on resync_request)
+	GuildTag       string          `json:"guild_tag,omitempty"`     // Player's guild tag (player_joined, guild_chat, guild_changed)
 }
 
 // BatchedMessage contains multiple messages for efficient transmission
@@ -107,33 +154,80 @@ type BatchedMessage struct {
 	Type     string             `json:"type"`
 	Messages []WebSocketMessage `json:"messages"`
 	Count    int                `json:"count"`
+	// Room state version as of the batch (snapshot)
+	StateVersion uint64 `json:"state_version,omitempty"`
 }
 
 // HandleWebSocket handles WebSocket connections with optimizations
 func HandleWebSocket(w http.ResponseWriter, r *http.Request) {
-	log.Printf("WebSocket connection attempt from %s", r.RemoteAddr)
-	log.Printf("Request headers: %v", r.Header)
+	logger := config.Logger(r.Context()).With("remote_addr", r.RemoteAddr)
+
+	// No new sessions once shutdown has started
+	if IsDraining() {
+		logger.Info("WebSocket connection rejected: server shutting down")
+		config.Error(w, "Server shutting down", http.StatusServiceUnavailable)
+		return
+	}
 
-	playerID := r.URL.Query().Get("token")
-	if playerID == "" {
-		log.Printf("WebSocket connection rejected: no token provided")
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	playerID, ok := config.ResolvePrincipal(r.URL.Query().Get("token"))
+	if !ok {
+		logger.Info("WebSocket connection rejected: missing or invalid token")
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	log.Printf("WebSocket connection attempt for player: %s", playerID)
+	logger = logger.With("player_id", playerID)
+	logger.Debug("WebSocket connection attempt")
 
-	// Check connection limit
-	if !connectionPool.canAcceptConnection() {
-		log.Printf("Connection rejected for player %s: server at capacity", playerID)
-		http.Error(w, "Server at capacity", http.StatusServiceUnavailable)
+	if config.GetBanStore().IsBanned(playerID) {
+		logger.Info("WebSocket connection rejected: account banned")
+		config.Error(w, "Account is banned", http.StatusForbidden)
 		return
 	}
+	if config.IsDeletionPending(playerID) {
+		logger.Info("WebSocket connection rejected: account scheduled for deletion")
+		config.Error(w, "Account is scheduled for deletion", http.StatusForbidden)
+		return
+	}
+
+	protocolVersion, err := parseProtocolVersion(r.URL.Query().Get("protocol_version"))
+	if err != nil {
+		logger.Info("WebSocket connection rejected", "error", err)
+		config.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Check connection limit; clients that pass ?queue=1 may wait for a slot instead
+	queued := false
+	if !connectionPool.canAcceptConnection() {
+		if r.URL.Query().Get("queue") != "1" || !connectionPool.canQueue() {
+			logger.Warn("WebSocket connection rejected: server at capacity")
+			config.Error(w, "Server at capacity", http.StatusServiceUnavailable)
+			return
+		}
+		queued = true
+	}
+
+	// Trace connection setup (upgrade through initial state), not the whole session
+	setupCtx, setupSpan := config.StartSpan(config.ExtractTraceContext(r.Context(), r), "ws.connect",
+		attribute.String("player_id", playerID))
+	defer setupSpan.End()
 
 	// Upgrade HTTP connection to WebSocket
-	conn, err := upgrader.Upgrade(w, r, nil)
+	requestID := config.RequestID(r.Context())
+	responseHeader := http.Header{}
+	if requestID != "" {
+		responseHeader.Set(config.RequestIDHeader, requestID)
+	}
+	conn, err := upgraderFor(r).Upgrade(w, r, responseHeader)
 	if err != nil {
-		log.Printf("WebSocket upgrade failed for player %s: %v", playerID, err)
+		logger.Warn("WebSocket upgrade failed", "error", err)
+		return
+	}
+
+	// Hold the connection until a slot frees up
+	if queued && !connectionPool.waitForAdmission(setupCtx, conn, playerID) {
+		conn.Close()
 		return
 	}
 
@@ -143,7 +237,10 @@ func HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	// Find player in any room
 	player := rm.GetPlayer(playerID)
 	if player == nil {
-		log.Printf("Player %s not found in any room for WebSocket connection", playerID)
+		logger.Info("Player not found in any room for WebSocket connection")
+		if queued {
+			connectionPool.releaseReservation()
+		}
 		conn.Close()
 		return
 	}
@@ -151,7 +248,10 @@ func HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	// Get the room containing this player
 	room := rm.GetPlayerRoom(playerID)
 	if room == nil {
-		log.Printf("Room not found for player %s", playerID)
+		logger.Info("Room not found for player")
+		if queued {
+			connectionPool.releaseReservation()
+		}
 		conn.Close()
 		return
 	}
@@ -165,11 +265,34 @@ func HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		send:     make(chan []byte, 256), // Buffered channel for async sending
 		ctx:      ctx,
 		cancel:   cancel,
+		logger:   logger.With("room_id", room.ID),
+
+		requestID: requestID,
+
+		remoteAddr:  config.ClientIP(r),
+		connectedAt: time.Now(),
 	}
+	_, connection.isService = config.GetServiceAccount(playerID)
+	connection.codec = codecFor(conn.Subprotocol())
+	connection.protocolVersion.Store(int32(protocolVersion))
+	connection.deltaPositions.Store(r.URL.Query().Get("position_encoding") == "delta")
+	connection.reliable = takeReliableBuffer(playerID)
+	connection.quality.wake = make(chan struct{}, 1)
 
 	// Register connection
-	connectionPool.addConnection(playerID, connection)
-	defer connectionPool.removeConnection(playerID)
+	connectionPool.addConnection(playerID, connection, queued)
+	defer connectionPool.removeConnection(playerID, connection)
+	connection.bindRoom(room)
+	defer connection.unbindRoom()
+	defer func() {
+		// Keep unacked messages for a reconnect unless a newer connection already has them
+		if current, exists := connectionPool.getConnection(playerID); !exists || current == connection {
+			stashReliableBuffer(playerID, connection.reliable)
+		}
+	}()
+
+	// A valid resume token re-binds to the held Player without announcing a rejoin
+	resumed := checkResumeToken(playerID, r.URL.Query().Get("resume"))
 
 	// Update player's WebSocket connection
 	room.mu.Lock()
@@ -178,10 +301,70 @@ func HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	player.LastSeen = time.Now()
 	room.mu.Unlock()
 
-	log.Printf("WebSocket connected for player %s in room %s", playerID, room.ID)
+	// Preferred chat language can be supplied at connect time
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		player.SetLanguage(lang)
+	}
 
-	// Send initial room state
-	connection.sendInitialRoomState(room, playerID)
+	// Others need to know what this player looks like before they're announced
+	if !connection.isService {
+		connection.loadAccount(setupCtx, player)
+	}
+	connection.loadChatPrefs(setupCtx)
+	connection.loadGuild(setupCtx, player)
+
+	connection.logger.Info("WebSocket connected")
+
+	// Track presence and XP, and let friends know this player is online (and offline once the socket closes)
+	if !connection.isService {
+		GetPresence().Connected(playerID)
+		GetProgression().Connected(setupCtx, playerID, room.ID)
+		defer func() {
+			// A newer connection for the same player keeps them online
+			if current, exists := connectionPool.getConnection(playerID); !exists || current == connection {
+				GetPresence().Disconnected(playerID)
+				GetProgression().Disconnected(playerID)
+			}
+		}()
+	}
+
+	// Tell the client which protocol version is in effect, then send initial room state
+	connection.sendProtocolInfo("protocol")
+	connection.sendRoomLayout(room)
+	connection.sendInitialRoomState(room, playerID, !resumed)
+	connection.sendRoomObjects(room)
+	connection.sendRoomNPCs(room)
+	connection.sendActiveGame(room)
+	room.refreshInterest(playerID)
+	rm.wakeRoom(room)
+	if resumed {
+		position := player.GetPosition()
+		connection.sendMessage(WebSocketMessage{
+			Type:      "session_resumed",
+			PlayerID:  playerID,
+			RoomID:    room.ID,
+			Position:  &position,
+			Timestamp: time.Now().UnixMilli(),
+		})
+		connection.logger.Info("Session resumed")
+	}
+	connection.sendResumeToken()
+
+	// Catch the client up on chat/joins/leaves it missed while disconnected
+	if since := parseReplaySince(r.URL.Query().Get("since")); since > 0 {
+		connection.replayMissed(room, since)
+	}
+
+	// Resend reliable messages the client never acked (v1 clients get them after a hello)
+	if connection.reliableDelivery() {
+		connection.retransmitPending()
+	}
+
+	// Hand over private messages that arrived while the player was offline
+	go connection.deliverInbox()
+
+	setupSpan.SetAttributes(attribute.String("room_id", room.ID), attribute.Bool("resumed", resumed))
+	setupSpan.End()
 
 	// Start connection handlers
 	go connection.writePump()
@@ -193,17 +376,22 @@ func HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 }
 
 // canAcceptConnection checks if server can accept more connections
+// (queued clients go first, so newcomers can't jump the queue)
 func (cp *ConnectionPool) canAcceptConnection() bool {
 	cp.mu.RLock()
 	defer cp.mu.RUnlock()
-	return cp.count < MaxConcurrentConnections
+	return len(cp.waiting) == 0 && cp.count+cp.reserved < settings.WebSocket.MaxConnections
 }
 
-// addConnection adds a connection to the pool
-func (cp *ConnectionPool) addConnection(playerID string, conn *Connection) {
+// addConnection adds a connection to the pool, consuming a queue reservation if admitted from the queue
+func (cp *ConnectionPool) addConnection(playerID string, conn *Connection, fromQueue bool) {
 	cp.mu.Lock()
 	defer cp.mu.Unlock()
 
+	if fromQueue {
+		cp.reserved--
+	}
+
 	// Remove existing connection if any
 	if existingConn, exists := cp.connections[playerID]; exists {
 		existingConn.cancel()
@@ -213,19 +401,21 @@ func (cp *ConnectionPool) addConnection(playerID string, conn *Connection) {
 
 	cp.connections[playerID] = conn
 	cp.count++
-	log.Printf("Connection pool: %d/%d connections", cp.count, MaxConcurrentConnections)
+	slog.Debug("Connection pool updated", "connections", cp.count, "max", settings.WebSocket.MaxConnections)
 }
 
 // removeConnection removes a connection from the pool
-func (cp *ConnectionPool) removeConnection(playerID string) {
+func (cp *ConnectionPool) removeConnection(playerID string, conn *Connection) {
 	cp.mu.Lock()
 	defer cp.mu.Unlock()
 
-	if conn, exists := cp.connections[playerID]; exists {
+	// A replaced connection must not remove the one that replaced it
+	if current, exists := cp.connections[playerID]; exists && current == conn {
 		conn.cancel()
 		delete(cp.connections, playerID)
 		cp.count--
-		log.Printf("Connection pool: %d/%d connections", cp.count, MaxConcurrentConnections)
+		slog.Debug("Connection pool updated", "connections", cp.count, "max", settings.WebSocket.MaxConnections)
+		cp.admitNextLocked()
 	}
 }
 
@@ -238,20 +428,26 @@ func (cp *ConnectionPool) getConnection(playerID string) (*Connection, bool) {
 }
 
 // sendInitialRoomState sends current players to newly connected player
-func (c *Connection) sendInitialRoomState(room *Room, playerID string) {
+func (c *Connection) sendInitialRoomState(room *Room, playerID string, announce bool) {
 	room.mu.RLock()
 	defer room.mu.RUnlock()
 
 	var messages []WebSocketMessage
 	for id, p := range room.Players {
-		if id != playerID {
-			messages = append(messages, WebSocketMessage{
+		if id != playerID && !p.Hidden {
+			joined := WebSocketMessage{
 				Type:      "player_joined",
 				PlayerID:  p.ID,
 				Position:  &p.Position,
 				Username:  p.Username,
+				Avatar:    p.GetAvatar(),
+				GuildTag:  p.GetGuildTag(),
 				Timestamp: time.Now().UnixMilli(),
-			})
+			}
+			if settings.WebSocket.ShareLatency {
+				joined.RTTMs = playerRTTMillis(p.ID)
+			}
+			messages = append(messages, joined)
 		}
 	}
 
@@ -259,12 +455,19 @@ func (c *Connection) sendInitialRoomState(room *Room, playerID string) {
 		c.sendBatchedMessages(messages)
 	}
 
+	// Hidden service accounts join silently, and resumed sessions never left
+	if room.Players[playerID].Hidden || !announce {
+		return
+	}
+
 	// Notify other players about new player
 	joinMessage := WebSocketMessage{
 		Type:      "player_joined",
 		PlayerID:  playerID,
 		Position:  &room.Players[playerID].Position,
 		Username:  room.Players[playerID].Username,
+		Avatar:    room.Players[playerID].GetAvatar(),
+		GuildTag:  room.Players[playerID].GetGuildTag(),
 		Timestamp: time.Now().UnixMilli(),
 	}
 
@@ -274,7 +477,8 @@ func (c *Connection) sendInitialRoomState(room *Room, playerID string) {
 
 // writePump handles outgoing messages with batching
 func (c *Connection) writePump() {
-	ticker := time.NewTicker(PingPeriod)
+	defer c.recoverPanic("writePump")
+	ticker := time.NewTicker(settings.WebSocket.PingPeriod)
 	defer func() {
 		ticker.Stop()
 		c.ws.Close()
@@ -284,20 +488,30 @@ func (c *Connection) writePump() {
 	for {
 		select {
 		case message, ok := <-c.send:
-			c.ws.SetWriteDeadline(time.Now().Add(WriteTimeout))
+			c.ws.SetWriteDeadline(time.Now().Add(settings.WebSocket.WriteTimeout))
 			if !ok {
 				c.ws.WriteMessage(websocket.CloseMessage, []byte{})
 				return
 			}
 
-			if err := c.ws.WriteMessage(websocket.TextMessage, message); err != nil {
-				log.Printf("Write error for player %s: %v", c.playerID, err)
+			if err := c.writeFrame(message); err != nil {
+				c.logger.Info("WebSocket write error", "error", err)
+				return
+			}
+			c.recordSent(len(message))
+			c.drainBacklog()
+			if !c.writeDegradedNotice() {
+				return
+			}
+
+		case <-c.quality.wake:
+			if !c.writeDegradedNotice() {
 				return
 			}
 
 		case <-ticker.C:
-			c.ws.SetWriteDeadline(time.Now().Add(WriteTimeout))
-			if err := c.ws.WriteMessage(websocket.PingMessage, nil); err != nil {
+			c.ws.SetWriteDeadline(time.Now().Add(settings.WebSocket.WriteTimeout))
+			if err := c.ws.WriteMessage(websocket.PingMessage, pingPayload(time.Now())); err != nil {
 				return
 			}
 
@@ -309,125 +523,543 @@ func (c *Connection) writePump() {
 
 // readPump handles incoming messages
 func (c *Connection) readPump(rm *RoomManager) {
+	defer c.recoverPanic("readPump")
 	defer c.cancel()
 
-	c.ws.SetReadDeadline(time.Now().Add(ReadTimeout))
-	c.ws.SetPongHandler(func(string) error {
-		c.ws.SetReadDeadline(time.Now().Add(PongTimeout))
+	c.ws.SetReadDeadline(time.Now().Add(settings.WebSocket.ReadTimeout))
+	c.ws.SetPongHandler(func(payload string) error {
+		now := time.Now()
+		c.ws.SetReadDeadline(now.Add(settings.WebSocket.PongTimeout))
+		c.handlePong(payload, now)
 		return nil
 	})
 
 	for {
-		var message WebSocketMessage
-		err := c.ws.ReadJSON(&message)
+		_, data, err := c.ws.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("WebSocket error for player %s: %v", c.playerID, err)
+				c.logger.Info("WebSocket error", "error", err)
 			}
 			break
 		}
 
-		c.ws.SetReadDeadline(time.Now().Add(ReadTimeout))
-		c.handlePlayerAction(rm, message)
+		c.ws.SetReadDeadline(time.Now().Add(settings.WebSocket.ReadTimeout))
+		c.recordReceived(len(data))
+
+		var message WebSocketMessage
+		if err := c.codec.Unmarshal(data, &message); err != nil {
+			c.logger.Debug("Malformed message", "codec", c.codec.Name(), "error", err)
+			if c.checkRateLimit("") {
+				c.sendMessageError("", &MessageError{Code: ErrCodeMalformedMessage, Message: "message could not be decoded as " + c.codec.Name()})
+			}
+			continue
+		}
+		c.handleMessageSafely(rm, message)
 	}
 
 	// Cleanup on disconnect
 	c.handleDisconnect(rm)
 }
 
+// untracedMessageTypes are high-frequency client messages handled without a span
+var untracedMessageTypes = map[string]bool{
+	"position_update": true,
+	"ack":             true,
+	"typing_start":    true,
+	"typing_stop":     true,
+	"ping":            true,
+}
+
 // handlePlayerAction processes incoming WebSocket messages
 func (c *Connection) handlePlayerAction(rm *RoomManager, message WebSocketMessage) {
+	// Over-budget messages are dropped before any work is done
+	if !c.checkRateLimit(message.Type) {
+		return
+	}
+
+	if err := validateMessage(message); err != nil {
+		c.logger.Debug("Rejected message", "type", message.Type, "code", err.Code, "field", err.Field)
+		if err.Code == ErrCodeUnknownType {
+			wsMessagesReceived.WithLabelValues("unknown").Inc()
+		}
+		c.sendMessageError(message.Type, err)
+		return
+	}
+
+	// Any client input (other than automatic acks) counts as activity for AFK detection
+	if !c.isService && message.Type != "ack" && message.Type != "ping" {
+		GetPresence().Touch(c.playerID)
+	}
+
+	// Frequent, cheap messages aren't worth a span each
+	if !untracedMessageTypes[message.Type] {
+		_, span := config.StartSpan(c.ctx, "ws.message",
+			attribute.String("message.type", message.Type), attribute.String("player_id", c.playerID))
+		defer span.End()
+	}
+
 	switch message.Type {
+	case "hello":
+		c.handleHello(message)
+	case "ack":
+		c.handleAck(message.Ack)
+	case "ping":
+		c.handlePing(message)
+	case "time_sync":
+		c.handleTimeSync(message)
+	case "resync_request":
+		c.handleResyncRequest(rm, message)
 	case "position_update":
-		if message.Position != nil {
-			rm.handlePositionUpdate(c.playerID, *message.Position, message.Username)
-		}
+		rm.handlePositionUpdate(c.playerID, *message.Position, message.Username, message.InputSeq)
 	case "leave_room":
 		rm.RemovePlayer(c.playerID)
 		c.cancel()
 	case "chat_message":
+		c.typing = false // Sending implies the client stopped typing
 		c.handleChatMessage(rm, message)
+	case "emote":
+		c.handleEmote(rm, message)
+	case "join_channel", "leave_channel", "create_channel", "delete_channel", "channel_invite":
+		c.handleChannelMessage(rm, message)
+	case "list_channels":
+		if room := rm.GetPlayerRoom(c.playerID); room != nil {
+			c.sendChannelList(room)
+		}
+	case "typing_start", "typing_stop":
+		c.handleTyping(rm, message)
 	case "private_message":
 		c.handlePrivateMessage(rm, message)
+	case "set_language":
+		if player := rm.GetPlayer(c.playerID); player != nil {
+			player.SetLanguage(message.Language)
+		}
+	case "party_invite", "party_accept", "party_leave":
+		c.handlePartyMessage(message)
+	case "mute", "unmute":
+		c.handleMute(rm, message)
+	case "set_chat_filter":
+		c.handleSetChatFilter(rm, message)
+	case "set_delta_positions":
+		c.handleSetDeltaPositions(message)
+	case "global_chat":
+		c.handleGlobalChat(rm, message)
+	case "set_global_chat":
+		c.handleSetGlobalChat(message)
+	case "guild_chat":
+		c.handleGuildChat(rm, message)
+	case "ban":
+		c.handleBan(rm, message)
+	case "unban":
+		c.handleUnban(rm, message)
+	case "interact", "place_object", "remove_object":
+		c.handleObjectMessage(rm, message)
+	case "npc_interact":
+		c.handleNPCInteract(rm, message)
+	case "game_start", "game_input", "game_stop":
+		c.handleGameMessage(rm, message)
 	}
+	wsMessagesReceived.WithLabelValues(message.Type).Inc()
 }
 
-// handleChatMessage processes chat messages
-func (c *Connection) handleChatMessage(rm *RoomManager, message WebSocketMessage) {
+// handlePartyMessage handles party invite/accept/leave requests over WebSocket
+func (c *Connection) handlePartyMessage(message WebSocketMessage) {
+	pm := GetPartyManager()
+
+	var err error
+	switch message.Type {
+	case "party_invite":
+		var party *Party
+		if party, err = pm.Invite(c.playerID, message.TargetPlayerID); err == nil {
+			NotifyPartyInvite(party, message.TargetPlayerID)
+		}
+	case "party_accept":
+		var party *Party
+		if party, err = pm.Accept(c.playerID, message.PartyID); err == nil {
+			notifyPartyUpdate(party)
+		}
+	case "party_leave":
+		var party *Party
+		var released []string
+		if party, released, err = pm.Leave(c.playerID); err == nil {
+			NotifyPartyChanged(party, append(released, c.playerID)...)
+		}
+	}
+
+	if err != nil {
+		c.sendMessage(WebSocketMessage{
+			Type:      "party_error",
+			PlayerID:  "system",
+			Text:      err.Error(),
+			Timestamp: time.Now().UnixMilli(),
+		})
+	}
+}
+
+// sendPartyInfo sends a party snapshot in the message's data field
+func (c *Connection) sendPartyInfo(messageType string, info PartyInfo) {
+	data, err := json.Marshal(info)
+	if err != nil {
+		c.logger.Error("Error marshaling party info", "error", err)
+		return
+	}
+	c.sendMessage(WebSocketMessage{
+		Type:      messageType,
+		PlayerID:  "system",
+		PartyID:   info.ID,
+		Data:      data,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
+
+// moveConnectionToRoom re-binds a player's live connection after the server moved them
+// to another room: the old room sees them leave and they get the new room's state
+func moveConnectionToRoom(playerID string, oldRoom, newRoom *Room) {
+	if oldRoom != nil && oldRoom != newRoom {
+		leaveMessage := WebSocketMessage{
+			Type:      "player_left",
+			PlayerID:  playerID,
+			Timestamp: time.Now().UnixMilli(),
+		}
+		go broadcastToRoomAsync(oldRoom, playerID, leaveMessage)
+	}
+
+	conn, exists := connectionPool.getConnection(playerID)
+	if !exists {
+		return
+	}
+
+	conn.bindRoom(newRoom)
+
+	newRoom.mu.Lock()
+	if player, inRoom := newRoom.Players[playerID]; inRoom {
+		player.WS = conn.ws
+	}
+	newRoom.mu.Unlock()
+
+	conn.sendMessage(WebSocketMessage{
+		Type:      "room_changed",
+		PlayerID:  "system",
+		RoomID:    newRoom.ID,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	conn.sendRoomLayout(newRoom)
+	conn.sendInitialRoomState(newRoom, playerID, true)
+	conn.sendRoomObjects(newRoom)
+	conn.sendRoomNPCs(newRoom)
+	conn.sendActiveGame(newRoom)
+	newRoom.refreshInterest(playerID)
+	GetRoomManager().wakeRoom(newRoom)
+}
+
+// handleBan lets a room host/moderator ban a player from their current room
+func (c *Connection) handleBan(rm *RoomManager, message WebSocketMessage) {
 	room := rm.GetPlayerRoom(c.playerID)
-	if room == nil {
-		log.Printf("Player %s not found in any room for chat message", c.playerID)
+	if room == nil || message.TargetPlayerID == "" {
 		return
 	}
 
-	chatMessage := WebSocketMessage{
-		Type:      "chat_message",
-		PlayerID:  c.playerID,
-		Text:      message.Text,
-		Username:  message.Username,
+	wasPresent, err := rm.BanPlayer(room.ID, c.playerID, message.TargetPlayerID)
+	if err != nil {
+		c.logger.Info("Ban rejected", "error", err)
+		c.sendMessage(WebSocketMessage{
+			Type:           "ban_error",
+			PlayerID:       "system",
+			TargetPlayerID: message.TargetPlayerID,
+			Text:           err.Error(),
+			Timestamp:      time.Now().UnixMilli(),
+		})
+		return
+	}
+
+	c.sendMessage(WebSocketMessage{
+		Type:           "ban_applied",
+		PlayerID:       "system",
+		TargetPlayerID: message.TargetPlayerID,
+		Timestamp:      time.Now().UnixMilli(),
+	})
+
+	if !wasPresent {
+		return
+	}
+
+	// Tell the banned player and drop their connection once the notice is flushed
+	if conn, exists := connectionPool.getConnection(message.TargetPlayerID); exists {
+		conn.closeWithNotice("banned", "You have been banned from this room")
+	}
+
+	leaveMessage := WebSocketMessage{
+		Type:      "player_left",
+		PlayerID:  message.TargetPlayerID,
 		Timestamp: time.Now().UnixMilli(),
 	}
+	go broadcastToRoomAsync(room, message.TargetPlayerID, leaveMessage)
+}
+
+// handleUnban lets a room host/moderator lift a ban in their current room
+func (c *Connection) handleUnban(rm *RoomManager, message WebSocketMessage) {
+	room := rm.GetPlayerRoom(c.playerID)
+	if room == nil || message.TargetPlayerID == "" {
+		return
+	}
 
-	// Broadcast chat message asynchronously
-	go broadcastToRoomAsync(room, c.playerID, chatMessage)
+	responseType := "unban_applied"
+	text := ""
+	if err := rm.UnbanPlayer(room.ID, c.playerID, message.TargetPlayerID); err != nil {
+		c.logger.Info("Unban rejected", "error", err)
+		responseType = "ban_error"
+		text = err.Error()
+	}
+
+	c.sendMessage(WebSocketMessage{
+		Type:           responseType,
+		PlayerID:       "system",
+		TargetPlayerID: message.TargetPlayerID,
+		Text:           text,
+		Timestamp:      time.Now().UnixMilli(),
+	})
 }
 
-// handlePrivateMessage processes private messages between players
-func (c *Connection) handlePrivateMessage(rm *RoomManager, message WebSocketMessage) {
-	// Rate limiting: max 20 messages per minute
+// handleMute lets a room host/moderator mute (for message.Minutes) or unmute a player
+func (c *Connection) handleMute(rm *RoomManager, message WebSocketMessage) {
+	room := rm.GetPlayerRoom(c.playerID)
+	if room == nil || message.TargetPlayerID == "" {
+		return
+	}
+
+	var err error
+	if message.Type == "mute" {
+		err = rm.MutePlayer(room.ID, c.playerID, message.TargetPlayerID, time.Duration(message.Minutes)*time.Minute)
+	} else {
+		err = rm.UnmutePlayer(room.ID, c.playerID, message.TargetPlayerID)
+	}
+	if err != nil {
+		c.logger.Info("Mute request rejected", "type", message.Type, "error", err)
+		c.sendMessage(WebSocketMessage{
+			Type:           "mute_error",
+			PlayerID:       "system",
+			TargetPlayerID: message.TargetPlayerID,
+			Text:           err.Error(),
+			Timestamp:      time.Now().UnixMilli(),
+		})
+		return
+	}
+
+	c.sendMessage(WebSocketMessage{
+		Type:           message.Type + "_applied",
+		PlayerID:       "system",
+		TargetPlayerID: message.TargetPlayerID,
+		Minutes:        message.Minutes,
+		Timestamp:      time.Now().UnixMilli(),
+	})
+	NotifyMuteChanged(room, message.TargetPlayerID)
+}
+
+// NotifyMuteChanged tells a player their mute state in a room changed
+func NotifyMuteChanged(room *Room, playerID string) {
+	conn, exists := connectionPool.getConnection(playerID)
+	if !exists {
+		return
+	}
+
+	remaining := room.MuteRemaining(playerID)
+	messageType := "unmuted"
+	if remaining > 0 {
+		messageType = "muted"
+	}
+	conn.sendMessage(WebSocketMessage{
+		Type:      messageType,
+		PlayerID:  "system",
+		RoomID:    room.ID,
+		Minutes:   int((remaining + time.Minute - 1) / time.Minute),
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
+
+// handleSetChatFilter lets a room host/moderator turn the chat filter on or off
+func (c *Connection) handleSetChatFilter(rm *RoomManager, message WebSocketMessage) {
+	room := rm.GetPlayerRoom(c.playerID)
+	if room == nil || message.Enabled == nil {
+		return
+	}
+
+	if err := rm.SetChatFilter(room.ID, c.playerID, *message.Enabled); err != nil {
+		c.sendMessage(WebSocketMessage{
+			Type:      "chat_filter_error",
+			PlayerID:  "system",
+			Text:      err.Error(),
+			Timestamp: time.Now().UnixMilli(),
+		})
+		return
+	}
+
+	go broadcastToRoomAsync(room, "", WebSocketMessage{
+		Type:      "chat_filter_changed",
+		PlayerID:  c.playerID,
+		RoomID:    room.ID,
+		Enabled:   message.Enabled,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
+
+// handleTyping relays typing indicators to the room. typing_start is throttled per player and
+// typing_stop is only relayed after a relayed start, so clients can't spam either.
+func (c *Connection) handleTyping(rm *RoomManager, message WebSocketMessage) {
 	now := time.Now()
-	if now.Sub(c.lastMessageTime) < time.Minute {
-		c.messageCount++
-		if c.messageCount > 20 {
-			log.Printf("Rate limit exceeded for player %s", c.playerID)
+	if message.Type == "typing_start" {
+		// Repeated starts refresh the indicator on clients, but no more than once per interval
+		if now.Sub(c.lastTypingStart) < TypingThrottle {
 			return
 		}
+		c.lastTypingStart = now
+		c.typing = true
 	} else {
-		c.messageCount = 1
-		c.lastMessageTime = now
+		if !c.typing {
+			return
+		}
+		c.typing = false
 	}
 
-	// Validate message length (max 500 characters)
-	if len(message.Text) > 500 {
-		log.Printf("Private message from %s too long (%d characters)", c.playerID, len(message.Text))
+	room := rm.GetPlayerRoom(c.playerID)
+	if room == nil || room.MuteRemaining(c.playerID) > 0 {
+		return
+	}
+
+	typingMessage := WebSocketMessage{
+		Type:      message.Type,
+		PlayerID:  c.playerID,
+		Username:  message.Username,
+		Timestamp: now.UnixMilli(),
+	}
+	go func() {
+		broadcastToRoomFiltered(room, c.playerID, typingMessage, blockedBy(room, c.playerID))
+	}()
+}
+
+// handleChatMessage processes chat messages
+func (c *Connection) handleChatMessage(rm *RoomManager, message WebSocketMessage) {
+	room := rm.GetPlayerRoom(c.playerID)
+	if room == nil {
+		c.logger.Debug("Player not found in any room for chat message")
 		return
 	}
 
-	// Validate message content
-	if strings.TrimSpace(message.Text) == "" {
-		log.Printf("Private message from %s is empty or whitespace only", c.playerID)
+	if remaining := room.MuteRemaining(c.playerID); remaining > 0 {
+		c.sendChatRejected("chat_rejected", "You are muted in this room", ChatRejection{
+			Reason:           "muted",
+			RemainingSeconds: int(remaining.Round(time.Second).Seconds()),
+		})
 		return
 	}
 
-	if message.TargetPlayerID == "" {
-		log.Printf("Private message from %s missing target player ID", c.playerID)
+	channel := message.Channel
+	if channel == "" {
+		channel = DefaultChannel
+	}
+	var notInChannel func(string) bool
+	var err error
+	if channel == ZoneChannel {
+		notInChannel, err = room.zoneAudience(c.playerID)
+	} else {
+		notInChannel, err = rm.channelForPost(room, c.playerID, channel)
+	}
+	if err != nil {
+		c.sendChatRejected("chat_rejected", err.Error(), ChatRejection{Reason: "channel"})
 		return
 	}
 
-	if message.TargetPlayerID == c.playerID {
-		log.Printf("Player %s tried to send private message to themselves", c.playerID)
+	mode := message.Mode
+	if mode == "" {
+		mode = ChatModeRoom
+	}
+	var outOfRange func(string) bool
+	switch mode {
+	case ChatModeRoom:
+	case ChatModeLocal:
+		outOfRange = room.localAudience(c.playerID, settings.Chat.LocalRadius)
+	default:
+		c.sendChatRejected("chat_rejected", ErrUnknownChatMode.Error(), ChatRejection{Reason: "mode"})
 		return
 	}
 
-	// Check if target player exists and is online
-	targetPlayer := rm.GetPlayer(message.TargetPlayerID)
-	if targetPlayer == nil {
-		log.Printf("Target player %s not found for private message from %s", message.TargetPlayerID, c.playerID)
-		// Send error message back to sender
-		errorMessage := WebSocketMessage{
-			Type:      "private_message_error",
-			PlayerID:  "system",
-			Text:      "Player not found or offline",
-			Timestamp: time.Now().UnixMilli(),
+	text := message.Text
+	if room.chatFilterEnabled() {
+		var allowed bool
+		if text, allowed = c.applyContentFilter(text); !allowed {
+			return
 		}
-		data, err := json.Marshal(errorMessage)
-		if err == nil {
-			select {
-			case c.send <- data:
-			default:
-				log.Printf("Send channel full for player %s, dropping message", c.playerID)
-			}
+	}
+
+	chatMessage := WebSocketMessage{
+		Type:      "chat_message",
+		PlayerID:  c.playerID,
+		Text:      text,
+		Username:  message.Username,
+		Timestamp: time.Now().UnixMilli(),
+		System:    c.isService,
+		Channel:   channel,
+		Mode:      mode,
+	}
+	skip := combineSkips(notInChannel, outOfRange)
+
+	// Keep history so clients can load recent chat when they join (zone and local chat are for
+	// whoever was nearby)
+	if strings.TrimSpace(message.Text) != "" && config.DB != nil && channel != ZoneChannel && mode == ChatModeRoom {
+		config.SaveChatMessageAsync(room.ID, channel, c.playerID, message.Username, text, chatMessage.Timestamp)
+	}
+
+	if !c.isService && strings.TrimSpace(message.Text) != "" {
+		GetProgression().awardChatXP(c.playerID)
+	}
+
+	senderLang := ""
+	if sender := rm.GetPlayer(c.playerID); sender != nil {
+		senderLang = sender.GetLanguage()
+	}
+
+	// Broadcast chat message asynchronously to the channel, skipping players who blocked the sender
+	go func() {
+		skip := combineSkips(skip, blockedBy(room, c.playerID))
+		// Translate per recipient language when a provider is configured
+		if t := getTranslator(); t != nil {
+			broadcastChatTranslated(t, room, senderLang, chatMessage, skip)
+			return
 		}
+		broadcastToRoomFiltered(room, c.playerID, chatMessage, skip)
+	}()
+}
+
+// handlePrivateMessage processes private messages between players
+func (c *Connection) handlePrivateMessage(rm *RoomManager, message WebSocketMessage) {
+	// Length and required fields are checked by the message schema
+	if message.TargetPlayerID == "" {
+		// Looking up the account may hit the database, so it runs off the read loop
+		go c.whisperByUsername(rm, message)
+		return
+	}
+	if message.TargetPlayerID == c.playerID {
+		c.logger.Debug("Private message addressed to self")
+		return
+	}
+
+	// Online targets get the message now; registered but offline players get it in their inbox
+	conn, online := connectionPool.getConnection(message.TargetPlayerID)
+	if !online {
+		exists, err := config.UserExists(message.TargetPlayerID)
+		if err != nil || !exists {
+			c.logger.Debug("Private message target not found", "target_id", message.TargetPlayerID)
+			c.sendMessage(WebSocketMessage{
+				Type:      "private_message_error",
+				PlayerID:  "system",
+				Text:      "Player not found",
+				Timestamp: time.Now().UnixMilli(),
+			})
+			return
+		}
+	}
+
+	// Private messages are always filtered since there's no room setting to opt out
+	text, allowed := c.applyContentFilter(message.Text)
+	if !allowed {
 		return
 	}
 
@@ -436,136 +1068,353 @@ func (c *Connection) handlePrivateMessage(rm *RoomManager, message WebSocketMess
 		Type:           "private_message",
 		PlayerID:       c.playerID,
 		TargetPlayerID: message.TargetPlayerID,
-		Text:           message.Text,
+		Text:           text,
 		Username:       message.Username,
 		Timestamp:      time.Now().UnixMilli(),
+		System:         c.isService,
 	}
 
-	// Send to target player directly
-	if conn, exists := connectionPool.getConnection(message.TargetPlayerID); exists {
-		data, err := json.Marshal(privateMessage)
-		if err == nil {
-			select {
-			case conn.send <- data:
-			default:
-				log.Printf("Send channel full for player %s, dropping private message", message.TargetPlayerID)
+	// Blocked senders still get the normal confirmation so they can't tell they've been blocked
+	status := "delivered"
+	switch {
+	case config.GetBlockStore().IsBlocked(message.TargetPlayerID, c.playerID):
+		c.logger.Debug("Private message dropped (blocked)", "target_id", message.TargetPlayerID)
+	case online:
+		conn.sendMessage(privateMessage)
+	default:
+		err := config.SaveOfflineMessage(config.OfflineMessage{
+			SenderID:    c.playerID,
+			RecipientID: message.TargetPlayerID,
+			Username:    message.Username,
+			Text:        text,
+			System:      c.isService,
+		})
+		if err != nil {
+			c.logger.Warn("Could not queue offline private message", "target_id", message.TargetPlayerID, "error", err)
+			errorText := "Could not deliver message, please try again later"
+			if errors.Is(err, config.ErrInboxFull) {
+				errorText = "Player's inbox is full"
 			}
+			c.sendMessage(WebSocketMessage{
+				Type:           "private_message_error",
+				PlayerID:       "system",
+				TargetPlayerID: message.TargetPlayerID,
+				Text:           errorText,
+				Timestamp:      time.Now().UnixMilli(),
+			})
+			return
 		}
-	} else {
-		log.Printf("Player %s not connected, cannot send private message", message.TargetPlayerID)
+		status = "queued"
+		title := message.Username
+		if title == "" {
+			title = "New message"
+		}
+		config.SendPushAsync(message.TargetPlayerID, config.NotifyPrivateMessage, config.PushNotification{
+			Title: title,
+			Body:  text,
+			Data:  map[string]string{"sender_id": c.playerID},
+		})
 	}
 
 	// Send confirmation to sender directly
-	confirmationMessage := WebSocketMessage{
+	confirmationText := "Message sent successfully"
+	if status == "queued" {
+		confirmationText = "Player is offline, message will be delivered when they connect"
+	}
+	c.sendMessage(WebSocketMessage{
 		Type:           "private_message_sent",
 		PlayerID:       "system",
 		TargetPlayerID: message.TargetPlayerID,
-		Text:           "Message sent successfully",
+		TargetUsername: message.TargetUsername,
+		Text:           confirmationText,
+		Status:         status,
 		Timestamp:      time.Now().UnixMilli(),
+	})
+
+	c.logger.Debug("Private message sent", "target_id", message.TargetPlayerID)
+}
+
+// whisperByUsername resolves a private message's target_username and sends it on. A
+// username several accounts share is rejected rather than guessed at.
+func (c *Connection) whisperByUsername(rm *RoomManager, message WebSocketMessage) {
+	defer c.recoverPanic("whisperByUsername")
+
+	targetIDs, err := c.resolveUsername(message.TargetUsername)
+	errorText := ""
+	switch {
+	case err != nil:
+		c.logger.Warn("Could not look up private message target", "target_username", message.TargetUsername, "error", err)
+		errorText = "Could not deliver message, please try again later"
+	case len(targetIDs) == 0:
+		errorText = "Player not found"
+	case len(targetIDs) > 1:
+		errorText = "Ambiguous username, several players have it"
 	}
-	data, err := json.Marshal(confirmationMessage)
-	if err == nil {
-		select {
-		case c.send <- data:
-		default:
-			log.Printf("Send channel full for player %s, dropping confirmation message", c.playerID)
+	if errorText != "" {
+		c.logger.Debug("Private message target not resolved", "target_username", message.TargetUsername, "matches", len(targetIDs))
+		c.sendMessage(WebSocketMessage{
+			Type:           "private_message_error",
+			PlayerID:       "system",
+			TargetUsername: message.TargetUsername,
+			Text:           errorText,
+			Timestamp:      time.Now().UnixMilli(),
+		})
+		return
+	}
+
+	message.TargetPlayerID = targetIDs[0]
+	c.handlePrivateMessage(rm, message)
+}
+
+// resolveUsername returns the IDs of the accounts with the username (ignoring case),
+// whatever room or instance the players are in, or whether they're online at all. Players
+// connected here are matched first; two of them is already ambiguous, so the account
+// store is only asked when that doesn't settle it. At most two IDs are returned.
+func (c *Connection) resolveUsername(username string) ([]string, error) {
+	username = strings.TrimSpace(username)
+	var matches []string
+	for _, conn := range connectionPool.snapshot() {
+		if name := conn.accountName.Load(); name != nil && strings.EqualFold(*name, username) {
+			if matches = append(matches, conn.playerID); len(matches) > 1 {
+				return matches, nil
+			}
 		}
 	}
 
-	log.Printf("Private message sent from %s to %s", c.playerID, message.TargetPlayerID)
+	ctx, cancel := context.WithTimeout(c.ctx, UsernameLookupTimeout)
+	defer cancel()
+	accountIDs, err := config.GetUserStore().FindByUsername(ctx, username, 2)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range accountIDs {
+		if !slices.Contains(matches, id) {
+			matches = append(matches, id)
+		}
+	}
+	return matches, nil
+}
+
+// blockedBy returns a recipient filter that skips the room's players who blocked senderID.
+// Their block lists are read now, which may hit the database, so call it off the read loop;
+// the filter itself only checks a snapshot and is quick enough for the room's hub.
+func blockedBy(room *Room, senderID string) func(playerID string) bool {
+	room.mu.RLock()
+	playerIDs := make([]string, 0, len(room.Players))
+	for playerID := range room.Players {
+		if playerID != senderID {
+			playerIDs = append(playerIDs, playerID)
+		}
+	}
+	room.mu.RUnlock()
+
+	blocks := config.GetBlockStore()
+	blockers := make(map[string]bool)
+	for _, playerID := range playerIDs {
+		if blocks.IsBlocked(playerID, senderID) {
+			blockers[playerID] = true
+		}
+	}
+	return func(playerID string) bool { return blockers[playerID] }
 }
 
 // handleDisconnect cleans up when player disconnects
 func (c *Connection) handleDisconnect(rm *RoomManager) {
+	// A newer connection for this player took over; the room state is theirs now
+	if current, exists := connectionPool.getConnection(c.playerID); exists && current != c {
+		return
+	}
+
 	room := rm.GetPlayerRoom(c.playerID)
+	if room == nil {
+		revokeResumeToken(c.playerID)
+		return
+	}
+
+	// Brief drops can resume without the room noticing
+	if getResumeWindow() > 0 {
+		rm.holdForResume(room, c.playerID)
+		return
+	}
+	rm.removeDisconnectedPlayer(room, c.playerID)
+}
+
+// removeDisconnectedPlayer drops a player from the room and notifies the others
+func (rm *RoomManager) removeDisconnectedPlayer(room *Room, playerID string) {
+	revokeResumeToken(playerID)
+
+	hidden := false
+	var freedSeat *RoomObject
+	room.mu.Lock()
+	if player, exists := room.Players[playerID]; exists {
+		hidden = player.Hidden
+		delete(room.Players, playerID)
+		room.removeInterestLocked(playerID)
+		freedSeat = room.vacateSeatLocked(playerID)
+		slog.Info("Removed player from room", "player_id", playerID, "room_id", room.ID, "remaining", len(room.Players))
+	}
+	room.mu.Unlock()
+
+	if freedSeat != nil {
+		broadcastObjectUpdate(room, *freedSeat)
+	}
+
+	if hidden {
+		return
+	}
+
+	// Notify other players asynchronously
+	leaveMessage := WebSocketMessage{
+		Type:      "player_left",
+		PlayerID:  playerID,
+		Timestamp: time.Now().UnixMilli(),
+	}
+	go broadcastToRoomAsync(room, playerID, leaveMessage)
+}
+
+// closeWithNotice sends a final system message and closes the connection shortly after,
+// giving writePump a chance to flush it
+func (c *Connection) closeWithNotice(messageType, text string) {
+	c.sendMessage(WebSocketMessage{
+		Type:      messageType,
+		PlayerID:  "system",
+		Text:      text,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	time.AfterFunc(time.Second, c.cancel)
+}
+
+// KickPlayer removes a player from their room, notifies the room, and closes their
+// connection on behalf of actorID
+func KickPlayer(actorID, playerID, reason string) {
+	room := evictPlayer(playerID, "kicked", reason)
+
+	details := map[string]interface{}{"reason": reason}
 	if room != nil {
-		room.mu.Lock()
-		if _, exists := room.Players[c.playerID]; exists {
-			delete(room.Players, c.playerID)
-			log.Printf("Removed player %s from room %s. Remaining players: %d",
-				c.playerID, room.ID, len(room.Players))
-		}
-		room.mu.Unlock()
+		details["room_id"] = room.ID
+	}
+	config.RecordAudit(context.Background(), actorID, config.AuditKick, playerID, details)
+}
+
+// evictPlayer removes a player from their room, closes their connection with a notice of
+// messageType, and tells the room they left. Returns the room they were in, if any.
+func evictPlayer(playerID, messageType, text string) *Room {
+	rm := GetRoomManager()
+	room := rm.GetPlayerRoom(playerID)
+	rm.RemovePlayerOptimized(playerID)
 
-		// Notify other players asynchronously
+	if conn, exists := connectionPool.getConnection(playerID); exists {
+		conn.closeWithNotice(messageType, text)
+	}
+
+	if room != nil {
 		leaveMessage := WebSocketMessage{
 			Type:      "player_left",
-			PlayerID:  c.playerID,
+			PlayerID:  playerID,
 			Timestamp: time.Now().UnixMilli(),
 		}
-		go broadcastToRoomAsync(room, c.playerID, leaveMessage)
+		go broadcastToRoomAsync(room, playerID, leaveMessage)
 	}
+	return room
 }
 
-// sendBatchedMessages sends multiple messages efficiently
-func (c *Connection) sendBatchedMessages(messages []WebSocketMessage) {
-	if len(messages) == 0 {
+// sendMessage marshals a single message and queues it without blocking
+func (c *Connection) sendMessage(message WebSocketMessage) {
+	if reliableTypes[message.Type] && c.reliableDelivery() {
+		c.sendReliable(message)
 		return
 	}
 
-	batchedMessage := BatchedMessage{
-		Type:     "batch",
-		Messages: messages,
-		Count:    len(messages),
+	message, ok := c.adaptOutgoing(message)
+	if !ok {
+		return
 	}
 
-	data, err := json.Marshal(batchedMessage)
+	data, err := c.codec.Marshal(message)
 	if err != nil {
-		log.Printf("Error marshaling batch for player %s: %v", c.playerID, err)
+		c.logger.Error("Error marshaling message", "type", message.Type, "error", err)
 		return
 	}
 
-	select {
-	case c.send <- data:
-	default:
-		log.Printf("Send channel full for player %s, dropping batch", c.playerID)
+	if c.enqueue(data) {
+		wsMessagesSent.WithLabelValues(message.Type).Inc()
+	} else {
+		wsMessagesDropped.WithLabelValues(message.Type).Inc()
 	}
 }
 
-// broadcastToRoomAsync broadcasts message to all players in room asynchronously
-func broadcastToRoomAsync(room *Room, excludePlayerID string, message WebSocketMessage) {
-	room.mu.RLock()
-	var targets []*Connection
+// sendBatchedMessages sends multiple messages efficiently
+func (c *Connection) sendBatchedMessages(messages []WebSocketMessage) {
+	c.sendBatch("batch", messages)
+}
 
-	for playerID := range room.Players {
-		if playerID != excludePlayerID {
-			if conn, exists := connectionPool.getConnection(playerID); exists {
-				targets = append(targets, conn)
-			}
+// sendBatch sends messages as one BatchedMessage of the given type
+func (c *Connection) sendBatch(batchType string, messages []WebSocketMessage) {
+	c.sendBatchAt(batchType, messages, 0)
+}
+
+// sendBatchAt is sendBatch for a batch stamped with the room's state version
+func (c *Connection) sendBatchAt(batchType string, messages []WebSocketMessage, version uint64) {
+	if len(messages) == 0 {
+		return
+	}
+
+	adapted := make([]WebSocketMessage, 0, len(messages))
+	for _, message := range messages {
+		if message, ok := c.adaptOutgoing(message); ok {
+			adapted = append(adapted, message)
 		}
 	}
-	room.mu.RUnlock()
 
-	// Send to all targets concurrently
-	data, err := json.Marshal(message)
+	batchedMessage := BatchedMessage{
+		Type:         batchTypeForVersion(c.version(), batchType),
+		Messages:     adapted,
+		Count:        len(adapted),
+		StateVersion: version,
+	}
+
+	data, err := c.codec.Marshal(batchedMessage)
 	if err != nil {
-		log.Printf("Error marshaling message: %v", err)
+		c.logger.Error("Error marshaling batch", "error", err)
 		return
 	}
 
-	var wg sync.WaitGroup
-	for _, conn := range targets {
-		wg.Add(1)
-		go func(c *Connection) {
-			defer wg.Done()
-			select {
-			case c.send <- data:
-			default:
-				log.Printf("Send channel full for player %s, dropping message", c.playerID)
-			}
-		}(conn)
+	if c.enqueue(data) {
+		wsMessagesSent.WithLabelValues(batchedMessage.Type).Inc()
+	} else {
+		wsMessagesDropped.WithLabelValues(batchedMessage.Type).Inc()
 	}
-	wg.Wait()
+}
+
+// broadcastToRoomAsync broadcasts message to all players in room asynchronously
+func broadcastToRoomAsync(room *Room, excludePlayerID string, message WebSocketMessage) {
+	broadcastToRoomFiltered(room, excludePlayerID, message, nil)
+}
+
+// broadcastToRoomFiltered is broadcastToRoomAsync that also skips recipients for which skip returns true
+func broadcastToRoomFiltered(room *Room, excludePlayerID string, message WebSocketMessage, skip func(playerID string) bool) {
+	deliverToRoom(room, excludePlayerID, message, skip)
+	publishRoomBroadcast(room.ID, excludePlayerID, message)
+}
+
+// deliverToRoom sends a message to the room's players connected to this instance. The
+// room's hub stamps its state version and records it for replay.
+func deliverToRoom(room *Room, excludePlayerID string, message WebSocketMessage, skip func(playerID string) bool) {
+	room.hub().send(hubBroadcast{message: message, exclude: excludePlayerID, skip: skip})
 }
 
 // GetConnectionStats returns WebSocket connection statistics
 func GetConnectionStats() map[string]interface{} {
+	avgRTT := connectionPool.averageRTTMillis()
+
 	connectionPool.mu.RLock()
 	defer connectionPool.mu.RUnlock()
 
 	return map[string]interface{}{
+		"queued_connections":  len(connectionPool.waiting),
 		"active_connections":  connectionPool.count,
-		"max_connections":     MaxConcurrentConnections,
-		"utilization_percent": float64(connectionPool.count) / float64(MaxConcurrentConnections) * 100,
+		"max_connections":     settings.WebSocket.MaxConnections,
+		"utilization_percent": float64(connectionPool.count) / float64(settings.WebSocket.MaxConnections) * 100,
+		"avg_rtt_ms":          avgRTT,
 	}
 }
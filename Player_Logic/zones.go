@@ -0,0 +1,150 @@
+package Player_Logic
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Zones are the named areas of a room's layout. When a position update carries a player
+// across a zone boundary the room gets "zone_left"/"zone_entered" (Zone is the zone ID,
+// Text its name; hidden players only hear about their own moves), registered ZoneHandlers
+// run, and chat sent to the "zone" channel reaches only players sharing a zone with the
+// sender.
+const ZoneChannel = "zone"
+
+// ErrNotInZone is returned when posting to the zone channel from outside every zone
+var ErrNotInZone = errors.New("you are not in a zone")
+
+// ZoneHandler is called after a player enters (entered=true) or leaves a zone
+type ZoneHandler func(room *Room, playerID string, zone LayoutZone, entered bool)
+
+var zoneHandlers struct {
+	handlers []ZoneHandler
+	mu       sync.RWMutex
+}
+
+// AddZoneHandler registers a callback for zone transitions (e.g. triggers, ambient audio)
+func AddZoneHandler(handler ZoneHandler) {
+	zoneHandlers.mu.Lock()
+	zoneHandlers.handlers = append(zoneHandlers.handlers, handler)
+	zoneHandlers.mu.Unlock()
+}
+
+// zoneChange is one boundary crossing found by updateZonesLocked
+type zoneChange struct {
+	zone    LayoutZone
+	entered bool
+}
+
+// Zone returns the layout's zone with the given ID
+func (l *RoomLayout) Zone(id string) (LayoutZone, bool) {
+	for _, zone := range l.Zones {
+		if zone.ID == id {
+			return zone, true
+		}
+	}
+	return LayoutZone{}, false
+}
+
+// ZonesAt returns the IDs of the zones containing a position
+func (l *RoomLayout) ZonesAt(position Position) []string {
+	var ids []string
+	for _, zone := range l.Zones {
+		if zone.Area.Contains(position) {
+			ids = append(ids, zone.ID)
+		}
+	}
+	return ids
+}
+
+// updateZonesLocked recomputes the zones the player stands in and returns the zones left
+// followed by the zones entered (caller holds r.mu)
+func (r *Room) updateZonesLocked(player *Player) []zoneChange {
+	layout := r.layoutLocked()
+	if len(layout.Zones) == 0 && len(player.zones) == 0 {
+		return nil
+	}
+
+	current := layout.ZonesAt(player.Position)
+	var changes []zoneChange
+	for _, id := range player.zones {
+		if !slices.Contains(current, id) {
+			zone, _ := layout.Zone(id)
+			zone.ID = id
+			changes = append(changes, zoneChange{zone: zone})
+		}
+	}
+	for _, id := range current {
+		if !slices.Contains(player.zones, id) {
+			zone, _ := layout.Zone(id)
+			changes = append(changes, zoneChange{zone: zone, entered: true})
+		}
+	}
+	player.zones = current
+	return changes
+}
+
+// PlayerZones returns the IDs of the zones a player is standing in
+func (r *Room) PlayerZones(playerID string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if player, exists := r.Players[playerID]; exists {
+		return slices.Clone(player.zones)
+	}
+	return nil
+}
+
+// notifyZoneChanges announces a player's zone crossings and runs the zone handlers
+func notifyZoneChanges(room *Room, playerID string, hidden bool, changes []zoneChange) {
+	zoneHandlers.mu.RLock()
+	handlers := zoneHandlers.handlers
+	zoneHandlers.mu.RUnlock()
+
+	for _, change := range changes {
+		message := WebSocketMessage{
+			Type:      "zone_left",
+			PlayerID:  playerID,
+			RoomID:    room.ID,
+			Zone:      change.zone.ID,
+			Text:      change.zone.Name,
+			Timestamp: time.Now().UnixMilli(),
+		}
+		if change.entered {
+			message.Type = "zone_entered"
+		}
+		if hidden {
+			if conn, exists := connectionPool.getConnection(playerID); exists {
+				conn.sendMessage(message)
+			}
+		} else {
+			broadcastToRoomAsync(room, "", message)
+		}
+
+		for _, handler := range handlers {
+			handler(room, playerID, change.zone, change.entered)
+		}
+	}
+}
+
+// zoneAudience returns a skip function leaving out everyone who shares no zone with the sender
+func (r *Room) zoneAudience(senderID string) (func(string) bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sender, exists := r.Players[senderID]
+	if !exists || len(sender.zones) == 0 {
+		return nil, ErrNotInZone
+	}
+	audience := make(map[string]bool)
+	for id, player := range r.Players {
+		for _, zone := range player.zones {
+			if slices.Contains(sender.zones, zone) {
+				audience[id] = true
+				break
+			}
+		}
+	}
+	return func(id string) bool { return !audience[id] }, nil
+}
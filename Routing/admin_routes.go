@@ -0,0 +1,286 @@
+package Routing
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"strconv"
+	"time"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+// SetupAdminRoutes configures all admin-only routes (guarded by the admin API key)
+func SetupAdminRoutes() *config.Router {
+	router := config.NewRouter("/admin")
+
+	// Every admin route needs the admin key and is written to the audit log
+	router.Use(config.Recover, config.RequireAdmin)
+
+	// List active global bans
+	router.Get("/bans", handleListBans)
+
+	// Issue a temporary or permanent global ban
+	router.Post("/bans/issue", handleIssueBan)
+
+	// Lift a global ban
+	router.Post("/bans/lift", handleLiftBan)
+
+	// Scheduled broadcasts (POST to schedule, GET to list, DELETE ?id= to cancel)
+	router.Post("/broadcasts", handleScheduleBroadcast)
+	router.Get("/broadcasts", handleListScheduledBroadcasts)
+	router.Delete("/broadcasts", handleCancelBroadcast)
+
+	// Referral performance report
+	router.Get("/referrals", handleReferralReport)
+
+	// Players whose position updates were clamped (speed/bounds), for anti-cheat review
+	router.Get("/movement-violations", handleMovementViolations)
+
+	// Give a player inventory items
+	router.Post("/inventory/grant", handleGrantItem)
+
+	// Credit or debit a player's coins
+	router.Post("/wallet/adjust", handleAdjustWallet)
+
+	// Room layouts (POST to create or replace, GET to list, DELETE ?id= to remove)
+	router.Post("/layouts", handleSaveLayout)
+	router.Get("/layouts", handleAdminListLayouts)
+	router.Delete("/layouts", handleDeleteLayout)
+
+	// Server events (POST to schedule, GET to list, DELETE ?id= to cancel)
+	router.Post("/events", handleScheduleEvent)
+	router.Get("/events", handleAdminListEvents)
+	router.Delete("/events", handleCancelEvent)
+
+	// Rooms, connections, database, and runtime in one payload for the ops dashboard
+	router.Get("/overview", handleAdminOverview)
+
+	// Moderation and admin action history (GET, ?actor= ?target= ?action= ?before= ?limit=)
+	router.Get("/audit", handleAuditLog)
+
+	// Live rooms, players, and connections
+	registerRoomAdminRoutes(router)
+
+	// Player report moderation queue
+	registerReportAdminRoutes(router)
+
+	// pprof and runtime diagnostics
+	registerDebugRoutes(router)
+
+	return router
+}
+
+// handleListBans returns all bans that currently apply
+func handleListBans(w http.ResponseWriter, r *http.Request) {
+	bans, err := config.GetBanStore().ListActiveBans()
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"bans": bans})
+}
+
+// handleIssueBan bans a user globally; duration_minutes of 0 makes the ban permanent
+func handleIssueBan(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		UserId          string `json:"userId"`
+		Reason          string `json:"reason"`
+		IssuedBy        string `json:"issued_by"`
+		DurationMinutes int    `json:"duration_minutes"`
+	}
+	var body reqBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		config.Logger(r.Context()).Warn("Decode error", "error", err)
+		config.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if body.UserId == "" || body.Reason == "" {
+		config.Error(w, "userId and reason are required", http.StatusBadRequest)
+		return
+	}
+	if body.DurationMinutes < 0 {
+		config.Error(w, "duration_minutes must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	if body.IssuedBy == "" {
+		body.IssuedBy = config.AdminActor(r)
+	}
+	ban, err := config.GetBanStore().IssueBan(body.UserId, body.Reason, body.IssuedBy,
+		time.Duration(body.DurationMinutes)*time.Minute)
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// Kick the user out of whatever room they're in right now
+	Player_Logic.KickPlayer(body.IssuedBy, body.UserId, "Your account has been banned: "+body.Reason)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "ban": ban})
+}
+
+// handleLiftBan revokes a user's active global bans
+func handleLiftBan(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		UserId string `json:"userId"`
+	}
+	var body reqBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		config.Logger(r.Context()).Warn("Decode error", "error", err)
+		config.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if body.UserId == "" {
+		config.Error(w, "userId is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := config.GetBanStore().LiftBan(body.UserId, config.AdminActor(r)); err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// handleReferralReport returns per-referrer referral counts (?limit=, default 50)
+func handleReferralReport(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > 500 {
+			config.Error(w, "limit must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	stats, err := config.GetReferralStats(limit)
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"referrers": stats})
+}
+
+// handleMovementViolations lists players with movement violations (?flagged=1 for repeat offenders only)
+func handleMovementViolations(w http.ResponseWriter, r *http.Request) {
+	violations := Player_Logic.GetMovementMonitor().List()
+	if r.URL.Query().Get("flagged") == "1" {
+		flagged := violations[:0]
+		for _, v := range violations {
+			if v.Flagged {
+				flagged = append(flagged, v)
+			}
+		}
+		violations = flagged
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"violations": violations})
+}
+
+// handleScheduleBroadcast schedules an admin broadcast
+func handleScheduleBroadcast(w http.ResponseWriter, r *http.Request) {
+	var body Player_Logic.ScheduledBroadcast
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		config.Logger(r.Context()).Warn("Decode error", "error", err)
+		config.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	job, err := Player_Logic.ScheduleBroadcast(body)
+	if err != nil {
+		config.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	config.RecordAudit(r.Context(), config.AdminActor(r), config.AuditAnnouncement, job.ID, map[string]interface{}{
+		"kind":     body.Kind,
+		"text":     body.Text,
+		"everyone": body.Everyone,
+		"room_ids": body.RoomIDs,
+		"send_at":  body.SendAt,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "job": job})
+}
+
+// handleListScheduledBroadcasts returns the broadcasts waiting to be sent
+func handleListScheduledBroadcasts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"broadcasts": Player_Logic.GetScheduler().List(Player_Logic.BroadcastJobKind),
+	})
+}
+
+// handleCancelBroadcast cancels a scheduled broadcast (?id=)
+func handleCancelBroadcast(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		config.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+	if !Player_Logic.GetScheduler().Cancel(id) {
+		config.Error(w, "Broadcast not found or already sent", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// handleAdminOverview combines room, connection, database, and runtime stats with every
+// room's player list
+func handleAdminOverview(w http.ResponseWriter, r *http.Request) {
+	rooms := roomManager.ListRooms()
+	roomDetails := make([]map[string]interface{}, 0, len(rooms))
+	for _, room := range rooms {
+		players, err := roomManager.ListRoomPlayers(room.ID)
+		if err != nil {
+			continue // Closed since it was listed
+		}
+		roomDetails = append(roomDetails, map[string]interface{}{
+			"room":    room,
+			"players": players,
+		})
+	}
+
+	dbStats := config.GetDBStats()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"generated_at": time.Now(),
+		"room_manager": roomManager.GetManagerStats(),
+		"connections":  Player_Logic.GetConnectionStats(),
+		"database": map[string]interface{}{
+			"available":            config.DB != nil,
+			"max_open_connections": dbStats.MaxOpenConnections,
+			"open_connections":     dbStats.OpenConnections,
+			"in_use":               dbStats.InUse,
+			"idle":                 dbStats.Idle,
+			"wait_count":           dbStats.WaitCount,
+			"wait_duration_ms":     dbStats.WaitDuration.Milliseconds(),
+			"async_worker":         config.GetAsyncWorkerStats(),
+		},
+		"runtime": map[string]interface{}{
+			"uptime_seconds":   int64(time.Since(startedAt).Seconds()),
+			"goroutines":       runtime.NumGoroutine(),
+			"heap_alloc_bytes": mem.HeapAlloc,
+			"sys_bytes":        mem.Sys,
+			"num_gc":           mem.NumGC,
+		},
+		"rooms": roomDetails,
+	})
+}
@@ -0,0 +1,49 @@
+package Routing
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"velvet/config"
+)
+
+// handleAuditLog returns audit log entries, newest first, paged with ?before=
+func handleAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := config.AuditFilter{
+		ActorID:  query.Get("actor"),
+		TargetID: query.Get("target"),
+		Action:   query.Get("action"),
+		Limit:    config.DefaultAuditListLimit,
+	}
+	if value := query.Get("before"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			config.Error(w, "before must be an audit entry id", http.StatusBadRequest)
+			return
+		}
+		filter.Before = parsed
+	}
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > config.MaxAuditListLimit {
+			config.Error(w, "limit must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = parsed
+	}
+
+	if config.DB == nil {
+		config.Error(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+	entries, err := config.ListAuditLog(r.Context(), filter)
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"entries": entries})
+}
@@ -1,9 +1,11 @@
 package Routing
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
 	"net/http"
+	"velvet/Player_Logic"
 	"velvet/config"
 )
 
@@ -11,30 +13,37 @@ import (
 func SetupAuthRoutes() *config.Router {
 	router := config.NewRouter("/auth")
 
+	// Outermost, so panics anywhere below become a 500
+	router.Use(config.Recover)
+
+	// Throttle sign-up/sign-in probing per IP and per player
+	router.UseFor("", config.RateLimit(
+		config.NewRateLimiterFromConfig(settings.Security.AuthIPLimit),
+		config.NewRateLimiterFromConfig(settings.Security.AuthPlayerLimit),
+	))
+
 	// User exists endpoint
-	router.HandleFunc("/user-exists", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
+	router.Post("/user-exists", func(w http.ResponseWriter, r *http.Request) {
 		type reqBody struct {
 			UserId string `json:"userId"`
 		}
 		var body reqBody
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-			log.Println("Decode error:", err)
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			config.Logger(r.Context()).Warn("Decode error", "error", err)
+			config.Error(w, "Invalid request body", http.StatusBadRequest)
 			return
 		}
 		if body.UserId == "" {
-			http.Error(w, "userId is required", http.StatusBadRequest)
+			config.Error(w, "userId is required", http.StatusBadRequest)
+			return
+		}
+		if rejectInactiveAccount(w, body.UserId) {
 			return
 		}
-		var exists bool
-		err := config.DB.QueryRow(`SELECT EXISTS (SELECT 1 FROM "User" WHERE "userId" = $1)`, body.UserId).Scan(&exists)
+		exists, err := config.GetUserStore().Exists(r.Context(), body.UserId)
 		if err != nil {
-			log.Println("Database error:", err)
-			http.Error(w, "Database error", http.StatusInternalServerError)
+			config.Logger(r.Context()).Error("Database error", "error", err)
+			config.Error(w, "Database error", http.StatusInternalServerError)
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
@@ -42,89 +51,228 @@ func SetupAuthRoutes() *config.Router {
 	})
 
 	// Update or insert user endpoint
-	router.HandleFunc("/update-user", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
+	router.Post("/update-user", func(w http.ResponseWriter, r *http.Request) {
 		type reqBody struct {
-			UserId     string `json:"userId"`
-			Username   string `json:"username"`
-			Gender     string `json:"gender"`
-			Email      string `json:"email"`
-			ProfilePic string `json:"profile_pic"`
+			UserId     string `json:"userId" validate:"required,max=128"`
+			Username   string `json:"username" validate:"required,max=32"`
+			Gender     string `json:"gender" validate:"required,oneof=male female other"`
+			Email      string `json:"email" validate:"max=254,email"`
+			ProfilePic string `json:"profile_pic" validate:"max=2048"`
+			// Optional referral code, only honored when this call registers the user
+			ReferralCode string `json:"referral_code" validate:"max=64"`
 		}
 		var body reqBody
-		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-			log.Println("Decode error:", err)
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+		if !decodeBody(w, r, &body) {
 			return
 		}
-		if body.UserId == "" || body.Username == "" || body.Gender == "" {
-			http.Error(w, "userId, username, and gender are required", http.StatusBadRequest)
+		if rejectInactiveAccount(w, body.UserId) {
 			return
 		}
-		_, err := config.DB.Exec(`
-			INSERT INTO "User" ("userId", username, gender, email, profile_pic)
-			VALUES ($1, $2, $3, $4, $5)
-			ON CONFLICT ("userId") DO UPDATE SET username = $2, gender = $3, email = $4, profile_pic = $5
-		`, body.UserId, body.Username, body.Gender, body.Email, body.ProfilePic)
+		// The account and its referral are written together
+		err := config.WithTx(r.Context(), func(ctx context.Context) error {
+			inserted, err := config.GetUserStore().Upsert(ctx, config.User{
+				ID:         body.UserId,
+				Username:   body.Username,
+				Gender:     body.Gender,
+				Email:      body.Email,
+				ProfilePic: body.ProfilePic,
+			})
+			if err != nil {
+				return err
+			}
+
+			// Track referrals at registration; a bad code never blocks signup, so the
+			// referral gets its own savepoint
+			if inserted && body.ReferralCode != "" {
+				err := config.WithTx(ctx, func(ctx context.Context) error {
+					_, err := config.RecordReferral(ctx, body.ReferralCode, body.UserId, config.ClientIP(r), r.Header.Get(config.DeviceIDHeader))
+					return err
+				})
+				if err != nil {
+					config.Logger(r.Context()).Warn("Referral for new user not recorded", "user_id", body.UserId, "error", err)
+				}
+			}
+			return nil
+		})
 		if err != nil {
-			log.Println("Database error:", err)
-			http.Error(w, "Database error", http.StatusInternalServerError)
+			config.Logger(r.Context()).Error("Database error", "error", err)
+			config.Error(w, "Database error", http.StatusInternalServerError)
 			return
 		}
+		Player_Logic.SetAccountName(body.UserId, body.Username)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]bool{"success": true})
 	})
 
 	// Get user data by userId
-	router.HandleFunc("/get-user", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
+	router.Post("/get-user", func(w http.ResponseWriter, r *http.Request) {
 		type reqBody struct {
 			UserId string `json:"userId"`
 		}
 		var body reqBody
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-			log.Println("Decode error:", err)
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			config.Logger(r.Context()).Warn("Decode error", "error", err)
+			config.Error(w, "Invalid request body", http.StatusBadRequest)
 			return
 		}
 		if body.UserId == "" {
-			http.Error(w, "userId is required", http.StatusBadRequest)
+			config.Error(w, "userId is required", http.StatusBadRequest)
+			return
+		}
+		if rejectInactiveAccount(w, body.UserId) {
+			return
+		}
+		logger := config.Logger(r.Context()).With("user_id", body.UserId)
+		user, err := config.GetUserStore().Get(r.Context(), body.UserId)
+		if errors.Is(err, config.ErrUserNotFound) {
+			config.Error(w, "User not found", http.StatusNotFound)
 			return
 		}
-		var username, gender, email, profilePic string
-		var lastRoom *string
-
-		log.Printf("🔍 Fetching user data for userId: %s", body.UserId)
-		err := config.DB.QueryRow(`SELECT username, gender, email, profile_pic, last_room FROM "User" WHERE "userId" = $1`, body.UserId).Scan(&username, &gender, &email, &profilePic, &lastRoom)
 		if err != nil {
-			log.Printf("❌ Database error getting user %s: %v", body.UserId, err)
-			http.Error(w, "Database error", http.StatusInternalServerError)
+			logger.Error("Database error getting user", "error", err)
+			config.Error(w, "Database error", http.StatusInternalServerError)
 			return
 		}
+		logger.Debug("Loaded user", "last_room", user.LastRoom)
+
+		// Storage usage is informational; don't fail the profile if it can't be read
+		usage, err := config.GetQuotaUsage(body.UserId)
+		if err != nil {
+			logger.Warn("Could not read storage usage", "error", err)
+		}
+
+		avatar := config.DefaultAvatar()
+		if user.Avatar != nil {
+			avatar = *user.Avatar
+		}
 
-		lastRoomStr := ""
-		if lastRoom != nil {
-			lastRoomStr = *lastRoom
-			log.Printf("✅ Found last_room for user %s: %s", body.UserId, lastRoomStr)
+		// Like usage, progress shouldn't fail the profile
+		var progress *Player_Logic.ProgressInfo
+		if info, err := Player_Logic.GetProgression().Get(r.Context(), body.UserId); err != nil {
+			logger.Warn("Could not read progress", "error", err)
 		} else {
-			log.Printf("⚠️ No last_room found for user %s", body.UserId)
+			progress = &info
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"username":    username,
-			"gender":      gender,
-			"email":       email,
-			"profile_pic": profilePic,
-			"last_room":   lastRoomStr,
+			"username":    user.Username,
+			"gender":      user.Gender,
+			"email":       user.Email,
+			"profile_pic": user.ProfilePic,
+			"last_room":   user.LastRoom,
+			"usage":       usage,
+			"avatar":      avatar,
+			"progress":    progress,
 		})
 	})
 
+	// Request an archive of everything stored about the caller (POST), or check on it (GET)
+	router.Post("/export-data", handleRequestDataExport)
+	router.Get("/export-data", handleDataExportStatus)
+
+	// Delete the caller's account, restorable until the grace period ends (DELETE)
+	router.Delete("/account", handleDeleteAccount)
+
+	// Undo an account deletion inside the grace period (POST)
+	router.Post("/account/restore", handleRestoreAccount)
+
 	return router
 }
+
+// handleDataExportStatus reports the status of the caller's latest data export
+func handleDataExportStatus(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	export, err := Player_Logic.GetDataExport(playerID)
+	if err != nil {
+		config.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"export": export})
+}
+
+// handleRequestDataExport starts a data export for the caller
+func handleRequestDataExport(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	export, err := Player_Logic.RequestDataExport(playerID)
+	switch {
+	case errors.Is(err, Player_Logic.ErrExportInProgress), errors.Is(err, Player_Logic.ErrExportTooSoon):
+		config.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, config.ErrStorageUnavailable), config.DB == nil:
+		config.Error(w, "Data export is not available", http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		config.Logger(r.Context()).Error("Could not start data export", "error", err)
+		config.Error(w, "Could not start data export", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{"export": export})
+}
+
+// handleDeleteAccount soft-deletes the caller's account and signs them out
+func handleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	deletion, err := Player_Logic.DeleteAccount(r.Context(), playerID)
+	if err != nil {
+		writeAccountError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":     true,
+		"purge_after": deletion.PurgeAfter,
+	})
+}
+
+// handleRestoreAccount cancels a pending account deletion
+func handleRestoreAccount(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := Player_Logic.RestoreAccount(r.Context(), playerID); err != nil {
+		writeAccountError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// writeAccountError maps account deletion errors to HTTP statuses
+func writeAccountError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, config.ErrUserNotFound), errors.Is(err, config.ErrNoPendingDeletion):
+		config.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, config.ErrDeletionPending):
+		config.Error(w, err.Error(), http.StatusConflict)
+	case config.DB == nil:
+		config.Error(w, "Database not available", http.StatusServiceUnavailable)
+	default:
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,145 @@
+package Routing
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+// registerAvatarRoutes adds the avatar endpoints to the player router
+func registerAvatarRoutes(router *config.Router) {
+	// GET returns the caller's avatar and the available parts. POST saves a new one, or
+	// with a multipart body uploads a profile picture.
+	router.Get("/avatar", handleGetAvatar)
+	router.Post("/avatar", handleSaveAvatar)
+}
+
+// handleGetAvatar returns the caller's avatar and the parts it can be built from
+func handleGetAvatar(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := config.GetUserStore().Get(r.Context(), playerID)
+	if errors.Is(err, config.ErrUserNotFound) {
+		config.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	avatar := config.DefaultAvatar()
+	if user.Avatar != nil {
+		avatar = *user.Avatar
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"avatar":  avatar,
+		"options": config.AvatarOptions(),
+	})
+}
+
+// handleSaveAvatar saves the caller's avatar, or uploads a profile picture from a multipart body
+func handleSaveAvatar(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		handleAvatarUpload(w, r, playerID)
+		return
+	}
+
+	var avatar config.Avatar
+	if err := json.NewDecoder(r.Body).Decode(&avatar); err != nil {
+		config.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := avatar.Validate(); err != nil {
+		config.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := config.GetUserStore().SetAvatar(r.Context(), playerID, avatar)
+	if errors.Is(err, config.ErrUserNotFound) {
+		config.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// Let the room see the new look right away
+	Player_Logic.UpdateAvatar(playerID, avatar)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "avatar": avatar})
+}
+
+// handleAvatarUpload stores the "image" file of a multipart upload as the caller's profile picture
+func handleAvatarUpload(w http.ResponseWriter, r *http.Request, playerID string) {
+	maxBytes := config.AvatarImageMaxBytes()
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+64<<10) // Room for multipart headers
+
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			config.Error(w, "Image is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		config.Error(w, "image file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		config.Error(w, "Invalid upload", http.StatusBadRequest)
+		return
+	}
+	if int64(len(data)) > maxBytes {
+		config.Error(w, "Image is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// Trust the bytes, not the client's declared type
+	contentType := http.DetectContentType(data)
+	if _, ok := config.AvatarImageExtension(contentType); !ok {
+		config.Error(w, "Image must be PNG, JPEG, GIF, or WebP", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	url, err := config.SaveAvatarImage(r.Context(), playerID, contentType, data)
+	if writeQuotaError(w, err) {
+		return
+	}
+	if errors.Is(err, config.ErrUserNotFound) {
+		config.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, config.ErrStorageUnavailable) {
+		config.Error(w, "Uploads are not available", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		config.Logger(r.Context()).Error("Avatar upload failed", "error", err)
+		config.Error(w, "Upload failed", http.StatusInternalServerError)
+		return
+	}
+
+	config.Logger(r.Context()).Info("Profile picture uploaded", "player_id", playerID, "bytes", len(data))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "profile_pic": url})
+}
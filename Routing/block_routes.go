@@ -0,0 +1,71 @@
+package Routing
+
+import (
+	"encoding/json"
+	"net/http"
+	"velvet/config"
+)
+
+// registerBlockRoutes adds the block list endpoints to the player router
+func registerBlockRoutes(router *config.Router) {
+	router.Post("/block", handleBlockAction)
+	router.Post("/unblock", handleBlockAction)
+	router.Get("/blocked", handleListBlocked)
+}
+
+// handleBlockAction blocks or unblocks a user based on the path
+func handleBlockAction(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	type RequestBody struct {
+		TargetID string `json:"target_id"`
+	}
+	var body RequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.TargetID == "" {
+		config.Error(w, "target_id is required", http.StatusBadRequest)
+		return
+	}
+
+	blocks := config.GetBlockStore()
+	var err error
+	if r.URL.Path == "/player/block" {
+		if body.TargetID == playerID {
+			config.Error(w, "Cannot block yourself", http.StatusBadRequest)
+			return
+		}
+		err = blocks.Block(playerID, body.TargetID)
+	} else {
+		err = blocks.Unblock(playerID, body.TargetID)
+	}
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// handleListBlocked returns the caller's block list
+func handleListBlocked(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	blocked, err := config.GetBlockStore().ListBlocked(playerID)
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"blocked": blocked})
+}
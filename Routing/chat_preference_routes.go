@@ -0,0 +1,53 @@
+package Routing
+
+import (
+	"encoding/json"
+	"net/http"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+// registerChatPreferenceRoutes adds chat preferences to the player router
+func registerChatPreferenceRoutes(router *config.Router) {
+	// Read (GET) or replace (PUT) the caller's chat preferences
+	router.Get("/chat-preferences", handleChatPrefs)
+	router.Put("/chat-preferences", handleChatPrefs)
+}
+
+// handleChatPrefs returns or replaces the caller's chat preferences
+func handleChatPrefs(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if config.DB == nil {
+		config.Error(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		var prefs config.ChatPrefs
+		if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+			config.Logger(r.Context()).Warn("Decode error", "error", err)
+			config.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := config.SetChatPrefs(r.Context(), playerID, prefs); err != nil {
+			config.Logger(r.Context()).Error("Database error", "error", err)
+			config.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		Player_Logic.SetGlobalChat(playerID, prefs.GlobalChat)
+	}
+
+	prefs, err := config.GetChatPrefs(r.Context(), playerID)
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"preferences": prefs})
+}
@@ -0,0 +1,69 @@
+package Routing
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+// handleRoomMessages returns chat history for the caller's room
+// (?channel=&before=<unix ms>&limit=, newest page first, messages oldest first)
+func handleRoomMessages(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	roomID := config.PathParam(r, "roomID")
+
+	channel := query.Get("channel")
+	if channel == "" {
+		channel = Player_Logic.DefaultChannel
+	}
+
+	// History is only visible to players currently in the room (and channel)
+	room := roomManager.GetPlayerRoom(playerID)
+	if room == nil || room.ID != roomID {
+		config.Error(w, "Not a member of this room", http.StatusForbidden)
+		return
+	}
+	if !room.CanReadChannel(playerID, channel) {
+		config.Error(w, "Not a member of this channel", http.StatusForbidden)
+		return
+	}
+
+	var before int64
+	if value := query.Get("before"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			config.Error(w, "before must be a unix timestamp in milliseconds", http.StatusBadRequest)
+			return
+		}
+		before = parsed
+	}
+
+	limit := config.DefaultChatHistoryLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > config.MaxChatHistoryLimit {
+			config.Error(w, "limit must be between 1 and 200", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	// Senders the caller has blocked are hidden, same as live chat
+	messages, err := config.GetChatHistory(roomID, channel, playerID, before, limit)
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"messages": messages})
+}
@@ -0,0 +1,60 @@
+package Routing
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+// registerDailyRewardRoutes adds the daily login reward endpoints to the player router
+func registerDailyRewardRoutes(router *config.Router) {
+	router.Get("/daily-reward", handleDailyRewardStatus)
+	router.Post("/daily-reward/claim", handleClaimDailyReward)
+}
+
+// handleDailyRewardStatus returns the caller's streak and whether today's reward is claimed
+func handleDailyRewardStatus(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	status, err := Player_Logic.GetDailyRewardStatus(r.Context(), playerID)
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleClaimDailyReward grants today's reward into the caller's inventory
+func handleClaimDailyReward(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	reward, streak, err := Player_Logic.ClaimDailyReward(r.Context(), playerID)
+	if errors.Is(err, config.ErrAlreadyClaimed) {
+		config.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		writeInventoryError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"streak":  streak,
+		"reward":  reward,
+	})
+}
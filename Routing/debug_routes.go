@@ -0,0 +1,148 @@
+package Routing
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sync/atomic"
+	"time"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+var (
+	// startedAt is when the process started serving, for uptime reporting
+	startedAt = time.Now()
+
+	// blockProfileRate mirrors runtime.SetBlockProfileRate, which can't be read back
+	blockProfileRate atomic.Int64
+)
+
+// pprofProfiles are the named runtime profiles served under /admin/debug/pprof/
+var pprofProfiles = []string{"goroutine", "heap", "allocs", "block", "mutex", "threadcreate"}
+
+// registerDebugRoutes mounts pprof and runtime diagnostics on the admin router. Block and
+// mutex profiles stay empty until sampling is turned on (PPROF_BLOCK_RATE,
+// PPROF_MUTEX_FRACTION, or POST /admin/debug/profiling).
+func registerDebugRoutes(router *config.Router) {
+	router.HandleFunc("/debug/pprof/", pprof.Index)
+	router.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	router.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	router.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	router.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	for _, name := range pprofProfiles {
+		router.HandleFunc("/debug/pprof/"+name, pprof.Handler(name).ServeHTTP)
+	}
+
+	// Full stack dump of every goroutine as plain text
+	router.Get("/debug/goroutines", handleGoroutineDump)
+
+	// Memory, GC, and goroutine counts as JSON
+	router.Get("/debug/runtime", handleRuntimeStats)
+
+	// Adjust block/mutex profile sampling without a restart
+	router.Post("/debug/profiling", handleProfilingRates)
+}
+
+// applyProfilingRates turns on block and mutex profiling as configured (both off by default)
+func applyProfilingRates(cfg config.ProfilingConfig) {
+	setBlockProfileRate(cfg.BlockRate)
+	runtime.SetMutexProfileFraction(cfg.MutexFraction)
+}
+
+// setBlockProfileRate applies and records the block profile rate
+func setBlockProfileRate(rate int) {
+	runtime.SetBlockProfileRate(rate)
+	blockProfileRate.Store(int64(rate))
+}
+
+// profilingRates reports the current block and mutex sampling settings
+func profilingRates() map[string]interface{} {
+	return map[string]interface{}{
+		"block_rate":     blockProfileRate.Load(),
+		"mutex_fraction": runtime.SetMutexProfileFraction(-1),
+	}
+}
+
+// handleGoroutineDump writes the stacks of all goroutines
+func handleGoroutineDump(w http.ResponseWriter, r *http.Request) {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(buf)
+}
+
+// handleRuntimeStats reports goroutines, memory, and GC figures
+func handleRuntimeStats(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	var lastGC int64
+	if mem.LastGC > 0 {
+		lastGC = time.Unix(0, int64(mem.LastGC)).UnixMilli()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"go_version":     runtime.Version(),
+		"uptime_seconds": int64(time.Since(startedAt).Seconds()),
+		"num_cpu":        runtime.NumCPU(),
+		"gomaxprocs":     runtime.GOMAXPROCS(0),
+		"goroutines":     runtime.NumGoroutine(),
+		"connections":    Player_Logic.GetConnectionStats()["active_connections"],
+		"memory": map[string]interface{}{
+			"heap_alloc_bytes":  mem.HeapAlloc,
+			"heap_inuse_bytes":  mem.HeapInuse,
+			"heap_objects":      mem.HeapObjects,
+			"stack_inuse_bytes": mem.StackInuse,
+			"sys_bytes":         mem.Sys,
+			"total_alloc_bytes": mem.TotalAlloc,
+		},
+		"gc": map[string]interface{}{
+			"num_gc":         mem.NumGC,
+			"pause_total_ms": float64(mem.PauseTotalNs) / float64(time.Millisecond),
+			"last_gc":        lastGC,
+			"next_gc_bytes":  mem.NextGC,
+		},
+		"profiling": profilingRates(),
+	})
+}
+
+// handleProfilingRates sets the block profile rate and mutex profile fraction (0 turns them off)
+func handleProfilingRates(w http.ResponseWriter, r *http.Request) {
+	type RequestBody struct {
+		BlockRate     *int `json:"block_rate"`     // Nanoseconds blocked per sampled event; 1 samples everything
+		MutexFraction *int `json:"mutex_fraction"` // On average 1/n contention events are sampled
+	}
+	var body RequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		config.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if (body.BlockRate != nil && *body.BlockRate < 0) || (body.MutexFraction != nil && *body.MutexFraction < 0) {
+		config.Error(w, "rates must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	if body.BlockRate != nil {
+		setBlockProfileRate(*body.BlockRate)
+	}
+	if body.MutexFraction != nil {
+		runtime.SetMutexProfileFraction(*body.MutexFraction)
+	}
+
+	rates := profilingRates()
+	config.Logger(r.Context()).Info("Profiling rates updated", "block_rate", rates["block_rate"], "mutex_fraction", rates["mutex_fraction"])
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rates)
+}
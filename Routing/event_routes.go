@@ -0,0 +1,69 @@
+package Routing
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+// registerEventRoutes adds the event calendar to the player router
+func registerEventRoutes(router *config.Router) {
+	// Live and upcoming server events (GET)
+	router.Get("/events", handleUpcomingEvents)
+}
+
+// handleUpcomingEvents returns live and upcoming events by start time
+func handleUpcomingEvents(w http.ResponseWriter, r *http.Request) {
+	if _, ok := config.ResolvePrincipal(r.Header.Get("Authorization")); !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"events": Player_Logic.UpcomingEvents()})
+}
+
+// handleScheduleEvent schedules a server event
+func handleScheduleEvent(w http.ResponseWriter, r *http.Request) {
+	var body Player_Logic.ScheduledEvent
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		config.Logger(r.Context()).Warn("Decode error", "error", err)
+		config.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	event, err := Player_Logic.ScheduleEvent(body)
+	if errors.Is(err, Player_Logic.ErrTooManyEvents) {
+		config.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		config.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "event": event})
+}
+
+// handleAdminListEvents returns live and upcoming events for admins
+func handleAdminListEvents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"events": Player_Logic.UpcomingEvents()})
+}
+
+// handleCancelEvent cancels a scheduled event (?id=)
+func handleCancelEvent(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		config.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+	if err := Player_Logic.CancelEvent(id); err != nil {
+		config.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
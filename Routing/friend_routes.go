@@ -0,0 +1,89 @@
+package Routing
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+// registerFriendRoutes adds the friends endpoints to the player router
+func registerFriendRoutes(router *config.Router) {
+	router.Get("/friends", handleListFriends)
+	router.Post("/friends/request", handleFriendAction)
+	router.Post("/friends/accept", handleFriendAction)
+	router.Post("/friends/decline", handleFriendAction)
+	router.Post("/friends/remove", handleFriendAction)
+}
+
+// handleListFriends returns the caller's friends and pending requests
+func handleListFriends(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	list, err := config.ListFriends(playerID)
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// handleFriendAction sends, accepts, declines, or removes a friendship based on the path
+func handleFriendAction(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	type RequestBody struct {
+		FriendID string `json:"friend_id"`
+	}
+	var body RequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.FriendID == "" {
+		config.Error(w, "friend_id is required", http.StatusBadRequest)
+		return
+	}
+
+	status := ""
+	var err error
+	switch r.URL.Path {
+	case "/player/friends/request":
+		if status, err = config.SendFriendRequest(playerID, body.FriendID); err == nil {
+			Player_Logic.NotifyFriendRequest(playerID, body.FriendID, status)
+		}
+	case "/player/friends/accept":
+		if err = config.AcceptFriendRequest(playerID, body.FriendID); err == nil {
+			status = config.FriendshipAccepted
+			Player_Logic.NotifyFriendRequest(playerID, body.FriendID, status)
+		}
+	case "/player/friends/decline":
+		err = config.DeclineFriendRequest(playerID, body.FriendID)
+	case "/player/friends/remove":
+		err = config.RemoveFriend(playerID, body.FriendID)
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, config.ErrAlreadyFriends), errors.Is(err, config.ErrRequestPending):
+			config.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, config.ErrNoFriendRequest), errors.Is(err, config.ErrFriendshipMissing):
+			config.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			config.Logger(r.Context()).Error("Database error", "error", err)
+			config.Error(w, "Database error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "status": status})
+}
@@ -0,0 +1,528 @@
+package Routing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A small GraphQL executor for read-only queries: fields, arguments, aliases, variables,
+// and __typename. Fragments, directives, mutations, and subscriptions aren't supported.
+
+const (
+	maxGraphQLDepth  = 8   // Deepest nesting of selection sets
+	maxGraphQLFields = 500 // Most fields resolved per request
+)
+
+// gqlSelection is one field in a selection set
+type gqlSelection struct {
+	alias      string // Response key; the field name unless aliased
+	name       string
+	args       map[string]interface{}
+	selections []*gqlSelection
+}
+
+// gqlResolver returns a field's value. Object fields return a source for the object type
+// (or a []interface{} of them for lists); scalar fields return JSON-encodable values.
+type gqlResolver func(req *gqlRequest, source interface{}, args map[string]interface{}) (interface{}, error)
+
+// gqlField is a field on an object type; typ is nil for scalars
+type gqlField struct {
+	typ     *gqlObject
+	resolve gqlResolver
+}
+
+// gqlObject is an object type in the schema
+type gqlObject struct {
+	name   string
+	fields map[string]gqlField
+}
+
+// gqlError is an entry in the response's errors list
+type gqlError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// gqlRequest carries per-request state through resolvers
+type gqlRequest struct {
+	ctx       context.Context
+	principal string                  // Authenticated caller
+	users     map[string]*gqlUserInfo // Loaded users, so repeated lookups hit the store once
+	errors    []gqlError
+	resolved  int
+}
+
+// gqlResult is an object in the response, keeping fields in selection order
+type gqlResult struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (r *gqlResult) set(key string, value interface{}) {
+	if _, exists := r.values[key]; !exists {
+		r.keys = append(r.keys, key)
+	}
+	r.values[key] = value
+}
+
+func (r *gqlResult) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range r.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		value, err := json.Marshal(r.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// executeGraphQL parses a query and resolves it against the root type. Field errors null
+// out the field and are collected in req.errors; an error return means nothing ran.
+func executeGraphQL(req *gqlRequest, root *gqlObject, query string, variables map[string]interface{}) (*gqlResult, error) {
+	selections, err := parseGraphQL(query, variables)
+	if err != nil {
+		return nil, err
+	}
+	return req.executeSelections(root, nil, selections, nil, 1), nil
+}
+
+// executeSelections resolves a selection set on an object
+func (req *gqlRequest) executeSelections(typ *gqlObject, source interface{}, selections []*gqlSelection, path []interface{}, depth int) *gqlResult {
+	result := &gqlResult{values: make(map[string]interface{}, len(selections))}
+	for _, sel := range selections {
+		fieldPath := append(append([]interface{}{}, path...), sel.alias)
+		if sel.name == "__typename" {
+			result.set(sel.alias, typ.name)
+			continue
+		}
+		result.set(sel.alias, req.executeField(typ, source, sel, fieldPath, depth))
+	}
+	return result
+}
+
+// executeField resolves one field and its sub-selections, recording any error
+func (req *gqlRequest) executeField(typ *gqlObject, source interface{}, sel *gqlSelection, path []interface{}, depth int) interface{} {
+	fail := func(format string, args ...interface{}) interface{} {
+		req.errors = append(req.errors, gqlError{Message: fmt.Sprintf(format, args...), Path: path})
+		return nil
+	}
+
+	field, exists := typ.fields[sel.name]
+	if !exists {
+		return fail("Cannot query field %q on type %q", sel.name, typ.name)
+	}
+	if field.typ == nil && len(sel.selections) > 0 {
+		return fail("Field %q is a scalar and can't have a selection set", sel.name)
+	}
+	if field.typ != nil && len(sel.selections) == 0 {
+		return fail("Field %q of type %q needs a selection set", sel.name, field.typ.name)
+	}
+	if field.typ != nil && depth >= maxGraphQLDepth {
+		return fail("Query is nested too deeply (max %d levels)", maxGraphQLDepth)
+	}
+	if req.resolved++; req.resolved > maxGraphQLFields {
+		return fail("Query resolves too many fields (max %d)", maxGraphQLFields)
+	}
+
+	value, err := field.resolve(req, source, sel.args)
+	if err != nil {
+		return fail("%s", err.Error())
+	}
+	if field.typ == nil || value == nil {
+		return value
+	}
+
+	if list, isList := value.([]interface{}); isList {
+		results := make([]interface{}, len(list))
+		for i, item := range list {
+			if item == nil {
+				continue
+			}
+			itemPath := append(append([]interface{}{}, path...), i)
+			results[i] = req.executeSelections(field.typ, item, sel.selections, itemPath, depth+1)
+		}
+		return results
+	}
+	return req.executeSelections(field.typ, value, sel.selections, path, depth+1)
+}
+
+// gqlStringArg returns a required string argument
+func gqlStringArg(args map[string]interface{}, name string) (string, error) {
+	value, ok := args[name].(string)
+	if !ok || value == "" {
+		return "", fmt.Errorf("argument %q is required", name)
+	}
+	return value, nil
+}
+
+// gqlStringListArg returns a required list-of-strings argument; a single string is
+// accepted as a one-item list, as GraphQL input coercion allows
+func gqlStringListArg(args map[string]interface{}, name string) ([]string, error) {
+	switch value := args[name].(type) {
+	case string:
+		return []string{value}, nil
+	case []interface{}:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("argument %q must be a list of IDs", name)
+			}
+			items = append(items, s)
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("argument %q is required", name)
+}
+
+// gqlParser is a recursive-descent parser over a query string
+type gqlParser struct {
+	src       string
+	pos       int
+	variables map[string]interface{}
+}
+
+// parseGraphQL parses a single query operation into its top-level selections, substituting
+// variables as it goes
+func parseGraphQL(query string, variables map[string]interface{}) ([]*gqlSelection, error) {
+	p := &gqlParser{src: query, variables: variables}
+
+	if p.peek() != '{' {
+		keyword := p.name()
+		switch keyword {
+		case "query":
+		case "mutation", "subscription":
+			return nil, fmt.Errorf("%s operations are not supported", keyword)
+		case "fragment":
+			return nil, fmt.Errorf("fragments are not supported")
+		default:
+			return nil, p.errorf("expected a query")
+		}
+		if isNameStart(p.peek()) {
+			p.name() // Operation name
+		}
+		if p.peek() == '(' {
+			if err := p.variableDefinitions(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	selections, err := p.selectionSet(1)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != 0 {
+		return nil, p.errorf("only one operation per request is supported")
+	}
+	return selections, nil
+}
+
+func (p *gqlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("syntax error at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// skip moves past whitespace, commas, and comments
+func (p *gqlParser) skip() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// peek returns the next significant character, or 0 at the end
+func (p *gqlParser) peek() byte {
+	p.skip()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *gqlParser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
+
+// name reads a name token, returning "" if there isn't one
+func (p *gqlParser) name() string {
+	if !isNameStart(p.peek()) {
+		return ""
+	}
+	start := p.pos
+	for p.pos < len(p.src) && isNameChar(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// variableDefinitions reads ($name: Type = default, ...), filling in defaults for
+// variables the request didn't supply
+func (p *gqlParser) variableDefinitions() error {
+	p.pos++ // (
+	for p.peek() != ')' {
+		if err := p.expect('$'); err != nil {
+			return err
+		}
+		name := p.name()
+		if name == "" {
+			return p.errorf("expected a variable name")
+		}
+		if err := p.expect(':'); err != nil {
+			return err
+		}
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if p.peek() == '=' {
+			p.pos++
+			value, err := p.value(true)
+			if err != nil {
+				return err
+			}
+			if _, supplied := p.variables[name]; !supplied {
+				if p.variables == nil {
+					p.variables = make(map[string]interface{})
+				}
+				p.variables[name] = value
+			}
+		}
+		if p.peek() == 0 {
+			return p.errorf("unterminated variable definitions")
+		}
+	}
+	p.pos++ // )
+	return nil
+}
+
+// skipType reads a type reference like ID, [ID!]!; types aren't checked
+func (p *gqlParser) skipType() error {
+	if p.peek() == '[' {
+		p.pos++
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if err := p.expect(']'); err != nil {
+			return err
+		}
+	} else if p.name() == "" {
+		return p.errorf("expected a type")
+	}
+	if p.peek() == '!' {
+		p.pos++
+	}
+	return nil
+}
+
+// selectionSet reads { field field ... }
+func (p *gqlParser) selectionSet(depth int) ([]*gqlSelection, error) {
+	if depth > maxGraphQLDepth {
+		return nil, p.errorf("query is nested too deeply (max %d levels)", maxGraphQLDepth)
+	}
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+
+	var selections []*gqlSelection
+	for p.peek() != '}' {
+		switch c := p.peek(); {
+		case c == 0:
+			return nil, p.errorf("unterminated selection set")
+		case c == '.':
+			return nil, fmt.Errorf("fragments are not supported")
+		case c == '@':
+			return nil, fmt.Errorf("directives are not supported")
+		}
+		sel, err := p.field(depth)
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	p.pos++ // }
+
+	if len(selections) == 0 {
+		return nil, p.errorf("empty selection set")
+	}
+	return selections, nil
+}
+
+// field reads [alias:] name [(args)] [{ selections }]
+func (p *gqlParser) field(depth int) (*gqlSelection, error) {
+	name := p.name()
+	if name == "" {
+		return nil, p.errorf("expected a field name")
+	}
+	sel := &gqlSelection{alias: name, name: name}
+	if p.peek() == ':' {
+		p.pos++
+		if sel.name = p.name(); sel.name == "" {
+			return nil, p.errorf("expected a field name after alias %q", name)
+		}
+	}
+
+	if p.peek() == '(' {
+		p.pos++
+		sel.args = make(map[string]interface{})
+		for p.peek() != ')' {
+			arg := p.name()
+			if arg == "" {
+				return nil, p.errorf("expected an argument name")
+			}
+			if err := p.expect(':'); err != nil {
+				return nil, err
+			}
+			value, err := p.value(false)
+			if err != nil {
+				return nil, err
+			}
+			sel.args[arg] = value
+		}
+		p.pos++ // )
+	}
+
+	if p.peek() == '{' {
+		selections, err := p.selectionSet(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		sel.selections = selections
+	}
+	return sel, nil
+}
+
+// value reads an input value; constant values (defaults) can't reference variables
+func (p *gqlParser) value(constant bool) (interface{}, error) {
+	switch c := p.peek(); {
+	case c == '$':
+		if constant {
+			return nil, p.errorf("variables aren't allowed here")
+		}
+		p.pos++
+		name := p.name()
+		if name == "" {
+			return nil, p.errorf("expected a variable name")
+		}
+		return p.variables[name], nil
+	case c == '"':
+		return p.stringValue()
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.numberValue()
+	case c == '[':
+		p.pos++
+		list := make([]interface{}, 0)
+		for p.peek() != ']' {
+			if p.peek() == 0 {
+				return nil, p.errorf("unterminated list")
+			}
+			item, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		p.pos++
+		return list, nil
+	case c == '{':
+		p.pos++
+		object := make(map[string]interface{})
+		for p.peek() != '}' {
+			key := p.name()
+			if key == "" {
+				return nil, p.errorf("expected an object field name")
+			}
+			if err := p.expect(':'); err != nil {
+				return nil, err
+			}
+			item, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			object[key] = item
+		}
+		p.pos++
+		return object, nil
+	case isNameStart(c):
+		switch name := p.name(); name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		default:
+			return name, nil // Enum value
+		}
+	}
+	return nil, p.errorf("expected a value")
+}
+
+// stringValue reads a double-quoted string with JSON-style escapes
+func (p *gqlParser) stringValue() (string, error) {
+	start := p.pos
+	p.pos++ // Opening quote
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos += 2
+		case '"':
+			p.pos++
+			value, err := strconv.Unquote(p.src[start:p.pos])
+			if err != nil {
+				return "", p.errorf("invalid string")
+			}
+			return value, nil
+		case '\n':
+			return "", p.errorf("unterminated string")
+		default:
+			p.pos++
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// numberValue reads an int or float; ints come back as float64 like JSON variables do
+func (p *gqlParser) numberValue() (float64, error) {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+	value, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil {
+		return 0, p.errorf("invalid number")
+	}
+	return value, nil
+}
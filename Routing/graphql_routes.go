@@ -0,0 +1,310 @@
+package Routing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+const (
+	maxGraphQLBodyBytes = 64 << 10
+	maxGraphQLUserIDs   = 100 // Most IDs per users/presence lookup
+)
+
+// gqlUserInfo is a User source; user is nil when the account doesn't exist
+type gqlUserInfo struct {
+	id   string
+	user *config.User
+}
+
+// gqlPresenceInfo is a Presence source
+type gqlPresenceInfo struct {
+	userID string
+	info   Player_Logic.PresenceInfo
+}
+
+// GraphQL schema:
+//
+//	type Query {
+//	  me: User
+//	  user(id: ID!): User
+//	  users(ids: [ID!]!): [User]
+//	  room(id: ID!): Room          # "main" for the main room
+//	  rooms: [Room]
+//	  presence(ids: [ID!]!): [Presence]
+//	}
+//	type User {
+//	  id: ID!  username: String  gender: String  profilePic: String  lastRoom: String
+//	  email: String                # Only on the caller's own user
+//	  presence: Presence
+//	  room: Room                   # Room they're in right now
+//	  friends: [User]              # Only on the caller's own user
+//	}
+//	type Room {
+//	  id: ID!  playerCount: Int  capacity: Int  reservedSlots: Int  isMain: Boolean
+//	  createdAt: String  players: [User]
+//	}
+//	type Presence { userId: ID!  status: String  lastActive: Float }
+var (
+	gqlQueryType    = &gqlObject{name: "Query"}
+	gqlUserType     = &gqlObject{name: "User"}
+	gqlRoomType     = &gqlObject{name: "Room"}
+	gqlPresenceType = &gqlObject{name: "Presence"}
+)
+
+// The types refer to each other, so their fields are filled in here rather than in the
+// var block to avoid an initialization cycle
+func init() {
+	gqlQueryType.fields = map[string]gqlField{
+		"me": {typ: gqlUserType, resolve: func(req *gqlRequest, _ interface{}, _ map[string]interface{}) (interface{}, error) {
+			return req.loadUser(req.principal)
+		}},
+		"user": {typ: gqlUserType, resolve: func(req *gqlRequest, _ interface{}, args map[string]interface{}) (interface{}, error) {
+			id, err := gqlStringArg(args, "id")
+			if err != nil {
+				return nil, err
+			}
+			return req.loadUser(id)
+		}},
+		"users": {typ: gqlUserType, resolve: func(req *gqlRequest, _ interface{}, args map[string]interface{}) (interface{}, error) {
+			ids, err := gqlIDsArg(args)
+			if err != nil {
+				return nil, err
+			}
+			return req.loadUsers(ids)
+		}},
+		"room": {typ: gqlRoomType, resolve: func(req *gqlRequest, _ interface{}, args map[string]interface{}) (interface{}, error) {
+			id, err := gqlStringArg(args, "id")
+			if err != nil {
+				return nil, err
+			}
+			return gqlRoom(id), nil
+		}},
+		"rooms": {typ: gqlRoomType, resolve: func(req *gqlRequest, _ interface{}, _ map[string]interface{}) (interface{}, error) {
+			rooms := roomManager.ListRooms()
+			sort.Slice(rooms, func(i, j int) bool { return rooms[i].ID < rooms[j].ID })
+			list := make([]interface{}, len(rooms))
+			for i, room := range rooms {
+				list[i] = room
+			}
+			return list, nil
+		}},
+		"presence": {typ: gqlPresenceType, resolve: func(req *gqlRequest, _ interface{}, args map[string]interface{}) (interface{}, error) {
+			ids, err := gqlIDsArg(args)
+			if err != nil {
+				return nil, err
+			}
+			presence := Player_Logic.GetPresence().Get(ids)
+			list := make([]interface{}, len(ids))
+			for i, id := range ids {
+				list[i] = gqlPresenceInfo{userID: id, info: presence[id]}
+			}
+			return list, nil
+		}},
+	}
+
+	gqlUserType.fields = map[string]gqlField{
+		"id":         {resolve: gqlUserField(func(u *gqlUserInfo) interface{} { return u.id })},
+		"username":   {resolve: gqlUserField(func(u *gqlUserInfo) interface{} { return u.user.Username })},
+		"gender":     {resolve: gqlUserField(func(u *gqlUserInfo) interface{} { return u.user.Gender })},
+		"profilePic": {resolve: gqlUserField(func(u *gqlUserInfo) interface{} { return u.user.ProfilePic })},
+		"lastRoom":   {resolve: gqlUserField(func(u *gqlUserInfo) interface{} { return u.user.LastRoom })},
+		"email": {resolve: func(req *gqlRequest, source interface{}, _ map[string]interface{}) (interface{}, error) {
+			user := source.(*gqlUserInfo)
+			if user.id != req.principal {
+				return nil, nil
+			}
+			return user.user.Email, nil
+		}},
+		"presence": {typ: gqlPresenceType, resolve: func(req *gqlRequest, source interface{}, _ map[string]interface{}) (interface{}, error) {
+			id := source.(*gqlUserInfo).id
+			return gqlPresenceInfo{userID: id, info: Player_Logic.GetPresence().Get([]string{id})[id]}, nil
+		}},
+		"room": {typ: gqlRoomType, resolve: func(req *gqlRequest, source interface{}, _ map[string]interface{}) (interface{}, error) {
+			id := source.(*gqlUserInfo).id
+			if account, isService := config.GetServiceAccount(id); isService && account.Hidden {
+				return nil, nil
+			}
+			room := roomManager.GetPlayerRoom(id)
+			if room == nil {
+				return nil, nil
+			}
+			return gqlRoom(room.ID), nil
+		}},
+		"friends": {typ: gqlUserType, resolve: func(req *gqlRequest, source interface{}, _ map[string]interface{}) (interface{}, error) {
+			id := source.(*gqlUserInfo).id
+			if id != req.principal {
+				return nil, errors.New("friends are only visible on your own user")
+			}
+			friendIDs, err := config.GetFriendIDs(id)
+			if err != nil {
+				config.Logger(req.ctx).Error("Failed to load friends", "user_id", id, "error", err)
+				return nil, errors.New("failed to load friends")
+			}
+			sort.Strings(friendIDs)
+			return req.loadUsers(friendIDs)
+		}},
+	}
+
+	gqlRoomType.fields = map[string]gqlField{
+		"id":            {resolve: gqlRoomField(func(r Player_Logic.RoomInfo) interface{} { return r.ID })},
+		"playerCount":   {resolve: gqlRoomField(func(r Player_Logic.RoomInfo) interface{} { return r.PlayerCount })},
+		"capacity":      {resolve: gqlRoomField(func(r Player_Logic.RoomInfo) interface{} { return r.Capacity })},
+		"reservedSlots": {resolve: gqlRoomField(func(r Player_Logic.RoomInfo) interface{} { return r.ReservedSlots })},
+		"isMain":        {resolve: gqlRoomField(func(r Player_Logic.RoomInfo) interface{} { return r.IsMain })},
+		"createdAt":     {resolve: gqlRoomField(func(r Player_Logic.RoomInfo) interface{} { return r.CreatedAt.Format(time.RFC3339) })},
+		"players": {typ: gqlUserType, resolve: func(req *gqlRequest, source interface{}, _ map[string]interface{}) (interface{}, error) {
+			ids := roomManager.VisiblePlayerIDs(source.(Player_Logic.RoomInfo).ID)
+			sort.Strings(ids)
+			return req.loadUsers(ids)
+		}},
+	}
+
+	gqlPresenceType.fields = map[string]gqlField{
+		"userId": {resolve: func(_ *gqlRequest, source interface{}, _ map[string]interface{}) (interface{}, error) {
+			return source.(gqlPresenceInfo).userID, nil
+		}},
+		"status": {resolve: func(_ *gqlRequest, source interface{}, _ map[string]interface{}) (interface{}, error) {
+			return source.(gqlPresenceInfo).info.Status, nil
+		}},
+		"lastActive": {resolve: func(_ *gqlRequest, source interface{}, _ map[string]interface{}) (interface{}, error) {
+			if lastActive := source.(gqlPresenceInfo).info.LastActive; lastActive != 0 {
+				return lastActive, nil
+			}
+			return nil, nil
+		}},
+	}
+}
+
+// gqlUserField adapts a getter into a User field resolver
+func gqlUserField(get func(*gqlUserInfo) interface{}) gqlResolver {
+	return func(_ *gqlRequest, source interface{}, _ map[string]interface{}) (interface{}, error) {
+		return get(source.(*gqlUserInfo)), nil
+	}
+}
+
+// gqlRoomField adapts a getter into a Room field resolver
+func gqlRoomField(get func(Player_Logic.RoomInfo) interface{}) gqlResolver {
+	return func(_ *gqlRequest, source interface{}, _ map[string]interface{}) (interface{}, error) {
+		return get(source.(Player_Logic.RoomInfo)), nil
+	}
+}
+
+// gqlRoom returns a Room source, or nil if the room doesn't exist
+func gqlRoom(roomID string) interface{} {
+	if roomID == Player_Logic.PortalMainRoom {
+		roomID = roomManager.MainRoomID()
+	}
+	info, exists := roomManager.GetRoomInfo(roomID)
+	if !exists {
+		return nil
+	}
+	return info
+}
+
+// gqlIDsArg returns the ids argument, capped at maxGraphQLUserIDs
+func gqlIDsArg(args map[string]interface{}) ([]string, error) {
+	ids, err := gqlStringListArg(args, "ids")
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > maxGraphQLUserIDs {
+		return nil, fmt.Errorf("at most %d ids per lookup", maxGraphQLUserIDs)
+	}
+	return ids, nil
+}
+
+// loadUser returns a User source, or nil if the account doesn't exist or is scheduled for
+// deletion
+func (req *gqlRequest) loadUser(userID string) (interface{}, error) {
+	info, cached := req.users[userID]
+	if !cached {
+		user, err := config.GetUserStore().Get(req.ctx, userID)
+		if err != nil && !errors.Is(err, config.ErrUserNotFound) {
+			config.Logger(req.ctx).Error("Database error getting user", "user_id", userID, "error", err)
+			return nil, errors.New("failed to load user")
+		}
+		if user != nil && config.IsDeletionPending(userID) {
+			user = nil
+		}
+		info = &gqlUserInfo{id: userID, user: user}
+		req.users[userID] = info
+	}
+	if info.user == nil {
+		return nil, nil
+	}
+	return info, nil
+}
+
+// loadUsers returns a list of User sources, with nil entries for missing accounts
+func (req *gqlRequest) loadUsers(userIDs []string) (interface{}, error) {
+	list := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		user, err := req.loadUser(id)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = user
+	}
+	return list, nil
+}
+
+// HandleGraphQL serves read-only GraphQL queries over users, friends, rooms, and presence.
+// Queries come as a POST JSON body ({"query", "variables"}) or GET ?query=.
+func HandleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	switch r.Method {
+	case http.MethodGet:
+		body.Query = r.URL.Query().Get("query")
+		if raw := r.URL.Query().Get("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &body.Variables); err != nil {
+				config.Error(w, "Invalid variables", http.StatusBadRequest)
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGraphQLBodyBytes)).Decode(&body); err != nil {
+			config.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	default:
+		config.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if rejectInactiveAccount(w, principal) {
+		return
+	}
+	if body.Query == "" {
+		config.Error(w, "query is required", http.StatusBadRequest)
+		return
+	}
+
+	req := &gqlRequest{ctx: r.Context(), principal: principal, users: make(map[string]*gqlUserInfo)}
+	data, err := executeGraphQL(req, gqlQueryType, body.Query, body.Variables)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": []gqlError{{Message: err.Error()}}})
+		return
+	}
+	response := map[string]interface{}{"data": data}
+	if len(req.errors) > 0 {
+		response["errors"] = req.errors
+	}
+	json.NewEncoder(w).Encode(response)
+}
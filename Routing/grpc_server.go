@@ -0,0 +1,203 @@
+package Routing
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"velvet/Player_Logic"
+	"velvet/config"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Internal gRPC API for other backend services (matchmaking, moderation bots). Messages
+// are JSON rather than protobuf, so clients call with the "json" content subtype
+// (grpc.CallContentSubtype("json")) and need no generated stubs:
+//
+//	velvet.internal.RoomService/GetPlayerLocation {"player_id"}
+//	velvet.internal.RoomService/MovePlayer        {"player_id", "room_id"}
+//	velvet.internal.RoomService/Broadcast         {"kind", "text", "room_ids" | "everyone"}
+
+const grpcServiceName = "velvet.internal.RoomService"
+
+// jsonCodec encodes gRPC messages as JSON
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                               { return "json" }
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// GetPlayerLocationRequest asks where a player is
+type GetPlayerLocationRequest struct {
+	PlayerID string `json:"player_id"`
+}
+
+// MovePlayerRequest moves a player into another room
+type MovePlayerRequest struct {
+	PlayerID string `json:"player_id"`
+	RoomID   string `json:"room_id"` // "main" for the main room
+}
+
+// MovePlayerResponse reports the move
+type MovePlayerResponse struct {
+	PlayerID string `json:"player_id"`
+	FromRoom string `json:"from_room"`
+	ToRoom   string `json:"to_room"`
+}
+
+// BroadcastResponse acknowledges a delivered broadcast
+type BroadcastResponse struct {
+	Delivered bool `json:"delivered"`
+}
+
+// roomService implements the internal RoomService
+type roomService interface {
+	GetPlayerLocation(ctx context.Context, req *GetPlayerLocationRequest) (*Player_Logic.PlayerLocation, error)
+	MovePlayer(ctx context.Context, req *MovePlayerRequest) (*MovePlayerResponse, error)
+	Broadcast(ctx context.Context, req *Player_Logic.ScheduledBroadcast) (*BroadcastResponse, error)
+}
+
+type roomServiceServer struct {
+	rm *Player_Logic.RoomManager
+}
+
+func (s *roomServiceServer) GetPlayerLocation(ctx context.Context, req *GetPlayerLocationRequest) (*Player_Logic.PlayerLocation, error) {
+	if req.PlayerID == "" {
+		return nil, status.Error(codes.InvalidArgument, "player_id is required")
+	}
+	location, err := s.rm.LocatePlayer(req.PlayerID)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &location, nil
+}
+
+func (s *roomServiceServer) MovePlayer(ctx context.Context, req *MovePlayerRequest) (*MovePlayerResponse, error) {
+	if req.PlayerID == "" || req.RoomID == "" {
+		return nil, status.Error(codes.InvalidArgument, "player_id and room_id are required")
+	}
+	fromRoom, err := s.rm.ForceMovePlayer(req.PlayerID, req.RoomID)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	toRoom := req.RoomID
+	if toRoom == Player_Logic.PortalMainRoom {
+		toRoom = s.rm.MainRoomID()
+	}
+	config.Logger(ctx).Info("gRPC moved player", "player_id", req.PlayerID, "from_room", fromRoom, "to_room", toRoom)
+	return &MovePlayerResponse{PlayerID: req.PlayerID, FromRoom: fromRoom, ToRoom: toRoom}, nil
+}
+
+func (s *roomServiceServer) Broadcast(ctx context.Context, req *Player_Logic.ScheduledBroadcast) (*BroadcastResponse, error) {
+	if err := Player_Logic.SendBroadcast(*req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &BroadcastResponse{Delivered: true}, nil
+}
+
+// grpcError maps room manager errors to gRPC status codes
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, Player_Logic.ErrPlayerNotConnected), errors.Is(err, Player_Logic.ErrRoomNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, Player_Logic.ErrSameRoom):
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+	// Bans and full rooms
+	return status.Error(codes.FailedPrecondition, err.Error())
+}
+
+// unaryHandler builds a MethodDesc handler that decodes Req and calls call
+func unaryHandler[Req any, Resp any](method string, call func(roomService, context.Context, *Req) (*Resp, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: method,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := new(Req)
+			if err := dec(req); err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+			if interceptor == nil {
+				return call(srv.(roomService), ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + grpcServiceName + "/" + method}
+			return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return call(srv.(roomService), ctx, req.(*Req))
+			})
+		},
+	}
+}
+
+var roomServiceDesc = grpc.ServiceDesc{
+	ServiceName: grpcServiceName,
+	HandlerType: (*roomService)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryHandler("GetPlayerLocation", roomService.GetPlayerLocation),
+		unaryHandler("MovePlayer", roomService.MovePlayer),
+		unaryHandler("Broadcast", roomService.Broadcast),
+	},
+	Metadata: "velvet/internal/room_service",
+}
+
+// grpcAuth requires "authorization: Bearer <GRPC_AUTH_TOKEN>" on every call and logs it
+func grpcAuth(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		var presented string
+		if values := md.Get("authorization"); len(values) > 0 {
+			presented = strings.TrimPrefix(values[0], "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			slog.Warn("Rejected gRPC call", "method", info.FullMethod)
+			return nil, status.Error(codes.Unauthenticated, "invalid or missing token")
+		}
+
+		resp, err := handler(ctx, req)
+		slog.Debug("gRPC call", "method", info.FullMethod, "code", status.Code(err).String())
+		return resp, err
+	}
+}
+
+// StartGRPCServer serves the internal API on GRPC_ADDR, authenticated with GRPC_AUTH_TOKEN.
+// It returns nil when GRPC_ADDR is unset. Keep the port off the public network.
+func StartGRPCServer() (*grpc.Server, error) {
+	addr := os.Getenv("GRPC_ADDR")
+	if addr == "" {
+		slog.Info("Internal gRPC API disabled (GRPC_ADDR not set)")
+		return nil, nil
+	}
+	token := os.Getenv("GRPC_AUTH_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("GRPC_AUTH_TOKEN is required when GRPC_ADDR is set")
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	server := grpc.NewServer(grpc.UnaryInterceptor(grpcAuth(token)))
+	server.RegisterService(&roomServiceDesc, &roomServiceServer{rm: Player_Logic.GetRoomManager()})
+
+	go func() {
+		slog.Info("Internal gRPC API starting", "addr", listener.Addr().String())
+		if err := server.Serve(listener); err != nil {
+			slog.Error("Internal gRPC API stopped", "error", err)
+		}
+	}()
+	return server, nil
+}
@@ -0,0 +1,263 @@
+package Routing
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+// registerGuildRoutes adds the guild endpoints to the player router
+func registerGuildRoutes(router *config.Router) {
+	// The caller's guild with members (GET), description (PUT), or disband it (DELETE)
+	router.Get("/guild", handleMyGuild)
+	router.Put("/guild", handleUpdateGuild)
+	router.Delete("/guild", handleDisbandGuild)
+	router.Post("/guild/leave", handleLeaveGuild)
+	router.Post("/guild/kick", handleGuildMemberAction)
+	router.Post("/guild/role", handleGuildMemberAction)
+
+	// Create a guild (POST {name, tag, description}); any guild's profile; join one
+	router.Post("/guilds", handleCreateGuild)
+	router.Get("/guilds/{guildID}", handleGetGuild)
+	router.Post("/guilds/{guildID}/join", handleJoinGuild)
+}
+
+// guildCaller resolves the caller and checks the database is up, writing the error if not
+func guildCaller(w http.ResponseWriter, r *http.Request) (string, bool) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return "", false
+	}
+	if config.DB == nil {
+		config.Error(w, "Database not available", http.StatusServiceUnavailable)
+		return "", false
+	}
+	return playerID, true
+}
+
+// writeGuildError maps guild errors to status codes
+func writeGuildError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, config.ErrGuildNotFound), errors.Is(err, config.ErrNotInGuild), errors.Is(err, config.ErrNotGuildMember):
+		config.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, config.ErrGuildPermission):
+		config.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, config.ErrGuildTaken), errors.Is(err, config.ErrAlreadyInGuild),
+		errors.Is(err, config.ErrGuildFull), errors.Is(err, config.ErrLeaderMustTransfer):
+		config.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, config.ErrInvalidGuildTag):
+		config.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+	}
+}
+
+// writeGuildProfile responds with a guild and its members
+func writeGuildProfile(w http.ResponseWriter, r *http.Request, guild *config.Guild, extra map[string]interface{}) {
+	members, err := config.ListGuildMembers(r.Context(), guild.ID)
+	if err != nil {
+		writeGuildError(w, r, err)
+		return
+	}
+	response := map[string]interface{}{"guild": guild, "members": members}
+	for key, value := range extra {
+		response[key] = value
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleMyGuild returns the caller's guild, their role, and the members
+func handleMyGuild(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := guildCaller(w, r)
+	if !ok {
+		return
+	}
+
+	membership, err := config.GetGuildMembership(r.Context(), playerID)
+	if err != nil {
+		writeGuildError(w, r, err)
+		return
+	}
+	writeGuildProfile(w, r, membership.Guild, map[string]interface{}{"role": membership.Role})
+}
+
+// handleGetGuild returns any guild's profile and members
+func handleGetGuild(w http.ResponseWriter, r *http.Request) {
+	if _, ok := guildCaller(w, r); !ok {
+		return
+	}
+	guildID, err := strconv.ParseInt(config.PathParam(r, "guildID"), 10, 64)
+	if err != nil {
+		config.Error(w, "Guild not found", http.StatusNotFound)
+		return
+	}
+
+	guild, err := config.GetGuild(r.Context(), guildID)
+	if err != nil {
+		writeGuildError(w, r, err)
+		return
+	}
+	writeGuildProfile(w, r, guild, nil)
+}
+
+// handleCreateGuild creates a guild led by the caller
+func handleCreateGuild(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := guildCaller(w, r)
+	if !ok {
+		return
+	}
+
+	type RequestBody struct {
+		Name        string `json:"name" validate:"required,min=3,max=32"`
+		Tag         string `json:"tag" validate:"required,max=5"`
+		Description string `json:"description" validate:"max=500"`
+	}
+	var body RequestBody
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	guild, err := config.CreateGuild(r.Context(), playerID, body.Name, body.Tag, body.Description)
+	if err != nil {
+		writeGuildError(w, r, err)
+		return
+	}
+	Player_Logic.GuildChanged(playerID, guild)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{"guild": guild})
+}
+
+// handleJoinGuild adds the caller to a guild
+func handleJoinGuild(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := guildCaller(w, r)
+	if !ok {
+		return
+	}
+	guildID, err := strconv.ParseInt(config.PathParam(r, "guildID"), 10, 64)
+	if err != nil {
+		config.Error(w, "Guild not found", http.StatusNotFound)
+		return
+	}
+
+	if err := config.JoinGuild(r.Context(), guildID, playerID); err != nil {
+		writeGuildError(w, r, err)
+		return
+	}
+	guild, err := config.GetGuild(r.Context(), guildID)
+	if err != nil {
+		writeGuildError(w, r, err)
+		return
+	}
+	Player_Logic.GuildChanged(playerID, guild)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"guild": guild})
+}
+
+// handleUpdateGuild changes the description of the caller's guild
+func handleUpdateGuild(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := guildCaller(w, r)
+	if !ok {
+		return
+	}
+
+	type RequestBody struct {
+		Description string `json:"description" validate:"max=500"`
+	}
+	var body RequestBody
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	guild, err := config.UpdateGuildDescription(r.Context(), playerID, body.Description)
+	if err != nil {
+		writeGuildError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"guild": guild})
+}
+
+// handleLeaveGuild removes the caller from their guild
+func handleLeaveGuild(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := guildCaller(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := config.LeaveGuild(r.Context(), playerID); err != nil {
+		writeGuildError(w, r, err)
+		return
+	}
+	Player_Logic.GuildChanged(playerID, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+}
+
+// handleDisbandGuild deletes the caller's guild; only its leader can
+func handleDisbandGuild(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := guildCaller(w, r)
+	if !ok {
+		return
+	}
+
+	memberIDs, err := config.DisbandGuild(r.Context(), playerID)
+	if err != nil {
+		writeGuildError(w, r, err)
+		return
+	}
+	for _, memberID := range memberIDs {
+		Player_Logic.GuildChanged(memberID, nil)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+}
+
+// handleGuildMemberAction kicks a member or changes their role based on the path
+func handleGuildMemberAction(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := guildCaller(w, r)
+	if !ok {
+		return
+	}
+
+	type RequestBody struct {
+		PlayerID string `json:"player_id" validate:"required,max=128"`
+		Role     string `json:"role" validate:"oneof=leader officer member"`
+	}
+	var body RequestBody
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	var err error
+	switch r.URL.Path {
+	case "/player/guild/kick":
+		if _, err = config.KickGuildMember(r.Context(), playerID, body.PlayerID); err == nil {
+			Player_Logic.GuildChanged(body.PlayerID, nil)
+		}
+	case "/player/guild/role":
+		if body.Role == "" {
+			config.Error(w, "role is required", http.StatusBadRequest)
+			return
+		}
+		_, err = config.SetGuildRole(r.Context(), playerID, body.PlayerID, body.Role)
+	}
+	if err != nil {
+		writeGuildError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+}
@@ -0,0 +1,66 @@
+package Routing
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+// registerInstanceRoutes adds the main room instance endpoints to the player router
+func registerInstanceRoutes(router *config.Router) {
+	// The main room and its instances with occupancy (GET)
+	router.Get("/main-instances", handleListMainInstances)
+
+	// Move the caller to another main room instance (POST {room_id})
+	router.Post("/main-instances/hop", handleHopInstance)
+}
+
+// handleListMainInstances returns the main room and its instances
+func handleListMainInstances(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"instances": roomManager.MainInstances()})
+}
+
+// handleHopInstance moves the caller's live session to another main room instance
+func handleHopInstance(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	type RequestBody struct {
+		RoomID string `json:"room_id" validate:"required,max=16"`
+	}
+	var body RequestBody
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	room, err := roomManager.HopInstance(r.Context(), playerID, body.RoomID)
+	if err != nil {
+		switch {
+		case errors.Is(err, Player_Logic.ErrRoomNotFound):
+			config.Error(w, "Room not found", http.StatusNotFound)
+		case errors.Is(err, Player_Logic.ErrNotMainInstance):
+			config.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, Player_Logic.ErrPlayerBanned):
+			config.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, Player_Logic.ErrPlayerNotConnected), errors.Is(err, Player_Logic.ErrSameRoom), errors.Is(err, Player_Logic.ErrRoomFull):
+			config.Error(w, err.Error(), http.StatusConflict)
+		default:
+			config.Logger(r.Context()).Error("Error hopping instances", "player_id", playerID, "error", err)
+			config.Error(w, "Failed to change instance", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"room_id":  room.ID,
+		"capacity": room.GetCapacity(),
+		"players":  buildPlayerList(room),
+	})
+}
@@ -0,0 +1,139 @@
+package Routing
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+// registerInventoryRoutes adds the inventory endpoints to the player router
+func registerInventoryRoutes(router *config.Router) {
+	// GET lists owned items; POST uses or discards one
+	router.Get("/inventory", handleGetInventory)
+	router.Post("/inventory", handleInventoryAction)
+
+	// Every item the server knows about
+	router.Get("/items", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": Player_Logic.ItemCatalog()})
+	})
+}
+
+// handleGetInventory returns the caller's inventory
+func handleGetInventory(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	items, err := Player_Logic.GetInventory(r.Context(), playerID)
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+}
+
+// handleInventoryAction uses or discards one of the caller's items
+func handleInventoryAction(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	type RequestBody struct {
+		Action   string `json:"action"` // "use" or "discard"
+		ItemID   string `json:"item_id"`
+		Quantity int    `json:"quantity"` // Discard only; defaults to 1
+	}
+	var body RequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ItemID == "" {
+		config.Error(w, "item_id is required", http.StatusBadRequest)
+		return
+	}
+	if body.Quantity == 0 {
+		body.Quantity = 1
+	}
+
+	var remaining int
+	var err error
+	switch body.Action {
+	case "use":
+		remaining, err = Player_Logic.UseItem(r.Context(), playerID, body.ItemID)
+	case "discard":
+		remaining, err = Player_Logic.DiscardItem(r.Context(), playerID, body.ItemID, body.Quantity)
+	default:
+		config.Error(w, "action must be use or discard", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeInventoryError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"item_id":   body.ItemID,
+		"remaining": remaining,
+	})
+}
+
+// handleGrantItem gives a player items, e.g. as compensation or an event prize
+func handleGrantItem(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		UserId   string `json:"userId"`
+		ItemID   string `json:"item_id"`
+		Quantity int    `json:"quantity"`
+		Source   string `json:"source"`
+	}
+	var body reqBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		config.Logger(r.Context()).Warn("Decode error", "error", err)
+		config.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if body.UserId == "" || body.ItemID == "" {
+		config.Error(w, "userId and item_id are required", http.StatusBadRequest)
+		return
+	}
+	if body.Quantity == 0 {
+		body.Quantity = 1
+	}
+	if body.Source == "" {
+		body.Source = "admin"
+	}
+
+	item, err := Player_Logic.GrantItem(r.Context(), body.UserId, body.ItemID, body.Quantity, body.Source)
+	if err != nil {
+		writeInventoryError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "item": item})
+}
+
+// writeInventoryError maps inventory errors to HTTP responses
+func writeInventoryError(w http.ResponseWriter, r *http.Request, err error) {
+	if writeQuotaError(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, Player_Logic.ErrUnknownItem),
+		errors.Is(err, Player_Logic.ErrInvalidQuantity),
+		errors.Is(err, Player_Logic.ErrNotConsumable):
+		config.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, config.ErrStackFull), errors.Is(err, config.ErrNotEnoughItems):
+		config.Error(w, err.Error(), http.StatusConflict)
+	default:
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,98 @@
+package Routing
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+// registerLayoutRoutes adds the layout listing to the player router
+func registerLayoutRoutes(router *config.Router) {
+	// Layouts rooms can be created from (GET)
+	router.Get("/layouts", handleListLayouts)
+}
+
+// handleListLayouts returns every layout a new room can use
+func handleListLayouts(w http.ResponseWriter, r *http.Request) {
+	if _, ok := config.ResolvePrincipal(r.Header.Get("Authorization")); !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	layouts, err := Player_Logic.ListLayouts(r.Context())
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"layouts": layouts})
+}
+
+// handleSaveLayout creates or replaces a stored layout
+func handleSaveLayout(w http.ResponseWriter, r *http.Request) {
+	var body Player_Logic.RoomLayout
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		config.Logger(r.Context()).Warn("Decode error", "error", err)
+		config.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := body.Validate(); err != nil {
+		config.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	layout, err := Player_Logic.SaveLayout(r.Context(), body)
+	if err != nil {
+		writeLayoutError(w, r, err)
+		return
+	}
+	config.Logger(r.Context()).Info("Layout saved", "layout_id", layout.ID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "layout": layout})
+}
+
+// handleAdminListLayouts returns every stored and built-in layout
+func handleAdminListLayouts(w http.ResponseWriter, r *http.Request) {
+	layouts, err := Player_Logic.ListLayouts(r.Context())
+	if err != nil {
+		writeLayoutError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"layouts": layouts})
+}
+
+// handleDeleteLayout removes a stored layout (?id=)
+func handleDeleteLayout(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		config.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+	if err := Player_Logic.DeleteLayout(r.Context(), id); err != nil {
+		writeLayoutError(w, r, err)
+		return
+	}
+	config.Logger(r.Context()).Info("Layout deleted", "layout_id", id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// writeLayoutError maps layout errors to HTTP statuses
+func writeLayoutError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, Player_Logic.ErrUnknownLayout):
+		config.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, Player_Logic.ErrBuiltinLayout):
+		config.Error(w, err.Error(), http.StatusConflict)
+	case config.DB == nil:
+		config.Error(w, "Database not available", http.StatusServiceUnavailable)
+	default:
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+	}
+}
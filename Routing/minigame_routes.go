@@ -0,0 +1,71 @@
+package Routing
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+// registerMiniGameRoutes adds the mini-game leaderboards to the player router
+func registerMiniGameRoutes(router *config.Router) {
+	// Mini-games that can be started in rooms (GET)
+	router.Get("/games", handleListMiniGames)
+	// Top players for a mini-game (GET ?game=&limit=)
+	router.Get("/leaderboard", handleLeaderboard)
+}
+
+// handleListMiniGames returns the names of the available mini-games
+func handleListMiniGames(w http.ResponseWriter, r *http.Request) {
+	if _, ok := config.ResolvePrincipal(r.Header.Get("Authorization")); !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"games": Player_Logic.MiniGameNames()})
+}
+
+// handleLeaderboard returns a mini-game's leaderboard
+func handleLeaderboard(w http.ResponseWriter, r *http.Request) {
+	if _, ok := config.ResolvePrincipal(r.Header.Get("Authorization")); !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	game := query.Get("game")
+	if !slices.Contains(Player_Logic.MiniGameNames(), game) {
+		config.Error(w, "Unknown game", http.StatusBadRequest)
+		return
+	}
+
+	limit := config.DefaultLeaderboardLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > config.MaxLeaderboardLimit {
+			config.Error(w, "limit must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	if config.DB == nil {
+		config.Error(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+	entries, err := config.GetLeaderboard(r.Context(), game, limit)
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"game":    game,
+		"entries": entries,
+	})
+}
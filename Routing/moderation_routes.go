@@ -0,0 +1,58 @@
+package Routing
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+// registerModerationRoutes adds room moderation endpoints to the player router
+func registerModerationRoutes(router *config.Router) {
+	router.Post("/room-mute", handleRoomMute)
+	router.Post("/room-unmute", handleRoomMute)
+}
+
+// handleRoomMute mutes (for minutes) or unmutes a player in a room the caller moderates
+func handleRoomMute(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	type RequestBody struct {
+		RoomID   string `json:"room_id"`
+		TargetID string `json:"target_id"`
+		Minutes  int    `json:"minutes"`
+	}
+	var body RequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.RoomID == "" || body.TargetID == "" {
+		config.Error(w, "room_id and target_id are required", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	if r.URL.Path == "/player/room-mute" {
+		err = roomManager.MutePlayer(body.RoomID, actorID, body.TargetID, time.Duration(body.Minutes)*time.Minute)
+	} else {
+		err = roomManager.UnmutePlayer(body.RoomID, actorID, body.TargetID)
+	}
+	if errors.Is(err, Player_Logic.ErrRoomNotFound) {
+		config.Error(w, "Room not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		config.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if room := roomManager.GetPlayerRoom(body.TargetID); room != nil && room.ID == body.RoomID {
+		Player_Logic.NotifyMuteChanged(room, body.TargetID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
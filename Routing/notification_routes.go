@@ -0,0 +1,102 @@
+package Routing
+
+import (
+	"encoding/json"
+	"net/http"
+	"velvet/config"
+)
+
+// registerNotificationRoutes adds push device registration and preferences to the player router
+func registerNotificationRoutes(router *config.Router) {
+	// Register (POST) or unregister (DELETE) a device token for push notifications
+	router.Post("/devices", handleDevices)
+	router.Delete("/devices", handleDevices)
+
+	// Read (GET) or replace (PUT) the caller's notification preferences
+	router.Get("/notifications", handleNotificationPrefs)
+	router.Put("/notifications", handleNotificationPrefs)
+}
+
+// handleDevices registers or unregisters one of the caller's device tokens
+func handleDevices(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	type RequestBody struct {
+		Token    string `json:"token"`
+		Platform string `json:"platform"` // android, ios, or web; POST only
+	}
+	var body RequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		config.Logger(r.Context()).Warn("Decode error", "error", err)
+		config.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if body.Token == "" || len(body.Token) > config.MaxDeviceTokenLength {
+		config.Error(w, "token is required", http.StatusBadRequest)
+		return
+	}
+	if config.DB == nil {
+		config.Error(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var err error
+	if r.Method == http.MethodDelete {
+		err = config.UnregisterDeviceToken(r.Context(), playerID, body.Token)
+	} else {
+		if !config.ValidPlatform(body.Platform) {
+			config.Error(w, "platform must be android, ios, or web", http.StatusBadRequest)
+			return
+		}
+		err = config.RegisterDeviceToken(r.Context(), playerID, body.Token, body.Platform)
+	}
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// handleNotificationPrefs returns or replaces the caller's notification preferences
+func handleNotificationPrefs(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if config.DB == nil {
+		config.Error(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		var prefs config.NotificationPrefs
+		if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+			config.Logger(r.Context()).Warn("Decode error", "error", err)
+			config.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := config.SetNotificationPrefs(r.Context(), playerID, prefs); err != nil {
+			config.Logger(r.Context()).Error("Database error", "error", err)
+			config.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	prefs, err := config.GetNotificationPrefs(r.Context(), playerID)
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"preferences": prefs})
+}
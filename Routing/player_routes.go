@@ -2,7 +2,7 @@ package Routing
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
 	"net/http"
 	"velvet/Player_Logic"
 	"velvet/config"
@@ -13,39 +13,48 @@ import (
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins for development
-	},
+	CheckOrigin:     config.CheckWebSocketOrigin,
 }
 
-var roomManager = Player_Logic.GetRoomManager()
+// roomManager is resolved in SetupPlayerRoutes rather than at package init, so the
+// database is ready when the manager restores persisted rooms
+var roomManager *Player_Logic.RoomManager
 
 // SetupPlayerRoutes configures all player-related routes
 func SetupPlayerRoutes() *config.Router {
 	router := config.NewRouter("/player")
+	roomManager = Player_Logic.GetRoomManager()
+
+	// Outermost, so panics anywhere below become a 500
+	router.Use(config.Recover)
+
+	// Retried joins with an Idempotency-Key get the first attempt's response instead of
+	// moving the player again. Registered before the rate limit so replays don't count.
+	router.UseFor("/join-", config.Idempotent(config.NewIdempotencyCacheFromConfig()))
+
+	// Throttle join attempts per IP and per player
+	router.UseFor("/join-", config.RateLimit(
+		config.NewRateLimiterFromConfig(settings.Security.JoinIPLimit),
+		config.NewRateLimiterFromConfig(settings.Security.JoinPlayerLimit),
+	))
 
 	// Join room endpoint
-	router.HandleFunc("/join-room", handleJoinRoom)
+	router.Post("/join-room", handleJoinRoom)
 
 	// Join specific room endpoint
-	router.HandleFunc("/join-specific-room", handleJoinSpecificRoom)
+	router.Post("/join-specific-room", handleJoinSpecificRoom)
 
 	// Leave room endpoint
-	router.HandleFunc("/leave-room", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
+	router.Post("/leave-room", func(w http.ResponseWriter, r *http.Request) {
 		// Get token from Authorization header
-		token := r.Header.Get("Authorization")
-		if token == "" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+		if !ok {
+			config.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
 		// Remove player from room
-		roomManager.RemovePlayer(token)
+		roomManager.RemovePlayer(playerID)
 
 		// Return success response
 		response := struct {
@@ -60,216 +69,518 @@ func SetupPlayerRoutes() *config.Router {
 		json.NewEncoder(w).Encode(response)
 	})
 
-	// Database stats endpoint for monitoring
-	router.HandleFunc("/db-stats", handleDatabaseStats)
+	// Room directory
+	router.Get("/rooms", handleListRooms)
+
+	// One room's directory entry (GET)
+	router.Get("/rooms/{roomID}", handleGetRoom)
+
+	// Main room instances
+	registerInstanceRoutes(router)
+
+	// Emote catalog for avatar animations
+	router.Get("/emotes", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"emotes": Player_Logic.EmoteCatalog()})
+	})
+
+	// Room chat history (GET ?before=&limit=)
+	router.Get("/rooms/{roomID}/messages", handleRoomMessages)
+
+	// Friends
+	registerFriendRoutes(router)
+
+	// Block list
+	registerBlockRoutes(router)
+
+	// Room moderation (mute/unmute)
+	registerModerationRoutes(router)
+
+	// Inventory
+	registerInventoryRoutes(router)
+
+	// Avatar customization
+	registerAvatarRoutes(router)
+
+	// Daily login rewards
+	registerDailyRewardRoutes(router)
+
+	// Coins
+	registerWalletRoutes(router)
+
+	// Room layouts
+	registerLayoutRoutes(router)
+
+	// Mini-game leaderboards
+	registerMiniGameRoutes(router)
+
+	// Server events
+	registerEventRoutes(router)
+
+	// Player reports
+	registerReportRoutes(router)
+
+	// Push notification devices and preferences
+	registerNotificationRoutes(router)
+
+	// Chat preferences (global chat opt-out)
+	registerChatPreferenceRoutes(router)
 
-	// WebSocket connection stats endpoint for monitoring
-	router.HandleFunc("/ws-stats", handleWebSocketStats)
+	// Guilds
+	registerGuildRoutes(router)
+
+	// Online/away/offline status lookup
+	router.Get("/presence", handlePresence)
+
+	// Parties
+	router.Get("/party", handleGetParty)
+	router.Post("/party/invite", handlePartyInvite)
+	router.Post("/party/accept", handlePartyAccept)
+	router.Post("/party/leave", handlePartyLeave)
+
+	// Matchmaking queue (POST to join, DELETE to leave, GET for status)
+	router.Post("/queue", handleJoinMatchmaking)
+	router.Delete("/queue", handleLeaveMatchmaking)
+	router.Get("/queue", handleMatchmakingStatus)
+
+	// Room layout export/import
+	router.Get("/room-export", handleRoomExport)
+	router.Post("/room-import", handleRoomImport)
+
+	// Referral code for inviting friends
+	router.Get("/referral-code", handleReferralCode)
 
 	// WebSocket endpoint for real-time communication
-	router.HandleFunc("/ws", Player_Logic.HandleWebSocket)
+	router.Get("/ws", Player_Logic.HandleWebSocket)
 
 	return router
 }
 
-// handleDatabaseStats returns database connection pool statistics
-func handleDatabaseStats(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// handleJoinRoom handles player joining a room
+func handleJoinRoom(w http.ResponseWriter, r *http.Request) {
+	// Get player ID from authorization header
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if rejectInactiveAccount(w, playerID) {
 		return
 	}
 
-	stats := config.GetDBStats()
+	logger := config.Logger(r.Context()).With("player_id", playerID)
+	logger.Info("Join room request received")
 
+	// Add player to room
+	room, err := roomManager.AddPlayer(r.Context(), playerID)
+	if err != nil {
+		logger.Error("Error adding player to room", "error", err)
+		if errors.Is(err, Player_Logic.ErrPlayerBanned) {
+			config.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		config.Error(w, "Failed to join room", http.StatusInternalServerError)
+		return
+	}
+
+	// 💾 Update last_room in User table (async - non-blocking)
+	config.UpdateLastRoomAsync(r.Context(), playerID, room.ID)
+
+	// Send response
 	response := map[string]interface{}{
-		"max_open_connections": stats.MaxOpenConnections,
-		"open_connections":     stats.OpenConnections,
-		"in_use":               stats.InUse,
-		"idle":                 stats.Idle,
-		"wait_count":           stats.WaitCount,
-		"wait_duration_ms":     stats.WaitDuration.Milliseconds(),
-		"max_idle_closed":      stats.MaxIdleClosed,
-		"max_idle_time_closed": stats.MaxIdleTimeClosed,
-		"max_lifetime_closed":  stats.MaxLifetimeClosed,
+		"room_id":  room.ID,
+		"capacity": room.GetCapacity(),
+		"players":  buildPlayerList(room),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding database stats response: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		logger.Error("Error encoding response", "error", err)
+		config.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+
+	logger.Info("Join room request completed", "room_id", room.ID)
 }
 
-// handleWebSocketStats returns WebSocket connection statistics
-func handleWebSocketStats(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// handleJoinSpecificRoom handles player joining a specific room
+func handleJoinSpecificRoom(w http.ResponseWriter, r *http.Request) {
+	// Get player ID from authorization header
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if rejectInactiveAccount(w, playerID) {
+		return
+	}
+
+	// Parse request body to get room ID
+	type RequestBody struct {
+		RoomID        string `json:"room_id" validate:"required,max=10"`
+		Capacity      int    `json:"capacity" validate:"min=0,max=500"` // Only applied when the room is created
+		ReservedSlots int    `json:"reserved_slots" validate:"min=0"`   // Only applied when the room is created
+		LayoutID      string `json:"layout_id" validate:"max=64"`       // Only applied when the room is created
+	}
+	var body RequestBody
+	if !decodeBody(w, r, &body) {
 		return
 	}
 
-	wsStats := Player_Logic.GetConnectionStats()
-	dbStats := config.GetDBStats()
-	roomStats := roomManager.GetRoomStats()
-	managerStats := roomManager.GetManagerStats()
+	logger := config.Logger(r.Context()).With("player_id", playerID, "room_id", body.RoomID)
+	logger.Info("Join specific room request received")
+
+	opts := Player_Logic.RoomOptions{
+		Capacity:      body.Capacity,
+		ReservedSlots: body.ReservedSlots,
+		LayoutID:      body.LayoutID,
+	}
 
+	// Add player to specific room; a party leader brings the whole party along
+	var room *Player_Logic.Room
+	var err error
+	if party := Player_Logic.GetPartyManager().GetPlayerParty(playerID); party != nil && party.LeaderID == playerID {
+		var previousRooms map[string]*Player_Logic.Room
+		room, previousRooms, err = roomManager.AddGroupToSpecificRoom(r.Context(), playerID, party.MemberIDsExcept(playerID), body.RoomID, opts)
+		if err == nil {
+			Player_Logic.MovePartyConnections(room, previousRooms, playerID)
+			for memberID := range previousRooms {
+				config.UpdateLastRoomAsync(r.Context(), memberID, room.ID)
+			}
+		}
+	} else {
+		room, err = roomManager.AddPlayerToSpecificRoomWithOptions(r.Context(), playerID, body.RoomID, opts)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, Player_Logic.ErrPlayerBanned):
+			config.Error(w, Player_Logic.ErrPlayerBanned.Error(), http.StatusForbidden)
+		case errors.Is(err, Player_Logic.ErrRoomFull):
+			config.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, Player_Logic.ErrUnknownLayout), errors.Is(err, Player_Logic.ErrInvalidRoomOptions):
+			config.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			logger.Error("Error adding player to specific room", "error", err)
+			config.Error(w, "Failed to join room", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// 💾 Update last_room in User table (async - non-blocking)
+	config.UpdateLastRoomAsync(r.Context(), playerID, room.ID)
+
+	// Send response
 	response := map[string]interface{}{
-		"websocket": wsStats,
-		"database": map[string]interface{}{
-			"active_connections": dbStats.OpenConnections,
-			"max_connections":    dbStats.MaxOpenConnections,
-		},
-		"rooms":        roomStats,
-		"room_manager": managerStats,
-		"server_performance": map[string]interface{}{
-			"buffer_size_kb":          8, // 8KB buffers
-			"batching_enabled":        true,
-			"async_broadcasting":      true,
-			"connection_pooling":      true,
-			"o1_player_lookup":        true,
-			"reduced_lock_contention": true,
-			"automatic_cleanup":       true,
-		},
+		"room_id":  room.ID,
+		"capacity": room.GetCapacity(),
+		"players":  buildPlayerList(room),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding WebSocket stats response: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		logger.Error("Error encoding response", "error", err)
+		config.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+
+	logger.Info("Join specific room request completed")
 }
 
-// handleJoinRoom handles player joining a room
-func handleJoinRoom(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// buildPlayerList returns the visible players of a room for join responses
+func buildPlayerList(room *Player_Logic.Room) []map[string]interface{} {
+	players := make([]map[string]interface{}, 0)
+	for _, player := range room.VisiblePlayers() {
+		position := player.GetPosition()
+		players = append(players, map[string]interface{}{
+			"id":         player.ID,
+			"is_service": player.IsService,
+			"position": map[string]float64{
+				"x": position.X,
+				"y": position.Y,
+			},
+		})
+	}
+	return players
+}
+
+// handleGetRoom returns a room's directory entry; "main" is the main room
+func handleGetRoom(w http.ResponseWriter, r *http.Request) {
+	roomID := config.PathParam(r, "roomID")
+	if roomID == Player_Logic.PortalMainRoom {
+		roomID = roomManager.MainRoomID()
+	}
+	room, exists := roomManager.GetRoomInfo(roomID)
+	if !exists {
+		config.Error(w, "Room not found", http.StatusNotFound)
 		return
 	}
 
-	// Get player ID from authorization header
-	playerID := r.Header.Get("Authorization")
-	if playerID == "" {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(room)
+}
+
+// handleListRooms returns the room directory with occupancy and capacity
+func handleListRooms(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"rooms": roomManager.ListRooms()}); err != nil {
+		config.Logger(r.Context()).Error("Error encoding room directory response", "error", err)
+		config.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+}
 
-	log.Printf("Join room request received")
-	log.Printf("Adding player %s to room", playerID)
+// handleGetParty returns the caller's current party
+func handleGetParty(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
-	// Add player to room
-	room, err := roomManager.AddPlayer(playerID)
+	response := map[string]interface{}{"party": nil}
+	if party := Player_Logic.GetPartyManager().GetPlayerParty(playerID); party != nil {
+		response["party"] = party.Info()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handlePartyInvite invites a player to the caller's party
+func handlePartyInvite(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	type RequestBody struct {
+		TargetPlayerID string `json:"target_player_id"`
+	}
+	var body RequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.TargetPlayerID == "" {
+		config.Error(w, "target_player_id is required", http.StatusBadRequest)
+		return
+	}
+
+	party, err := Player_Logic.GetPartyManager().Invite(playerID, body.TargetPlayerID)
 	if err != nil {
-		log.Printf("Error adding player to room: %v", err)
-		http.Error(w, "Failed to join room", http.StatusInternalServerError)
+		config.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	Player_Logic.NotifyPartyInvite(party, body.TargetPlayerID)
 
-	// 💾 Update last_room in User table (async - non-blocking)
-	config.UpdateLastRoomAsync(playerID, room.ID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "party": party.Info()})
+}
 
-	// Get all players in the room
-	players := make([]map[string]interface{}, 0)
-	for id, player := range room.Players {
-		players = append(players, map[string]interface{}{
-			"id": id,
-			"position": map[string]float64{
-				"x": player.Position.X,
-				"y": player.Position.Y,
-			},
-		})
+// handlePartyAccept accepts a pending party invite
+func handlePartyAccept(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
 	}
 
-	// Send response
-	response := map[string]interface{}{
-		"room_id": room.ID,
-		"players": players,
+	type RequestBody struct {
+		PartyID string `json:"party_id"`
+	}
+	var body RequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.PartyID == "" {
+		config.Error(w, "party_id is required", http.StatusBadRequest)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding response: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	party, err := Player_Logic.GetPartyManager().Accept(playerID, body.PartyID)
+	if err != nil {
+		config.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	Player_Logic.NotifyPartyChanged(party)
 
-	log.Printf("Join room request completed successfully")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "party": party.Info()})
 }
 
-// handleJoinSpecificRoom handles player joining a specific room
-func handleJoinSpecificRoom(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// handlePartyLeave leaves the caller's party
+func handlePartyLeave(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	// Get player ID from authorization header
-	playerID := r.Header.Get("Authorization")
-	if playerID == "" {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	party, released, err := Player_Logic.GetPartyManager().Leave(playerID)
+	if err != nil {
+		config.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	Player_Logic.NotifyPartyChanged(party, append(released, playerID)...)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// handleJoinMatchmaking puts the caller in the matchmaking queue
+func handleJoinMatchmaking(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if rejectInactiveAccount(w, playerID) {
 		return
 	}
 
-	// Parse request body to get room ID
 	type RequestBody struct {
-		RoomID string `json:"room_id"`
+		PartySize int    `json:"party_size"`
+		Region    string `json:"region"`
 	}
 	var body RequestBody
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		log.Printf("Error decoding request body: %v", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		config.Logger(r.Context()).Warn("Error decoding request body", "error", err)
+		config.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if body.RoomID == "" {
-		http.Error(w, "room_id is required", http.StatusBadRequest)
+	entry, err := Player_Logic.GetMatchmaking().Enqueue(playerID, body.Region, body.PartySize)
+	if err != nil {
+		config.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "entry": entry})
+}
 
-	// Validate room ID format (should be alphanumeric, max 10 characters for safety)
-	if len(body.RoomID) > 10 {
-		http.Error(w, "room_id too long (max 10 characters)", http.StatusBadRequest)
+// handleLeaveMatchmaking takes the caller out of the matchmaking queue
+func handleLeaveMatchmaking(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	removed := Player_Logic.GetMatchmaking().Dequeue(playerID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": removed})
+}
+
+// handleMatchmakingStatus reports the caller's place in the queue, or the room they were matched into
+func handleMatchmakingStatus(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	entry, position, matchedRoomID := Player_Logic.GetMatchmaking().Status(playerID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"queued":   entry != nil,
+		"entry":    entry,
+		"position": position,
+		"room_id":  matchedRoomID,
+	})
+}
+
+// handleRoomExport returns a room's settings as a portable JSON document (?room_id=)
+func handleRoomExport(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	log.Printf("Join specific room request received - Player: %s, Room: %s", playerID, body.RoomID)
+	roomID := r.URL.Query().Get("room_id")
+	if roomID == "" {
+		config.Error(w, "room_id is required", http.StatusBadRequest)
+		return
+	}
 
-	// Add player to specific room
-	room, err := roomManager.AddPlayerToSpecificRoom(playerID, body.RoomID)
+	export, err := roomManager.ExportRoom(roomID, playerID)
 	if err != nil {
-		log.Printf("Error adding player to specific room: %v", err)
-		// Return the specific error message
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		config.Logger(r.Context()).Info("Room export rejected", "player_id", playerID, "error", err)
+		if errors.Is(err, Player_Logic.ErrRoomNotFound) {
+			config.Error(w, "Room not found", http.StatusNotFound)
+			return
+		}
+		config.Error(w, err.Error(), http.StatusForbidden)
 		return
 	}
 
-	// 💾 Update last_room in User table (async - non-blocking)
-	config.UpdateLastRoomAsync(playerID, room.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"room-"+roomID+".json\"")
+	if err := json.NewEncoder(w).Encode(export); err != nil {
+		config.Logger(r.Context()).Error("Error encoding room export", "error", err)
+		config.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
 
-	// Get all players in the room
-	players := make([]map[string]interface{}, 0)
-	for id, player := range room.Players {
-		players = append(players, map[string]interface{}{
-			"id": id,
-			"position": map[string]float64{
-				"x": player.Position.X,
-				"y": player.Position.Y,
-			},
-		})
+// handleRoomImport recreates a room from an exported document, with the caller as host
+func handleRoomImport(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if rejectInactiveAccount(w, playerID) {
+		return
 	}
 
-	// Send response
-	response := map[string]interface{}{
-		"room_id": room.ID,
-		"players": players,
+	type RequestBody struct {
+		RoomID string                   `json:"room_id"` // Optional; a code is generated when empty
+		Room   *Player_Logic.RoomExport `json:"room"`
+	}
+	var body RequestBody
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, Player_Logic.MaxRoomExportBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		config.Logger(r.Context()).Warn("Error decoding room import", "error", err)
+		config.Error(w, "Invalid room document", http.StatusBadRequest)
+		return
+	}
+	if body.Room == nil {
+		config.Error(w, "room is required", http.StatusBadRequest)
+		return
+	}
+	if len(body.RoomID) > 10 {
+		config.Error(w, "room_id too long (max 10 characters)", http.StatusBadRequest)
+		return
+	}
+
+	room, err := roomManager.ImportRoom(playerID, body.RoomID, body.Room)
+	if errors.Is(err, Player_Logic.ErrRoomExists) {
+		config.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		config.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding response: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"room_id": room.ID,
+	})
+}
+
+// handleReferralCode returns the caller's referral code, creating it on first request
+func handleReferralCode(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	code, err := config.GetOrCreateReferralCode(playerID, config.ClientIP(r))
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("Join specific room request completed successfully - Player: %s, Room: %s", playerID, body.RoomID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"referral_code": code})
 }
@@ -0,0 +1,36 @@
+package Routing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+// handlePresence returns presence for a comma-separated list of player IDs (?ids=a,b,c)
+func handlePresence(w http.ResponseWriter, r *http.Request) {
+	if _, ok := config.ResolvePrincipal(r.Header.Get("Authorization")); !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var ids []string
+	for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		config.Error(w, "ids is required", http.StatusBadRequest)
+		return
+	}
+	if len(ids) > Player_Logic.MaxPresenceLookup {
+		config.Error(w, fmt.Sprintf("at most %d ids per request", Player_Logic.MaxPresenceLookup), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"presence": Player_Logic.GetPresence().Get(ids)})
+}
@@ -0,0 +1,258 @@
+package Routing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+// Actions an admin can take when resolving a report
+const (
+	ReportActionNone = "none"
+	ReportActionWarn = "warn" // Message the reported player
+	ReportActionKick = "kick" // Disconnect them
+	ReportActionMute = "mute" // Mute them in the room the report came from
+	ReportActionBan  = "ban"  // Global ban; duration_minutes 0 is permanent
+)
+
+// registerReportRoutes adds player reporting to the player router
+func registerReportRoutes(router *config.Router) {
+	// Report another player (POST)
+	router.Post("/report", handleCreateReport)
+}
+
+// registerReportAdminRoutes adds the moderation queue to the admin router
+func registerReportAdminRoutes(router *config.Router) {
+	// Unresolved reports, or ?status= open/triaged/resolved/dismissed (GET, ?limit=)
+	router.Get("/reports", handleListReports)
+
+	// Claim a report for review (POST)
+	router.Post("/reports/triage", handleTriageReport)
+
+	// Resolve (optionally kicking, muting, or banning) or dismiss a report (POST)
+	router.Post("/reports/resolve", handleResolveReport)
+}
+
+// handleCreateReport files a report about another player from the caller
+func handleCreateReport(w http.ResponseWriter, r *http.Request) {
+	reporterID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	type RequestBody struct {
+		ReportedID  string `json:"reported_id"`
+		Reason      string `json:"reason"`
+		ChatExcerpt string `json:"chat_excerpt"`
+	}
+	var body RequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		config.Logger(r.Context()).Warn("Decode error", "error", err)
+		config.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if body.ReportedID == "" || body.ReportedID == reporterID {
+		config.Error(w, "reported_id must be another player", http.StatusBadRequest)
+		return
+	}
+	if body.Reason == "" || len(body.Reason) > config.MaxReportReasonLength {
+		config.Error(w, fmt.Sprintf("reason must be 1-%d characters", config.MaxReportReasonLength), http.StatusBadRequest)
+		return
+	}
+	if len(body.ChatExcerpt) > config.MaxReportExcerptLength {
+		config.Error(w, fmt.Sprintf("chat_excerpt is limited to %d characters", config.MaxReportExcerptLength), http.StatusBadRequest)
+		return
+	}
+
+	report := config.Report{
+		ReporterID:  reporterID,
+		ReportedID:  body.ReportedID,
+		Reason:      body.Reason,
+		ChatExcerpt: body.ChatExcerpt,
+	}
+	if room := roomManager.GetPlayerRoom(reporterID); room != nil {
+		report.RoomID = room.ID
+	}
+
+	created, err := config.CreateReport(r.Context(), report)
+	if err != nil {
+		writeReportError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "report_id": created.ID})
+}
+
+// handleListReports returns the moderation queue
+func handleListReports(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	status := query.Get("status")
+	if status != "" && !config.ValidReportStatus(status) {
+		config.Error(w, "status must be open, triaged, resolved, or dismissed", http.StatusBadRequest)
+		return
+	}
+	limit := config.DefaultReportListLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > config.MaxReportListLimit {
+			config.Error(w, "limit must be between 1 and 200", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	reports, err := config.ListReports(r.Context(), status, limit)
+	if err != nil {
+		writeReportError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"reports": reports})
+}
+
+// handleTriageReport marks a report as under review
+func handleTriageReport(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		ID        int64  `json:"id"`
+		HandledBy string `json:"handled_by"`
+		Note      string `json:"note"`
+	}
+	var body reqBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		config.Logger(r.Context()).Warn("Decode error", "error", err)
+		config.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if body.ID <= 0 || body.HandledBy == "" {
+		config.Error(w, "id and handled_by are required", http.StatusBadRequest)
+		return
+	}
+
+	report, err := config.TriageReport(r.Context(), body.ID, body.HandledBy, body.Note)
+	if err != nil {
+		writeReportError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "report": report})
+}
+
+// handleResolveReport applies the chosen action to the reported player, then closes the report
+func handleResolveReport(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		ID              int64  `json:"id"`
+		Status          string `json:"status"` // resolved or dismissed
+		Action          string `json:"action"`
+		DurationMinutes int    `json:"duration_minutes"` // For mute and ban
+		HandledBy       string `json:"handled_by"`
+		Note            string `json:"note"`
+	}
+	var body reqBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		config.Logger(r.Context()).Warn("Decode error", "error", err)
+		config.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if body.ID <= 0 || body.HandledBy == "" {
+		config.Error(w, "id and handled_by are required", http.StatusBadRequest)
+		return
+	}
+	if body.Status != config.ReportResolved && body.Status != config.ReportDismissed {
+		config.Error(w, config.ErrInvalidReportStatus.Error(), http.StatusBadRequest)
+		return
+	}
+	if body.Action == "" {
+		body.Action = ReportActionNone
+	}
+	if body.Status == config.ReportDismissed && body.Action != ReportActionNone {
+		config.Error(w, "dismissed reports cannot take an action", http.StatusBadRequest)
+		return
+	}
+	if body.DurationMinutes < 0 {
+		config.Error(w, "duration_minutes must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	report, err := config.GetReport(r.Context(), body.ID)
+	if err != nil {
+		writeReportError(w, r, err)
+		return
+	}
+	if report.Status != config.ReportOpen && report.Status != config.ReportTriaged {
+		writeReportError(w, r, config.ErrReportClosed)
+		return
+	}
+
+	duration := time.Duration(body.DurationMinutes) * time.Minute
+	reason := fmt.Sprintf("Report #%d: %s", report.ID, report.Reason)
+	switch body.Action {
+	case ReportActionNone:
+
+	case ReportActionWarn:
+		text := body.Note
+		if text == "" {
+			text = "A moderator reviewed a report about your behavior. Please follow the community rules."
+		}
+		Player_Logic.NotifyModerationWarning(report.ReportedID, text)
+
+	case ReportActionKick:
+		Player_Logic.KickPlayer(body.HandledBy, report.ReportedID, "You were removed by a moderator")
+
+	case ReportActionMute:
+		if report.RoomID == "" {
+			config.Error(w, "The report has no room to mute the player in", http.StatusConflict)
+			return
+		}
+		if err := roomManager.AdminMutePlayer(body.HandledBy, report.RoomID, report.ReportedID, duration); err != nil {
+			config.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+	case ReportActionBan:
+		if _, err := config.GetBanStore().IssueBan(report.ReportedID, reason, body.HandledBy, duration); err != nil {
+			config.Logger(r.Context()).Error("Database error", "error", err)
+			config.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		Player_Logic.KickPlayer(body.HandledBy, report.ReportedID, "Your account has been banned: "+report.Reason)
+
+	default:
+		config.Error(w, "action must be none, warn, kick, mute, or ban", http.StatusBadRequest)
+		return
+	}
+
+	closed, err := config.CloseReport(r.Context(), body.ID, body.Status, body.Action, body.HandledBy, body.Note)
+	if err != nil {
+		writeReportError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "report": closed})
+}
+
+// writeReportError maps report errors to HTTP statuses
+func writeReportError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, config.ErrReportNotFound):
+		config.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, config.ErrDuplicateReport), errors.Is(err, config.ErrReportClosed):
+		config.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, config.ErrInvalidReportStatus):
+		config.Error(w, err.Error(), http.StatusBadRequest)
+	case config.DB == nil:
+		config.Error(w, "Database not available", http.StatusServiceUnavailable)
+	default:
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+	}
+}
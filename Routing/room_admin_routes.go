@@ -0,0 +1,150 @@
+package Routing
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+// registerRoomAdminRoutes mounts live room and connection management on the admin router
+func registerRoomAdminRoutes(router *config.Router) {
+	// Every room with occupancy, capacity, and any live event (GET)
+	router.Get("/rooms", handleAdminListRooms)
+
+	// Everyone in a room, hidden service accounts included (GET ?room_id=)
+	router.Get("/rooms/players", handleAdminRoomPlayers)
+
+	// Move everyone to the main room and delete the room (POST)
+	router.Post("/rooms/close", handleAdminCloseRoom)
+
+	// Kick a player out of their room and close their connection (POST)
+	router.Post("/players/remove", handleAdminRemovePlayer)
+
+	// Open WebSocket connections (GET, optional ?room_id= or ?player_id=)
+	router.Get("/connections", handleAdminConnections)
+}
+
+// handleAdminListRooms returns every room in the directory
+func handleAdminListRooms(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"rooms":        roomManager.ListRooms(),
+		"main_room_id": roomManager.MainRoomID(),
+	})
+}
+
+// handleAdminRoomPlayers returns the players in a room
+func handleAdminRoomPlayers(w http.ResponseWriter, r *http.Request) {
+	roomID := r.URL.Query().Get("room_id")
+	if roomID == "" {
+		config.Error(w, "room_id is required", http.StatusBadRequest)
+		return
+	}
+	players, err := roomManager.ListRoomPlayers(roomID)
+	if err != nil {
+		config.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"room_id": roomID,
+		"players": players,
+	})
+}
+
+// handleAdminCloseRoom closes a room, moving its players to the main room
+func handleAdminCloseRoom(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		RoomID string `json:"room_id"`
+		Reason string `json:"reason"`
+	}
+	var body reqBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		config.Logger(r.Context()).Warn("Decode error", "error", err)
+		config.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if body.RoomID == "" {
+		config.Error(w, "room_id is required", http.StatusBadRequest)
+		return
+	}
+	if body.Reason == "" {
+		body.Reason = "This room was closed by an administrator"
+	}
+
+	moved, err := roomManager.CloseRoom(config.AdminActor(r), body.RoomID, body.Reason)
+	switch {
+	case errors.Is(err, Player_Logic.ErrRoomNotFound):
+		config.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, Player_Logic.ErrCannotCloseMain):
+		config.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "players_moved": moved})
+}
+
+// handleAdminRemovePlayer disconnects a player and removes them from their room
+func handleAdminRemovePlayer(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		UserId string `json:"userId"`
+		Reason string `json:"reason"`
+	}
+	var body reqBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		config.Logger(r.Context()).Warn("Decode error", "error", err)
+		config.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if body.UserId == "" {
+		config.Error(w, "userId is required", http.StatusBadRequest)
+		return
+	}
+	if body.Reason == "" {
+		body.Reason = "You were removed by an administrator"
+	}
+
+	if err := roomManager.ForceRemovePlayer(config.AdminActor(r), body.UserId, body.Reason); err != nil {
+		config.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// handleAdminConnections returns open connections, or one player's connection with
+// ?player_id=. ?degraded=true lists only connections that are dropping messages or lagging.
+func handleAdminConnections(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	w.Header().Set("Content-Type", "application/json")
+	if playerID := query.Get("player_id"); playerID != "" {
+		info, exists := Player_Logic.GetConnectionInfo(playerID)
+		if !exists {
+			config.Error(w, "Player is not connected", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"connection": info})
+		return
+	}
+
+	connections := Player_Logic.ListConnections(query.Get("room_id"))
+	if query.Get("degraded") == "true" {
+		degraded := connections[:0]
+		for _, info := range connections {
+			if info.Quality.Degraded {
+				degraded = append(degraded, info)
+			}
+		}
+		connections = degraded
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"connections": connections,
+		"count":       len(connections),
+	})
+}
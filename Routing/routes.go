@@ -1,9 +1,23 @@
 package Routing
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
+	"velvet/config"
 )
 
+// settings is the configuration injected by Configure; the defaults apply until then
+var settings = config.DefaultAppConfig()
+
+// Configure injects the application config; call it once at startup before setting up routes
+func Configure(cfg *config.AppConfig) {
+	settings = cfg
+	upgrader.ReadBufferSize = cfg.WebSocket.ReadBufferSize
+	upgrader.WriteBufferSize = cfg.WebSocket.WriteBufferSize
+	applyProfilingRates(cfg.Profiling)
+}
+
 // SetupRoutes configures all the routes for the application
 func SetupRoutes() http.Handler {
 	mux := http.NewServeMux()
@@ -19,5 +33,66 @@ func SetupRoutes() http.Handler {
 	// Mount player routes
 	mux.Handle("/player/", SetupPlayerRoutes())
 
+	// Mount admin routes
+	mux.Handle("/admin/", SetupAdminRoutes())
+
 	return mux
 }
+
+// rejectInactiveAccount writes a 403 and returns true when the user is globally banned or
+// their account is scheduled for deletion
+func rejectInactiveAccount(w http.ResponseWriter, userID string) bool {
+	if config.GetBanStore().IsBanned(userID) {
+		slog.Info("Rejected request from banned user", "user_id", userID)
+		config.Error(w, "Account is banned", http.StatusForbidden)
+		return true
+	}
+	if config.IsDeletionPending(userID) {
+		slog.Info("Rejected request from account pending deletion", "user_id", userID)
+		config.Error(w, "Account is scheduled for deletion", http.StatusForbidden)
+		return true
+	}
+	return false
+}
+
+// decodeBody decodes and validates a JSON request body into dst, writing a 400 listing the
+// invalid fields and returning false when it doesn't pass
+func decodeBody(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	err := config.DecodeJSON(r, dst)
+	if err == nil {
+		return true
+	}
+	var validationErr *config.ValidationError
+	if !errors.As(err, &validationErr) {
+		// A malformed validate tag on the body's struct
+		config.Logger(r.Context()).Error("Could not validate request body", "error", err)
+		config.Error(w, "Internal server error", http.StatusInternalServerError)
+		return false
+	}
+	config.Logger(r.Context()).Debug("Rejected request body", "error", err)
+	config.WriteError(w, http.StatusBadRequest, config.APIError{
+		Code:    config.CodeInvalidRequest,
+		Message: "Invalid request body",
+		Details: validationErr.Fields,
+	})
+	return false
+}
+
+// writeQuotaError writes a structured 403 and returns true when err is a quota violation
+func writeQuotaError(w http.ResponseWriter, err error) bool {
+	var quotaErr *config.QuotaError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+	config.WriteError(w, http.StatusForbidden, config.APIError{
+		Code:    config.CodeQuotaExceeded,
+		Message: quotaErr.Error(),
+		Details: map[string]interface{}{
+			"resource":  quotaErr.Resource,
+			"limit":     quotaErr.Limit,
+			"used":      quotaErr.Used,
+			"requested": quotaErr.Requested,
+		},
+	})
+	return true
+}
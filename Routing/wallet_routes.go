@@ -0,0 +1,108 @@
+package Routing
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"velvet/Player_Logic"
+	"velvet/config"
+)
+
+// registerWalletRoutes adds the wallet endpoint to the player router
+func registerWalletRoutes(router *config.Router) {
+	// Balance and transaction history (GET ?before=&limit=)
+	router.Get("/wallet", handleWallet)
+}
+
+// handleWallet returns the caller's coin balance and recent transactions
+func handleWallet(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := config.ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		config.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	var before int64
+	if value := query.Get("before"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			config.Error(w, "before must be a transaction id", http.StatusBadRequest)
+			return
+		}
+		before = parsed
+	}
+
+	limit := config.DefaultWalletHistoryLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > config.MaxWalletHistoryLimit {
+			config.Error(w, "limit must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	balance, err := config.GetBalance(r.Context(), playerID)
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	transactions, err := config.GetWalletTransactions(r.Context(), playerID, before, limit)
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"balance":      balance,
+		"transactions": transactions,
+	})
+}
+
+// handleAdjustWallet credits (positive amount) or debits (negative amount) a player's coins
+func handleAdjustWallet(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		UserId string `json:"userId"`
+		Amount int64  `json:"amount"`
+		Reason string `json:"reason"`
+	}
+	var body reqBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		config.Logger(r.Context()).Warn("Decode error", "error", err)
+		config.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if body.UserId == "" || body.Amount == 0 {
+		config.Error(w, "userId and a non-zero amount are required", http.StatusBadRequest)
+		return
+	}
+	if body.Reason == "" {
+		body.Reason = "admin"
+	}
+
+	var balance int64
+	var err error
+	if body.Amount > 0 {
+		balance, err = Player_Logic.CreditCoins(r.Context(), body.UserId, body.Amount, body.Reason, "")
+	} else {
+		balance, err = Player_Logic.DebitCoins(r.Context(), body.UserId, -body.Amount, body.Reason, "")
+	}
+	if errors.Is(err, config.ErrInsufficientFunds) {
+		config.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		config.Logger(r.Context()).Error("Database error", "error", err)
+		config.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	config.Logger(r.Context()).Info("Wallet adjusted", "user_id", body.UserId, "amount", body.Amount, "reason", body.Reason)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "balance": balance})
+}
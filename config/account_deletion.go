@@ -0,0 +1,196 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+var (
+	ErrDeletionPending   = errors.New("account is already scheduled for deletion")
+	ErrNoPendingDeletion = errors.New("account is not scheduled for deletion")
+)
+
+// AccountDeletion is a soft-deleted account waiting out its undo window
+type AccountDeletion struct {
+	UserID      string    `json:"user_id"`
+	RequestedAt time.Time `json:"requested_at"`
+	PurgeAfter  time.Time `json:"purge_after"`
+}
+
+// purgeStatements remove a user's rows from every table this service owns. Bans, reports
+// about the user, and the audit log are kept for moderation.
+var purgeStatements = []string{
+	`DELETE FROM chat_messages WHERE sender_id = $1`,
+	`DELETE FROM messages WHERE sender_id = $1 OR recipient_id = $1`,
+	`DELETE FROM inventory_items WHERE user_id = $1`,
+	`DELETE FROM wallet_transactions WHERE user_id = $1`,
+	`DELETE FROM wallets WHERE user_id = $1`,
+	`DELETE FROM player_progress WHERE user_id = $1`,
+	`DELETE FROM daily_rewards WHERE user_id = $1`,
+	`DELETE FROM avatars WHERE user_id = $1`,
+	`DELETE FROM player_usage WHERE user_id = $1`,
+	`DELETE FROM friendships WHERE requester_id = $1 OR addressee_id = $1`,
+	`DELETE FROM blocks WHERE blocker_id = $1 OR blocked_id = $1`,
+	`DELETE FROM referral_codes WHERE user_id = $1`,
+	`DELETE FROM referrals WHERE referrer_id = $1 OR referee_id = $1`,
+	`DELETE FROM minigame_stats WHERE user_id = $1`,
+	`DELETE FROM player_reports WHERE reporter_id = $1`,
+	`DELETE FROM device_tokens WHERE user_id = $1`,
+	`DELETE FROM notification_preferences WHERE user_id = $1`,
+	`DELETE FROM chat_preferences WHERE user_id = $1`,
+	// A guild the user leads passes to its senior officer, else its longest-standing member;
+	// one they're alone in is disbanded
+	`DELETE FROM guilds g WHERE g.leader_id = $1
+		AND NOT EXISTS (SELECT 1 FROM guild_members m WHERE m.guild_id = g.id AND m.user_id <> $1)`,
+	`UPDATE guilds g SET leader_id = (
+		SELECT m.user_id FROM guild_members m WHERE m.guild_id = g.id AND m.user_id <> $1
+		ORDER BY m.role = 'officer' DESC, m.joined_at LIMIT 1
+	) WHERE g.leader_id = $1`,
+	`UPDATE guild_members m SET role = 'leader' FROM guilds g
+		WHERE m.guild_id = g.id AND m.user_id = g.leader_id AND m.role <> 'leader'
+		AND g.id IN (SELECT guild_id FROM guild_members WHERE user_id = $1)`,
+	`DELETE FROM guild_members WHERE user_id = $1`,
+}
+
+// RequestAccountDeletion soft-deletes an account; it's purged once grace has passed unless
+// CancelAccountDeletion runs first
+func RequestAccountDeletion(ctx context.Context, userID string, grace time.Duration) (*AccountDeletion, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	exists, err := GetUserStore().Exists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+
+	deletion := AccountDeletion{UserID: userID}
+	err = Conn(ctx).QueryRowContext(ctx, `
+		INSERT INTO account_deletions (user_id, purge_after) VALUES ($1, NOW() + $2 * INTERVAL '1 second')
+		ON CONFLICT (user_id) DO NOTHING
+		RETURNING requested_at, purge_after
+	`, userID, int64(grace.Seconds())).Scan(&deletion.RequestedAt, &deletion.PurgeAfter)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDeletionPending
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule deletion of user %s: %w", userID, err)
+	}
+
+	slog.Info("Account deletion requested", "user_id", userID, "purge_after", deletion.PurgeAfter)
+	return &deletion, nil
+}
+
+// CancelAccountDeletion restores a soft-deleted account that hasn't been purged yet
+func CancelAccountDeletion(ctx context.Context, userID string) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	result, err := Conn(ctx).ExecContext(ctx, `DELETE FROM account_deletions WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel deletion of user %s: %w", userID, err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrNoPendingDeletion
+	}
+
+	slog.Info("Account deletion cancelled", "user_id", userID)
+	return nil
+}
+
+// IsDeletionPending reports whether an account is soft-deleted. Lookup failures are logged
+// and treated as not deleted, like IsBanned.
+func IsDeletionPending(userID string) bool {
+	if DB == nil {
+		return false
+	}
+
+	var pending bool
+	err := DB.QueryRow(`SELECT EXISTS (SELECT 1 FROM account_deletions WHERE user_id = $1)`, userID).Scan(&pending)
+	if err != nil {
+		slog.Warn("Account deletion lookup failed", "user_id", userID, "error", err)
+		return false
+	}
+	return pending
+}
+
+// DueAccountDeletions returns up to limit accounts whose undo window has passed
+func DueAccountDeletions(ctx context.Context, limit int) ([]string, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := Conn(ctx).QueryContext(ctx, `
+		SELECT user_id FROM account_deletions WHERE purge_after <= NOW() ORDER BY purge_after LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due account deletions: %w", err)
+	}
+	defer rows.Close()
+
+	userIDs := make([]string, 0)
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan account deletion: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+// PurgeAccount permanently removes a soft-deleted account and its data
+func PurgeAccount(ctx context.Context, userID string) error {
+	err := WithTx(ctx, func(ctx context.Context) error {
+		// Re-check inside the transaction so a restore that just landed wins
+		var found int
+		err := Conn(ctx).QueryRowContext(ctx,
+			`SELECT 1 FROM account_deletions WHERE user_id = $1 AND purge_after <= NOW() FOR UPDATE`, userID,
+		).Scan(&found)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoPendingDeletion
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check deletion of user %s: %w", userID, err)
+		}
+
+		var imageKey string
+		err = Conn(ctx).QueryRowContext(ctx,
+			`DELETE FROM avatar_images WHERE user_id = $1 RETURNING object_key`, userID,
+		).Scan(&imageKey)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to delete avatar image for user %s: %w", userID, err)
+		}
+
+		for _, statement := range purgeStatements {
+			if _, err := Conn(ctx).ExecContext(ctx, statement, userID); err != nil {
+				return fmt.Errorf("failed to purge user %s: %w", userID, err)
+			}
+		}
+		if err := GetUserStore().Delete(ctx, userID); err != nil && !errors.Is(err, ErrUserNotFound) {
+			return err
+		}
+		if _, err := Conn(ctx).ExecContext(ctx, `DELETE FROM account_deletions WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("failed to clear deletion of user %s: %w", userID, err)
+		}
+
+		if store := GetBlobStore(); store != nil && imageKey != "" {
+			AfterCommit(ctx, func() { deleteBlob(store, imageKey) })
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Account purged", "user_id", userID)
+	return nil
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// AdminKeyHeader carries the admin API key on admin requests
+const AdminKeyHeader = "X-Admin-Key"
+
+// IsAdminRequest checks the request's admin key against ADMIN_API_KEY.
+// Admin access is disabled entirely when no key is configured.
+func IsAdminRequest(r *http.Request) bool {
+	expected := settings.Security.AdminAPIKey
+	if expected == "" {
+		return false
+	}
+	provided := r.Header.Get(AdminKeyHeader)
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) == 1
+}
+
+// RequireAdmin wraps a handler so only requests with a valid admin key reach it. Every
+// admitted call is written to the audit log with its method, query, and status.
+func RequireAdmin(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !IsAdminRequest(r) {
+			Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(recorder, r)
+
+		RecordAudit(r.Context(), AdminActor(r), AuditAdminAPI, r.URL.Path, map[string]interface{}{
+			"method": r.Method,
+			"query":  r.URL.RawQuery,
+			"status": recorder.status,
+		})
+	}
+}
@@ -0,0 +1,375 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// AppConfig is the game server's tunable configuration: connection limits, timeouts, room
+// limits, and simulation settings. main loads it once with LoadAppConfig and injects it
+// with Configure here and in Player_Logic and Routing. Infrastructure connections (database
+// URL, broker, TLS, tracing, logging) read their own variables where they're initialized.
+type AppConfig struct {
+	Server      ServerConfig
+	WebSocket   WebSocketConfig
+	Rooms       RoomConfig
+	Chat        ChatConfig
+	Progression ProgressionConfig
+	Accounts    AccountConfig
+	Profiling   ProfilingConfig
+	Security    SecurityConfig
+	AsyncDB     AsyncDBConfig
+}
+
+// ServerConfig covers the HTTP listener and shutdown
+type ServerConfig struct {
+	Addr            string        // Listen address, from PORT
+	ShutdownTimeout time.Duration // SHUTDOWN_TIMEOUT_SECONDS
+	DrainCountdown  time.Duration // SHUTDOWN_DRAIN_SECONDS, warning given to WebSocket clients
+}
+
+// WebSocketConfig covers connection limits and timeouts
+type WebSocketConfig struct {
+	ReadBufferSize  int           // WS_READ_BUFFER_SIZE (bytes)
+	WriteBufferSize int           // WS_WRITE_BUFFER_SIZE (bytes)
+	MaxConnections  int           // WS_MAX_CONNECTIONS per instance
+	MaxQueueLength  int           // WS_MAX_QUEUE_LENGTH, clients allowed to wait for a slot
+	QueueTimeout    time.Duration // WS_QUEUE_TIMEOUT_SECONDS
+	WriteTimeout    time.Duration // WS_WRITE_TIMEOUT_SECONDS
+	ReadTimeout     time.Duration // WS_READ_TIMEOUT_SECONDS
+	PongTimeout     time.Duration // WS_PONG_TIMEOUT_SECONDS
+	PingPeriod      time.Duration // WS_PING_PERIOD_SECONDS, must be shorter than PongTimeout
+	ResumeWindow    time.Duration // SESSION_RESUME_SECONDS (0 removes dropped players immediately)
+	ShareLatency    bool          // WS_SHARE_LATENCY, include players' round-trip times in player_joined
+	Compression     bool          // WS_COMPRESSION, offer permessage-deflate (clients can still opt out)
+	CompressMinSize int           // WS_COMPRESSION_THRESHOLD_BYTES, smaller frames are sent uncompressed
+	MaxSendBacklog  int           // WS_MAX_SEND_BACKLOG_BYTES queued behind a full send buffer before disconnecting
+	DegradedDrops   int           // WS_DEGRADED_DROPS, dropped frames per minute that mark a connection degraded
+	DegradedRTT     time.Duration // WS_DEGRADED_RTT_MS, round-trip time that marks a connection degraded (0 disables)
+}
+
+// RoomConfig covers room capacity and simulation
+type RoomConfig struct {
+	MaxPlayers            int           // ROOM_MAX_PLAYERS, upper bound for any room's capacity
+	InactiveTimeout       time.Duration // ROOM_INACTIVE_TIMEOUT_SECONDS before empty rooms are removed
+	MainRoomReservedSlots int           // MAIN_ROOM_RESERVED_SLOTS
+	MainRoomLayout        string        // MAIN_ROOM_LAYOUT, layout ID the main room is built from
+	MainRoomMaxInstances  int           // MAIN_ROOM_MAX_INSTANCES, copies of the main room opened as it fills (0 disables)
+	PrivilegedPlayerIDs   []string      // PRIVILEGED_PLAYER_IDS, may use reserved slots
+	TickRate              int           // ROOM_TICK_RATE in Hz (0 broadcasts every update)
+	InterestRadius        float64       // AOI_RADIUS in world units (0 is room-wide)
+	MaxMoveSpeed          float64       // MOVE_MAX_SPEED in world units per second (0 is unlimited)
+	MapWidth              float64       // MAP_WIDTH (0 is unbounded)
+	MapHeight             float64       // MAP_HEIGHT (0 is unbounded)
+	AwayAfter             time.Duration // PRESENCE_AWAY_AFTER_SECONDS of inactivity before "away"
+}
+
+// ChatConfig covers chat filtering and translation
+type ChatConfig struct {
+	BannedTerms       []string // CHAT_BANNED_TERMS, "term:mild" or "term:severe"
+	TranslationAPIURL string   // TRANSLATION_API_URL (empty disables translation)
+	TranslationAPIKey string   // TRANSLATION_API_KEY
+	LocalRadius       float64  // LOCAL_CHAT_RADIUS in world units reached by "local" chat
+}
+
+// ProgressionConfig covers how players earn XP
+type ProgressionConfig struct {
+	XPPerMinuteOnline int           // XP_PER_MINUTE_ONLINE while connected and not away
+	XPPerChat         int           // XP_PER_CHAT_MESSAGE
+	ChatXPCooldown    time.Duration // XP_CHAT_COOLDOWN_SECONDS between chat messages that earn XP
+	XPPerRoomVisit    int           // XP_PER_ROOM_VISIT, first visit to each room per day
+	FlushInterval     time.Duration // XP_FLUSH_SECONDS between batched writes
+	RewardTimezone    string        // DAILY_REWARD_TIMEZONE (IANA name) whose midnight starts a new reward day
+}
+
+// AccountConfig covers account lifecycle
+type AccountConfig struct {
+	DeletionGrace       time.Duration // ACCOUNT_DELETION_GRACE_SECONDS a deleted account can still be restored
+	ReferralRewardCoins int           // REFERRAL_REWARD_COINS credited to both players for an accepted referral (0 disables)
+}
+
+// ProfilingConfig covers block and mutex profile sampling for the admin pprof endpoints
+type ProfilingConfig struct {
+	BlockRate     int // PPROF_BLOCK_RATE (0 is off)
+	MutexFraction int // PPROF_MUTEX_FRACTION (0 is off)
+}
+
+// SecurityConfig covers admin access, HTTP rate limits, and which proxies are trusted
+type SecurityConfig struct {
+	AdminAPIKey     string          // ADMIN_API_KEY (empty disables the admin API)
+	TrustedProxies  []string        // TRUSTED_PROXIES, IPs or CIDRs whose X-Forwarded-For is believed
+	IdempotencyTTL  time.Duration   // IDEMPOTENCY_TTL_SECONDS a join's response is replayed for
+	AuthIPLimit     RateLimitConfig // RATE_LIMIT_AUTH_IP_*
+	AuthPlayerLimit RateLimitConfig // RATE_LIMIT_AUTH_PLAYER_*
+	JoinIPLimit     RateLimitConfig // RATE_LIMIT_JOIN_IP_*
+	JoinPlayerLimit RateLimitConfig // RATE_LIMIT_JOIN_PLAYER_*
+}
+
+// RateLimitConfig is one HTTP rate limit, from RATE_LIMIT_<group>_PER_MINUTE and
+// RATE_LIMIT_<group>_BURST
+type RateLimitConfig struct {
+	PerMinute int // 0 disables the limit
+	Burst     int
+}
+
+// AsyncDBConfig covers the queue for non-critical database writes
+type AsyncDBConfig struct {
+	Workers   int // DB_ASYNC_WORKERS
+	QueueSize int // DB_ASYNC_QUEUE_SIZE, operations waiting before new ones are dropped
+}
+
+// Server-wide bounds that configuration can't go beyond
+const (
+	MinRoomCapacity   = 2
+	MinWSBufferSize   = 512
+	MaxRoomCapacity   = 500
+	MaxWSBufferSize   = 1 << 20
+	DefaultListenAddr = ":8080"
+)
+
+// DefaultAppConfig returns the built-in settings
+func DefaultAppConfig() *AppConfig {
+	return &AppConfig{
+		Server: ServerConfig{
+			Addr:            DefaultListenAddr,
+			ShutdownTimeout: 30 * time.Second,
+			DrainCountdown:  10 * time.Second,
+		},
+		WebSocket: WebSocketConfig{
+			ReadBufferSize:  8192,
+			WriteBufferSize: 8192,
+			MaxConnections:  1000,
+			MaxQueueLength:  500,
+			QueueTimeout:    10 * time.Minute,
+			WriteTimeout:    10 * time.Second,
+			ReadTimeout:     60 * time.Second,
+			PongTimeout:     60 * time.Second,
+			PingPeriod:      54 * time.Second,
+			ResumeWindow:    15 * time.Second,
+			Compression:     true,
+			CompressMinSize: 512,
+			MaxSendBacklog:  1 << 20,
+			DegradedDrops:   10,
+			DegradedRTT:     time.Second,
+		},
+		Rooms: RoomConfig{
+			MaxPlayers:           20,
+			InactiveTimeout:      30 * time.Minute,
+			TickRate:             20,
+			InterestRadius:       600,
+			MaxMoveSpeed:         600,
+			AwayAfter:            5 * time.Minute,
+			MainRoomLayout:       "default",
+			MainRoomMaxInstances: 10,
+		},
+		Chat: ChatConfig{
+			LocalRadius: 300,
+		},
+		Progression: ProgressionConfig{
+			XPPerMinuteOnline: 2,
+			XPPerChat:         1,
+			ChatXPCooldown:    30 * time.Second,
+			XPPerRoomVisit:    10,
+			FlushInterval:     30 * time.Second,
+			RewardTimezone:    "UTC",
+		},
+		Accounts: AccountConfig{
+			DeletionGrace:       72 * time.Hour,
+			ReferralRewardCoins: 100,
+		},
+		Security: SecurityConfig{
+			IdempotencyTTL:  2 * time.Minute,
+			AuthIPLimit:     RateLimitConfig{PerMinute: 30, Burst: 10},
+			AuthPlayerLimit: RateLimitConfig{PerMinute: 30, Burst: 10},
+			JoinIPLimit:     RateLimitConfig{PerMinute: 60, Burst: 20},
+			JoinPlayerLimit: RateLimitConfig{PerMinute: 20, Burst: 5},
+		},
+		AsyncDB: AsyncDBConfig{
+			Workers:   DefaultDBAsyncWorkers,
+			QueueSize: DefaultDBAsyncQueueSize,
+		},
+	}
+}
+
+// LoadAppConfig reads the environment over the defaults and validates the result
+func LoadAppConfig() (*AppConfig, error) {
+	cfg := DefaultAppConfig()
+
+	if port := os.Getenv("PORT"); port != "" {
+		cfg.Server.Addr = ":" + strings.TrimPrefix(port, ":")
+	}
+	cfg.Server.ShutdownTimeout = GetEnvSeconds("SHUTDOWN_TIMEOUT_SECONDS", cfg.Server.ShutdownTimeout)
+	cfg.Server.DrainCountdown = GetEnvSeconds("SHUTDOWN_DRAIN_SECONDS", cfg.Server.DrainCountdown)
+
+	ws := &cfg.WebSocket
+	ws.ReadBufferSize = GetEnvInt("WS_READ_BUFFER_SIZE", ws.ReadBufferSize)
+	ws.WriteBufferSize = GetEnvInt("WS_WRITE_BUFFER_SIZE", ws.WriteBufferSize)
+	ws.MaxConnections = GetEnvInt("WS_MAX_CONNECTIONS", ws.MaxConnections)
+	ws.MaxQueueLength = GetEnvInt("WS_MAX_QUEUE_LENGTH", ws.MaxQueueLength)
+	ws.QueueTimeout = GetEnvSeconds("WS_QUEUE_TIMEOUT_SECONDS", ws.QueueTimeout)
+	ws.WriteTimeout = GetEnvSeconds("WS_WRITE_TIMEOUT_SECONDS", ws.WriteTimeout)
+	ws.ReadTimeout = GetEnvSeconds("WS_READ_TIMEOUT_SECONDS", ws.ReadTimeout)
+	ws.PongTimeout = GetEnvSeconds("WS_PONG_TIMEOUT_SECONDS", ws.PongTimeout)
+	ws.PingPeriod = GetEnvSeconds("WS_PING_PERIOD_SECONDS", ws.PingPeriod)
+	ws.ResumeWindow = GetEnvSeconds("SESSION_RESUME_SECONDS", ws.ResumeWindow)
+	ws.ShareLatency = GetEnvBool("WS_SHARE_LATENCY", ws.ShareLatency)
+	ws.Compression = GetEnvBool("WS_COMPRESSION", ws.Compression)
+	ws.CompressMinSize = GetEnvInt("WS_COMPRESSION_THRESHOLD_BYTES", ws.CompressMinSize)
+	ws.MaxSendBacklog = GetEnvInt("WS_MAX_SEND_BACKLOG_BYTES", ws.MaxSendBacklog)
+	ws.DegradedDrops = GetEnvInt("WS_DEGRADED_DROPS", ws.DegradedDrops)
+	ws.DegradedRTT = time.Duration(GetEnvInt("WS_DEGRADED_RTT_MS", int(ws.DegradedRTT/time.Millisecond))) * time.Millisecond
+
+	rooms := &cfg.Rooms
+	rooms.MaxPlayers = GetEnvInt("ROOM_MAX_PLAYERS", rooms.MaxPlayers)
+	rooms.InactiveTimeout = GetEnvSeconds("ROOM_INACTIVE_TIMEOUT_SECONDS", rooms.InactiveTimeout)
+	rooms.MainRoomReservedSlots = GetEnvInt("MAIN_ROOM_RESERVED_SLOTS", rooms.MainRoomReservedSlots)
+	rooms.MainRoomLayout = GetEnvString("MAIN_ROOM_LAYOUT", rooms.MainRoomLayout)
+	rooms.MainRoomMaxInstances = GetEnvInt("MAIN_ROOM_MAX_INSTANCES", rooms.MainRoomMaxInstances)
+	rooms.PrivilegedPlayerIDs = GetEnvList("PRIVILEGED_PLAYER_IDS")
+	rooms.TickRate = GetEnvInt("ROOM_TICK_RATE", rooms.TickRate)
+	rooms.InterestRadius = GetEnvFloat("AOI_RADIUS", rooms.InterestRadius)
+	rooms.MaxMoveSpeed = GetEnvFloat("MOVE_MAX_SPEED", rooms.MaxMoveSpeed)
+	rooms.MapWidth = GetEnvFloat("MAP_WIDTH", rooms.MapWidth)
+	rooms.MapHeight = GetEnvFloat("MAP_HEIGHT", rooms.MapHeight)
+	rooms.AwayAfter = GetEnvSeconds("PRESENCE_AWAY_AFTER_SECONDS", rooms.AwayAfter)
+
+	cfg.Chat.BannedTerms = GetEnvList("CHAT_BANNED_TERMS")
+	cfg.Chat.TranslationAPIURL = os.Getenv("TRANSLATION_API_URL")
+	cfg.Chat.TranslationAPIKey = os.Getenv("TRANSLATION_API_KEY")
+	cfg.Chat.LocalRadius = GetEnvFloat("LOCAL_CHAT_RADIUS", cfg.Chat.LocalRadius)
+
+	progression := &cfg.Progression
+	progression.XPPerMinuteOnline = GetEnvInt("XP_PER_MINUTE_ONLINE", progression.XPPerMinuteOnline)
+	progression.XPPerChat = GetEnvInt("XP_PER_CHAT_MESSAGE", progression.XPPerChat)
+	progression.ChatXPCooldown = GetEnvSeconds("XP_CHAT_COOLDOWN_SECONDS", progression.ChatXPCooldown)
+	progression.XPPerRoomVisit = GetEnvInt("XP_PER_ROOM_VISIT", progression.XPPerRoomVisit)
+	progression.FlushInterval = GetEnvSeconds("XP_FLUSH_SECONDS", progression.FlushInterval)
+	progression.RewardTimezone = GetEnvString("DAILY_REWARD_TIMEZONE", progression.RewardTimezone)
+
+	cfg.Accounts.DeletionGrace = GetEnvSeconds("ACCOUNT_DELETION_GRACE_SECONDS", cfg.Accounts.DeletionGrace)
+	cfg.Accounts.ReferralRewardCoins = GetEnvInt("REFERRAL_REWARD_COINS", cfg.Accounts.ReferralRewardCoins)
+
+	cfg.Profiling.BlockRate = GetEnvInt("PPROF_BLOCK_RATE", cfg.Profiling.BlockRate)
+	cfg.Profiling.MutexFraction = GetEnvInt("PPROF_MUTEX_FRACTION", cfg.Profiling.MutexFraction)
+
+	security := &cfg.Security
+	security.AdminAPIKey = os.Getenv("ADMIN_API_KEY")
+	security.TrustedProxies = GetEnvList("TRUSTED_PROXIES")
+	security.IdempotencyTTL = GetEnvSeconds("IDEMPOTENCY_TTL_SECONDS", security.IdempotencyTTL)
+	security.AuthIPLimit = getEnvRateLimit("AUTH_IP", security.AuthIPLimit)
+	security.AuthPlayerLimit = getEnvRateLimit("AUTH_PLAYER", security.AuthPlayerLimit)
+	security.JoinIPLimit = getEnvRateLimit("JOIN_IP", security.JoinIPLimit)
+	security.JoinPlayerLimit = getEnvRateLimit("JOIN_PLAYER", security.JoinPlayerLimit)
+
+	cfg.AsyncDB.Workers = GetEnvInt("DB_ASYNC_WORKERS", cfg.AsyncDB.Workers)
+	cfg.AsyncDB.QueueSize = GetEnvInt("DB_ASYNC_QUEUE_SIZE", cfg.AsyncDB.QueueSize)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// settings is the configuration injected by Configure; the defaults apply until then
+var settings = DefaultAppConfig()
+
+// Configure injects the validated application config into this package's middleware and
+// workers. Call it once at startup, before InitDB or setting up routes.
+func Configure(cfg *AppConfig) {
+	settings = cfg
+}
+
+// getEnvRateLimit reads RATE_LIMIT_<group>_PER_MINUTE and RATE_LIMIT_<group>_BURST
+func getEnvRateLimit(group string, def RateLimitConfig) RateLimitConfig {
+	return RateLimitConfig{
+		PerMinute: GetEnvInt("RATE_LIMIT_"+group+"_PER_MINUTE", def.PerMinute),
+		Burst:     GetEnvInt("RATE_LIMIT_"+group+"_BURST", def.Burst),
+	}
+}
+
+// Validate reports every setting that is out of range
+func (c *AppConfig) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT_SECONDS must be positive")
+	check(c.Server.DrainCountdown >= 0 && c.Server.DrainCountdown < c.Server.ShutdownTimeout,
+		"SHUTDOWN_DRAIN_SECONDS must be between 0 and SHUTDOWN_TIMEOUT_SECONDS")
+
+	ws := c.WebSocket
+	check(ws.ReadBufferSize >= MinWSBufferSize && ws.ReadBufferSize <= MaxWSBufferSize,
+		"WS_READ_BUFFER_SIZE must be between %d and %d", MinWSBufferSize, MaxWSBufferSize)
+	check(ws.WriteBufferSize >= MinWSBufferSize && ws.WriteBufferSize <= MaxWSBufferSize,
+		"WS_WRITE_BUFFER_SIZE must be between %d and %d", MinWSBufferSize, MaxWSBufferSize)
+	check(ws.MaxConnections > 0, "WS_MAX_CONNECTIONS must be positive")
+	check(ws.MaxQueueLength >= 0, "WS_MAX_QUEUE_LENGTH must not be negative")
+	check(ws.QueueTimeout > 0, "WS_QUEUE_TIMEOUT_SECONDS must be positive")
+	check(ws.WriteTimeout > 0, "WS_WRITE_TIMEOUT_SECONDS must be positive")
+	check(ws.ReadTimeout > 0, "WS_READ_TIMEOUT_SECONDS must be positive")
+	check(ws.PingPeriod > 0 && ws.PingPeriod < ws.PongTimeout, "WS_PING_PERIOD_SECONDS must be positive and below WS_PONG_TIMEOUT_SECONDS")
+	check(ws.ResumeWindow >= 0, "SESSION_RESUME_SECONDS must not be negative")
+	check(ws.CompressMinSize >= 0, "WS_COMPRESSION_THRESHOLD_BYTES must not be negative")
+	check(ws.MaxSendBacklog > 0, "WS_MAX_SEND_BACKLOG_BYTES must be positive")
+	check(ws.DegradedDrops > 0, "WS_DEGRADED_DROPS must be positive")
+	check(ws.DegradedRTT >= 0, "WS_DEGRADED_RTT_MS must not be negative")
+
+	rooms := c.Rooms
+	check(rooms.MaxPlayers >= MinRoomCapacity && rooms.MaxPlayers <= MaxRoomCapacity,
+		"ROOM_MAX_PLAYERS must be between %d and %d", MinRoomCapacity, MaxRoomCapacity)
+	check(rooms.InactiveTimeout > 0, "ROOM_INACTIVE_TIMEOUT_SECONDS must be positive")
+	check(rooms.MainRoomReservedSlots >= 0 && rooms.MainRoomReservedSlots < rooms.MaxPlayers,
+		"MAIN_ROOM_RESERVED_SLOTS must be between 0 and ROOM_MAX_PLAYERS-1")
+	check(rooms.MainRoomLayout != "", "MAIN_ROOM_LAYOUT must not be empty")
+	check(rooms.MainRoomMaxInstances >= 0, "MAIN_ROOM_MAX_INSTANCES must not be negative")
+	check(rooms.TickRate >= 0 && rooms.TickRate <= 120, "ROOM_TICK_RATE must be between 0 and 120")
+	check(rooms.InterestRadius >= 0, "AOI_RADIUS must not be negative")
+	check(rooms.MaxMoveSpeed >= 0, "MOVE_MAX_SPEED must not be negative")
+	check(rooms.MapWidth >= 0 && rooms.MapHeight >= 0, "MAP_WIDTH and MAP_HEIGHT must not be negative")
+	check(rooms.AwayAfter > 0, "PRESENCE_AWAY_AFTER_SECONDS must be positive")
+
+	check(c.Chat.LocalRadius > 0, "LOCAL_CHAT_RADIUS must be positive")
+
+	progression := c.Progression
+	check(progression.XPPerMinuteOnline >= 0 && progression.XPPerChat >= 0 && progression.XPPerRoomVisit >= 0,
+		"XP_PER_MINUTE_ONLINE, XP_PER_CHAT_MESSAGE, and XP_PER_ROOM_VISIT must not be negative")
+	check(progression.ChatXPCooldown >= 0, "XP_CHAT_COOLDOWN_SECONDS must not be negative")
+	check(progression.FlushInterval > 0, "XP_FLUSH_SECONDS must be positive")
+	_, err := time.LoadLocation(progression.RewardTimezone)
+	check(err == nil, "DAILY_REWARD_TIMEZONE %q is not a known time zone", progression.RewardTimezone)
+
+	check(c.Accounts.DeletionGrace >= 0, "ACCOUNT_DELETION_GRACE_SECONDS must not be negative")
+	check(c.Accounts.ReferralRewardCoins >= 0, "REFERRAL_REWARD_COINS must not be negative")
+
+	check(c.Profiling.BlockRate >= 0, "PPROF_BLOCK_RATE must not be negative")
+	check(c.Profiling.MutexFraction >= 0, "PPROF_MUTEX_FRACTION must not be negative")
+
+	security := c.Security
+	for _, entry := range security.TrustedProxies {
+		_, err := parseTrustedProxy(entry)
+		check(err == nil, "TRUSTED_PROXIES entry %q is not an IP address or CIDR", entry)
+	}
+	check(security.IdempotencyTTL > 0, "IDEMPOTENCY_TTL_SECONDS must be positive")
+	checkRateLimit := func(group string, limit RateLimitConfig) {
+		check(limit.PerMinute >= 0, "RATE_LIMIT_%s_PER_MINUTE must not be negative", group)
+		check(limit.PerMinute == 0 || limit.Burst > 0, "RATE_LIMIT_%s_BURST must be positive", group)
+	}
+	checkRateLimit("AUTH_IP", security.AuthIPLimit)
+	checkRateLimit("AUTH_PLAYER", security.AuthPlayerLimit)
+	checkRateLimit("JOIN_IP", security.JoinIPLimit)
+	checkRateLimit("JOIN_PLAYER", security.JoinPlayerLimit)
+
+	check(c.AsyncDB.Workers > 0, "DB_ASYNC_WORKERS must be positive")
+	check(c.AsyncDB.QueueSize > 0, "DB_ASYNC_QUEUE_SIZE must be positive")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Audit log actions
+const (
+	AuditAdminAPI     = "admin_api" // Any call to the admin API (target is the path)
+	AuditKick         = "kick"
+	AuditBan          = "ban" // Global ban
+	AuditUnban        = "unban"
+	AuditRoomBan      = "room_ban"
+	AuditRoomUnban    = "room_unban"
+	AuditMute         = "mute"
+	AuditUnmute       = "unmute"
+	AuditAnnouncement = "announcement"
+)
+
+const (
+	// AdminActorHeader optionally names the operator behind an admin API call in the audit log
+	AdminActorHeader = "X-Admin-Actor"
+	// DefaultAdminActor is recorded when an admin call doesn't name its operator
+	DefaultAdminActor = "admin"
+
+	DefaultAuditListLimit = 100
+	MaxAuditListLimit     = 500
+)
+
+// AuditEntry is one recorded moderation or admin action
+type AuditEntry struct {
+	ID        int64                  `json:"id"`
+	ActorID   string                 `json:"actor_id"`
+	Action    string                 `json:"action"`
+	TargetID  string                 `json:"target_id,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// AuditFilter narrows ListAuditLog; zero fields match everything
+type AuditFilter struct {
+	ActorID  string
+	TargetID string
+	Action   string
+	Before   int64 // Only entries with a smaller ID, for paging
+	Limit    int
+}
+
+// AdminActor returns the operator named on an admin request, or DefaultAdminActor
+func AdminActor(r *http.Request) string {
+	if actor := r.Header.Get(AdminActorHeader); actor != "" {
+		return actor
+	}
+	return DefaultAdminActor
+}
+
+// RecordAudit queues an audit log entry. The timestamp is taken now, not when the write
+// runs. Returns false if the queue is full.
+func RecordAudit(ctx context.Context, actorID, action, targetID string, details map[string]interface{}) bool {
+	if DB == nil {
+		return true
+	}
+
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		slog.Warn("Could not encode audit details", "action", action, "error", err)
+		encoded = []byte("{}")
+	}
+	createdAt := time.Now()
+
+	operation := func(ctx context.Context) error {
+		_, err := DB.ExecContext(ctx, `
+			INSERT INTO audit_log (actor_id, action, target_id, details, created_at) VALUES ($1, $2, $3, $4, $5)
+		`, actorID, action, targetID, encoded, createdAt)
+		if err != nil {
+			return fmt.Errorf("failed to record %s audit entry: %w", action, err)
+		}
+		return nil
+	}
+
+	if !enqueueDBOperation(ctx, "record_audit", operation) {
+		slog.Warn("Database operation queue full, dropping audit entry", "action", action, "actor_id", actorID, "target_id", targetID)
+		return false
+	}
+	return true
+}
+
+// ListAuditLog returns matching entries, newest first
+func ListAuditLog(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := Conn(ctx).QueryContext(ctx, `
+		SELECT id, actor_id, action, target_id, details, created_at FROM audit_log
+		WHERE ($1 = '' OR actor_id = $1)
+			AND ($2 = '' OR target_id = $2)
+			AND ($3 = '' OR action = $3)
+			AND ($4 = 0 OR id < $4)
+		ORDER BY id DESC
+		LIMIT $5
+	`, filter.ActorID, filter.TargetID, filter.Action, filter.Before, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]AuditEntry, 0)
+	for rows.Next() {
+		var entry AuditEntry
+		var details []byte
+		if err := rows.Scan(&entry.ID, &entry.ActorID, &entry.Action, &entry.TargetID, &details, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		if err := json.Unmarshal(details, &entry.Details); err != nil {
+			return nil, fmt.Errorf("failed to decode audit details %d: %w", entry.ID, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// Avatar is how a player looks in the world. Parts are IDs from AvatarOptions; colors are
+// "#rrggbb" hex strings.
+type Avatar struct {
+	Body   string       `json:"body"`
+	Hair   string       `json:"hair"`
+	Outfit string       `json:"outfit"`
+	Colors AvatarColors `json:"colors"`
+}
+
+// AvatarColors tints the avatar's parts
+type AvatarColors struct {
+	Skin   string `json:"skin"`
+	Hair   string `json:"hair"`
+	Outfit string `json:"outfit"`
+}
+
+// avatarOptions lists the parts the client can render
+var avatarOptions = map[string][]string{
+	"body":   {"slim", "regular", "broad"},
+	"hair":   {"none", "short", "long", "curly", "ponytail", "bun", "mohawk"},
+	"outfit": {"casual", "hoodie", "suit", "dress", "overalls", "sporty"},
+}
+
+var hexColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// AvatarOptions returns the valid IDs for each avatar part
+func AvatarOptions() map[string][]string {
+	return avatarOptions
+}
+
+// DefaultAvatar is shown for players who haven't customized theirs
+func DefaultAvatar() Avatar {
+	return Avatar{
+		Body:   "regular",
+		Hair:   "short",
+		Outfit: "casual",
+		Colors: AvatarColors{Skin: "#e0ac69", Hair: "#3b2a1a", Outfit: "#4a6fa5"},
+	}
+}
+
+// Validate checks every part against AvatarOptions and every color is a hex string
+func (a Avatar) Validate() error {
+	var errs []error
+	parts := []struct{ name, value string }{{"body", a.Body}, {"hair", a.Hair}, {"outfit", a.Outfit}}
+	for _, part := range parts {
+		if !slices.Contains(avatarOptions[part.name], part.value) {
+			errs = append(errs, fmt.Errorf("unknown %s %q", part.name, part.value))
+		}
+	}
+	colors := []struct{ name, value string }{{"skin", a.Colors.Skin}, {"hair", a.Colors.Hair}, {"outfit", a.Colors.Outfit}}
+	for _, color := range colors {
+		if !hexColorPattern.MatchString(color.value) {
+			errs = append(errs, fmt.Errorf("%s color must be #rrggbb", color.name))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// Uploaded profile pictures. Each upload gets a fresh random key so CDNs can cache forever;
+// the previous image is deleted once the new one is committed. Image bytes count against
+// the asset_bytes quota.
+const DefaultAvatarImageMaxBytes = 2 << 20 // AVATAR_IMAGE_MAX_BYTES
+
+// ErrStorageUnavailable is returned when no BlobStore has been set up
+var ErrStorageUnavailable = errors.New("upload storage not configured")
+
+// avatarImageTypes maps the accepted content types to file extensions
+var avatarImageTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// AvatarImageMaxBytes is the largest profile picture accepted
+func AvatarImageMaxBytes() int64 {
+	return int64(GetEnvInt("AVATAR_IMAGE_MAX_BYTES", DefaultAvatarImageMaxBytes))
+}
+
+// AvatarImageExtension returns the file extension for an accepted image type
+func AvatarImageExtension(contentType string) (string, bool) {
+	ext, ok := avatarImageTypes[contentType]
+	return ext, ok
+}
+
+// SaveAvatarImage stores an image and makes it the user's profile_pic, returning its URL
+func SaveAvatarImage(ctx context.Context, userID, contentType string, data []byte) (string, error) {
+	store := GetBlobStore()
+	if store == nil {
+		return "", ErrStorageUnavailable
+	}
+	ext, ok := AvatarImageExtension(contentType)
+	if !ok {
+		return "", fmt.Errorf("unsupported image type %q", contentType)
+	}
+
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate object key: %w", err)
+	}
+	key := "avatars/" + hex.EncodeToString(raw) + ext
+
+	url, err := store.Put(ctx, key, contentType, data)
+	if err != nil {
+		return "", err
+	}
+
+	size := int64(len(data))
+	err = WithTx(ctx, func(ctx context.Context) error {
+		var oldKey string
+		var oldSize int64
+		err := Conn(ctx).QueryRowContext(ctx,
+			`SELECT object_key, size_bytes FROM avatar_images WHERE user_id = $1 FOR UPDATE`, userID,
+		).Scan(&oldKey, &oldSize)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to read avatar image for user %s: %w", userID, err)
+		}
+
+		// The old image's bytes come back before the new ones are charged
+		if oldSize > 0 {
+			if err := ReleaseQuota(ctx, userID, QuotaAssetBytes, oldSize); err != nil {
+				return err
+			}
+		}
+		if err := ConsumeQuota(ctx, userID, QuotaAssetBytes, size); err != nil {
+			return err
+		}
+
+		_, err = Conn(ctx).ExecContext(ctx, `
+			INSERT INTO avatar_images (user_id, object_key, size_bytes) VALUES ($1, $2, $3)
+			ON CONFLICT (user_id) DO UPDATE SET object_key = $2, size_bytes = $3, uploaded_at = NOW()
+		`, userID, key, size)
+		if err != nil {
+			return fmt.Errorf("failed to save avatar image for user %s: %w", userID, err)
+		}
+		if err := GetUserStore().SetProfilePic(ctx, userID, url); err != nil {
+			return err
+		}
+
+		if oldKey != "" {
+			AfterCommit(ctx, func() { deleteBlob(store, oldKey) })
+		}
+		return nil
+	})
+	if err != nil {
+		deleteBlob(store, key) // Nothing points at the new object
+		return "", err
+	}
+	return url, nil
+}
+
+// deleteBlob removes an object that's no longer referenced, logging failures
+func deleteBlob(store BlobStore, key string) {
+	if err := store.Delete(context.Background(), key); err != nil {
+		slog.Warn("Failed to delete stored object", "key", key, "error", err)
+	}
+}
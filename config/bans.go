@@ -0,0 +1,189 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// BanCacheTTL is how long a ban lookup (positive or negative) is served from memory
+const BanCacheTTL = time.Minute
+
+// Ban is a global ban on a user account
+type Ban struct {
+	ID        int64      `json:"id"`
+	UserID    string     `json:"user_id"`
+	Reason    string     `json:"reason"`
+	IssuedBy  string     `json:"issued_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // nil means permanent
+}
+
+// IsActive reports whether the ban still applies
+func (b *Ban) IsActive() bool {
+	return b.ExpiresAt == nil || time.Now().Before(*b.ExpiresAt)
+}
+
+// banCacheEntry caches the active ban for a user (nil when not banned)
+type banCacheEntry struct {
+	ban      *Ban
+	cachedAt time.Time
+}
+
+// BanStore persists global bans in Postgres with a short-lived lookup cache
+type BanStore struct {
+	cache map[string]banCacheEntry
+	mu    sync.RWMutex
+}
+
+var (
+	banStore     *BanStore
+	banStoreOnce sync.Once
+)
+
+// GetBanStore returns the singleton ban store
+func GetBanStore() *BanStore {
+	banStoreOnce.Do(func() {
+		banStore = &BanStore{cache: make(map[string]banCacheEntry)}
+	})
+	return banStore
+}
+
+// GetActiveBan returns the user's active ban, or nil if they are not banned
+func (bs *BanStore) GetActiveBan(userID string) (*Ban, error) {
+	bs.mu.RLock()
+	entry, cached := bs.cache[userID]
+	bs.mu.RUnlock()
+
+	if cached && time.Since(entry.cachedAt) < BanCacheTTL {
+		if entry.ban != nil && !entry.ban.IsActive() {
+			return nil, nil // Expired since it was cached
+		}
+		return entry.ban, nil
+	}
+
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	ban := &Ban{}
+	var expiresAt sql.NullTime
+	err := DB.QueryRow(`
+		SELECT id, user_id, reason, issued_by, created_at, expires_at FROM bans
+		WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY expires_at DESC NULLS FIRST LIMIT 1
+	`, userID).Scan(&ban.ID, &ban.UserID, &ban.Reason, &ban.IssuedBy, &ban.CreatedAt, &expiresAt)
+	if err == sql.ErrNoRows {
+		ban = nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to look up ban for user %s: %w", userID, err)
+	} else if expiresAt.Valid {
+		ban.ExpiresAt = &expiresAt.Time
+	}
+
+	bs.mu.Lock()
+	bs.cache[userID] = banCacheEntry{ban: ban, cachedAt: time.Now()}
+	bs.mu.Unlock()
+
+	return ban, nil
+}
+
+// IsBanned reports whether a user is globally banned. Lookup failures are logged and
+// treated as not banned so a database hiccup doesn't lock everyone out.
+func (bs *BanStore) IsBanned(userID string) bool {
+	ban, err := bs.GetActiveBan(userID)
+	if err != nil {
+		slog.Warn("Ban lookup failed", "user_id", userID, "error", err)
+		return false
+	}
+	return ban != nil
+}
+
+// IssueBan bans a user; a zero duration makes the ban permanent
+func (bs *BanStore) IssueBan(userID, reason, issuedBy string, duration time.Duration) (*Ban, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	var expiresAt *time.Time
+	if duration > 0 {
+		expiry := time.Now().Add(duration)
+		expiresAt = &expiry
+	}
+
+	ban := &Ban{UserID: userID, Reason: reason, IssuedBy: issuedBy, ExpiresAt: expiresAt}
+	err := DB.QueryRow(`
+		INSERT INTO bans (user_id, reason, issued_by, expires_at) VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, userID, reason, issuedBy, expiresAt).Scan(&ban.ID, &ban.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue ban for user %s: %w", userID, err)
+	}
+
+	bs.invalidate(userID)
+	slog.Info("User banned", "user_id", userID, "issued_by", issuedBy, "reason", reason)
+	RecordAudit(context.Background(), issuedBy, AuditBan, userID, map[string]interface{}{
+		"reason":     reason,
+		"ban_id":     ban.ID,
+		"expires_at": expiresAt,
+	})
+	return ban, nil
+}
+
+// LiftBan revokes all active bans for a user on behalf of actorID
+func (bs *BanStore) LiftBan(userID, actorID string) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	_, err := DB.Exec(`UPDATE bans SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to lift ban for user %s: %w", userID, err)
+	}
+
+	bs.invalidate(userID)
+	slog.Info("User unbanned", "user_id", userID, "actor_id", actorID)
+	RecordAudit(context.Background(), actorID, AuditUnban, userID, nil)
+	return nil
+}
+
+// ListActiveBans returns every ban that currently applies
+func (bs *BanStore) ListActiveBans() ([]Ban, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.Query(`
+		SELECT id, user_id, reason, issued_by, created_at, expires_at FROM bans
+		WHERE revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bans: %w", err)
+	}
+	defer rows.Close()
+
+	bans := make([]Ban, 0)
+	for rows.Next() {
+		var ban Ban
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&ban.ID, &ban.UserID, &ban.Reason, &ban.IssuedBy, &ban.CreatedAt, &expiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ban: %w", err)
+		}
+		if expiresAt.Valid {
+			ban.ExpiresAt = &expiresAt.Time
+		}
+		bans = append(bans, ban)
+	}
+	return bans, rows.Err()
+}
+
+// invalidate drops a cached lookup after the user's bans change
+func (bs *BanStore) invalidate(userID string) {
+	bs.mu.Lock()
+	delete(bs.cache, userID)
+	bs.mu.Unlock()
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BlockCacheTTL is how long a user's block list is served from memory
+const BlockCacheTTL = 5 * time.Minute
+
+// BlockedUser is one entry in a user's block list
+type BlockedUser struct {
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// blockCacheEntry caches the set of users a blocker has blocked
+type blockCacheEntry struct {
+	blocked  map[string]bool
+	cachedAt time.Time
+}
+
+// BlockStore persists per-user block lists with an in-memory lookup cache
+type BlockStore struct {
+	cache map[string]blockCacheEntry
+	mu    sync.RWMutex
+}
+
+var (
+	blockStore     *BlockStore
+	blockStoreOnce sync.Once
+)
+
+// GetBlockStore returns the singleton block store
+func GetBlockStore() *BlockStore {
+	blockStoreOnce.Do(func() {
+		blockStore = &BlockStore{cache: make(map[string]blockCacheEntry)}
+	})
+	return blockStore
+}
+
+// IsBlocked reports whether blockerID has blocked senderID. Fails open so chat keeps
+// working if the database is unavailable.
+func (bs *BlockStore) IsBlocked(blockerID, senderID string) bool {
+	bs.mu.RLock()
+	entry, cached := bs.cache[blockerID]
+	bs.mu.RUnlock()
+
+	if !cached || time.Since(entry.cachedAt) >= BlockCacheTTL {
+		blocked, err := bs.load(blockerID)
+		if err != nil {
+			return false
+		}
+		entry = blockCacheEntry{blocked: blocked, cachedAt: time.Now()}
+	}
+	return entry.blocked[senderID]
+}
+
+// load reads a user's block list from the database into the cache
+func (bs *BlockStore) load(blockerID string) (map[string]bool, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.Query(`SELECT blocked_id FROM blocks WHERE blocker_id = $1`, blockerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load blocks for user %s: %w", blockerID, err)
+	}
+	defer rows.Close()
+
+	blocked := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan block: %w", err)
+		}
+		blocked[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	bs.mu.Lock()
+	bs.cache[blockerID] = blockCacheEntry{blocked: blocked, cachedAt: time.Now()}
+	bs.mu.Unlock()
+	return blocked, nil
+}
+
+// Block adds blockedID to blockerID's block list
+func (bs *BlockStore) Block(blockerID, blockedID string) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	if blockerID == blockedID {
+		return fmt.Errorf("cannot block yourself")
+	}
+
+	_, err := DB.Exec(`
+		INSERT INTO blocks (blocker_id, blocked_id) VALUES ($1, $2)
+		ON CONFLICT (blocker_id, blocked_id) DO NOTHING
+	`, blockerID, blockedID)
+	if err != nil {
+		return fmt.Errorf("failed to block user %s: %w", blockedID, err)
+	}
+
+	bs.invalidate(blockerID)
+	return nil
+}
+
+// Unblock removes blockedID from blockerID's block list
+func (bs *BlockStore) Unblock(blockerID, blockedID string) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	_, err := DB.Exec(`DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2`, blockerID, blockedID)
+	if err != nil {
+		return fmt.Errorf("failed to unblock user %s: %w", blockedID, err)
+	}
+
+	bs.invalidate(blockerID)
+	return nil
+}
+
+// ListBlocked returns everyone blockerID has blocked, most recent first
+func (bs *BlockStore) ListBlocked(blockerID string) ([]BlockedUser, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.Query(`
+		SELECT blocked_id, created_at FROM blocks WHERE blocker_id = $1 ORDER BY created_at DESC
+	`, blockerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blocks for user %s: %w", blockerID, err)
+	}
+	defer rows.Close()
+
+	blocked := make([]BlockedUser, 0)
+	for rows.Next() {
+		var b BlockedUser
+		if err := rows.Scan(&b.UserID, &b.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan block: %w", err)
+		}
+		blocked = append(blocked, b)
+	}
+	return blocked, rows.Err()
+}
+
+// invalidate drops a cached block list so the next lookup reloads it
+func (bs *BlockStore) invalidate(blockerID string) {
+	bs.mu.Lock()
+	delete(bs.cache, blockerID)
+	bs.mu.Unlock()
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Broker carries messages between server instances. Subjects are dot-separated
+// ("velvet.room.<id>"); SubscribePrefix receives every subject starting with a prefix
+// that ends in a dot.
+type Broker interface {
+	Name() string
+	Publish(subject string, data []byte) error
+	SubscribePrefix(prefix string, handler func(subject string, data []byte)) error
+	Close() error
+}
+
+// Message broker selection (MESSAGE_BROKER)
+const (
+	BrokerMemory = "memory" // Single instance (default)
+	BrokerRedis  = "redis"  // Redis pub/sub at REDIS_URL
+	BrokerNATS   = "nats"   // NATS at NATS_URL
+)
+
+var (
+	broker   Broker
+	brokerMu sync.RWMutex
+)
+
+// InitBroker connects the broker chosen by MESSAGE_BROKER. When unset, Redis is used if
+// REDIS_URL is configured and the in-memory broker otherwise.
+func InitBroker() error {
+	kind := strings.ToLower(os.Getenv("MESSAGE_BROKER"))
+	if kind == "" {
+		kind = BrokerMemory
+		if os.Getenv("REDIS_URL") != "" {
+			kind = BrokerRedis
+		}
+	}
+
+	var b Broker
+	var err error
+	switch kind {
+	case BrokerMemory:
+		b = newMemoryBroker()
+	case BrokerRedis:
+		b, err = newRedisBroker(os.Getenv("REDIS_URL"))
+	case BrokerNATS:
+		b, err = newNATSBroker(os.Getenv("NATS_URL"))
+	default:
+		return fmt.Errorf("unknown MESSAGE_BROKER %q (expected memory, redis, or nats)", kind)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect %s broker: %w", kind, err)
+	}
+
+	brokerMu.Lock()
+	broker = b
+	brokerMu.Unlock()
+
+	slog.Info("Message broker initialized", "broker", b.Name())
+	return nil
+}
+
+// GetBroker returns the active broker (in-memory until InitBroker runs)
+func GetBroker() Broker {
+	brokerMu.Lock()
+	defer brokerMu.Unlock()
+	if broker == nil {
+		broker = newMemoryBroker()
+	}
+	return broker
+}
+
+// CloseBroker disconnects the active broker
+func CloseBroker() error {
+	brokerMu.Lock()
+	b := broker
+	broker = nil
+	brokerMu.Unlock()
+	if b == nil {
+		return nil
+	}
+	return b.Close()
+}
+
+// memoryBroker delivers messages within this process only
+type memoryBroker struct {
+	subscriptions map[string][]func(subject string, data []byte)
+	mu            sync.RWMutex
+}
+
+func newMemoryBroker() *memoryBroker {
+	return &memoryBroker{subscriptions: make(map[string][]func(string, []byte))}
+}
+
+func (b *memoryBroker) Name() string { return BrokerMemory }
+
+func (b *memoryBroker) Publish(subject string, data []byte) error {
+	b.mu.RLock()
+	var matched []func(string, []byte)
+	for prefix, handlers := range b.subscriptions {
+		if strings.HasPrefix(subject, prefix) {
+			matched = append(matched, handlers...)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range matched {
+		handler(subject, data)
+	}
+	return nil
+}
+
+func (b *memoryBroker) SubscribePrefix(prefix string, handler func(subject string, data []byte)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscriptions[prefix] = append(b.subscriptions[prefix], handler)
+	return nil
+}
+
+func (b *memoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscriptions = make(map[string][]func(string, []byte))
+	return nil
+}
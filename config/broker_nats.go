@@ -0,0 +1,44 @@
+package config
+
+import (
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsBroker publishes over NATS core subjects
+type natsBroker struct {
+	conn *nats.Conn
+}
+
+func newNATSBroker(url string) (*natsBroker, error) {
+	if url == "" {
+		url = nats.DefaultURL
+	}
+	conn, err := nats.Connect(url,
+		nats.Name("velvet"),
+		nats.Timeout(5*time.Second),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &natsBroker{conn: conn}, nil
+}
+
+func (b *natsBroker) Name() string { return BrokerNATS }
+
+func (b *natsBroker) Publish(subject string, data []byte) error {
+	return b.conn.Publish(subject, data)
+}
+
+func (b *natsBroker) SubscribePrefix(prefix string, handler func(subject string, data []byte)) error {
+	_, err := b.conn.Subscribe(prefix+">", func(msg *nats.Msg) {
+		handler(msg.Subject, msg.Data)
+	})
+	return err
+}
+
+func (b *natsBroker) Close() error {
+	return b.conn.Drain()
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisBroker publishes over Redis pub/sub; subjects are used as channel names
+type redisBroker struct {
+	client *redis.Client
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newRedisBroker(url string) (*redisBroker, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(options)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	b := &redisBroker{client: client}
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	return b, nil
+}
+
+func (b *redisBroker) Name() string { return BrokerRedis }
+
+func (b *redisBroker) Publish(subject string, data []byte) error {
+	ctx, cancel := context.WithTimeout(b.ctx, time.Second)
+	defer cancel()
+	return b.client.Publish(ctx, subject, data).Err()
+}
+
+func (b *redisBroker) SubscribePrefix(prefix string, handler func(subject string, data []byte)) error {
+	pubsub := b.client.PSubscribe(b.ctx, prefix+"*")
+	if _, err := pubsub.Receive(b.ctx); err != nil {
+		pubsub.Close()
+		return err
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-b.ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				handler(msg.Channel, []byte(msg.Payload))
+			}
+		}
+	}()
+	return nil
+}
+
+func (b *redisBroker) Close() error {
+	b.cancel()
+	b.wg.Wait()
+	if err := b.client.Close(); err != nil {
+		slog.Error("Error closing Redis client", "error", err)
+		return err
+	}
+	return nil
+}
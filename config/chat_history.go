@@ -0,0 +1,87 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	DefaultChatHistoryLimit = 50
+	MaxChatHistoryLimit     = 200
+)
+
+// ChatMessage is a persisted room chat message
+type ChatMessage struct {
+	ID        int64  `json:"id"`
+	RoomID    string `json:"room_id"`
+	Channel   string `json:"channel"`
+	SenderID  string `json:"sender_id"`
+	Username  string `json:"username,omitempty"`
+	Text      string `json:"text"`
+	Timestamp int64  `json:"timestamp"` // Unix ms, same clock as live chat_message events
+}
+
+// SaveChatMessageAsync queues a room chat message for storage on the async DB worker
+func SaveChatMessageAsync(roomID, channel, senderID, username, text string, sentAt int64) {
+	operation := func(ctx context.Context) error {
+		_, err := DB.ExecContext(ctx, `
+			INSERT INTO chat_messages (room_id, channel, sender_id, username, text, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, roomID, channel, senderID, username, text, time.UnixMilli(sentAt))
+		if err != nil {
+			return fmt.Errorf("failed to store chat message in room %s: %w", roomID, err)
+		}
+		return nil
+	}
+
+	if !enqueueDBOperation(context.Background(), "save_chat_message", operation) {
+		slog.Warn("Database operation queue full, dropping chat history", "room_id", roomID)
+	}
+}
+
+// GetChatHistory returns up to limit messages sent in a room channel before the given Unix ms
+// timestamp (0 means now), oldest first, leaving out senders viewerID has blocked
+func GetChatHistory(roomID, channel, viewerID string, before int64, limit int) ([]ChatMessage, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	beforeTime := time.Now()
+	if before > 0 {
+		beforeTime = time.UnixMilli(before)
+	}
+
+	rows, err := DB.Query(`
+		SELECT id, room_id, channel, sender_id, username, text, created_at FROM chat_messages
+		WHERE room_id = $1 AND channel = $2 AND created_at < $3
+			AND NOT EXISTS (SELECT 1 FROM blocks b WHERE b.blocker_id = $5 AND b.blocked_id = sender_id)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
+	`, roomID, channel, beforeTime, limit, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat history for room %s: %w", roomID, err)
+	}
+	defer rows.Close()
+
+	messages := make([]ChatMessage, 0, limit)
+	for rows.Next() {
+		var m ChatMessage
+		var createdAt time.Time
+		if err := rows.Scan(&m.ID, &m.RoomID, &m.Channel, &m.SenderID, &m.Username, &m.Text, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan chat message: %w", err)
+		}
+		m.Timestamp = createdAt.UnixMilli()
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Query runs newest first for the LIMIT; clients render oldest first
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ChatPrefs are a user's chat settings; everything is on by default
+type ChatPrefs struct {
+	GlobalChat bool `json:"global_chat"` // Receive the server-wide chat channel
+}
+
+// GetChatPrefs returns a user's chat preferences
+func GetChatPrefs(ctx context.Context, userID string) (ChatPrefs, error) {
+	prefs := ChatPrefs{GlobalChat: true}
+	if DB == nil {
+		return prefs, fmt.Errorf("database not initialized")
+	}
+
+	err := Conn(ctx).QueryRowContext(ctx, `
+		SELECT global_chat FROM chat_preferences WHERE user_id = $1
+	`, userID).Scan(&prefs.GlobalChat)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return prefs, fmt.Errorf("failed to get chat preferences for user %s: %w", userID, err)
+	}
+	return prefs, nil
+}
+
+// SetChatPrefs saves a user's chat preferences
+func SetChatPrefs(ctx context.Context, userID string, prefs ChatPrefs) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	_, err := Conn(ctx).ExecContext(ctx, `
+		INSERT INTO chat_preferences (user_id, global_chat) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET global_chat = $2, updated_at = NOW()
+	`, userID, prefs.GlobalChat)
+	if err != nil {
+		return fmt.Errorf("failed to save chat preferences for user %s: %w", userID, err)
+	}
+	return nil
+}
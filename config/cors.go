@@ -0,0 +1,59 @@
+package config
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// CORS for browser clients. Only origins matching CORS_ALLOWED_ORIGINS (comma-separated,
+// e.g. "https://velvet.town,https://*.velvet.town"; see origins.go) get
+// Access-Control-Allow-Origin, echoed back with credentials allowed; other origins get no
+// CORS headers and the browser blocks the response.
+const (
+	corsAllowedMethods = "GET, POST, PUT, DELETE, OPTIONS"
+	corsAllowedHeaders = "Content-Type, Authorization, X-Requested-With, X-Admin-Key, X-Device-ID, X-Request-ID, Idempotency-Key"
+	corsExposedHeaders = "Retry-After, X-Request-ID, Idempotent-Replayed"
+	corsMaxAge         = 600 // Seconds browsers may cache a preflight result
+)
+
+// CORSOriginAllowed reports whether origin is on the CORS allowlist
+func CORSOriginAllowed(origin string) bool {
+	return getCORSPolicy().Allowed(origin)
+}
+
+// CORS adds CORS headers for allowed origins and answers preflight requests itself
+func CORS(next http.Handler) http.Handler {
+	// Load the allowlist at startup so a missing setting is reported right away
+	getCORSPolicy()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		allowed := CORSOriginAllowed(origin)
+
+		// Responses differ per origin, so caches must key on it
+		w.Header().Add("Vary", "Origin")
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+		}
+
+		// Preflight: an OPTIONS request announcing the method it wants to use
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			if !allowed {
+				Logger(r.Context()).Debug("CORS preflight from disallowed origin", "origin", origin)
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+			w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrAlreadyClaimed is returned when the daily reward was already claimed for the day
+var ErrAlreadyClaimed = errors.New("daily reward already claimed today")
+
+// DailyRewardState is a player's login streak. Days are calendar dates ("2006-01-02") in
+// the reward timezone.
+type DailyRewardState struct {
+	Streak        int       `json:"streak"`
+	LastClaimDay  string    `json:"last_claim_day,omitempty"`
+	LastClaimedAt time.Time `json:"last_claimed_at"`
+}
+
+// GetDailyRewardState returns a player's streak (zero if they've never claimed)
+func GetDailyRewardState(ctx context.Context, userID string) (DailyRewardState, error) {
+	if DB == nil {
+		return DailyRewardState{}, fmt.Errorf("database not initialized")
+	}
+
+	var state DailyRewardState
+	var lastDay time.Time
+	err := Conn(ctx).QueryRowContext(ctx,
+		`SELECT streak, last_claim_day, last_claimed_at FROM daily_rewards WHERE user_id = $1`, userID,
+	).Scan(&state.Streak, &lastDay, &state.LastClaimedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DailyRewardState{}, nil
+	}
+	if err != nil {
+		return DailyRewardState{}, fmt.Errorf("failed to get daily reward state for user %s: %w", userID, err)
+	}
+	state.LastClaimDay = lastDay.Format(time.DateOnly)
+	return state, nil
+}
+
+// ClaimDailyReward records today's claim and runs grant with the new streak in the same
+// transaction, so a failed grant leaves the day unclaimed. The streak continues if the last
+// claim was yesterday and restarts at 1 otherwise.
+func ClaimDailyReward(ctx context.Context, userID, today, yesterday string, grant func(ctx context.Context, streak int) error) (int, error) {
+	if DB == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+
+	var streak int
+	err := WithTx(ctx, func(ctx context.Context) error {
+		// The WHERE makes the claim atomic: a second claim for the same day updates nothing
+		err := Conn(ctx).QueryRowContext(ctx, `
+			INSERT INTO daily_rewards (user_id, streak, last_claim_day) VALUES ($1, 1, $2::date)
+			ON CONFLICT (user_id) DO UPDATE SET
+				streak = CASE WHEN daily_rewards.last_claim_day = $3::date THEN daily_rewards.streak + 1 ELSE 1 END,
+				last_claim_day = EXCLUDED.last_claim_day,
+				last_claimed_at = NOW()
+			WHERE daily_rewards.last_claim_day < EXCLUDED.last_claim_day
+			RETURNING streak
+		`, userID, today, yesterday).Scan(&streak)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAlreadyClaimed
+		}
+		if err != nil {
+			return fmt.Errorf("failed to claim daily reward for user %s: %w", userID, err)
+		}
+		return grant(ctx, streak)
+	})
+	return streak, err
+}
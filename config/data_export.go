@@ -0,0 +1,145 @@
+package config
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// exportSections lists the tables holding a user's data for ExportUserData, keyed by the
+// section name used in the archive. Each query takes the user ID as $1.
+var exportSections = []struct {
+	name  string
+	query string
+}{
+	{"chat_messages", `SELECT id, room_id, channel, username, text, created_at FROM chat_messages WHERE sender_id = $1 ORDER BY id`},
+	{"direct_messages", `SELECT id, sender_id, recipient_id, username, text, system, created_at, delivered_at FROM messages WHERE sender_id = $1 OR recipient_id = $1 ORDER BY id`},
+	{"inventory", `SELECT item_id, quantity, acquired_at, updated_at FROM inventory_items WHERE user_id = $1 ORDER BY acquired_at, item_id`},
+	{"wallet", `SELECT balance, updated_at FROM wallets WHERE user_id = $1`},
+	{"wallet_transactions", `SELECT id, amount, balance_after, reason, reference, created_at FROM wallet_transactions WHERE user_id = $1 ORDER BY id`},
+	{"progress", `SELECT xp, level, updated_at FROM player_progress WHERE user_id = $1`},
+	{"daily_rewards", `SELECT streak, last_claim_day, last_claimed_at FROM daily_rewards WHERE user_id = $1`},
+	{"avatar", `SELECT avatar, updated_at FROM avatars WHERE user_id = $1`},
+	{"avatar_image", `SELECT object_key, size_bytes, uploaded_at FROM avatar_images WHERE user_id = $1`},
+	{"storage_usage", `SELECT resource, used, updated_at FROM player_usage WHERE user_id = $1 ORDER BY resource`},
+	{"friendships", `SELECT requester_id, addressee_id, status, created_at, responded_at FROM friendships WHERE requester_id = $1 OR addressee_id = $1 ORDER BY created_at`},
+	{"blocks", `SELECT blocked_id, created_at FROM blocks WHERE blocker_id = $1 ORDER BY created_at`},
+	{"referral_code", `SELECT code, created_at FROM referral_codes WHERE user_id = $1`},
+	{"referrals", `SELECT referrer_id, referee_id, code, status, created_at FROM referrals WHERE referrer_id = $1 OR referee_id = $1 ORDER BY id`},
+	{"minigame_stats", `SELECT game, games_played, wins, best_score, total_score, last_played FROM minigame_stats WHERE user_id = $1 ORDER BY game`},
+	{"reports_filed", `SELECT id, reported_id, room_id, reason, chat_excerpt, status, created_at, closed_at FROM player_reports WHERE reporter_id = $1 ORDER BY id`},
+	// Reports about the user leave out who filed them
+	{"reports_received", `SELECT id, room_id, reason, status, action, created_at, closed_at FROM player_reports WHERE reported_id = $1 ORDER BY id`},
+	{"devices", `SELECT platform, created_at, updated_at FROM device_tokens WHERE user_id = $1 ORDER BY created_at`},
+	{"notification_preferences", `SELECT private_messages, friend_requests, updated_at FROM notification_preferences WHERE user_id = $1`},
+	{"chat_preferences", `SELECT global_chat, updated_at FROM chat_preferences WHERE user_id = $1`},
+	{"guild_membership", `SELECT g.name, g.tag, m.role, m.joined_at FROM guild_members m JOIN guilds g ON g.id = m.guild_id WHERE m.user_id = $1`},
+	{"bans", `SELECT id, reason, created_at, expires_at, revoked_at FROM bans WHERE user_id = $1 ORDER BY id`},
+}
+
+// ExportUserData collects everything stored about a user: their profile and a section of
+// rows per table. Live state (sessions, room) is added by the caller.
+func ExportUserData(ctx context.Context, userID string) (map[string]interface{}, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	archive := make(map[string]interface{}, len(exportSections)+1)
+
+	user, err := GetUserStore().Get(ctx, userID)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+	if user != nil {
+		archive["profile"] = map[string]interface{}{
+			"user_id":     user.ID,
+			"username":    user.Username,
+			"gender":      user.Gender,
+			"email":       user.Email,
+			"profile_pic": user.ProfilePic,
+			"last_room":   user.LastRoom,
+		}
+	}
+
+	for _, section := range exportSections {
+		rows, err := exportRows(ctx, section.query, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s for user %s: %w", section.name, userID, err)
+		}
+		archive[section.name] = rows
+	}
+	return archive, nil
+}
+
+// exportRows runs query and returns each row as a column -> value map
+func exportRows(ctx context.Context, query, userID string) ([]map[string]interface{}, error) {
+	rows, err := Conn(ctx).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0)
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			// JSONB columns come back as raw bytes
+			if raw, ok := values[i].([]byte); ok {
+				if json.Valid(raw) {
+					row[column] = json.RawMessage(raw)
+				} else {
+					row[column] = string(raw)
+				}
+				continue
+			}
+			row[column] = values[i]
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// SaveDataExport uploads an export archive under an unguessable key and returns its download
+// URL and key
+func SaveDataExport(ctx context.Context, data []byte) (string, string, error) {
+	store := GetBlobStore()
+	if store == nil {
+		return "", "", ErrStorageUnavailable
+	}
+
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("failed to generate object key: %w", err)
+	}
+	key := "exports/" + hex.EncodeToString(raw) + ".json"
+
+	url, err := store.Put(ctx, key, "application/json", data)
+	if err != nil {
+		return "", "", err
+	}
+	return url, key, nil
+}
+
+// DeleteDataExport removes an archive saved by SaveDataExport
+func DeleteDataExport(key string) {
+	if store := GetBlobStore(); store != nil {
+		deleteBlob(store, key)
+	}
+}
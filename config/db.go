@@ -1,25 +1,23 @@
 package config
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
-	"sync"
 	"time"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
 	DB *sql.DB
-	// Prepared statements for common queries
-	preparedStatements struct {
-		updateLastRoom *sql.Stmt
-		mu             sync.RWMutex
-	}
-	// Channel for async database operations
-	dbOperations chan func()
+	// Channel for async database operations (see db_worker.go)
+	dbOperations chan dbOperation
 )
 
 // DatabaseConfig holds database configuration
@@ -46,8 +44,11 @@ func InitDB() error {
 		return fmt.Errorf("DATABASE_URL not set in environment")
 	}
 
+	// Every query gets a span (a child of the caller's span when called with a context)
 	var err error
-	DB, err = sql.Open("postgres", dsn)
+	DB, err = otelsql.Open("postgres", dsn,
+		otelsql.WithAttributes(attribute.String("db.system", "postgresql")),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true, OmitRows: true}))
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -64,119 +65,54 @@ func InitDB() error {
 		return fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	// Initialize prepared statements
-	if err := initPreparedStatements(); err != nil {
-		return fmt.Errorf("failed to initialize prepared statements: %w", err)
-	}
-
-	// Start async database worker
-	initAsyncWorker()
-
-	log.Printf("Database initialized with connection pool (max: %d, idle: %d)",
-		config.MaxOpenConns, config.MaxIdleConns)
-
-	return nil
-}
-
-// initPreparedStatements prepares commonly used SQL statements
-func initPreparedStatements() error {
-	preparedStatements.mu.Lock()
-	defer preparedStatements.mu.Unlock()
-
-	// Close existing statements if they exist
-	if preparedStatements.updateLastRoom != nil {
-		preparedStatements.updateLastRoom.Close()
-		preparedStatements.updateLastRoom = nil
-	}
-
-	var err error
-
-	// Try to deallocate any existing prepared statements to avoid conflicts
-	_, deallocErr := DB.Exec("DEALLOCATE ALL")
-	if deallocErr != nil {
-		log.Printf("Warning: Failed to deallocate existing prepared statements: %v", deallocErr)
+	// Create service-owned tables
+	if err := ensureSchema(); err != nil {
+		return fmt.Errorf("failed to ensure schema: %w", err)
 	}
 
-	// Prepare statement for updating user's last room
-	preparedStatements.updateLastRoom, err = DB.Prepare(`UPDATE "User" SET last_room = $1 WHERE "userId" = $2`)
+	// Account queries go through the user store
+	users, err := NewPostgresUserStore(DB)
 	if err != nil {
-		return fmt.Errorf("failed to prepare updateLastRoom statement: %w", err)
+		return fmt.Errorf("failed to initialize user store: %w", err)
 	}
+	SetUserStore(users)
 
-	log.Println("Prepared statements initialized successfully")
-	return nil
-}
-
-// initAsyncWorker starts a goroutine to handle non-critical database operations
-func initAsyncWorker() {
-	dbOperations = make(chan func(), 1000) // Buffer up to 1000 operations
+	// Start async database worker
+	initAsyncWorker()
 
-	go func() {
-		for operation := range dbOperations {
-			operation()
-		}
-	}()
+	slog.Info("Database initialized with connection pool", "max_open", config.MaxOpenConns, "max_idle", config.MaxIdleConns)
 
-	log.Println("Async database worker started")
+	return nil
 }
 
 // UpdateLastRoomAsync updates user's last room asynchronously (non-blocking)
-func UpdateLastRoomAsync(userID, roomID string) {
-	operation := func() {
-		preparedStatements.mu.RLock()
-		stmt := preparedStatements.updateLastRoom
-		preparedStatements.mu.RUnlock()
-
-		if stmt == nil {
-			log.Printf("⚠️ Warning: updateLastRoom prepared statement not available")
-			return
+func UpdateLastRoomAsync(ctx context.Context, userID, roomID string) {
+	operation := func(ctx context.Context) error {
+		err := GetUserStore().SetLastRoom(ctx, userID, roomID)
+		if errors.Is(err, ErrUserNotFound) {
+			slog.Warn("No rows updated for last_room (user might not exist)", "player_id", userID)
+			return nil
 		}
-
-		result, err := stmt.Exec(roomID, userID)
 		if err != nil {
-			log.Printf("⚠️ Warning: Failed to update last_room in database: %v", err)
-			return
-		}
-
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected > 0 {
-			log.Printf("✅ Successfully updated last_room for player %s to room %s", userID, roomID)
-		} else {
-			log.Printf("⚠️ Warning: No rows updated for player %s (user might not exist)", userID)
+			return err
 		}
+		slog.Debug("Updated last_room", "player_id", userID, "room_id", roomID)
+		return nil
 	}
 
 	// Try to queue the operation, but don't block if the channel is full
-	select {
-	case dbOperations <- operation:
-		// Operation queued successfully
-	default:
-		log.Printf("⚠️ Warning: Database operation queue full, dropping update for user %s", userID)
+	if !enqueueDBOperation(ctx, "update_last_room", operation) {
+		slog.Warn("Database operation queue full, dropping last_room update", "player_id", userID)
 	}
 }
 
 // UpdateLastRoomSync updates user's last room synchronously (blocking)
 // Use this only when you need to ensure the operation completes before continuing
-func UpdateLastRoomSync(userID, roomID string) error {
-	preparedStatements.mu.RLock()
-	stmt := preparedStatements.updateLastRoom
-	preparedStatements.mu.RUnlock()
-
-	if stmt == nil {
-		return fmt.Errorf("updateLastRoom prepared statement not available")
-	}
-
-	result, err := stmt.Exec(roomID, userID)
-	if err != nil {
-		return fmt.Errorf("failed to update last_room: %w", err)
-	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("no rows updated for user %s (user might not exist)", userID)
+func UpdateLastRoomSync(ctx context.Context, userID, roomID string) error {
+	if err := GetUserStore().SetLastRoom(ctx, userID, roomID); err != nil {
+		return fmt.Errorf("failed to update last_room for user %s: %w", userID, err)
 	}
-
-	log.Printf("✅ Successfully updated last_room for player %s to room %s", userID, roomID)
+	slog.Debug("Updated last_room", "player_id", userID, "room_id", roomID)
 	return nil
 }
 
@@ -189,39 +125,25 @@ func GetDBStats() sql.DBStats {
 }
 
 // GetUserLastRoom retrieves the last room for a user (call this during sign-in)
-func GetUserLastRoom(userID string) (string, error) {
-	if DB == nil {
-		return "", fmt.Errorf("database not initialized")
+func GetUserLastRoom(ctx context.Context, userID string) (string, error) {
+	user, err := GetUserStore().Get(ctx, userID)
+	if errors.Is(err, ErrUserNotFound) {
+		return "", nil // User doesn't exist, return empty string
 	}
-
-	var lastRoom *string
-	err := DB.QueryRow(`SELECT last_room FROM "User" WHERE "userId" = $1`, userID).Scan(&lastRoom)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return "", nil // User doesn't exist, return empty string
-		}
-		return "", fmt.Errorf("failed to get last room for user %s: %w", userID, err)
+		return "", err
 	}
-
-	if lastRoom == nil {
-		return "", nil // No last room set
-	}
-
-	return *lastRoom, nil
+	return user.LastRoom, nil
 }
 
 // CloseDB gracefully closes the database connection and prepared statements
 func CloseDB() error {
-	if dbOperations != nil {
-		close(dbOperations)
-	}
-
-	preparedStatements.mu.Lock()
-	defer preparedStatements.mu.Unlock()
+	// Let queued writes finish before the connection goes away
+	stopAsyncWorker()
 
 	// Close prepared statements
-	if preparedStatements.updateLastRoom != nil {
-		preparedStatements.updateLastRoom.Close()
+	if err := GetUserStore().Close(); err != nil {
+		slog.Warn("Error closing user store", "error", err)
 	}
 
 	// Close database connection
@@ -229,6 +151,6 @@ func CloseDB() error {
 		return DB.Close()
 	}
 
-	log.Println("Database connections closed")
+	slog.Info("Database connections closed")
 	return nil
 }
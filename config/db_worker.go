@@ -0,0 +1,211 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DefaultDBAsyncWorkers   = 1
+	DefaultDBAsyncQueueSize = 1000
+
+	// The queue counts as saturated once it stays above this fill ratio for dbSaturationWindow
+	dbSaturationRatio  = 0.8
+	dbSaturationWindow = 30 * time.Second
+	dbMonitorInterval  = time.Second
+)
+
+// dbOperation is a queued non-critical database write
+type dbOperation struct {
+	name     string
+	fn       func(ctx context.Context) error
+	queuedAt time.Time
+	link     trace.Link // Span that queued the operation
+}
+
+// AsyncWorkerStats describes the async database queue for monitoring
+type AsyncWorkerStats struct {
+	Workers          int     `json:"workers"`
+	QueueDepth       int     `json:"queue_depth"`
+	QueueCapacity    int     `json:"queue_capacity"`
+	Processed        uint64  `json:"processed"`
+	Failed           uint64  `json:"failed"`
+	Dropped          uint64  `json:"dropped"`
+	AvgLatencyMs     float64 `json:"avg_latency_ms"`    // Time spent executing operations
+	AvgQueueWaitMs   float64 `json:"avg_queue_wait_ms"` // Time operations sat in the queue
+	Saturated        bool    `json:"saturated"`
+	SaturatedSeconds int64   `json:"saturated_seconds,omitempty"`
+}
+
+// asyncWorker tracks the worker goroutines and their counters
+var asyncWorker struct {
+	workers   int
+	wg        sync.WaitGroup
+	stop      chan struct{}
+	processed atomic.Uint64
+	failed    atomic.Uint64
+	dropped   atomic.Uint64
+	latencyNs atomic.Int64
+	waitNs    atomic.Int64
+
+	mu        sync.Mutex
+	fullSince time.Time // When the queue first crossed the saturation ratio (zero if below)
+	saturated bool
+
+	// queueMu guards dbOperations and closed. Producers hold it for reading while they
+	// send, so the queue is never closed under them.
+	queueMu sync.RWMutex
+	closed  bool
+}
+
+// initAsyncWorker starts goroutines to handle non-critical database operations
+func initAsyncWorker() {
+	workers, queueSize := settings.AsyncDB.Workers, settings.AsyncDB.QueueSize
+
+	queue := make(chan dbOperation, queueSize)
+	asyncWorker.queueMu.Lock()
+	dbOperations = queue
+	asyncWorker.closed = false
+	asyncWorker.queueMu.Unlock()
+	asyncWorker.workers = workers
+	asyncWorker.stop = make(chan struct{})
+
+	for i := 0; i < workers; i++ {
+		asyncWorker.wg.Add(1)
+		go func() {
+			defer asyncWorker.wg.Done()
+			for operation := range queue {
+				runDBOperation(operation)
+			}
+		}()
+	}
+	go monitorAsyncQueue()
+
+	slog.Info("Async database worker started", "workers", workers, "queue_size", queueSize)
+}
+
+// runDBOperation executes one operation and records its outcome
+func runDBOperation(operation dbOperation) {
+	start := time.Now()
+	wait := start.Sub(operation.queuedAt)
+	asyncWorker.waitNs.Add(int64(wait))
+
+	ctx, span := otel.Tracer(TracerName).Start(context.Background(), "db.async."+operation.name,
+		trace.WithLinks(operation.link),
+		trace.WithAttributes(attribute.Int64("queue_wait_ms", wait.Milliseconds())))
+	err := operation.fn(ctx)
+	EndSpan(span, err)
+
+	asyncWorker.latencyNs.Add(int64(time.Since(start)))
+	asyncWorker.processed.Add(1)
+	if err != nil {
+		asyncWorker.failed.Add(1)
+		slog.Warn("Async database operation failed", "operation", operation.name, "error", err)
+	}
+}
+
+// enqueueDBOperation queues fn without blocking; returns false if the queue is full or
+// stopped. The operation's span links back to the span in ctx that queued it.
+func enqueueDBOperation(ctx context.Context, name string, fn func(ctx context.Context) error) bool {
+	asyncWorker.queueMu.RLock()
+	defer asyncWorker.queueMu.RUnlock()
+	if dbOperations == nil || asyncWorker.closed {
+		return false
+	}
+
+	operation := dbOperation{name: name, fn: fn, queuedAt: time.Now(), link: trace.LinkFromContext(ctx)}
+	select {
+	case dbOperations <- operation:
+		return true
+	default:
+		asyncWorker.dropped.Add(1)
+		return false
+	}
+}
+
+// monitorAsyncQueue flags the queue as saturated when it stays nearly full
+func monitorAsyncQueue() {
+	ticker := time.NewTicker(dbMonitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-asyncWorker.stop:
+			return
+		case <-ticker.C:
+			depth, capacity := asyncQueueSize()
+			nearlyFull := float64(depth) >= float64(capacity)*dbSaturationRatio
+
+			asyncWorker.mu.Lock()
+			switch {
+			case !nearlyFull:
+				if asyncWorker.saturated {
+					slog.Info("Async database queue recovered from saturation")
+				}
+				asyncWorker.fullSince = time.Time{}
+				asyncWorker.saturated = false
+			case asyncWorker.fullSince.IsZero():
+				asyncWorker.fullSince = time.Now()
+			case !asyncWorker.saturated && time.Since(asyncWorker.fullSince) >= dbSaturationWindow:
+				asyncWorker.saturated = true
+				slog.Warn("Async database queue saturated; consider raising DB_ASYNC_WORKERS",
+					"depth", depth, "capacity", capacity, "for", dbSaturationWindow.String())
+			}
+			asyncWorker.mu.Unlock()
+		}
+	}
+}
+
+// asyncQueueSize returns the queue's depth and capacity (both 0 before it starts)
+func asyncQueueSize() (int, int) {
+	asyncWorker.queueMu.RLock()
+	defer asyncWorker.queueMu.RUnlock()
+	return len(dbOperations), cap(dbOperations)
+}
+
+// GetAsyncWorkerStats returns a snapshot of the async database queue
+func GetAsyncWorkerStats() AsyncWorkerStats {
+	stats := AsyncWorkerStats{
+		Workers:   asyncWorker.workers,
+		Processed: asyncWorker.processed.Load(),
+		Failed:    asyncWorker.failed.Load(),
+		Dropped:   asyncWorker.dropped.Load(),
+	}
+	stats.QueueDepth, stats.QueueCapacity = asyncQueueSize()
+	if stats.Processed > 0 {
+		stats.AvgLatencyMs = float64(asyncWorker.latencyNs.Load()) / float64(stats.Processed) / float64(time.Millisecond)
+		stats.AvgQueueWaitMs = float64(asyncWorker.waitNs.Load()) / float64(stats.Processed) / float64(time.Millisecond)
+	}
+
+	asyncWorker.mu.Lock()
+	stats.Saturated = asyncWorker.saturated
+	if stats.Saturated {
+		stats.SaturatedSeconds = int64(time.Since(asyncWorker.fullSince).Seconds())
+	}
+	asyncWorker.mu.Unlock()
+
+	return stats
+}
+
+// stopAsyncWorker drains the queue and waits for in-flight operations to finish. Later
+// enqueues are refused.
+func stopAsyncWorker() {
+	asyncWorker.queueMu.Lock()
+	if dbOperations == nil || asyncWorker.closed {
+		asyncWorker.queueMu.Unlock()
+		return
+	}
+	asyncWorker.closed = true
+	close(asyncWorker.stop)
+	close(dbOperations)
+	asyncWorker.queueMu.Unlock()
+
+	asyncWorker.wg.Wait()
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetEnvString reads an environment variable, falling back to def when unset
+func GetEnvString(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// GetEnvInt reads an integer environment variable, falling back to def when unset or invalid
+func GetEnvInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		slog.Warn("Invalid environment value, using default", "key", key, "value", value, "default", def)
+		return def
+	}
+	return parsed
+}
+
+// GetEnvFloat reads a decimal environment variable, falling back to def when unset or invalid
+func GetEnvFloat(key string, def float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+		slog.Warn("Invalid environment value, using default", "key", key, "value", value, "default", def)
+		return def
+	}
+	return parsed
+}
+
+// GetEnvBool reads a boolean environment variable ("true", "1", "false", ...), falling back
+// to def when unset or invalid
+func GetEnvBool(key string, def bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		slog.Warn("Invalid environment value, using default", "key", key, "value", value, "default", def)
+		return def
+	}
+	return parsed
+}
+
+// GetEnvList reads a comma-separated environment variable into a trimmed list
+func GetEnvList(key string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// GetEnvSeconds reads a whole number of seconds from an environment variable
+func GetEnvSeconds(key string, def time.Duration) time.Duration {
+	return time.Duration(GetEnvInt(key, int(def/time.Second))) * time.Second
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// APIError is the JSON body of every API error response
+type APIError struct {
+	Code      string      `json:"code"`                 // Stable, machine-readable, e.g. "not_found"
+	Message   string      `json:"message"`              // Human-readable; never an internal error
+	Details   interface{} `json:"details,omitempty"`    // Extra context, e.g. invalid fields
+	RequestID string      `json:"request_id,omitempty"` // Quote in bug reports to find the server logs
+}
+
+// Error codes with no single HTTP status of their own
+const (
+	CodeInvalidRequest = "invalid_request" // Body failed decoding or validation; details lists fields
+	CodeQuotaExceeded  = "quota_exceeded"
+	CodeInternal       = "internal_error"
+)
+
+// Error writes an error response with the code for its status. It takes the same
+// arguments as http.Error, so message must be safe to show a client.
+func Error(w http.ResponseWriter, message string, status int) {
+	WriteError(w, status, APIError{Code: StatusCode(status), Message: message})
+}
+
+// WriteError writes apiErr as the JSON body of a response with the given status. The
+// request ID comes from the X-Request-ID header LogRequests already set on the response.
+func WriteError(w http.ResponseWriter, status int, apiErr APIError) {
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = w.Header().Get(RequestIDHeader)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(apiErr)
+}
+
+// StatusCode returns the error code for an HTTP status, e.g. "not_found" for 404
+func StatusCode(status int) string {
+	switch status {
+	case http.StatusTooManyRequests:
+		return "rate_limited"
+	case http.StatusInternalServerError:
+		return CodeInternal
+	}
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	FriendshipPending  = "pending"
+	FriendshipAccepted = "accepted"
+)
+
+var (
+	ErrAlreadyFriends    = errors.New("already friends")
+	ErrRequestPending    = errors.New("friend request already sent")
+	ErrNoFriendRequest   = errors.New("no pending friend request")
+	ErrFriendshipMissing = errors.New("not friends")
+)
+
+// FriendList groups a user's friendships by state
+type FriendList struct {
+	Friends  []Friendship `json:"friends"`
+	Incoming []Friendship `json:"incoming"` // Requests waiting on this user
+	Outgoing []Friendship `json:"outgoing"` // Requests this user sent
+}
+
+// Friendship is one friendship row from the perspective of a user
+type Friendship struct {
+	UserID    string    `json:"user_id"` // The other user
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SendFriendRequest creates a pending request, or accepts the reverse request if one exists.
+// Returns the resulting status. The check and the write run in one transaction, holding a
+// lock on the pair so two users friending each other at once can't both insert a request.
+func SendFriendRequest(fromID, toID string) (string, error) {
+	if DB == nil {
+		return "", fmt.Errorf("database not initialized")
+	}
+	if fromID == toID {
+		return "", fmt.Errorf("cannot friend yourself")
+	}
+
+	var result string
+	err := WithTx(context.Background(), func(ctx context.Context) error {
+		pair := fromID + ":" + toID
+		if toID < fromID {
+			pair = toID + ":" + fromID
+		}
+		if _, err := Conn(ctx).ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, pair); err != nil {
+			return fmt.Errorf("failed to lock friendship: %w", err)
+		}
+
+		var requesterID, status string
+		err := Conn(ctx).QueryRowContext(ctx, `
+			SELECT requester_id, status FROM friendships
+			WHERE (requester_id = $1 AND addressee_id = $2) OR (requester_id = $2 AND addressee_id = $1)
+		`, fromID, toID).Scan(&requesterID, &status)
+		switch {
+		case err == sql.ErrNoRows:
+			// No relationship yet
+		case err != nil:
+			return fmt.Errorf("failed to check friendship: %w", err)
+		case status == FriendshipAccepted:
+			return ErrAlreadyFriends
+		case requesterID == fromID:
+			return ErrRequestPending
+		default:
+			// They already asked us: sending a request back accepts it
+			if err := respondToFriendRequest(ctx, fromID, toID, true); err != nil {
+				return err
+			}
+			result = FriendshipAccepted
+			return nil
+		}
+
+		_, err = Conn(ctx).ExecContext(ctx, `INSERT INTO friendships (requester_id, addressee_id, status) VALUES ($1, $2, $3)`,
+			fromID, toID, FriendshipPending)
+		if err != nil {
+			return fmt.Errorf("failed to create friend request: %w", err)
+		}
+		result = FriendshipPending
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if result == FriendshipPending {
+		slog.Info("Friend request sent", "from_id", fromID, "to_id", toID)
+	}
+	return result, nil
+}
+
+// AcceptFriendRequest accepts requesterID's pending request to userID
+func AcceptFriendRequest(userID, requesterID string) error {
+	return respondToFriendRequest(context.Background(), userID, requesterID, true)
+}
+
+// DeclineFriendRequest declines (deletes) requesterID's pending request to userID
+func DeclineFriendRequest(userID, requesterID string) error {
+	return respondToFriendRequest(context.Background(), userID, requesterID, false)
+}
+
+// respondToFriendRequest accepts or declines a pending request
+func respondToFriendRequest(ctx context.Context, userID, requesterID string, accept bool) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	var result sql.Result
+	var err error
+	if accept {
+		result, err = Conn(ctx).ExecContext(ctx, `
+			UPDATE friendships SET status = $3, responded_at = NOW()
+			WHERE requester_id = $1 AND addressee_id = $2 AND status = $4
+		`, requesterID, userID, FriendshipAccepted, FriendshipPending)
+	} else {
+		result, err = Conn(ctx).ExecContext(ctx, `
+			DELETE FROM friendships WHERE requester_id = $1 AND addressee_id = $2 AND status = $3
+		`, requesterID, userID, FriendshipPending)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to respond to friend request: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNoFriendRequest
+	}
+	return nil
+}
+
+// RemoveFriend deletes an accepted friendship in either direction
+func RemoveFriend(userID, friendID string) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	result, err := DB.Exec(`
+		DELETE FROM friendships
+		WHERE ((requester_id = $1 AND addressee_id = $2) OR (requester_id = $2 AND addressee_id = $1))
+			AND status = $3
+	`, userID, friendID, FriendshipAccepted)
+	if err != nil {
+		return fmt.Errorf("failed to remove friend: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrFriendshipMissing
+	}
+	return nil
+}
+
+// ListFriends returns a user's friends and pending requests
+func ListFriends(userID string) (*FriendList, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.Query(`
+		SELECT requester_id, addressee_id, status, created_at FROM friendships
+		WHERE requester_id = $1 OR addressee_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list friends: %w", err)
+	}
+	defer rows.Close()
+
+	list := &FriendList{Friends: []Friendship{}, Incoming: []Friendship{}, Outgoing: []Friendship{}}
+	for rows.Next() {
+		var requesterID, addresseeID string
+		var f Friendship
+		if err := rows.Scan(&requesterID, &addresseeID, &f.Status, &f.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan friendship: %w", err)
+		}
+
+		f.UserID = addresseeID
+		if requesterID != userID {
+			f.UserID = requesterID
+		}
+
+		switch {
+		case f.Status == FriendshipAccepted:
+			list.Friends = append(list.Friends, f)
+		case requesterID == userID:
+			list.Outgoing = append(list.Outgoing, f)
+		default:
+			list.Incoming = append(list.Incoming, f)
+		}
+	}
+	return list, rows.Err()
+}
+
+// GetFriendIDs returns the IDs of a user's accepted friends
+func GetFriendIDs(userID string) ([]string, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.Query(`
+		SELECT CASE WHEN requester_id = $1 THEN addressee_id ELSE requester_id END
+		FROM friendships
+		WHERE (requester_id = $1 OR addressee_id = $1) AND status = $2
+	`, userID, FriendshipAccepted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get friends of %s: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan friend id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
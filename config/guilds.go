@@ -0,0 +1,468 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lib/pq"
+)
+
+// Guilds are persistent player groups. Everyone belongs to at most one. The leader can
+// promote and demote members, hand over leadership, and disband the guild; officers can
+// kick members and edit the guild's description. A guild's tag (2-5 letters or digits,
+// upper case, unique) is shown next to its members' names.
+const (
+	GuildRoleLeader  = "leader"
+	GuildRoleOfficer = "officer"
+	GuildRoleMember  = "member"
+
+	GuildMaxMembers = 50
+	GuildMinTagLen  = 2
+	GuildMaxTagLen  = 5
+
+	// pqUniqueViolation and pqForeignKeyViolation are Postgres error codes
+	pqUniqueViolation     = "23505"
+	pqForeignKeyViolation = "23503"
+)
+
+var (
+	ErrGuildNotFound      = errors.New("guild not found")
+	ErrGuildTaken         = errors.New("guild name or tag already taken")
+	ErrInvalidGuildTag    = errors.New("guild tag must be 2-5 letters or digits")
+	ErrGuildFull          = errors.New("guild is full")
+	ErrAlreadyInGuild     = errors.New("already in a guild")
+	ErrNotInGuild         = errors.New("not in a guild")
+	ErrNotGuildMember     = errors.New("player is not in your guild")
+	ErrGuildPermission    = errors.New("your guild role doesn't allow that")
+	ErrLeaderMustTransfer = errors.New("the leader must hand over leadership or disband the guild before leaving")
+)
+
+// Guild is a guild's public profile
+type Guild struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Tag         string    `json:"tag"`
+	Description string    `json:"description"`
+	LeaderID    string    `json:"leader_id"`
+	MemberCount int       `json:"member_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// GuildMember is one member of a guild
+type GuildMember struct {
+	UserID   string    `json:"user_id"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// GuildMembership is the guild a user belongs to and their role in it
+type GuildMembership struct {
+	Guild *Guild `json:"guild"`
+	Role  string `json:"role"`
+}
+
+// guildRank orders roles so a member can only act on members ranked below them
+func guildRank(role string) int {
+	switch role {
+	case GuildRoleLeader:
+		return 2
+	case GuildRoleOfficer:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// NormalizeGuildTag upper-cases a tag, or returns ErrInvalidGuildTag
+func NormalizeGuildTag(tag string) (string, error) {
+	tag = strings.ToUpper(strings.TrimSpace(tag))
+	if len(tag) < GuildMinTagLen || len(tag) > GuildMaxTagLen {
+		return "", ErrInvalidGuildTag
+	}
+	for _, r := range tag {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return "", ErrInvalidGuildTag
+		}
+	}
+	return tag, nil
+}
+
+// CreateGuild creates a guild led by leaderID, who must not be in a guild yet
+func CreateGuild(ctx context.Context, leaderID, name, tag, description string) (*Guild, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	tag, err := NormalizeGuildTag(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	guild := &Guild{Name: strings.TrimSpace(name), Tag: tag, Description: description, LeaderID: leaderID, MemberCount: 1}
+	err = WithTx(ctx, func(ctx context.Context) error {
+		err := Conn(ctx).QueryRowContext(ctx, `
+			INSERT INTO guilds (name, tag, description, leader_id) VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at
+		`, guild.Name, guild.Tag, guild.Description, leaderID).Scan(&guild.ID, &guild.CreatedAt)
+		if isPQError(err, pqUniqueViolation) {
+			return ErrGuildTaken
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create guild: %w", err)
+		}
+		return addGuildMember(ctx, guild.ID, leaderID, GuildRoleLeader)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Guild created", "guild_id", guild.ID, "tag", guild.Tag, "leader_id", leaderID)
+	return guild, nil
+}
+
+// GetGuild returns a guild's profile, or ErrGuildNotFound
+func GetGuild(ctx context.Context, guildID int64) (*Guild, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	guild := &Guild{ID: guildID}
+	err := Conn(ctx).QueryRowContext(ctx, `
+		SELECT g.name, g.tag, g.description, g.leader_id, g.created_at,
+			(SELECT COUNT(*) FROM guild_members m WHERE m.guild_id = g.id)
+		FROM guilds g WHERE g.id = $1
+	`, guildID).Scan(&guild.Name, &guild.Tag, &guild.Description, &guild.LeaderID, &guild.CreatedAt, &guild.MemberCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGuildNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guild %d: %w", guildID, err)
+	}
+	return guild, nil
+}
+
+// ListGuildMembers returns a guild's members, highest role first, then by join time
+func ListGuildMembers(ctx context.Context, guildID int64) ([]GuildMember, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := Conn(ctx).QueryContext(ctx, `
+		SELECT user_id, role, joined_at FROM guild_members WHERE guild_id = $1
+		ORDER BY CASE role WHEN $2 THEN 0 WHEN $3 THEN 1 ELSE 2 END, joined_at
+	`, guildID, GuildRoleLeader, GuildRoleOfficer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members of guild %d: %w", guildID, err)
+	}
+	defer rows.Close()
+
+	members := []GuildMember{}
+	for rows.Next() {
+		var member GuildMember
+		if err := rows.Scan(&member.UserID, &member.Role, &member.JoinedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan guild member: %w", err)
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+// GetGuildMembership returns the user's guild and role, or ErrNotInGuild
+func GetGuildMembership(ctx context.Context, userID string) (*GuildMembership, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	var guildID int64
+	var role string
+	err := Conn(ctx).QueryRowContext(ctx, `
+		SELECT guild_id, role FROM guild_members WHERE user_id = $1
+	`, userID).Scan(&guildID, &role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotInGuild
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guild of user %s: %w", userID, err)
+	}
+
+	guild, err := GetGuild(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	return &GuildMembership{Guild: guild, Role: role}, nil
+}
+
+// JoinGuild adds the user to a guild as a member
+func JoinGuild(ctx context.Context, guildID int64, userID string) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	return WithTx(ctx, func(ctx context.Context) error {
+		// Locking the guild row serializes joins, so the member limit holds
+		var members int
+		err := Conn(ctx).QueryRowContext(ctx, `
+			SELECT (SELECT COUNT(*) FROM guild_members WHERE guild_id = g.id)
+			FROM guilds g WHERE g.id = $1 FOR UPDATE
+		`, guildID).Scan(&members)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrGuildNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock guild %d: %w", guildID, err)
+		}
+		if members >= GuildMaxMembers {
+			return ErrGuildFull
+		}
+		return addGuildMember(ctx, guildID, userID, GuildRoleMember)
+	})
+}
+
+// addGuildMember inserts a membership row
+func addGuildMember(ctx context.Context, guildID int64, userID, role string) error {
+	_, err := Conn(ctx).ExecContext(ctx, `
+		INSERT INTO guild_members (guild_id, user_id, role) VALUES ($1, $2, $3)
+	`, guildID, userID, role)
+	switch {
+	case isPQError(err, pqUniqueViolation):
+		return ErrAlreadyInGuild
+	case isPQError(err, pqForeignKeyViolation):
+		return ErrGuildNotFound
+	case err != nil:
+		return fmt.Errorf("failed to add user %s to guild %d: %w", userID, guildID, err)
+	}
+	return nil
+}
+
+// LeaveGuild removes the user from their guild and returns its ID. A leader who is the
+// last member disbands the guild; otherwise they get ErrLeaderMustTransfer.
+func LeaveGuild(ctx context.Context, userID string) (int64, error) {
+	if DB == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+
+	var guildID int64
+	err := WithTx(ctx, func(ctx context.Context) error {
+		var role string
+		var err error
+		if guildID, role, err = lockGuildMember(ctx, userID); err != nil {
+			return err
+		}
+		if role == GuildRoleLeader {
+			var others int
+			err := Conn(ctx).QueryRowContext(ctx, `
+				SELECT COUNT(*) FROM guild_members WHERE guild_id = $1 AND user_id <> $2
+			`, guildID, userID).Scan(&others)
+			if err != nil {
+				return fmt.Errorf("failed to count members of guild %d: %w", guildID, err)
+			}
+			if others > 0 {
+				return ErrLeaderMustTransfer
+			}
+			return deleteGuild(ctx, guildID)
+		}
+		return removeGuildMember(ctx, guildID, userID)
+	})
+	return guildID, err
+}
+
+// KickGuildMember removes targetID from actorID's guild. Officers and the leader can kick
+// members ranked below them.
+func KickGuildMember(ctx context.Context, actorID, targetID string) (int64, error) {
+	if DB == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+
+	var guildID int64
+	err := WithTx(ctx, func(ctx context.Context) error {
+		var actorRole, targetRole string
+		var err error
+		if guildID, actorRole, targetRole, err = lockGuildPair(ctx, actorID, targetID, GuildRoleOfficer); err != nil {
+			return err
+		}
+		if guildRank(targetRole) >= guildRank(actorRole) {
+			return ErrGuildPermission
+		}
+		return removeGuildMember(ctx, guildID, targetID)
+	})
+	return guildID, err
+}
+
+// SetGuildRole changes a member's role; only the leader can. Making someone else leader
+// hands over leadership and makes the old leader an officer.
+func SetGuildRole(ctx context.Context, actorID, targetID, role string) (int64, error) {
+	if DB == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+	if actorID == targetID {
+		return 0, ErrGuildPermission
+	}
+
+	var guildID int64
+	err := WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if guildID, _, _, err = lockGuildPair(ctx, actorID, targetID, GuildRoleLeader); err != nil {
+			return err
+		}
+		if role == GuildRoleLeader {
+			if err := setGuildMemberRole(ctx, actorID, GuildRoleOfficer); err != nil {
+				return err
+			}
+			_, err := Conn(ctx).ExecContext(ctx, `UPDATE guilds SET leader_id = $2 WHERE id = $1`, guildID, targetID)
+			if err != nil {
+				return fmt.Errorf("failed to transfer leadership of guild %d: %w", guildID, err)
+			}
+		}
+		return setGuildMemberRole(ctx, targetID, role)
+	})
+	return guildID, err
+}
+
+// UpdateGuildDescription changes the description of actorID's guild; officers and the
+// leader can
+func UpdateGuildDescription(ctx context.Context, actorID, description string) (*Guild, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	var guildID int64
+	err := WithTx(ctx, func(ctx context.Context) error {
+		var role string
+		var err error
+		if guildID, role, err = lockGuildMember(ctx, actorID); err != nil {
+			return err
+		}
+		if guildRank(role) < guildRank(GuildRoleOfficer) {
+			return ErrGuildPermission
+		}
+		_, err = Conn(ctx).ExecContext(ctx, `UPDATE guilds SET description = $2 WHERE id = $1`, guildID, description)
+		if err != nil {
+			return fmt.Errorf("failed to update guild %d: %w", guildID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetGuild(ctx, guildID)
+}
+
+// DisbandGuild deletes the leader's guild and returns the IDs of everyone who was in it
+func DisbandGuild(ctx context.Context, leaderID string) ([]string, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	var memberIDs []string
+	err := WithTx(ctx, func(ctx context.Context) error {
+		guildID, role, err := lockGuildMember(ctx, leaderID)
+		if err != nil {
+			return err
+		}
+		if role != GuildRoleLeader {
+			return ErrGuildPermission
+		}
+		members, err := ListGuildMembers(ctx, guildID)
+		if err != nil {
+			return err
+		}
+		for _, member := range members {
+			memberIDs = append(memberIDs, member.UserID)
+		}
+		return deleteGuild(ctx, guildID)
+	})
+	return memberIDs, err
+}
+
+// lockGuildMember locks the user's membership row and returns their guild and role
+func lockGuildMember(ctx context.Context, userID string) (int64, string, error) {
+	var guildID int64
+	var role string
+	err := Conn(ctx).QueryRowContext(ctx, `
+		SELECT guild_id, role FROM guild_members WHERE user_id = $1 FOR UPDATE
+	`, userID).Scan(&guildID, &role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", ErrNotInGuild
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to get guild of user %s: %w", userID, err)
+	}
+	return guildID, role, nil
+}
+
+// lockGuildPair locks the actor's and target's membership rows, checking they share a guild
+// and that the actor has at least minRole. Rows are locked in user ID order so two members
+// acting on each other can't deadlock.
+func lockGuildPair(ctx context.Context, actorID, targetID, minRole string) (guildID int64, actorRole, targetRole string, err error) {
+	rows, err := Conn(ctx).QueryContext(ctx, `
+		SELECT user_id, guild_id, role FROM guild_members WHERE user_id = ANY($1)
+		ORDER BY user_id FOR UPDATE
+	`, pq.Array([]string{actorID, targetID}))
+	if err != nil {
+		return 0, "", "", fmt.Errorf("failed to lock guild members: %w", err)
+	}
+	defer rows.Close()
+
+	guilds := make(map[string]int64, 2)
+	roles := make(map[string]string, 2)
+	for rows.Next() {
+		var userID, role string
+		var id int64
+		if err := rows.Scan(&userID, &id, &role); err != nil {
+			return 0, "", "", fmt.Errorf("failed to scan guild member: %w", err)
+		}
+		guilds[userID], roles[userID] = id, role
+	}
+	if err := rows.Err(); err != nil {
+		return 0, "", "", fmt.Errorf("failed to lock guild members: %w", err)
+	}
+
+	guildID, inGuild := guilds[actorID]
+	switch {
+	case !inGuild:
+		return 0, "", "", ErrNotInGuild
+	case guilds[targetID] != guildID:
+		return 0, "", "", ErrNotGuildMember
+	case guildRank(roles[actorID]) < guildRank(minRole):
+		return 0, "", "", ErrGuildPermission
+	}
+	return guildID, roles[actorID], roles[targetID], nil
+}
+
+// setGuildMemberRole updates a membership row's role
+func setGuildMemberRole(ctx context.Context, userID, role string) error {
+	_, err := Conn(ctx).ExecContext(ctx, `UPDATE guild_members SET role = $2 WHERE user_id = $1`, userID, role)
+	if err != nil {
+		return fmt.Errorf("failed to set guild role of user %s: %w", userID, err)
+	}
+	return nil
+}
+
+// removeGuildMember deletes a membership row
+func removeGuildMember(ctx context.Context, guildID int64, userID string) error {
+	_, err := Conn(ctx).ExecContext(ctx, `DELETE FROM guild_members WHERE guild_id = $1 AND user_id = $2`, guildID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove user %s from guild %d: %w", userID, guildID, err)
+	}
+	return nil
+}
+
+// deleteGuild deletes a guild; its memberships go with it
+func deleteGuild(ctx context.Context, guildID int64) error {
+	if _, err := Conn(ctx).ExecContext(ctx, `DELETE FROM guilds WHERE id = $1`, guildID); err != nil {
+		return fmt.Errorf("failed to delete guild %d: %w", guildID, err)
+	}
+	slog.Info("Guild disbanded", "guild_id", guildID)
+	return nil
+}
+
+// isPQError reports whether err is a Postgres error with the given code
+func isPQError(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Idempotency keys let clients on flaky networks retry a request without repeating its
+// side effects: the first request with a key runs, and retries with the same key (from the
+// same player, to the same path, with the same body) get its response replayed.
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed" // "true" on replayed responses
+	maxIdempotencyKeyLength  = 255
+	maxIdempotentBodyBytes   = 64 << 10
+	idempotencyPruneInterval = time.Minute
+)
+
+// idempotentEntry is the response to one idempotency key; done is closed once it's known
+type idempotentEntry struct {
+	fingerprint [sha256.Size]byte
+	done        chan struct{}
+	cached      bool // False if the first attempt failed server-side and may be retried
+	status      int
+	header      http.Header
+	body        []byte
+	expires     time.Time
+}
+
+// IdempotencyCache holds recent responses by player and idempotency key
+type IdempotencyCache struct {
+	ttl       time.Duration
+	entries   map[string]*idempotentEntry
+	lastPrune time.Time
+	mu        sync.Mutex
+}
+
+// NewIdempotencyCache keeps responses for ttl after they complete
+func NewIdempotencyCache(ttl time.Duration) *IdempotencyCache {
+	return &IdempotencyCache{
+		ttl:       ttl,
+		entries:   make(map[string]*idempotentEntry),
+		lastPrune: time.Now(),
+	}
+}
+
+// NewIdempotencyCacheFromConfig keeps responses for IDEMPOTENCY_TTL_SECONDS
+func NewIdempotencyCacheFromConfig() *IdempotencyCache {
+	return NewIdempotencyCache(settings.Security.IdempotencyTTL)
+}
+
+// claim returns the entry for key, and true if the caller created it and must complete it
+func (c *IdempotencyCache) claim(key string, fingerprint [sha256.Size]byte) (*idempotentEntry, bool) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.lastPrune) >= idempotencyPruneInterval {
+		c.pruneLocked(now)
+	}
+
+	if entry, exists := c.entries[key]; exists && (!entry.cached || now.Before(entry.expires)) {
+		return entry, false
+	}
+	entry := &idempotentEntry{fingerprint: fingerprint, done: make(chan struct{})}
+	c.entries[key] = entry
+	return entry, true
+}
+
+// complete stores the response to key, or forgets the key if the response shouldn't be
+// replayed, and wakes any retries waiting on it
+func (c *IdempotencyCache) complete(key string, entry *idempotentEntry, capture *responseCapture) {
+	c.mu.Lock()
+	if replayable(capture.status) {
+		entry.cached = true
+		entry.status = capture.status
+		entry.header = capture.header
+		entry.body = capture.body.Bytes()
+		entry.expires = time.Now().Add(c.ttl)
+	} else if c.entries[key] == entry {
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+	close(entry.done)
+}
+
+// pruneLocked drops expired responses
+func (c *IdempotencyCache) pruneLocked(now time.Time) {
+	for key, entry := range c.entries {
+		if entry.cached && !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	c.lastPrune = now
+}
+
+// replayable reports whether a response is final for its key. Server errors and rate
+// limiting are transient, so a retry should run the request again.
+func replayable(status int) bool {
+	return status < http.StatusInternalServerError && status != http.StatusTooManyRequests
+}
+
+// responseCapture records a response while writing it through
+type responseCapture struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (rc *responseCapture) WriteHeader(status int) {
+	if rc.header == nil {
+		rc.status = status
+		rc.header = rc.ResponseWriter.Header().Clone()
+		rc.header.Del(RequestIDHeader) // Replays carry the retry's own request ID
+	}
+	rc.ResponseWriter.WriteHeader(status)
+}
+
+func (rc *responseCapture) Write(data []byte) (int, error) {
+	if rc.header == nil {
+		rc.WriteHeader(http.StatusOK)
+	}
+	rc.body.Write(data)
+	return rc.ResponseWriter.Write(data)
+}
+
+// Idempotent replays the stored response to retries that repeat an Idempotency-Key.
+// Requests without a key or a valid Authorization token pass through untouched. A retry
+// that arrives while the first attempt is still running waits for it.
+func Idempotent(cache *IdempotencyCache) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+				return
+			}
+			playerID, ok := ResolvePrincipal(r.Header.Get("Authorization"))
+			if !ok {
+				next(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBodyBytes+1))
+			if err != nil {
+				Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			if len(body) > maxIdempotentBodyBytes {
+				Error(w, "Request body is too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			fingerprint := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+"\n"), body...))
+			cacheKey := playerID + "\x00" + key
+
+			for {
+				entry, owner := cache.claim(cacheKey, fingerprint)
+				if owner {
+					capture := &responseCapture{ResponseWriter: w}
+					// Deferred so a panicking handler still releases waiting retries
+					defer func() {
+						if capture.header == nil {
+							capture.status = http.StatusInternalServerError
+						}
+						cache.complete(cacheKey, entry, capture)
+					}()
+					next(capture, r)
+					return
+				}
+
+				if entry.fingerprint != fingerprint {
+					Error(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+					return
+				}
+				select {
+				case <-entry.done:
+				case <-r.Context().Done():
+					return
+				}
+				if entry.cached {
+					Logger(r.Context()).Debug("Replaying idempotent response", "path", r.URL.Path, "status", entry.status)
+					for name, values := range entry.header {
+						w.Header()[name] = values
+					}
+					w.Header().Set(IdempotentReplayedHeader, "true")
+					w.WriteHeader(entry.status)
+					w.Write(entry.body)
+					return
+				}
+				// The first attempt failed server-side; run this one instead
+			}
+		}
+	}
+}
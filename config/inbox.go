@@ -0,0 +1,106 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// MaxInboxSize caps undelivered private messages per recipient
+const MaxInboxSize = 200
+
+// ErrInboxFull is returned when a recipient already has MaxInboxSize undelivered messages
+var ErrInboxFull = errors.New("recipient's inbox is full")
+
+// OfflineMessage is a private message waiting for its recipient to connect
+type OfflineMessage struct {
+	ID          int64
+	SenderID    string
+	RecipientID string
+	Username    string // Sender's display name at send time
+	Text        string
+	System      bool // Sent by a service account
+	CreatedAt   time.Time
+}
+
+// UserExists reports whether a user account exists
+func UserExists(userID string) (bool, error) {
+	if DB == nil {
+		return false, fmt.Errorf("database not initialized")
+	}
+
+	var exists bool
+	err := DB.QueryRow(`SELECT EXISTS (SELECT 1 FROM "User" WHERE "userId" = $1)`, userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check user %s: %w", userID, err)
+	}
+	return exists, nil
+}
+
+// SaveOfflineMessage stores a private message for later delivery. The inbox size check
+// and insert are a single statement so concurrent senders can't overfill it.
+func SaveOfflineMessage(msg OfflineMessage) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	result, err := DB.Exec(`
+		INSERT INTO messages (sender_id, recipient_id, username, text, system)
+		SELECT $1, $2, $3, $4, $5
+		WHERE (SELECT COUNT(*) FROM messages WHERE recipient_id = $2 AND delivered_at IS NULL) < $6
+	`, msg.SenderID, msg.RecipientID, msg.Username, msg.Text, msg.System, MaxInboxSize)
+	if err != nil {
+		return fmt.Errorf("failed to store offline message for %s: %w", msg.RecipientID, err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrInboxFull
+	}
+	return nil
+}
+
+// GetUndeliveredMessages returns a recipient's queued messages, oldest first
+func GetUndeliveredMessages(recipientID string) ([]OfflineMessage, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.Query(`
+		SELECT id, sender_id, recipient_id, username, text, system, created_at FROM messages
+		WHERE recipient_id = $1 AND delivered_at IS NULL
+		ORDER BY created_at, id
+		LIMIT $2
+	`, recipientID, MaxInboxSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inbox for %s: %w", recipientID, err)
+	}
+	defer rows.Close()
+
+	var messages []OfflineMessage
+	for rows.Next() {
+		var m OfflineMessage
+		if err := rows.Scan(&m.ID, &m.SenderID, &m.RecipientID, &m.Username, &m.Text, &m.System, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan offline message: %w", err)
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+// MarkMessagesDelivered flags inbox messages as delivered
+func MarkMessagesDelivered(ids []int64) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := DB.Exec(`UPDATE messages SET delivered_at = NOW() WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to mark %d messages delivered: %w", len(ids), err)
+	}
+	return nil
+}
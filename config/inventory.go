@@ -0,0 +1,108 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrStackFull is returned when a grant would take an item past its stack limit
+	ErrStackFull = errors.New("item stack is full")
+	// ErrNotEnoughItems is returned when removing more of an item than the player owns
+	ErrNotEnoughItems = errors.New("not enough of this item")
+)
+
+// InventoryItem is a quantity of one item owned by a player
+type InventoryItem struct {
+	ItemID     string    `json:"item_id"`
+	Quantity   int       `json:"quantity"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// GetInventory returns everything a player owns, oldest first
+func GetInventory(ctx context.Context, userID string) ([]InventoryItem, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := Conn(ctx).QueryContext(ctx, `
+		SELECT item_id, quantity, acquired_at FROM inventory_items
+		WHERE user_id = $1
+		ORDER BY acquired_at, item_id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inventory for user %s: %w", userID, err)
+	}
+	defer rows.Close()
+
+	items := []InventoryItem{}
+	for rows.Next() {
+		var item InventoryItem
+		if err := rows.Scan(&item.ItemID, &item.Quantity, &item.AcquiredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan inventory item: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// GrantInventoryItem adds quantity of an item, up to maxStack, charging the inventory quota
+// in the same transaction. Returns the player's new total for the item.
+func GrantInventoryItem(ctx context.Context, userID, itemID string, quantity, maxStack int) (InventoryItem, error) {
+	item := InventoryItem{ItemID: itemID}
+	err := WithTx(ctx, func(ctx context.Context) error {
+		if err := ConsumeQuota(ctx, userID, QuotaInventoryItems, int64(quantity)); err != nil {
+			return err
+		}
+
+		err := Conn(ctx).QueryRowContext(ctx, `
+			INSERT INTO inventory_items (user_id, item_id, quantity) VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, item_id) DO UPDATE
+				SET quantity = inventory_items.quantity + EXCLUDED.quantity, updated_at = NOW()
+				WHERE inventory_items.quantity + EXCLUDED.quantity <= $4
+			RETURNING quantity, acquired_at
+		`, userID, itemID, quantity, maxStack).Scan(&item.Quantity, &item.AcquiredAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrStackFull
+		}
+		if err != nil {
+			return fmt.Errorf("failed to grant %s to user %s: %w", itemID, userID, err)
+		}
+		return nil
+	})
+	return item, err
+}
+
+// RemoveInventoryItem takes quantity of an item away and releases its quota. Returns how
+// many are left (the row is deleted at zero).
+func RemoveInventoryItem(ctx context.Context, userID, itemID string, quantity int) (int, error) {
+	var remaining int
+	err := WithTx(ctx, func(ctx context.Context) error {
+		err := Conn(ctx).QueryRowContext(ctx, `
+			UPDATE inventory_items SET quantity = quantity - $3, updated_at = NOW()
+			WHERE user_id = $1 AND item_id = $2 AND quantity > $3
+			RETURNING quantity
+		`, userID, itemID, quantity).Scan(&remaining)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Either exactly the owned amount (delete the row) or not enough
+			result, err := Conn(ctx).ExecContext(ctx, `
+				DELETE FROM inventory_items WHERE user_id = $1 AND item_id = $2 AND quantity = $3
+			`, userID, itemID, quantity)
+			if err != nil {
+				return fmt.Errorf("failed to remove %s from user %s: %w", itemID, userID, err)
+			}
+			if rows, _ := result.RowsAffected(); rows == 0 {
+				return ErrNotEnoughItems
+			}
+			remaining = 0
+		} else if err != nil {
+			return fmt.Errorf("failed to remove %s from user %s: %w", itemID, userID, err)
+		}
+
+		return ReleaseQuota(ctx, userID, QuotaInventoryItems, int64(quantity))
+	})
+	return remaining, err
+}
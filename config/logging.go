@@ -0,0 +1,124 @@
+package config
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InitLogging installs a log/slog default logger writing JSON (or LOG_FORMAT=text) at
+// LOG_LEVEL (debug, info, warn, error; default info). Request-scoped loggers carry
+// request_id, and WebSocket connections add player_id and room_id.
+func InitLogging() {
+	options := &slog.HandlerOptions{Level: parseLogLevel(os.Getenv("LOG_LEVEL"))}
+
+	var handler slog.Handler
+	if strings.EqualFold(os.Getenv("LOG_FORMAT"), "text") {
+		handler = slog.NewTextHandler(os.Stdout, options)
+	} else {
+		handler = slog.NewJSONHandler(os.Stdout, options)
+	}
+	slog.SetDefault(slog.New(handler))
+}
+
+// parseLogLevel maps a LOG_LEVEL value to a slog level, defaulting to info
+func parseLogLevel(value string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+type loggerKey struct{}
+
+type requestIDKey struct{}
+
+// WithLogger returns a context carrying the given logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// Logger returns the context's logger, or the default logger if it has none
+func Logger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// RequestID returns the ID LogRequests gave the context's request, or "" outside one
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID returns a random identifier for correlating a request's log lines
+func newRequestID() string {
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(raw)
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// Hijack passes through to the underlying writer so WebSocket upgrades keep working
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	s.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+// LogRequests gives each request an ID (kept from X-Request-ID when the caller sent a valid
+// one) and a logger carrying it, echoes the ID in the X-Request-ID response header, and logs
+// the request's completion at debug level
+func LogRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := incomingRequestID(r)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		logger := slog.Default().With("request_id", requestID)
+		if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.HasTraceID() {
+			logger = logger.With("trace_id", spanContext.TraceID().String())
+		}
+		ctx := context.WithValue(r.Context(), requestIDKey{}, requestID)
+		r = r.WithContext(WithLogger(ctx, logger))
+
+		started := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		logger.Debug("HTTP request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"duration_ms", time.Since(started).Milliseconds())
+	})
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus metrics. /metrics is only served when METRICS_ENABLED=1; if METRICS_TOKEN is
+// set, scrapers must also send "Authorization: Bearer <token>".
+const MetricsNamespace = "velvet"
+
+// MetricsEnabled reports whether the /metrics endpoint should be exposed
+func MetricsEnabled() bool {
+	return GetEnvInt("METRICS_ENABLED", 0) != 0
+}
+
+// RegisterDBMetrics exports the connection pool and async queue stats (call after InitDB)
+func RegisterDBMetrics() {
+	if DB != nil {
+		prometheus.MustRegister(collectors.NewDBStatsCollector(DB, "postgres"))
+	}
+
+	prometheus.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Name:      "db_async_queue_depth",
+			Help:      "Operations waiting in the async database queue.",
+		}, func() float64 { return float64(GetAsyncWorkerStats().QueueDepth) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Name:      "db_async_queue_capacity",
+			Help:      "Capacity of the async database queue.",
+		}, func() float64 { return float64(GetAsyncWorkerStats().QueueCapacity) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Name:      "db_async_processed_total",
+			Help:      "Async database operations executed.",
+		}, func() float64 { return float64(asyncWorker.processed.Load()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Name:      "db_async_failed_total",
+			Help:      "Async database operations that returned an error.",
+		}, func() float64 { return float64(asyncWorker.failed.Load()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Name:      "db_async_dropped_total",
+			Help:      "Async database operations dropped because the queue was full.",
+		}, func() float64 { return float64(asyncWorker.dropped.Load()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Name:      "db_async_saturated",
+			Help:      "1 while the async database queue is saturated.",
+		}, func() float64 {
+			if GetAsyncWorkerStats().Saturated {
+				return 1
+			}
+			return 0
+		}),
+	)
+}
+
+// MetricsHandler serves the Prometheus registry, checking METRICS_TOKEN when configured
+func MetricsHandler() http.Handler {
+	handler := promhttp.Handler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if token := os.Getenv("METRICS_TOKEN"); token != "" {
+			provided := r.Header.Get("Authorization")
+			if subtle.ConstantTimeCompare([]byte(provided), []byte("Bearer "+token)) != 1 {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	DefaultLeaderboardLimit = 10
+	MaxLeaderboardLimit     = 100
+)
+
+// GameResult is one player's outcome in a finished mini-game
+type GameResult struct {
+	UserID string
+	Score  int
+	Won    bool
+}
+
+// LeaderboardEntry is a player's totals for one mini-game
+type LeaderboardEntry struct {
+	UserID      string    `json:"user_id"`
+	Wins        int       `json:"wins"`
+	GamesPlayed int       `json:"games_played"`
+	BestScore   int       `json:"best_score"`
+	TotalScore  int64     `json:"total_score"`
+	LastPlayed  time.Time `json:"last_played"`
+}
+
+// RecordGameResultsAsync queues one batched write adding a finished game to each player's
+// totals. Returns false if the queue is full.
+func RecordGameResultsAsync(game string, results []GameResult) bool {
+	if DB == nil || len(results) == 0 {
+		return true
+	}
+
+	userIDs := make([]string, len(results))
+	scores := make([]int64, len(results))
+	wins := make([]int64, len(results))
+	for i, result := range results {
+		userIDs[i], scores[i] = result.UserID, int64(result.Score)
+		if result.Won {
+			wins[i] = 1
+		}
+	}
+
+	operation := func(ctx context.Context) error {
+		_, err := DB.ExecContext(ctx, `
+			INSERT INTO minigame_stats (game, user_id, games_played, wins, best_score, total_score)
+			SELECT $1, u, 1, w, s, s FROM unnest($2::text[], $3::bigint[], $4::int[]) AS t(u, s, w)
+			ON CONFLICT (game, user_id) DO UPDATE
+				SET games_played = minigame_stats.games_played + 1,
+					wins = minigame_stats.wins + EXCLUDED.wins,
+					best_score = GREATEST(minigame_stats.best_score, EXCLUDED.best_score),
+					total_score = minigame_stats.total_score + EXCLUDED.total_score,
+					last_played = NOW()
+		`, game, pq.Array(userIDs), pq.Array(scores), pq.Array(wins))
+		if err != nil {
+			return fmt.Errorf("failed to record %s results for %d players: %w", game, len(results), err)
+		}
+		return nil
+	}
+
+	if !enqueueDBOperation(context.Background(), "record_game_results", operation) {
+		slog.Warn("Database operation queue full, dropping game results", "game", game, "players", len(results))
+		return false
+	}
+	return true
+}
+
+// GetLeaderboard returns a mini-game's top players by wins, then best score
+func GetLeaderboard(ctx context.Context, game string, limit int) ([]LeaderboardEntry, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := Conn(ctx).QueryContext(ctx, `
+		SELECT user_id, wins, games_played, best_score, total_score, last_played
+		FROM minigame_stats WHERE game = $1
+		ORDER BY wins DESC, best_score DESC, user_id
+		LIMIT $2
+	`, game, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s leaderboard: %w", game, err)
+	}
+	defer rows.Close()
+
+	entries := []LeaderboardEntry{}
+	for rows.Next() {
+		var entry LeaderboardEntry
+		if err := rows.Scan(&entry.UserID, &entry.Wins, &entry.GamesPlayed, &entry.BestScore, &entry.TotalScore, &entry.LastPlayed); err != nil {
+			return nil, fmt.Errorf("failed to scan leaderboard entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Browser origin allowlists, shared by CORS and the WebSocket upgraders. Entries are exact
+// origins ("https://velvet.town") or wildcard subdomains ("https://*.velvet.town", which
+// matches any subdomain but not the apex). DEV_ALLOW_ANY_ORIGIN=1 accepts every origin and
+// is meant for local development only.
+
+// OriginPolicy matches request origins against an allowlist
+type OriginPolicy struct {
+	exact    map[string]bool
+	wildcard []wildcardOrigin
+	allowAny bool
+}
+
+// wildcardOrigin matches any subdomain of suffix under scheme
+type wildcardOrigin struct {
+	scheme string // e.g. "https://"
+	suffix string // e.g. ".velvet.town"
+}
+
+// NewOriginPolicy builds a policy from allowlist entries
+func NewOriginPolicy(entries []string, allowAny bool) *OriginPolicy {
+	policy := &OriginPolicy{exact: make(map[string]bool), allowAny: allowAny}
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimRight(entry, "/"))
+		if scheme, suffix, ok := strings.Cut(entry, "://*."); ok {
+			policy.wildcard = append(policy.wildcard, wildcardOrigin{scheme: scheme + "://", suffix: "." + suffix})
+			continue
+		}
+		policy.exact[entry] = true
+	}
+	return policy
+}
+
+// Empty reports whether the policy allows no origins at all
+func (p *OriginPolicy) Empty() bool {
+	return !p.allowAny && len(p.exact) == 0 && len(p.wildcard) == 0
+}
+
+// Allowed reports whether origin matches the allowlist
+func (p *OriginPolicy) Allowed(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	if p.allowAny {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if p.exact[origin] {
+		return true
+	}
+	for _, pattern := range p.wildcard {
+		host, ok := strings.CutPrefix(origin, pattern.scheme)
+		if ok && len(host) > len(pattern.suffix) && strings.HasSuffix(host, pattern.suffix) && !strings.Contains(host, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	corsPolicy       *OriginPolicy
+	corsPolicyOnce   sync.Once
+	wsOriginPolicy   *OriginPolicy
+	wsOriginOnce     sync.Once
+	devAnyOrigin     bool
+	devAnyOriginOnce sync.Once
+)
+
+// allowAnyOrigin reports whether DEV_ALLOW_ANY_ORIGIN is on, warning once if so
+func allowAnyOrigin() bool {
+	devAnyOriginOnce.Do(func() {
+		devAnyOrigin = GetEnvInt("DEV_ALLOW_ANY_ORIGIN", 0) == 1
+		if devAnyOrigin {
+			slog.Warn("DEV_ALLOW_ANY_ORIGIN is set, accepting requests from every origin")
+		}
+	})
+	return devAnyOrigin
+}
+
+// getCORSPolicy loads the CORS allowlist from CORS_ALLOWED_ORIGINS
+func getCORSPolicy() *OriginPolicy {
+	corsPolicyOnce.Do(func() {
+		corsPolicy = NewOriginPolicy(GetEnvList("CORS_ALLOWED_ORIGINS"), allowAnyOrigin())
+		if corsPolicy.Empty() {
+			slog.Warn("CORS_ALLOWED_ORIGINS is empty, cross-origin browser requests will be blocked")
+		}
+	})
+	return corsPolicy
+}
+
+// getWebSocketOriginPolicy loads WS_ALLOWED_ORIGINS, falling back to CORS_ALLOWED_ORIGINS
+func getWebSocketOriginPolicy() *OriginPolicy {
+	wsOriginOnce.Do(func() {
+		entries := GetEnvList("WS_ALLOWED_ORIGINS")
+		if len(entries) == 0 {
+			entries = GetEnvList("CORS_ALLOWED_ORIGINS")
+		}
+		wsOriginPolicy = NewOriginPolicy(entries, allowAnyOrigin())
+	})
+	return wsOriginPolicy
+}
+
+// CheckWebSocketOrigin is the CheckOrigin for WebSocket upgraders. Requests without an
+// Origin header (native clients) and same-host pages are accepted as well as the allowlist.
+func CheckWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if getWebSocketOriginPolicy().Allowed(origin) {
+		return true
+	}
+	if parsed, err := url.Parse(origin); err == nil && strings.EqualFold(parsed.Host, r.Host) {
+		return true
+	}
+
+	Logger(r.Context()).Info("WebSocket upgrade from disallowed origin rejected", "origin", origin)
+	return false
+}
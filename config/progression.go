@@ -0,0 +1,76 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/lib/pq"
+)
+
+// Progress is a player's stored XP and level
+type Progress struct {
+	XP    int64 `json:"xp"`
+	Level int   `json:"level"`
+}
+
+// XPGain is XP earned since the last write, with the level it brought the player to
+type XPGain struct {
+	UserID string
+	XP     int64
+	Level  int
+}
+
+// GetProgress returns a player's XP and level (level 1 with no XP if they have none yet)
+func GetProgress(ctx context.Context, userID string) (Progress, error) {
+	if DB == nil {
+		return Progress{}, fmt.Errorf("database not initialized")
+	}
+
+	progress := Progress{Level: 1}
+	err := Conn(ctx).QueryRowContext(ctx,
+		`SELECT xp, level FROM player_progress WHERE user_id = $1`, userID,
+	).Scan(&progress.XP, &progress.Level)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Progress{}, fmt.Errorf("failed to get progress for user %s: %w", userID, err)
+	}
+	return progress, nil
+}
+
+// SaveXPGainsAsync queues one batched write adding each gain to the player's stored XP.
+// Returns false if the queue is full.
+func SaveXPGainsAsync(gains []XPGain) bool {
+	if DB == nil || len(gains) == 0 {
+		return true
+	}
+
+	userIDs := make([]string, len(gains))
+	amounts := make([]int64, len(gains))
+	levels := make([]int64, len(gains))
+	for i, gain := range gains {
+		userIDs[i], amounts[i], levels[i] = gain.UserID, gain.XP, int64(gain.Level)
+	}
+
+	operation := func(ctx context.Context) error {
+		_, err := DB.ExecContext(ctx, `
+			INSERT INTO player_progress (user_id, xp, level)
+			SELECT * FROM unnest($1::text[], $2::bigint[], $3::int[])
+			ON CONFLICT (user_id) DO UPDATE
+				SET xp = player_progress.xp + EXCLUDED.xp,
+					level = GREATEST(player_progress.level, EXCLUDED.level),
+					updated_at = NOW()
+		`, pq.Array(userIDs), pq.Array(amounts), pq.Array(levels))
+		if err != nil {
+			return fmt.Errorf("failed to save XP for %d players: %w", len(gains), err)
+		}
+		return nil
+	}
+
+	if !enqueueDBOperation(context.Background(), "save_xp", operation) {
+		slog.Warn("Database operation queue full, keeping XP for the next flush", "players", len(gains))
+		return false
+	}
+	return true
+}
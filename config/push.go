@@ -0,0 +1,256 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Notification kinds, each of which a user can turn off in their preferences
+const (
+	NotifyPrivateMessage = "private_message"
+	NotifyFriendRequest  = "friend_request"
+)
+
+// Device platforms accepted at registration
+const (
+	PlatformAndroid = "android"
+	PlatformIOS     = "ios"
+	PlatformWeb     = "web"
+)
+
+const (
+	MaxDeviceTokenLength = 4096
+	MaxDevicesPerUser    = 10  // Oldest registrations are dropped past this
+	MaxPushBodyLength    = 200 // Longer bodies (chat text) are cut off with an ellipsis
+	pushSendTimeout      = 10 * time.Second
+)
+
+// ErrInvalidDeviceToken is returned by a PushSender when the push service no longer
+// accepts a token; the token is then unregistered
+var ErrInvalidDeviceToken = errors.New("device token is no longer valid")
+
+// PushNotification is a notification shown on a user's devices
+type PushNotification struct {
+	Title string
+	Body  string
+	Data  map[string]string // Delivered to the app alongside the notification
+}
+
+// PushSender delivers notifications to device tokens
+type PushSender interface {
+	Name() string
+	Send(ctx context.Context, token string, notification PushNotification) error
+}
+
+// NotificationPrefs are a user's push notification settings; everything is on by default
+type NotificationPrefs struct {
+	PrivateMessages bool `json:"private_messages"`
+	FriendRequests  bool `json:"friend_requests"`
+}
+
+// Allows reports whether the preferences allow a kind of notification
+func (p NotificationPrefs) Allows(kind string) bool {
+	switch kind {
+	case NotifyPrivateMessage:
+		return p.PrivateMessages
+	case NotifyFriendRequest:
+		return p.FriendRequests
+	}
+	return true
+}
+
+var (
+	pushSender   PushSender
+	pushSenderMu sync.RWMutex
+)
+
+// InitPush sets up FCM when FCM_CREDENTIALS_FILE is configured; otherwise push
+// notifications are disabled
+func InitPush() error {
+	if os.Getenv("FCM_CREDENTIALS_FILE") == "" {
+		slog.Info("Push notifications disabled (FCM_CREDENTIALS_FILE not set)")
+		return nil
+	}
+
+	sender, err := NewFCMSenderFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to set up FCM: %w", err)
+	}
+	SetPushSender(sender)
+	slog.Info("Push notifications initialized", "sender", sender.Name())
+	return nil
+}
+
+// GetPushSender returns the active sender, or nil when push is disabled
+func GetPushSender() PushSender {
+	pushSenderMu.RLock()
+	defer pushSenderMu.RUnlock()
+	return pushSender
+}
+
+// SetPushSender replaces the active sender
+func SetPushSender(sender PushSender) {
+	pushSenderMu.Lock()
+	defer pushSenderMu.Unlock()
+	pushSender = sender
+}
+
+// ValidPlatform reports whether platform is one of the device platforms
+func ValidPlatform(platform string) bool {
+	switch platform {
+	case PlatformAndroid, PlatformIOS, PlatformWeb:
+		return true
+	}
+	return false
+}
+
+// RegisterDeviceToken attaches a device token to a user, moving it from any earlier owner,
+// and drops the user's oldest tokens past MaxDevicesPerUser
+func RegisterDeviceToken(ctx context.Context, userID, token, platform string) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	return WithTx(ctx, func(ctx context.Context) error {
+		_, err := Conn(ctx).ExecContext(ctx, `
+			INSERT INTO device_tokens (token, user_id, platform) VALUES ($1, $2, $3)
+			ON CONFLICT (token) DO UPDATE SET user_id = $2, platform = $3, updated_at = NOW()
+		`, token, userID, platform)
+		if err != nil {
+			return fmt.Errorf("failed to register device token for user %s: %w", userID, err)
+		}
+
+		_, err = Conn(ctx).ExecContext(ctx, `
+			DELETE FROM device_tokens WHERE user_id = $1 AND token NOT IN (
+				SELECT token FROM device_tokens WHERE user_id = $1 ORDER BY updated_at DESC LIMIT $2
+			)
+		`, userID, MaxDevicesPerUser)
+		if err != nil {
+			return fmt.Errorf("failed to trim device tokens for user %s: %w", userID, err)
+		}
+		return nil
+	})
+}
+
+// UnregisterDeviceToken removes one of a user's device tokens
+func UnregisterDeviceToken(ctx context.Context, userID, token string) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	_, err := Conn(ctx).ExecContext(ctx, `DELETE FROM device_tokens WHERE user_id = $1 AND token = $2`, userID, token)
+	if err != nil {
+		return fmt.Errorf("failed to unregister device token for user %s: %w", userID, err)
+	}
+	return nil
+}
+
+// GetDeviceTokens returns a user's registered device tokens
+func GetDeviceTokens(ctx context.Context, userID string) ([]string, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := Conn(ctx).QueryContext(ctx, `SELECT token FROM device_tokens WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device tokens for user %s: %w", userID, err)
+	}
+	defer rows.Close()
+
+	tokens := make([]string, 0)
+	for rows.Next() {
+		var token string
+		if err := rows.Scan(&token); err != nil {
+			return nil, fmt.Errorf("failed to scan device token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// GetNotificationPrefs returns a user's notification preferences
+func GetNotificationPrefs(ctx context.Context, userID string) (NotificationPrefs, error) {
+	prefs := NotificationPrefs{PrivateMessages: true, FriendRequests: true}
+	if DB == nil {
+		return prefs, fmt.Errorf("database not initialized")
+	}
+
+	err := Conn(ctx).QueryRowContext(ctx, `
+		SELECT private_messages, friend_requests FROM notification_preferences WHERE user_id = $1
+	`, userID).Scan(&prefs.PrivateMessages, &prefs.FriendRequests)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return prefs, fmt.Errorf("failed to get notification preferences for user %s: %w", userID, err)
+	}
+	return prefs, nil
+}
+
+// SetNotificationPrefs saves a user's notification preferences
+func SetNotificationPrefs(ctx context.Context, userID string, prefs NotificationPrefs) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	_, err := Conn(ctx).ExecContext(ctx, `
+		INSERT INTO notification_preferences (user_id, private_messages, friend_requests) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET private_messages = $2, friend_requests = $3, updated_at = NOW()
+	`, userID, prefs.PrivateMessages, prefs.FriendRequests)
+	if err != nil {
+		return fmt.Errorf("failed to save notification preferences for user %s: %w", userID, err)
+	}
+	return nil
+}
+
+// SendPushAsync notifies every device of a user in the background, unless push is disabled
+// or the user turned this kind of notification off
+func SendPushAsync(userID, kind string, notification PushNotification) {
+	sender := GetPushSender()
+	if sender == nil || DB == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), pushSendTimeout)
+		defer cancel()
+
+		prefs, err := GetNotificationPrefs(ctx, userID)
+		if err != nil {
+			slog.Warn("Could not read notification preferences", "user_id", userID, "error", err)
+			return
+		}
+		if !prefs.Allows(kind) {
+			return
+		}
+
+		tokens, err := GetDeviceTokens(ctx, userID)
+		if err != nil {
+			slog.Warn("Could not load device tokens", "user_id", userID, "error", err)
+			return
+		}
+
+		if body := []rune(notification.Body); len(body) > MaxPushBodyLength {
+			notification.Body = string(body[:MaxPushBodyLength-1]) + "…"
+		}
+		if notification.Data == nil {
+			notification.Data = make(map[string]string)
+		}
+		notification.Data["kind"] = kind
+		for _, token := range tokens {
+			err := sender.Send(ctx, token, notification)
+			if errors.Is(err, ErrInvalidDeviceToken) {
+				if err := UnregisterDeviceToken(ctx, userID, token); err != nil {
+					slog.Warn("Could not remove invalid device token", "user_id", userID, "error", err)
+				}
+				continue
+			}
+			if err != nil {
+				slog.Warn("Push notification failed", "user_id", userID, "kind", kind, "sender", sender.Name(), "error", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,208 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	fcmScope    = "https://www.googleapis.com/auth/firebase.messaging"
+	fcmEndpoint = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+	// fcmTokenRefreshMargin renews the OAuth access token this long before it expires
+	fcmTokenRefreshMargin = time.Minute
+)
+
+// FCMSender sends notifications through the Firebase Cloud Messaging HTTP v1 API,
+// authenticating as a service account with self-signed JWTs
+type FCMSender struct {
+	projectID   string
+	clientEmail string
+	tokenURI    string
+	privateKey  *rsa.PrivateKey
+	client      *http.Client
+
+	accessToken string
+	expiresAt   time.Time
+	mu          sync.Mutex
+}
+
+// fcmCredentials is the subset of a Google service account key file FCM needs
+type fcmCredentials struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// NewFCMSenderFromEnv reads the service account key at FCM_CREDENTIALS_FILE; the project
+// comes from FCM_PROJECT_ID or the key file
+func NewFCMSenderFromEnv() (*FCMSender, error) {
+	raw, err := os.ReadFile(os.Getenv("FCM_CREDENTIALS_FILE"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FCM credentials: %w", err)
+	}
+	var creds fcmCredentials
+	if err := json.Unmarshal(raw, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse FCM credentials: %w", err)
+	}
+
+	projectID := GetEnvString("FCM_PROJECT_ID", creds.ProjectID)
+	if projectID == "" || creds.ClientEmail == "" || creds.TokenURI == "" {
+		return nil, errors.New("FCM credentials need project_id, client_email, and token_uri")
+	}
+
+	block, _ := pem.Decode([]byte(creds.PrivateKey))
+	if block == nil {
+		return nil, errors.New("FCM credentials have no PEM private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse FCM private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("FCM private key is not an RSA key")
+	}
+
+	return &FCMSender{
+		projectID:   projectID,
+		clientEmail: creds.ClientEmail,
+		tokenURI:    creds.TokenURI,
+		privateKey:  key,
+		client:      &http.Client{Timeout: pushSendTimeout},
+	}, nil
+}
+
+func (s *FCMSender) Name() string { return "fcm" }
+
+// Send delivers one notification, returning ErrInvalidDeviceToken for unregistered tokens
+func (s *FCMSender) Send(ctx context.Context, token string, notification PushNotification) error {
+	accessToken, err := s.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"message": map[string]interface{}{
+			"token": token,
+			"notification": map[string]string{
+				"title": notification.Title,
+				"body":  notification.Body,
+			},
+			"data": notification.Data,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode FCM message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf(fcmEndpoint, s.projectID), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("FCM request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode == http.StatusNotFound && strings.Contains(string(body), "UNREGISTERED"):
+		return ErrInvalidDeviceToken
+	case resp.StatusCode == http.StatusBadRequest && strings.Contains(string(body), "registration token"):
+		return ErrInvalidDeviceToken
+	case resp.StatusCode == http.StatusUnauthorized:
+		s.mu.Lock()
+		s.accessToken = "" // Fetch a fresh one next time
+		s.mu.Unlock()
+	}
+	return fmt.Errorf("FCM returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// token returns a cached OAuth access token, exchanging a signed JWT for a new one as needed
+func (s *FCMSender) token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.accessToken != "" && time.Now().Add(fcmTokenRefreshMargin).Before(s.expiresAt) {
+		return s.accessToken, nil
+	}
+
+	assertion, err := s.signJWT(time.Now())
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("FCM token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("FCM token endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode FCM token response: %w", err)
+	}
+	s.accessToken = result.AccessToken
+	s.expiresAt = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	return s.accessToken, nil
+}
+
+// signJWT builds the RS256 assertion the token endpoint exchanges for an access token
+func (s *FCMSender) signJWT(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   s.clientEmail,
+		"scope": fcmScope,
+		"aud":   s.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+
+	encoding := base64.RawURLEncoding
+	unsigned := encoding.EncodeToString(header) + "." + encoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign FCM assertion: %w", err)
+	}
+	return unsigned + "." + encoding.EncodeToString(signature), nil
+}
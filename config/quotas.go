@@ -0,0 +1,159 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// QuotaResource names something a player accumulates storage for
+type QuotaResource string
+
+const (
+	QuotaInventoryItems QuotaResource = "inventory_items"
+	QuotaRoomObjects    QuotaResource = "room_objects"
+	QuotaAssetBytes     QuotaResource = "asset_bytes"
+)
+
+// Default per-account limits, overridable with QUOTA_* environment variables
+const (
+	DefaultInventoryItemsQuota = 500
+	DefaultRoomObjectsQuota    = 200
+	DefaultAssetBytesQuota     = 50 * 1024 * 1024
+)
+
+// ErrQuotaExceeded is matched by every *QuotaError via errors.Is
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaError describes a write that would take an account over its quota
+type QuotaError struct {
+	Resource  QuotaResource `json:"resource"`
+	Limit     int64         `json:"limit"`
+	Used      int64         `json:"used"`
+	Requested int64         `json:"requested"`
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s quota exceeded: %d used + %d requested > %d", e.Resource, e.Used, e.Requested, e.Limit)
+}
+
+// Is lets callers check errors.Is(err, ErrQuotaExceeded)
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// QuotaUsage is one resource's usage for the profile endpoint
+type QuotaUsage struct {
+	Resource QuotaResource `json:"resource"`
+	Used     int64         `json:"used"`
+	Limit    int64         `json:"limit"`
+}
+
+var (
+	quotaLimits     map[QuotaResource]int64
+	quotaLimitsOnce sync.Once
+)
+
+// QuotaLimit returns the configured limit for a resource
+func QuotaLimit(resource QuotaResource) int64 {
+	quotaLimitsOnce.Do(func() {
+		quotaLimits = map[QuotaResource]int64{
+			QuotaInventoryItems: int64(GetEnvInt("QUOTA_INVENTORY_ITEMS", DefaultInventoryItemsQuota)),
+			QuotaRoomObjects:    int64(GetEnvInt("QUOTA_ROOM_OBJECTS", DefaultRoomObjectsQuota)),
+			QuotaAssetBytes:     int64(GetEnvInt("QUOTA_ASSET_BYTES", DefaultAssetBytesQuota)),
+		}
+	})
+	return quotaLimits[resource]
+}
+
+// ConsumeQuota records amount of new usage, or returns a *QuotaError if it would exceed the limit.
+// The check and the increment happen in one statement so concurrent writes can't overshoot.
+// Inside WithTx the usage is rolled back with the rest of the transaction.
+func ConsumeQuota(ctx context.Context, userID string, resource QuotaResource, amount int64) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	limit := QuotaLimit(resource)
+	if amount > limit {
+		used, _ := getQuotaUsed(userID, resource)
+		return &QuotaError{Resource: resource, Limit: limit, Used: used, Requested: amount}
+	}
+
+	var used int64
+	err := Conn(ctx).QueryRowContext(ctx, `
+		INSERT INTO player_usage (user_id, resource, used) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, resource) DO UPDATE SET used = player_usage.used + EXCLUDED.used, updated_at = NOW()
+		WHERE player_usage.used + EXCLUDED.used <= $4
+		RETURNING used
+	`, userID, string(resource), amount, limit).Scan(&used)
+	if err == sql.ErrNoRows {
+		used, _ = getQuotaUsed(userID, resource)
+		return &QuotaError{Resource: resource, Limit: limit, Used: used, Requested: amount}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update %s usage for user %s: %w", resource, userID, err)
+	}
+	return nil
+}
+
+// ReleaseQuota gives back usage after something is deleted
+func ReleaseQuota(ctx context.Context, userID string, resource QuotaResource, amount int64) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	_, err := Conn(ctx).ExecContext(ctx, `
+		UPDATE player_usage SET used = GREATEST(used - $3, 0), updated_at = NOW()
+		WHERE user_id = $1 AND resource = $2
+	`, userID, string(resource), amount)
+	if err != nil {
+		return fmt.Errorf("failed to release %s usage for user %s: %w", resource, userID, err)
+	}
+	return nil
+}
+
+// GetQuotaUsage returns usage against every quota for a user
+func GetQuotaUsage(userID string) ([]QuotaUsage, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.Query(`SELECT resource, used FROM player_usage WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage for user %s: %w", userID, err)
+	}
+	defer rows.Close()
+
+	used := make(map[QuotaResource]int64)
+	for rows.Next() {
+		var resource string
+		var amount int64
+		if err := rows.Scan(&resource, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan usage: %w", err)
+		}
+		used[QuotaResource(resource)] = amount
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	usage := make([]QuotaUsage, 0, 3)
+	for _, resource := range []QuotaResource{QuotaInventoryItems, QuotaRoomObjects, QuotaAssetBytes} {
+		usage = append(usage, QuotaUsage{Resource: resource, Used: used[resource], Limit: QuotaLimit(resource)})
+	}
+	return usage, nil
+}
+
+// getQuotaUsed reads current usage for one resource
+func getQuotaUsed(userID string, resource QuotaResource) (int64, error) {
+	var used int64
+	err := DB.QueryRow(`SELECT used FROM player_usage WHERE user_id = $1 AND resource = $2`,
+		userID, string(resource)).Scan(&used)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return used, err
+}
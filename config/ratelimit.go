@@ -0,0 +1,162 @@
+package config
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// HTTP rate limiting. Each limited route group gets a token bucket per client IP and per
+// authenticated player; a request must fit in both. Limits are requests per minute with a
+// burst allowance, set via RATE_LIMIT_<GROUP>_PER_MINUTE and RATE_LIMIT_<GROUP>_BURST
+// for AUTH_IP, AUTH_PLAYER, JOIN_IP, and JOIN_PLAYER (0 per minute disables that limit).
+const (
+	rateLimitIdleTTL       = 10 * time.Minute
+	rateLimitPruneInterval = time.Minute
+)
+
+// TokenBucket refills at rate tokens per second up to burst
+type TokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a full bucket
+func NewTokenBucket(ratePerSecond float64, burst int) *TokenBucket {
+	return &TokenBucket{rate: ratePerSecond, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Take removes n tokens if available. Otherwise it returns false and how long until n
+// tokens will be available.
+func (b *TokenBucket) Take(n float64, now time.Time) (bool, time.Duration) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+	if b.tokens >= n {
+		b.tokens -= n
+		return true, 0
+	}
+	if b.rate <= 0 {
+		return false, time.Hour
+	}
+	return false, time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
+
+// RateLimiter holds a token bucket per key (IP address or player ID)
+type RateLimiter struct {
+	perMinute int
+	burst     int
+	buckets   map[string]*TokenBucket
+	lastPrune time.Time
+	mu        sync.Mutex
+}
+
+// NewRateLimiter allows perMinute requests per key with bursts of up to burst
+func NewRateLimiter(perMinute, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		perMinute: perMinute,
+		burst:     burst,
+		buckets:   make(map[string]*TokenBucket),
+		lastPrune: time.Now(),
+	}
+}
+
+// NewRateLimiterFromConfig builds a configured limit; returns nil (no limit) when the rate is 0
+func NewRateLimiterFromConfig(limit RateLimitConfig) *RateLimiter {
+	if limit.PerMinute <= 0 {
+		return nil
+	}
+	return NewRateLimiter(limit.PerMinute, limit.Burst)
+}
+
+// Allow takes a token for key, returning how long to wait when there is none
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastPrune) >= rateLimitPruneInterval {
+		l.pruneLocked(now)
+	}
+
+	bucket, exists := l.buckets[key]
+	if !exists {
+		bucket = NewTokenBucket(float64(l.perMinute)/60, l.burst)
+		l.buckets[key] = bucket
+	}
+	return bucket.Take(1, now)
+}
+
+// pruneLocked drops buckets that have been idle long enough to be full again
+func (l *RateLimiter) pruneLocked(now time.Time) {
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= rateLimitIdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastPrune = now
+}
+
+// RateLimit rejects requests over the per-IP or per-player budget with 429 and Retry-After.
+// Either limiter may be nil. The player budget is keyed on the resolved principal and only
+// applies to existing accounts and service accounts; any other token only counts per IP,
+// so making up player IDs can't buy extra budget.
+func RateLimit(byIP, byPlayer *RateLimiter) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if byIP != nil {
+				if ok, wait := byIP.Allow(ClientIP(r)); !ok {
+					writeRateLimited(w, r, wait)
+					return
+				}
+			}
+			if byPlayer != nil {
+				if playerID, ok := knownPrincipal(r); ok {
+					if ok, wait := byPlayer.Allow(playerID); !ok {
+						writeRateLimited(w, r, wait)
+						return
+					}
+				}
+			}
+			next(w, r)
+		}
+	}
+}
+
+// knownPrincipal resolves the request's Authorization token to a service account or an
+// existing player account
+func knownPrincipal(r *http.Request) (string, bool) {
+	principalID, ok := ResolvePrincipal(r.Header.Get("Authorization"))
+	if !ok {
+		return "", false
+	}
+	if _, isService := GetServiceAccount(principalID); isService {
+		return principalID, true
+	}
+	exists, err := GetUserStore().Exists(r.Context(), principalID)
+	if err != nil {
+		Logger(r.Context()).Warn("Could not check account for rate limiting", "error", err)
+		return "", false
+	}
+	return principalID, exists
+}
+
+// writeRateLimited sends a 429 telling the client when to retry (whole seconds, at least 1)
+func writeRateLimited(w http.ResponseWriter, r *http.Request, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	Logger(r.Context()).Info("Rate limit exceeded", "path", r.URL.Path, "client_ip", ClientIP(r), "retry_after", seconds)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	Error(w, "Too many requests", http.StatusTooManyRequests)
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recover turns a panic in a handler into a logged stack trace and a JSON 500, so one bad
+// request can't take the process down with it
+func Recover(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered) // Deliberate abort; net/http handles it quietly
+			}
+
+			Logger(r.Context()).Error("Panic in HTTP handler",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"panic", fmt.Sprint(recovered),
+				"stack", string(debug.Stack()))
+
+			Error(w, "Internal server error", http.StatusInternalServerError)
+		}()
+		next(w, r)
+	}
+}
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ReferralCodeLength = 8
+	ReferralCodeChars  = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // No 0/O/1/I to avoid typos
+
+	// Anti-abuse heuristics
+	MaxReferralsPerIPPerDay = 3 // More referred signups than this from one IP get flagged
+
+	ReferralStatusAccepted = "accepted"
+	ReferralStatusFlagged  = "flagged"
+)
+
+// Referral records that a new user signed up with another user's referral code
+type Referral struct {
+	ID         int64     `json:"id"`
+	ReferrerID string    `json:"referrer_id"`
+	RefereeID  string    `json:"referee_id"`
+	Code       string    `json:"code"`
+	Status     string    `json:"status"`
+	FlagReason string    `json:"flag_reason,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ReferralStats summarizes a referrer's performance for admin reporting
+type ReferralStats struct {
+	ReferrerID string `json:"referrer_id"`
+	Code       string `json:"code"`
+	Total      int    `json:"total"`
+	Accepted   int    `json:"accepted"`
+	Flagged    int    `json:"flagged"`
+}
+
+// ReferralRewardHook is called for every accepted referral (e.g. to credit currency)
+type ReferralRewardHook func(referrerID, refereeID string) error
+
+var referralRewardHook struct {
+	hook ReferralRewardHook
+	mu   sync.RWMutex
+}
+
+// SetReferralRewardHook installs the function that rewards accepted referrals
+func SetReferralRewardHook(hook ReferralRewardHook) {
+	referralRewardHook.mu.Lock()
+	defer referralRewardHook.mu.Unlock()
+	referralRewardHook.hook = hook
+}
+
+// generateReferralCode creates a random referral code
+func generateReferralCode() (string, error) {
+	raw := make([]byte, ReferralCodeLength)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	code := make([]byte, ReferralCodeLength)
+	for i, b := range raw {
+		code[i] = ReferralCodeChars[int(b)%len(ReferralCodeChars)]
+	}
+	return string(code), nil
+}
+
+// GetOrCreateReferralCode returns the user's referral code, creating one on first use
+func GetOrCreateReferralCode(userID, clientIP string) (string, error) {
+	if DB == nil {
+		return "", fmt.Errorf("database not initialized")
+	}
+
+	var code string
+	err := DB.QueryRow(`SELECT code FROM referral_codes WHERE user_id = $1`, userID).Scan(&code)
+	if err == nil {
+		return code, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to get referral code for user %s: %w", userID, err)
+	}
+
+	// Retry a few times in the unlikely case of a code collision
+	for attempt := 0; attempt < 3; attempt++ {
+		code, err = generateReferralCode()
+		if err != nil {
+			return "", fmt.Errorf("failed to generate referral code: %w", err)
+		}
+		err = DB.QueryRow(`
+			INSERT INTO referral_codes (user_id, code, created_ip) VALUES ($1, $2, $3)
+			ON CONFLICT (user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+			RETURNING code
+		`, userID, code, clientIP).Scan(&code)
+		if err == nil {
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("failed to create referral code for user %s: %w", userID, err)
+}
+
+// RecordReferral attributes a new signup to a referral code. Suspicious referrals are
+// stored as flagged and don't trigger rewards. Inside WithTx the reward waits for the commit.
+func RecordReferral(ctx context.Context, code, refereeID, clientIP, deviceID string) (*Referral, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+	var referrerID, referrerIP string
+	err := Conn(ctx).QueryRowContext(ctx, `SELECT user_id, created_ip FROM referral_codes WHERE code = $1`, code).Scan(&referrerID, &referrerIP)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("unknown referral code %s", code)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up referral code: %w", err)
+	}
+
+	flagReason, err := referralAbuseCheck(ctx, referrerID, referrerIP, refereeID, clientIP, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	referral := &Referral{
+		ReferrerID: referrerID,
+		RefereeID:  refereeID,
+		Code:       code,
+		Status:     ReferralStatusAccepted,
+		FlagReason: flagReason,
+	}
+	if flagReason != "" {
+		referral.Status = ReferralStatusFlagged
+	}
+
+	err = Conn(ctx).QueryRowContext(ctx, `
+		INSERT INTO referrals (referrer_id, referee_id, code, ip, device_id, status, flag_reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, referrerID, refereeID, code, clientIP, deviceID, referral.Status, flagReason).Scan(&referral.ID, &referral.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record referral: %w", err)
+	}
+
+	if referral.Status == ReferralStatusFlagged {
+		slog.Warn("Referral flagged", "referee_id", refereeID, "referrer_id", referrerID, "reason", flagReason)
+		return referral, nil
+	}
+
+	slog.Info("Referral recorded", "referrer_id", referrerID, "referee_id", refereeID)
+
+	referralRewardHook.mu.RLock()
+	hook := referralRewardHook.hook
+	referralRewardHook.mu.RUnlock()
+	if hook != nil {
+		AfterCommit(ctx, func() {
+			go func() {
+				if err := hook(referrerID, refereeID); err != nil {
+					slog.Warn("Referral reward failed", "referrer_id", referrerID, "error", err)
+				}
+			}()
+		})
+	}
+
+	return referral, nil
+}
+
+// referralAbuseCheck applies IP/device heuristics and returns a flag reason, or "" if clean
+func referralAbuseCheck(ctx context.Context, referrerID, referrerIP, refereeID, clientIP, deviceID string) (string, error) {
+	if referrerID == refereeID {
+		return "self-referral", nil
+	}
+	if clientIP != "" && clientIP == referrerIP {
+		return "same IP as referrer", nil
+	}
+
+	var recentFromIP int
+	err := Conn(ctx).QueryRowContext(ctx, `SELECT COUNT(*) FROM referrals WHERE ip = $1 AND created_at > NOW() - INTERVAL '1 day'`,
+		clientIP).Scan(&recentFromIP)
+	if err != nil {
+		return "", fmt.Errorf("failed to check referral IP history: %w", err)
+	}
+	if recentFromIP >= MaxReferralsPerIPPerDay {
+		return "too many referred signups from this IP", nil
+	}
+
+	if deviceID != "" {
+		var deviceUsed bool
+		err := Conn(ctx).QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM referrals WHERE device_id = $1)`, deviceID).Scan(&deviceUsed)
+		if err != nil {
+			return "", fmt.Errorf("failed to check referral device history: %w", err)
+		}
+		if deviceUsed {
+			return "device already used for a referral", nil
+		}
+	}
+
+	return "", nil
+}
+
+// GetReferralStats returns per-referrer totals, best performers first
+func GetReferralStats(limit int) ([]ReferralStats, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.Query(`
+		SELECT r.referrer_id, r.code, COUNT(*),
+			COUNT(*) FILTER (WHERE r.status = $1),
+			COUNT(*) FILTER (WHERE r.status = $2)
+		FROM referrals r
+		GROUP BY r.referrer_id, r.code
+		ORDER BY COUNT(*) FILTER (WHERE r.status = $1) DESC
+		LIMIT $3
+	`, ReferralStatusAccepted, ReferralStatusFlagged, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referral stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make([]ReferralStats, 0)
+	for rows.Next() {
+		var s ReferralStats
+		if err := rows.Scan(&s.ReferrerID, &s.Code, &s.Total, &s.Accepted, &s.Flagged); err != nil {
+			return nil, fmt.Errorf("failed to scan referral stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
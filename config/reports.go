@@ -0,0 +1,195 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Report statuses. Reports start open; an admin can triage one (claim it for review) and
+// then resolve it (action taken) or dismiss it.
+const (
+	ReportOpen      = "open"
+	ReportTriaged   = "triaged"
+	ReportResolved  = "resolved"
+	ReportDismissed = "dismissed"
+)
+
+const (
+	MaxReportReasonLength  = 500
+	MaxReportExcerptLength = 2000
+	DefaultReportListLimit = 50
+	MaxReportListLimit     = 200
+)
+
+var (
+	ErrReportNotFound      = errors.New("report not found")
+	ErrDuplicateReport     = errors.New("you already have an open report about this player")
+	ErrReportClosed        = errors.New("report is already resolved or dismissed")
+	ErrInvalidReportStatus = errors.New("status must be resolved or dismissed")
+)
+
+// Report is a player's complaint about another player
+type Report struct {
+	ID          int64      `json:"id"`
+	ReporterID  string     `json:"reporter_id"`
+	ReportedID  string     `json:"reported_id"`
+	RoomID      string     `json:"room_id,omitempty"`
+	Reason      string     `json:"reason"`
+	ChatExcerpt string     `json:"chat_excerpt,omitempty"`
+	Status      string     `json:"status"`
+	Action      string     `json:"action,omitempty"` // What the resolving admin did (ban, mute, ...)
+	Note        string     `json:"note,omitempty"`
+	HandledBy   string     `json:"handled_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ClosedAt    *time.Time `json:"closed_at,omitempty"`
+}
+
+// ValidReportStatus reports whether status is one of the report statuses
+func ValidReportStatus(status string) bool {
+	switch status {
+	case ReportOpen, ReportTriaged, ReportResolved, ReportDismissed:
+		return true
+	}
+	return false
+}
+
+// CreateReport files a report. A reporter can only have one unresolved report per player.
+func CreateReport(ctx context.Context, report Report) (*Report, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	report.Status = ReportOpen
+	err := Conn(ctx).QueryRowContext(ctx, `
+		INSERT INTO player_reports (reporter_id, reported_id, room_id, reason, chat_excerpt)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (reporter_id, reported_id) WHERE status IN ('open', 'triaged') DO NOTHING
+		RETURNING id, created_at
+	`, report.ReporterID, report.ReportedID, report.RoomID, report.Reason, report.ChatExcerpt,
+	).Scan(&report.ID, &report.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDuplicateReport
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to file report about %s: %w", report.ReportedID, err)
+	}
+
+	slog.Info("Player reported", "report_id", report.ID, "reporter_id", report.ReporterID, "reported_id", report.ReportedID)
+	EmitWebhook(WebhookPlayerReported, map[string]interface{}{
+		"report_id":   report.ID,
+		"reporter_id": report.ReporterID,
+		"reported_id": report.ReportedID,
+		"room_id":     report.RoomID,
+		"reason":      report.Reason,
+	})
+	return &report, nil
+}
+
+// ListReports returns reports with the given status ("" for open and triaged), oldest first
+func ListReports(ctx context.Context, status string, limit int) ([]Report, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	query := `SELECT ` + reportColumns + ` FROM player_reports WHERE status = ANY($1) ORDER BY created_at, id LIMIT $2`
+	statuses := []string{ReportOpen, ReportTriaged}
+	if status != "" {
+		statuses = []string{status}
+	}
+
+	rows, err := Conn(ctx).QueryContext(ctx, query, pq.Array(statuses), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports := make([]Report, 0)
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, *report)
+	}
+	return reports, rows.Err()
+}
+
+// GetReport returns one report
+func GetReport(ctx context.Context, id int64) (*Report, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	report, err := scanReport(Conn(ctx).QueryRowContext(ctx,
+		`SELECT `+reportColumns+` FROM player_reports WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReportNotFound
+	}
+	return report, err
+}
+
+// TriageReport marks an open report as under review by an admin
+func TriageReport(ctx context.Context, id int64, handledBy, note string) (*Report, error) {
+	return updateReport(ctx, id, ReportTriaged, "", handledBy, note)
+}
+
+// CloseReport resolves or dismisses an open or triaged report, recording what was done
+func CloseReport(ctx context.Context, id int64, status, action, handledBy, note string) (*Report, error) {
+	if status != ReportResolved && status != ReportDismissed {
+		return nil, ErrInvalidReportStatus
+	}
+	return updateReport(ctx, id, status, action, handledBy, note)
+}
+
+// updateReport moves an unresolved report to status
+func updateReport(ctx context.Context, id int64, status, action, handledBy, note string) (*Report, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	report, err := scanReport(Conn(ctx).QueryRowContext(ctx, `
+		UPDATE player_reports
+		SET status = $2, action = $3, handled_by = $4, note = $5,
+			closed_at = CASE WHEN $2 IN ('resolved', 'dismissed') THEN NOW() END
+		WHERE id = $1 AND status IN ('open', 'triaged')
+		RETURNING `+reportColumns, id, status, action, handledBy, note))
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, getErr := GetReport(ctx, id); getErr != nil {
+			return nil, getErr
+		}
+		return nil, ErrReportClosed
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update report %d: %w", id, err)
+	}
+
+	slog.Info("Report updated", "report_id", id, "status", status, "action", action, "handled_by", handledBy)
+	return report, nil
+}
+
+const reportColumns = `id, reporter_id, reported_id, room_id, reason, chat_excerpt, status, action, note, handled_by, created_at, closed_at`
+
+// scanReport reads a row selected with reportColumns
+func scanReport(row interface{ Scan(...interface{}) error }) (*Report, error) {
+	var report Report
+	var closedAt sql.NullTime
+	err := row.Scan(&report.ID, &report.ReporterID, &report.ReportedID, &report.RoomID, &report.Reason,
+		&report.ChatExcerpt, &report.Status, &report.Action, &report.Note, &report.HandledBy,
+		&report.CreatedAt, &closedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan report: %w", err)
+	}
+	if closedAt.Valid {
+		report.ClosedAt = &closedAt.Time
+	}
+	return &report, nil
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DeviceIDHeader is an optional client-supplied device identifier
+const DeviceIDHeader = "X-Device-ID"
+
+// RequestIDHeader carries the request ID: echoed on every response, and accepted from a
+// proxy or client that already assigned one
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds request IDs accepted from the X-Request-ID header
+const maxRequestIDLength = 64
+
+// ClientIP returns the caller's IP. X-Forwarded-For is only honored when the connection
+// comes from a proxy listed in TRUSTED_PROXIES (IPs or CIDRs): the client is then the
+// rightmost hop that isn't one of those proxies. Anyone else could put any address there.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	forwarded := r.Header.Values("X-Forwarded-For")
+	if len(forwarded) == 0 || !isTrustedProxy(host) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(forwarded, ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break // Garbage in the header; trust only what came before it
+		}
+		host = hop
+		if !isTrustedProxy(hop) {
+			break
+		}
+	}
+	return host
+}
+
+var (
+	trustedProxies     []*net.IPNet
+	trustedProxiesOnce sync.Once
+)
+
+// parseTrustedProxy parses a TRUSTED_PROXIES entry, a single IP or a CIDR
+func parseTrustedProxy(entry string) (*net.IPNet, error) {
+	if !strings.Contains(entry, "/") {
+		if strings.Contains(entry, ":") {
+			entry += "/128"
+		} else {
+			entry += "/32"
+		}
+	}
+	_, network, err := net.ParseCIDR(entry)
+	return network, err
+}
+
+// isTrustedProxy reports whether ip is in TRUSTED_PROXIES
+func isTrustedProxy(ip string) bool {
+	trustedProxiesOnce.Do(func() {
+		// Entries were checked by AppConfig.Validate
+		for _, entry := range settings.Security.TrustedProxies {
+			if network, err := parseTrustedProxy(entry); err == nil {
+				trustedProxies = append(trustedProxies, network)
+			}
+		}
+	})
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// incomingRequestID returns the request's X-Request-ID if it's safe to log and echo
+// (letters, digits, '-', '_', and '.'), or "" so a new one is generated
+func incomingRequestID(r *http.Request) string {
+	id := r.Header.Get(RequestIDHeader)
+	if id == "" || len(id) > maxRequestIDLength {
+		return ""
+	}
+	for _, c := range id {
+		isAlnum := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+		if !isAlnum && c != '-' && c != '_' && c != '.' {
+			return ""
+		}
+	}
+	return id
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLayoutNotFound is returned when no stored layout has the requested ID
+var ErrLayoutNotFound = errors.New("layout not found")
+
+// RoomLayoutRow is one stored room layout, with its definition serialized as JSON
+type RoomLayoutRow struct {
+	ID         string
+	Name       string
+	Definition []byte
+	UpdatedAt  time.Time
+}
+
+// GetRoomLayout returns the stored layout with the given ID
+func GetRoomLayout(ctx context.Context, id string) (*RoomLayoutRow, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	row := RoomLayoutRow{ID: id}
+	err := Conn(ctx).QueryRowContext(ctx,
+		`SELECT name, definition, updated_at FROM room_layouts WHERE id = $1`, id,
+	).Scan(&row.Name, &row.Definition, &row.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrLayoutNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get layout %s: %w", id, err)
+	}
+	return &row, nil
+}
+
+// ListRoomLayouts returns every stored layout ordered by ID
+func ListRoomLayouts(ctx context.Context) ([]RoomLayoutRow, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := Conn(ctx).QueryContext(ctx,
+		`SELECT id, name, definition, updated_at FROM room_layouts ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list layouts: %w", err)
+	}
+	defer rows.Close()
+
+	var layouts []RoomLayoutRow
+	for rows.Next() {
+		var row RoomLayoutRow
+		if err := rows.Scan(&row.ID, &row.Name, &row.Definition, &row.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan layout: %w", err)
+		}
+		layouts = append(layouts, row)
+	}
+	return layouts, rows.Err()
+}
+
+// SaveRoomLayout creates or replaces a stored layout
+func SaveRoomLayout(ctx context.Context, row RoomLayoutRow) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	_, err := Conn(ctx).ExecContext(ctx, `
+		INSERT INTO room_layouts (id, name, definition) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, definition = EXCLUDED.definition, updated_at = NOW()
+	`, row.ID, row.Name, row.Definition)
+	if err != nil {
+		return fmt.Errorf("failed to save layout %s: %w", row.ID, err)
+	}
+	return nil
+}
+
+// DeleteRoomLayout removes a stored layout
+func DeleteRoomLayout(ctx context.Context, id string) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	result, err := Conn(ctx).ExecContext(ctx, `DELETE FROM room_layouts WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete layout %s: %w", id, err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrLayoutNotFound
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// RoomSnapshotRow is one persisted room, with its state serialized as JSON
+type RoomSnapshotRow struct {
+	RoomID       string
+	IsMain       bool
+	Snapshot     []byte
+	LastActivity time.Time
+}
+
+// SaveRoomSnapshots replaces all stored room snapshots with the given set in one transaction
+func SaveRoomSnapshots(rows []RoomSnapshotRow) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	err := WithTx(context.Background(), func(ctx context.Context) error {
+		if _, err := Conn(ctx).ExecContext(ctx, `DELETE FROM room_snapshots`); err != nil {
+			return fmt.Errorf("failed to clear room snapshots: %w", err)
+		}
+
+		for _, row := range rows {
+			_, err := Conn(ctx).ExecContext(ctx, `
+				INSERT INTO room_snapshots (room_id, is_main, snapshot, last_activity) VALUES ($1, $2, $3, $4)
+			`, row.RoomID, row.IsMain, row.Snapshot, row.LastActivity)
+			if err != nil {
+				return fmt.Errorf("failed to save snapshot for room %s: %w", row.RoomID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Saved room snapshots", "count", len(rows))
+	return nil
+}
+
+// LoadRoomSnapshots returns snapshots of rooms active within maxAge
+func LoadRoomSnapshots(maxAge time.Duration) ([]RoomSnapshotRow, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.Query(`
+		SELECT room_id, is_main, snapshot, last_activity FROM room_snapshots WHERE last_activity > $1
+	`, time.Now().Add(-maxAge))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load room snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []RoomSnapshotRow
+	for rows.Next() {
+		var row RoomSnapshotRow
+		if err := rows.Scan(&row.RoomID, &row.IsMain, &row.Snapshot, &row.LastActivity); err != nil {
+			return nil, fmt.Errorf("failed to scan room snapshot: %w", err)
+		}
+		snapshots = append(snapshots, row)
+	}
+	return snapshots, rows.Err()
+}
@@ -1,28 +1,180 @@
 package config
 
 import (
+	"context"
 	"net/http"
+	"sort"
 	"strings"
 )
 
+// Middleware wraps a handler with behavior shared by several routes
+type Middleware func(http.HandlerFunc) http.HandlerFunc
+
+// scopedMiddleware applies to routes whose path starts with pathPrefix
+type scopedMiddleware struct {
+	pathPrefix string
+	middleware Middleware
+}
+
+// route holds the handlers registered for one path
+type route struct {
+	methods map[string]http.HandlerFunc // Per-method handlers from Get/Post/Put/Delete
+	any     http.HandlerFunc            // HandleFunc handler; serves every method
+}
+
+// handler returns the handler for method, or nil if the route doesn't accept it.
+// GET handlers also answer HEAD.
+func (rt *route) handler(method string) http.HandlerFunc {
+	if handler, exists := rt.methods[method]; exists {
+		return handler
+	}
+	if method == http.MethodHead {
+		if handler, exists := rt.methods[http.MethodGet]; exists {
+			return handler
+		}
+	}
+	return rt.any
+}
+
+// allowed lists the methods the route accepts, for the Allow header
+func (rt *route) allowed() string {
+	methods := make([]string, 0, len(rt.methods)+1)
+	for method := range rt.methods {
+		methods = append(methods, method)
+	}
+	if _, hasGet := rt.methods[http.MethodGet]; hasGet {
+		methods = append(methods, http.MethodHead)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
+
+// patternRoute is a route with {param} segments, e.g. /rooms/{roomID}
+type patternRoute struct {
+	segments []string
+	route    *route
+}
+
+// pathParamsKey is the context key for a request's path parameters
+type pathParamsKey struct{}
+
 // Router represents our custom router
 type Router struct {
-	routes map[string]http.HandlerFunc
-	prefix string
+	routes     map[string]*route // Every route by full path, patterns included
+	patterns   []patternRoute    // Tried in registration order when no exact route matches
+	prefix     string
+	middleware []scopedMiddleware
 }
 
 // NewRouter creates a new router instance
 func NewRouter(prefix string) *Router {
 	return &Router{
-		routes: make(map[string]http.HandlerFunc),
+		routes: make(map[string]*route),
 		prefix: prefix,
 	}
 }
 
-// HandleFunc adds a new route to the router
+// HandleFunc adds a route that serves every method. A {name} segment matches any single
+// non-empty segment, readable in the handler with PathParam; exact routes take precedence.
 func (r *Router) HandleFunc(path string, handler http.HandlerFunc) {
+	r.route(path).any = handler
+}
+
+// Get adds a route for GET (and HEAD) requests
+func (r *Router) Get(path string, handler http.HandlerFunc) {
+	r.Method(http.MethodGet, path, handler)
+}
+
+// Post adds a route for POST requests
+func (r *Router) Post(path string, handler http.HandlerFunc) {
+	r.Method(http.MethodPost, path, handler)
+}
+
+// Put adds a route for PUT requests
+func (r *Router) Put(path string, handler http.HandlerFunc) {
+	r.Method(http.MethodPut, path, handler)
+}
+
+// Delete adds a route for DELETE requests
+func (r *Router) Delete(path string, handler http.HandlerFunc) {
+	r.Method(http.MethodDelete, path, handler)
+}
+
+// Method adds a route for one HTTP method. Requests to the path with a method that has no
+// handler get a 405 listing the allowed methods.
+func (r *Router) Method(method, path string, handler http.HandlerFunc) {
+	r.route(path).methods[method] = handler
+}
+
+// route returns the entry for path, creating it on first registration
+func (r *Router) route(path string) *route {
 	fullPath := r.prefix + path
-	r.routes[fullPath] = handler
+	if rt, exists := r.routes[fullPath]; exists {
+		return rt
+	}
+
+	rt := &route{methods: make(map[string]http.HandlerFunc)}
+	r.routes[fullPath] = rt
+	if strings.Contains(path, "{") {
+		r.patterns = append(r.patterns, patternRoute{segments: strings.Split(fullPath, "/"), route: rt})
+	}
+	return rt
+}
+
+// PathParam returns the value of a {name} segment in the matched route, or "" if there's none
+func PathParam(r *http.Request, name string) string {
+	params, _ := r.Context().Value(pathParamsKey{}).(map[string]string)
+	return params[name]
+}
+
+// match finds the route for path, returning the values of any {param} segments
+func (r *Router) match(path string) (*route, map[string]string) {
+	if rt, exists := r.routes[path]; exists && !strings.Contains(path, "{") {
+		return rt, nil
+	}
+
+	segments := strings.Split(path, "/")
+	for _, pattern := range r.patterns {
+		if params, ok := matchSegments(pattern.segments, segments); ok {
+			return pattern.route, params
+		}
+	}
+	return nil, nil
+}
+
+// matchSegments compares a pattern against a path segment by segment
+func matchSegments(pattern, segments []string) (map[string]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, part := range pattern {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[part[1:len(part)-1]] = segments[i]
+			continue
+		}
+		if part != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// Use applies middleware to every route on the router, including its 405 responses.
+// Earlier middleware runs first.
+func (r *Router) Use(middleware ...Middleware) {
+	r.UseFor("", middleware...)
+}
+
+// UseFor applies middleware to the routes whose path (relative to the router prefix)
+// starts with pathPrefix; "" covers every route. Earlier middleware runs first.
+func (r *Router) UseFor(pathPrefix string, middleware ...Middleware) {
+	for _, mw := range middleware {
+		r.middleware = append(r.middleware, scopedMiddleware{pathPrefix: r.prefix + pathPrefix, middleware: mw})
+	}
 }
 
 // ServeHTTP implements the http.Handler interface
@@ -31,15 +183,32 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 	// Check if the path starts with our prefix
 	if !strings.HasPrefix(path, r.prefix) {
-		http.NotFound(w, req)
+		Error(w, "Not found", http.StatusNotFound)
 		return
 	}
 
-	// Look for the handler
-	if handler, exists := r.routes[path]; exists {
-		handler(w, req)
+	// Look for the route, then the handler for the method
+	rt, params := r.match(path)
+	if rt == nil {
+		Error(w, "Not found", http.StatusNotFound)
 		return
 	}
+	handler := rt.handler(req.Method)
+	if handler == nil {
+		allow := rt.allowed()
+		handler = func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Allow", allow)
+			Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
 
-	http.NotFound(w, req)
+	if params != nil {
+		req = req.WithContext(context.WithValue(req.Context(), pathParamsKey{}, params))
+	}
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		if strings.HasPrefix(path, r.middleware[i].pathPrefix) {
+			handler = r.middleware[i].middleware(handler)
+		}
+	}
+	handler(w, req)
 }
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// schemaStatements creates the tables owned by this service. The "User" table is
+// managed externally (only an index is added to it here); everything here is idempotent
+// and safe to run on every boot.
+var schemaStatements = []string{
+	`CREATE TABLE IF NOT EXISTS bans (
+		id         SERIAL PRIMARY KEY,
+		user_id    TEXT NOT NULL,
+		reason     TEXT NOT NULL DEFAULT '',
+		issued_by  TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		expires_at TIMESTAMPTZ,
+		revoked_at TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS bans_user_id_idx ON bans (user_id)`,
+	`CREATE TABLE IF NOT EXISTS referral_codes (
+		user_id    TEXT PRIMARY KEY,
+		code       TEXT NOT NULL UNIQUE,
+		created_ip TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE TABLE IF NOT EXISTS referrals (
+		id          SERIAL PRIMARY KEY,
+		referrer_id TEXT NOT NULL,
+		referee_id  TEXT NOT NULL UNIQUE,
+		code        TEXT NOT NULL,
+		ip          TEXT NOT NULL DEFAULT '',
+		device_id   TEXT NOT NULL DEFAULT '',
+		status      TEXT NOT NULL,
+		flag_reason TEXT NOT NULL DEFAULT '',
+		created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS referrals_referrer_id_idx ON referrals (referrer_id)`,
+	`CREATE INDEX IF NOT EXISTS referrals_ip_idx ON referrals (ip, created_at)`,
+	`CREATE TABLE IF NOT EXISTS room_snapshots (
+		room_id       TEXT PRIMARY KEY,
+		is_main       BOOLEAN NOT NULL DEFAULT FALSE,
+		snapshot      JSONB NOT NULL,
+		last_activity TIMESTAMPTZ NOT NULL,
+		saved_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE TABLE IF NOT EXISTS friendships (
+		requester_id TEXT NOT NULL,
+		addressee_id TEXT NOT NULL,
+		status       TEXT NOT NULL,
+		created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		responded_at TIMESTAMPTZ,
+		PRIMARY KEY (requester_id, addressee_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS friendships_addressee_idx ON friendships (addressee_id, status)`,
+	`CREATE TABLE IF NOT EXISTS player_usage (
+		user_id    TEXT NOT NULL,
+		resource   TEXT NOT NULL,
+		used       BIGINT NOT NULL DEFAULT 0,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (user_id, resource)
+	)`,
+	`CREATE TABLE IF NOT EXISTS blocks (
+		blocker_id TEXT NOT NULL,
+		blocked_id TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (blocker_id, blocked_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS chat_messages (
+		id         BIGSERIAL PRIMARY KEY,
+		room_id    TEXT NOT NULL,
+		sender_id  TEXT NOT NULL,
+		username   TEXT NOT NULL DEFAULT '',
+		text       TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS chat_messages_room_created_idx ON chat_messages (room_id, created_at DESC)`,
+	`ALTER TABLE chat_messages ADD COLUMN IF NOT EXISTS channel TEXT NOT NULL DEFAULT 'general'`,
+	`CREATE TABLE IF NOT EXISTS messages (
+		id           BIGSERIAL PRIMARY KEY,
+		sender_id    TEXT NOT NULL,
+		recipient_id TEXT NOT NULL,
+		username     TEXT NOT NULL DEFAULT '',
+		text         TEXT NOT NULL,
+		system       BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		delivered_at TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS messages_undelivered_idx ON messages (recipient_id, created_at) WHERE delivered_at IS NULL`,
+	`CREATE INDEX IF NOT EXISTS chat_messages_room_channel_created_idx ON chat_messages (room_id, channel, created_at DESC)`,
+	`CREATE TABLE IF NOT EXISTS inventory_items (
+		user_id     TEXT NOT NULL,
+		item_id     TEXT NOT NULL,
+		quantity    INTEGER NOT NULL CHECK (quantity > 0),
+		acquired_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (user_id, item_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS player_progress (
+		user_id    TEXT PRIMARY KEY,
+		xp         BIGINT NOT NULL DEFAULT 0,
+		level      INTEGER NOT NULL DEFAULT 1,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE TABLE IF NOT EXISTS daily_rewards (
+		user_id         TEXT PRIMARY KEY,
+		streak          INTEGER NOT NULL,
+		last_claim_day  DATE NOT NULL,
+		last_claimed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE TABLE IF NOT EXISTS wallets (
+		user_id    TEXT PRIMARY KEY,
+		balance    BIGINT NOT NULL DEFAULT 0 CHECK (balance >= 0),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE TABLE IF NOT EXISTS wallet_transactions (
+		id            BIGSERIAL PRIMARY KEY,
+		user_id       TEXT NOT NULL,
+		amount        BIGINT NOT NULL,
+		balance_after BIGINT NOT NULL,
+		reason        TEXT NOT NULL,
+		reference     TEXT NOT NULL DEFAULT '',
+		created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS wallet_transactions_user_idx ON wallet_transactions (user_id, id DESC)`,
+	`CREATE TABLE IF NOT EXISTS avatar_images (
+		user_id     TEXT PRIMARY KEY,
+		object_key  TEXT NOT NULL,
+		size_bytes  BIGINT NOT NULL,
+		uploaded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE TABLE IF NOT EXISTS avatars (
+		user_id    TEXT PRIMARY KEY,
+		avatar     JSONB NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE TABLE IF NOT EXISTS minigame_stats (
+		game         TEXT NOT NULL,
+		user_id      TEXT NOT NULL,
+		games_played INT NOT NULL DEFAULT 0,
+		wins         INT NOT NULL DEFAULT 0,
+		best_score   INT NOT NULL DEFAULT 0,
+		total_score  BIGINT NOT NULL DEFAULT 0,
+		last_played  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (game, user_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS minigame_stats_rank_idx ON minigame_stats (game, wins DESC, best_score DESC)`,
+	`CREATE TABLE IF NOT EXISTS player_reports (
+		id           BIGSERIAL PRIMARY KEY,
+		reporter_id  TEXT NOT NULL,
+		reported_id  TEXT NOT NULL,
+		room_id      TEXT NOT NULL DEFAULT '',
+		reason       TEXT NOT NULL,
+		chat_excerpt TEXT NOT NULL DEFAULT '',
+		status       TEXT NOT NULL DEFAULT 'open',
+		action       TEXT NOT NULL DEFAULT '',
+		note         TEXT NOT NULL DEFAULT '',
+		handled_by   TEXT NOT NULL DEFAULT '',
+		created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		closed_at    TIMESTAMPTZ
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS player_reports_unresolved_idx ON player_reports (reporter_id, reported_id) WHERE status IN ('open', 'triaged')`,
+	`CREATE INDEX IF NOT EXISTS player_reports_status_idx ON player_reports (status, created_at)`,
+	`CREATE TABLE IF NOT EXISTS audit_log (
+		id         BIGSERIAL PRIMARY KEY,
+		actor_id   TEXT NOT NULL,
+		action     TEXT NOT NULL,
+		target_id  TEXT NOT NULL DEFAULT '',
+		details    JSONB NOT NULL DEFAULT '{}',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor_id, id DESC)`,
+	`CREATE INDEX IF NOT EXISTS audit_log_target_idx ON audit_log (target_id, id DESC)`,
+	`CREATE TABLE IF NOT EXISTS account_deletions (
+		user_id      TEXT PRIMARY KEY,
+		requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		purge_after  TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS account_deletions_purge_idx ON account_deletions (purge_after)`,
+	`CREATE TABLE IF NOT EXISTS device_tokens (
+		token      TEXT PRIMARY KEY,
+		user_id    TEXT NOT NULL,
+		platform   TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS device_tokens_user_idx ON device_tokens (user_id, updated_at DESC)`,
+	`CREATE TABLE IF NOT EXISTS notification_preferences (
+		user_id          TEXT PRIMARY KEY,
+		private_messages BOOLEAN NOT NULL DEFAULT TRUE,
+		friend_requests  BOOLEAN NOT NULL DEFAULT TRUE,
+		updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE TABLE IF NOT EXISTS room_layouts (
+		id         TEXT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		definition JSONB NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE TABLE IF NOT EXISTS chat_preferences (
+		user_id     TEXT PRIMARY KEY,
+		global_chat BOOLEAN NOT NULL DEFAULT TRUE,
+		updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE TABLE IF NOT EXISTS guilds (
+		id          BIGSERIAL PRIMARY KEY,
+		name        TEXT NOT NULL,
+		tag         TEXT NOT NULL UNIQUE,
+		description TEXT NOT NULL DEFAULT '',
+		leader_id   TEXT NOT NULL,
+		created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS guilds_name_idx ON guilds (LOWER(name))`,
+	`CREATE TABLE IF NOT EXISTS guild_members (
+		user_id   TEXT PRIMARY KEY,
+		guild_id  BIGINT NOT NULL REFERENCES guilds (id) ON DELETE CASCADE,
+		role      TEXT NOT NULL,
+		joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS guild_members_guild_idx ON guild_members (guild_id)`,
+	// Private messages can be addressed by username (case-insensitive)
+	`CREATE INDEX IF NOT EXISTS user_username_lower_idx ON "User" (LOWER(username))`,
+}
+
+// ensureSchema applies schemaStatements in order
+func ensureSchema() error {
+	for _, statement := range schemaStatements {
+		if _, err := DB.Exec(statement); err != nil {
+			return fmt.Errorf("failed to apply schema statement: %w", err)
+		}
+	}
+
+	slog.Info("Database schema verified", "statements", len(schemaStatements))
+	return nil
+}
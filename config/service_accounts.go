@@ -0,0 +1,90 @@
+package config
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+const (
+	// ServiceTokenPrefix marks tokens that belong to service accounts instead of users
+	ServiceTokenPrefix = "svc_"
+	// ServicePrincipalPrefix prefixes the player ID assigned to service accounts
+	ServicePrincipalPrefix = "svc:"
+)
+
+// ServiceAccount is a non-human principal such as an event or moderation bot
+type ServiceAccount struct {
+	Name   string
+	Token  string
+	Hidden bool // Hidden accounts are left out of player lists
+}
+
+// PrincipalID returns the player ID the service account uses inside rooms
+func (sa ServiceAccount) PrincipalID() string {
+	return ServicePrincipalPrefix + sa.Name
+}
+
+var serviceAccounts struct {
+	byToken map[string]ServiceAccount
+	byID    map[string]ServiceAccount
+	once    sync.Once
+}
+
+// loadServiceAccounts parses SERVICE_ACCOUNTS ("name:token[:hidden],...")
+func loadServiceAccounts() {
+	serviceAccounts.byToken = make(map[string]ServiceAccount)
+	serviceAccounts.byID = make(map[string]ServiceAccount)
+
+	for _, entry := range GetEnvList("SERVICE_ACCOUNTS") {
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || parts[0] == "" || !strings.HasPrefix(parts[1], ServiceTokenPrefix) {
+			slog.Warn("Ignoring malformed service account entry", "name", parts[0])
+			continue
+		}
+
+		account := ServiceAccount{
+			Name:   parts[0],
+			Token:  parts[1],
+			Hidden: len(parts) > 2 && parts[2] == "hidden",
+		}
+		serviceAccounts.byToken[account.Token] = account
+		serviceAccounts.byID[account.PrincipalID()] = account
+	}
+
+	if len(serviceAccounts.byToken) > 0 {
+		slog.Info("Loaded service accounts", "count", len(serviceAccounts.byToken))
+	}
+}
+
+// ResolvePrincipal maps a client token to the player ID it acts as.
+// User tokens are their own player ID; service tokens must match a configured account.
+func ResolvePrincipal(token string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+	if strings.HasPrefix(token, ServicePrincipalPrefix) {
+		return "", false // Principal IDs can't be used to impersonate service accounts
+	}
+	if !strings.HasPrefix(token, ServiceTokenPrefix) {
+		return token, true
+	}
+
+	serviceAccounts.once.Do(loadServiceAccounts)
+	account, exists := serviceAccounts.byToken[token]
+	if !exists {
+		return "", false
+	}
+	return account.PrincipalID(), true
+}
+
+// GetServiceAccount returns the service account behind a principal ID, if any
+func GetServiceAccount(playerID string) (ServiceAccount, bool) {
+	if !strings.HasPrefix(playerID, ServicePrincipalPrefix) {
+		return ServiceAccount{}, false
+	}
+
+	serviceAccounts.once.Do(loadServiceAccounts)
+	account, exists := serviceAccounts.byID[playerID]
+	return account, exists
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// BlobStore holds uploaded files. Keys are slash-separated paths ("avatars/ab12.png");
+// Put returns the URL clients should load the file from.
+type BlobStore interface {
+	Name() string
+	Put(ctx context.Context, key, contentType string, data []byte) (string, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Upload storage selection (STORAGE_BACKEND)
+const (
+	StorageLocal = "local" // Files under UPLOAD_DIR, served by this process (default)
+	StorageS3    = "s3"    // S3 or an S3-compatible service (see NewS3BlobStoreFromEnv)
+)
+
+var (
+	blobStore   BlobStore
+	blobStoreMu sync.RWMutex
+)
+
+// InitStorage sets up the store chosen by STORAGE_BACKEND. When unset, S3 is used if
+// S3_BUCKET is configured and local disk otherwise.
+func InitStorage() error {
+	kind := strings.ToLower(os.Getenv("STORAGE_BACKEND"))
+	if kind == "" {
+		kind = StorageLocal
+		if os.Getenv("S3_BUCKET") != "" {
+			kind = StorageS3
+		}
+	}
+
+	var store BlobStore
+	var err error
+	switch kind {
+	case StorageLocal:
+		store, err = NewLocalBlobStore(GetEnvString("UPLOAD_DIR", "uploads"), GetEnvString("UPLOAD_PUBLIC_URL", "/uploads/"))
+	case StorageS3:
+		store, err = NewS3BlobStoreFromEnv()
+	default:
+		return fmt.Errorf("unknown STORAGE_BACKEND %q (expected local or s3)", kind)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set up %s storage: %w", kind, err)
+	}
+
+	SetBlobStore(store)
+	slog.Info("Upload storage initialized", "storage", store.Name())
+	return nil
+}
+
+// GetBlobStore returns the active store, or nil before InitStorage or SetBlobStore runs
+func GetBlobStore() BlobStore {
+	blobStoreMu.RLock()
+	defer blobStoreMu.RUnlock()
+	return blobStore
+}
+
+// SetBlobStore replaces the active store
+func SetBlobStore(store BlobStore) {
+	blobStoreMu.Lock()
+	defer blobStoreMu.Unlock()
+	blobStore = store
+}
+
+// UploadsHandler serves files from the local store at its public path, or returns nil when
+// uploads live elsewhere
+func UploadsHandler() (string, http.Handler) {
+	local, ok := GetBlobStore().(*LocalBlobStore)
+	if !ok || !strings.HasPrefix(local.publicURL, "/") {
+		return "", nil
+	}
+	return local.publicURL, http.StripPrefix(local.publicURL, http.FileServer(http.Dir(local.dir)))
+}
+
+// LocalBlobStore writes files to a directory on disk
+type LocalBlobStore struct {
+	dir       string
+	publicURL string // Prefix for returned URLs, ending in "/"
+}
+
+// NewLocalBlobStore creates dir if needed; URLs are publicURL followed by the key
+func NewLocalBlobStore(dir, publicURL string) (*LocalBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(publicURL, "/") {
+		publicURL += "/"
+	}
+	return &LocalBlobStore{dir: dir, publicURL: publicURL}, nil
+}
+
+func (s *LocalBlobStore) Name() string { return StorageLocal }
+
+func (s *LocalBlobStore) Put(_ context.Context, key, _ string, data []byte) (string, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	return s.publicURL + key, nil
+}
+
+func (s *LocalBlobStore) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// path maps a key to a file under the store's directory, rejecting keys that escape it
+func (s *LocalBlobStore) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
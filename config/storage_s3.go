@@ -0,0 +1,181 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3BlobStore writes objects to an S3 bucket with SigV4-signed requests. Objects should be
+// publicly readable through a bucket policy or a CDN in front of S3_PUBLIC_URL.
+type S3BlobStore struct {
+	bucket       string
+	region       string
+	endpoint     *url.URL // Custom endpoint (MinIO, R2, ...) uses path-style URLs
+	publicURL    string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	client       *http.Client
+}
+
+// NewS3BlobStoreFromEnv configures a store from S3_BUCKET, S3_REGION (default us-east-1),
+// optional S3_ENDPOINT and S3_PUBLIC_URL, and the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,
+// and AWS_SESSION_TOKEN credentials.
+func NewS3BlobStoreFromEnv() (*S3BlobStore, error) {
+	store := &S3BlobStore{
+		bucket:       os.Getenv("S3_BUCKET"),
+		region:       GetEnvString("S3_REGION", "us-east-1"),
+		publicURL:    os.Getenv("S3_PUBLIC_URL"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		client:       &http.Client{Timeout: 30 * time.Second},
+	}
+	if store.bucket == "" {
+		return nil, errors.New("S3_BUCKET is required")
+	}
+	if store.accessKey == "" || store.secretKey == "" {
+		return nil, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required")
+	}
+	if endpoint := os.Getenv("S3_ENDPOINT"); endpoint != "" {
+		parsed, err := url.Parse(endpoint)
+		if err != nil || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid S3_ENDPOINT %q", endpoint)
+		}
+		store.endpoint = parsed
+	}
+	if store.publicURL != "" && !strings.HasSuffix(store.publicURL, "/") {
+		store.publicURL += "/"
+	}
+	return store, nil
+}
+
+func (s *S3BlobStore) Name() string { return StorageS3 }
+
+func (s *S3BlobStore) Put(ctx context.Context, key, contentType string, data []byte) (string, error) {
+	objectURL := s.objectURL(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Cache-Control", "public, max-age=31536000, immutable") // Keys are never reused
+	if err := s.do(req, data); err != nil {
+		return "", fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+
+	if s.publicURL != "" {
+		return s.publicURL + key, nil
+	}
+	return objectURL, nil
+}
+
+func (s *S3BlobStore) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+	if err := s.do(req, nil); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// objectURL is the key's address: virtual-hosted on AWS, path-style on custom endpoints
+func (s *S3BlobStore) objectURL(key string) string {
+	if s.endpoint != nil {
+		return strings.TrimSuffix(s.endpoint.String(), "/") + "/" + s.bucket + "/" + key
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, key)
+}
+
+// do signs and sends req, treating any non-2xx response as an error
+func (s *S3BlobStore) do(req *http.Request, payload []byte) error {
+	s.sign(req, payload, time.Now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// sign adds AWS Signature Version 4 headers for the s3 service
+func (s *S3BlobStore) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	// Host plus every x-amz-* header is signed
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
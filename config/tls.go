@@ -0,0 +1,77 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Native HTTPS so clients can reach wss:// without a fronting proxy. Either set
+// TLS_CERT_FILE and TLS_KEY_FILE, or list domains in TLS_AUTOCERT_DOMAINS to get Let's
+// Encrypt certificates automatically (cached in TLS_AUTOCERT_CACHE_DIR, default "certs";
+// TLS_AUTOCERT_EMAIL is passed to the CA). Autocert answers HTTP-01 challenges on
+// TLS_HTTP_ADDR (default ":80"), which also redirects plain HTTP to HTTPS.
+const DefaultAutocertCacheDir = "certs"
+
+// TLSSettings describes how the server terminates TLS
+type TLSSettings struct {
+	CertFile string
+	KeyFile  string
+	Autocert *autocert.Manager
+}
+
+// LoadTLSSettings reads the TLS configuration; nil means serve plain HTTP
+func LoadTLSSettings() (*TLSSettings, error) {
+	certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	domains := GetEnvList("TLS_AUTOCERT_DOMAINS")
+
+	switch {
+	case certFile != "" && len(domains) > 0:
+		return nil, errors.New("set either TLS_CERT_FILE/TLS_KEY_FILE or TLS_AUTOCERT_DOMAINS, not both")
+	case (certFile == "") != (keyFile == ""):
+		return nil, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	case certFile != "":
+		return &TLSSettings{CertFile: certFile, KeyFile: keyFile}, nil
+	case len(domains) > 0:
+		cacheDir := os.Getenv("TLS_AUTOCERT_CACHE_DIR")
+		if cacheDir == "" {
+			cacheDir = DefaultAutocertCacheDir
+		}
+		return &TLSSettings{Autocert: &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(domains...),
+			Cache:      autocert.DirCache(cacheDir),
+			Email:      os.Getenv("TLS_AUTOCERT_EMAIL"),
+		}}, nil
+	}
+	return nil, nil
+}
+
+// ListenAndServe serves HTTPS on the server's address
+func (t *TLSSettings) ListenAndServe(server *http.Server) error {
+	if t.Autocert != nil {
+		server.TLSConfig = t.Autocert.TLSConfig()
+		return server.ListenAndServeTLS("", "")
+	}
+	return server.ListenAndServeTLS(t.CertFile, t.KeyFile)
+}
+
+// ChallengeServer returns the plain-HTTP server for ACME HTTP-01 challenges and redirects
+// to HTTPS, or nil when certificates come from files
+func (t *TLSSettings) ChallengeServer() *http.Server {
+	if t.Autocert == nil {
+		return nil
+	}
+	addr := os.Getenv("TLS_HTTP_ADDR")
+	if addr == "" {
+		addr = ":80"
+	}
+	return &http.Server{
+		Addr:              addr,
+		Handler:           t.Autocert.HTTPHandler(nil),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OpenTelemetry tracing. Spans are exported over OTLP/HTTP when OTEL_EXPORTER_OTLP_ENDPOINT
+// (or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT) is set; otherwise the global provider stays a
+// no-op and spans cost next to nothing. OTEL_SERVICE_NAME and the standard
+// OTEL_TRACES_SAMPLER variables are honored.
+const (
+	TracerName         = "velvet"
+	DefaultServiceName = "velvet-backend"
+)
+
+var tracerProvider *sdktrace.TracerProvider
+
+// InitTracing installs the global tracer provider and W3C trace-context propagation
+func InitTracing() error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return nil
+	}
+
+	exporter, err := otlptracehttp.New(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		return fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	tracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	slog.Info("Tracing enabled", "service", serviceName)
+	return nil
+}
+
+// ShutdownTracing flushes buffered spans to the exporter
+func ShutdownTracing(ctx context.Context) error {
+	if tracerProvider == nil {
+		return nil
+	}
+	return tracerProvider.Shutdown(ctx)
+}
+
+// StartSpan starts a span named name as a child of any span in ctx
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records err (if any) on the span and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// ExtractTraceContext returns ctx with the caller's trace context from the request headers
+func ExtractTraceContext(ctx context.Context, r *http.Request) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(r.Header))
+}
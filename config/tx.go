@@ -0,0 +1,123 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Querier is what *sql.DB and *sql.Tx have in common, so a query can run standalone or as
+// part of a transaction
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txState is the transaction carried in a context by WithTx
+type txState struct {
+	tx          *sql.Tx
+	savepoints  int
+	afterCommit []func()
+}
+
+type txKey struct{}
+
+// WithTx runs fn inside a transaction, committing when fn returns nil and rolling back when
+// it returns an error or panics. fn must do its queries through Conn(ctx). A WithTx nested in
+// another runs in a savepoint, so its failure can be handled without aborting the outer
+// transaction.
+func WithTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return withSavepoint(ctx, state, fn)
+	}
+	// Without a database (in-memory stores in tests) there is nothing to make atomic
+	if DB == nil {
+		return fn(ctx)
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	state := &txState{tx: tx}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, callback := range state.afterCommit {
+		callback()
+	}
+	return nil
+}
+
+// withSavepoint runs fn in a savepoint of the enclosing transaction
+func withSavepoint(ctx context.Context, state *txState, fn func(ctx context.Context) error) error {
+	state.savepoints++
+	name := fmt.Sprintf("sp_%d", state.savepoints)
+	callbacks := len(state.afterCommit)
+	if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	// Undo the savepoint's writes and forget the side effects it queued
+	rollback := func() {
+		state.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+		state.afterCommit = state.afterCommit[:callbacks]
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		rollback()
+		return err
+	}
+	if _, err := state.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	return nil
+}
+
+// Conn returns the transaction WithTx put in ctx, or the shared pool outside one
+func Conn(ctx context.Context) Querier {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+	return DB
+}
+
+// txFromContext returns the transaction in ctx, if any
+func txFromContext(ctx context.Context) (*sql.Tx, bool) {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		return nil, false
+	}
+	return state.tx, true
+}
+
+// AfterCommit runs fn once the transaction in ctx commits (never, if it rolls back), or
+// right away outside a transaction. Use it for side effects such as notifications that must
+// not happen for writes that end up discarded.
+func AfterCommit(ctx context.Context, fn func()) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		state.afterCommit = append(state.afterCommit, fn)
+		return
+	}
+	fn()
+}
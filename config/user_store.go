@@ -0,0 +1,167 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ErrUserNotFound is returned when no account has the given user ID
+var ErrUserNotFound = errors.New("user not found")
+
+// User is a player account
+type User struct {
+	ID         string
+	Username   string
+	Gender     string
+	Email      string
+	ProfilePic string
+	LastRoom   string  // Empty when the user hasn't joined a room yet
+	Avatar     *Avatar // Nil until the user customizes it
+}
+
+// UserStore persists player accounts. The Postgres store is installed by InitDB; tests and
+// alternative backends can swap in another with SetUserStore.
+type UserStore interface {
+	// Exists reports whether an account with the ID exists
+	Exists(ctx context.Context, userID string) (bool, error)
+	// Get returns the account, or ErrUserNotFound
+	Get(ctx context.Context, userID string) (*User, error)
+	// FindByUsername returns the IDs of up to limit accounts with the username, ignoring
+	// case and accounts scheduled for deletion. Usernames aren't unique, so there may be several.
+	FindByUsername(ctx context.Context, username string, limit int) ([]string, error)
+	// Upsert creates the account or updates its profile (LastRoom and Avatar are left
+	// alone) and reports whether it was created
+	Upsert(ctx context.Context, user User) (bool, error)
+	// SetLastRoom records the room the user last joined, or returns ErrUserNotFound
+	SetLastRoom(ctx context.Context, userID, roomID string) error
+	// SetAvatar saves the user's avatar, or returns ErrUserNotFound
+	SetAvatar(ctx context.Context, userID string, avatar Avatar) error
+	// SetProfilePic points the user's profile picture at url, or returns ErrUserNotFound
+	SetProfilePic(ctx context.Context, userID, url string) error
+	// Delete removes the account, or returns ErrUserNotFound
+	Delete(ctx context.Context, userID string) error
+	// Close releases the store's resources
+	Close() error
+}
+
+var (
+	userStore   UserStore
+	userStoreMu sync.RWMutex
+)
+
+// GetUserStore returns the active store (in-memory until InitDB or SetUserStore runs)
+func GetUserStore() UserStore {
+	userStoreMu.Lock()
+	defer userStoreMu.Unlock()
+	if userStore == nil {
+		userStore = NewMemoryUserStore()
+	}
+	return userStore
+}
+
+// SetUserStore replaces the active store
+func SetUserStore(store UserStore) {
+	userStoreMu.Lock()
+	defer userStoreMu.Unlock()
+	userStore = store
+}
+
+// MemoryUserStore keeps accounts in process memory, for tests and local development
+type MemoryUserStore struct {
+	users map[string]User
+	mu    sync.RWMutex
+}
+
+// NewMemoryUserStore returns an empty in-memory store
+func NewMemoryUserStore() *MemoryUserStore {
+	return &MemoryUserStore{users: make(map[string]User)}
+}
+
+func (s *MemoryUserStore) Exists(_ context.Context, userID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, exists := s.users[userID]
+	return exists, nil
+}
+
+func (s *MemoryUserStore) Get(_ context.Context, userID string) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (s *MemoryUserStore) FindByUsername(_ context.Context, username string, limit int) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var ids []string
+	for id, user := range s.users {
+		if len(ids) < limit && strings.EqualFold(user.Username, username) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (s *MemoryUserStore) Upsert(_ context.Context, user User) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, exists := s.users[user.ID]
+	user.LastRoom = existing.LastRoom
+	user.Avatar = existing.Avatar
+	s.users[user.ID] = user
+	return !exists, nil
+}
+
+func (s *MemoryUserStore) SetLastRoom(_ context.Context, userID, roomID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, exists := s.users[userID]
+	if !exists {
+		return ErrUserNotFound
+	}
+	user.LastRoom = roomID
+	s.users[userID] = user
+	return nil
+}
+
+func (s *MemoryUserStore) SetAvatar(_ context.Context, userID string, avatar Avatar) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, exists := s.users[userID]
+	if !exists {
+		return ErrUserNotFound
+	}
+	user.Avatar = &avatar
+	s.users[userID] = user
+	return nil
+}
+
+func (s *MemoryUserStore) SetProfilePic(_ context.Context, userID, url string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, exists := s.users[userID]
+	if !exists {
+		return ErrUserNotFound
+	}
+	user.ProfilePic = url
+	s.users[userID] = user
+	return nil
+}
+
+func (s *MemoryUserStore) Delete(_ context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.users[userID]; !exists {
+		return ErrUserNotFound
+	}
+	delete(s.users, userID)
+	return nil
+}
+
+func (s *MemoryUserStore) Close() error { return nil }
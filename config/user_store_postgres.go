@@ -0,0 +1,172 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// PostgresUserStore keeps accounts in the "User" table
+type PostgresUserStore struct {
+	db             *sql.DB
+	updateLastRoom *sql.Stmt // Prepared: last_room is written on every join
+}
+
+// NewPostgresUserStore prepares the store's statements on db
+func NewPostgresUserStore(db *sql.DB) (*PostgresUserStore, error) {
+	// Try to deallocate any existing prepared statements to avoid conflicts
+	if _, err := db.Exec("DEALLOCATE ALL"); err != nil {
+		slog.Warn("Failed to deallocate existing prepared statements", "error", err)
+	}
+
+	updateLastRoom, err := db.Prepare(`UPDATE "User" SET last_room = $1 WHERE "userId" = $2`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare updateLastRoom statement: %w", err)
+	}
+	return &PostgresUserStore{db: db, updateLastRoom: updateLastRoom}, nil
+}
+
+// conn returns the transaction in ctx (see WithTx) or the store's pool
+func (s *PostgresUserStore) conn(ctx context.Context) Querier {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx
+	}
+	return s.db
+}
+
+func (s *PostgresUserStore) Exists(ctx context.Context, userID string) (bool, error) {
+	var exists bool
+	err := s.conn(ctx).QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM "User" WHERE "userId" = $1)`, userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check user %s: %w", userID, err)
+	}
+	return exists, nil
+}
+
+func (s *PostgresUserStore) Get(ctx context.Context, userID string) (*User, error) {
+	user := User{ID: userID}
+	var lastRoom *string
+	var avatar []byte
+	err := s.conn(ctx).QueryRowContext(ctx, `
+		SELECT u.username, u.gender, u.email, u.profile_pic, u.last_room, a.avatar
+		FROM "User" u LEFT JOIN avatars a ON a.user_id = u."userId"
+		WHERE u."userId" = $1
+	`, userID).Scan(&user.Username, &user.Gender, &user.Email, &user.ProfilePic, &lastRoom, &avatar)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %s: %w", userID, err)
+	}
+	if lastRoom != nil {
+		user.LastRoom = *lastRoom
+	}
+	if avatar != nil {
+		user.Avatar = &Avatar{}
+		if err := json.Unmarshal(avatar, user.Avatar); err != nil {
+			return nil, fmt.Errorf("failed to decode avatar for user %s: %w", userID, err)
+		}
+	}
+	return &user, nil
+}
+
+func (s *PostgresUserStore) FindByUsername(ctx context.Context, username string, limit int) ([]string, error) {
+	rows, err := s.conn(ctx).QueryContext(ctx, `
+		SELECT "userId" FROM "User" u WHERE LOWER(u.username) = LOWER($1)
+			AND NOT EXISTS (SELECT 1 FROM account_deletions d WHERE d.user_id = u."userId")
+		LIMIT $2
+	`, username, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find users named %q: %w", username, err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan user id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (s *PostgresUserStore) Upsert(ctx context.Context, user User) (bool, error) {
+	// xmax = 0 only for freshly inserted rows, which tells registration apart from updates
+	var inserted bool
+	err := s.conn(ctx).QueryRowContext(ctx, `
+		INSERT INTO "User" ("userId", username, gender, email, profile_pic)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT ("userId") DO UPDATE SET username = $2, gender = $3, email = $4, profile_pic = $5
+		RETURNING (xmax = 0)
+	`, user.ID, user.Username, user.Gender, user.Email, user.ProfilePic).Scan(&inserted)
+	if err != nil {
+		return false, fmt.Errorf("failed to save user %s: %w", user.ID, err)
+	}
+	return inserted, nil
+}
+
+func (s *PostgresUserStore) SetLastRoom(ctx context.Context, userID, roomID string) error {
+	stmt := s.updateLastRoom
+	if tx, ok := txFromContext(ctx); ok {
+		stmt = tx.StmtContext(ctx, stmt)
+	}
+	result, err := stmt.ExecContext(ctx, roomID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update last_room: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (s *PostgresUserStore) SetAvatar(ctx context.Context, userID string, avatar Avatar) error {
+	encoded, err := json.Marshal(avatar)
+	if err != nil {
+		return fmt.Errorf("failed to encode avatar: %w", err)
+	}
+	// Selecting from "User" means unknown users insert nothing
+	result, err := s.conn(ctx).ExecContext(ctx, `
+		INSERT INTO avatars (user_id, avatar)
+		SELECT "userId", $2 FROM "User" WHERE "userId" = $1
+		ON CONFLICT (user_id) DO UPDATE SET avatar = EXCLUDED.avatar, updated_at = NOW()
+	`, userID, encoded)
+	if err != nil {
+		return fmt.Errorf("failed to save avatar for user %s: %w", userID, err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (s *PostgresUserStore) SetProfilePic(ctx context.Context, userID, url string) error {
+	result, err := s.conn(ctx).ExecContext(ctx, `UPDATE "User" SET profile_pic = $1 WHERE "userId" = $2`, url, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update profile_pic: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (s *PostgresUserStore) Delete(ctx context.Context, userID string) error {
+	result, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM "User" WHERE "userId" = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user %s: %w", userID, err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (s *PostgresUserStore) Close() error {
+	return s.updateLastRoom.Close()
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Request bodies are checked against `validate` struct tags, a comma-separated list of:
+//
+//	required     non-zero value
+//	max=N min=N  string length in characters, or numeric bounds
+//	oneof=a b c  one of the space-separated values
+//	email        an email address (empty passes unless required)
+//
+// Field errors use the field's json name.
+
+// FieldError describes one invalid field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field in a request body
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + ": " + field.Message
+	}
+	return "invalid request: " + strings.Join(messages, "; ")
+}
+
+// DecodeJSON decodes a JSON request body into dst (a struct pointer) and validates it.
+// Failures come back as a *ValidationError.
+func DecodeJSON(r *http.Request, dst interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &typeErr) && typeErr.Field != "":
+			return &ValidationError{Fields: []FieldError{{Field: typeErr.Field, Message: "must be of type " + typeErr.Type.String()}}}
+		case errors.Is(err, io.EOF):
+			return &ValidationError{Fields: []FieldError{{Field: "body", Message: "is required"}}}
+		default:
+			return &ValidationError{Fields: []FieldError{{Field: "body", Message: "is not valid JSON"}}}
+		}
+	}
+	return Validate(dst)
+}
+
+// Validate checks a struct's fields against their validate tags. Tags are parsed once per
+// type; a malformed one is reported as a plain error rather than a *ValidationError, since
+// it's a bug in the struct and not in the request.
+func Validate(v interface{}) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	rules, err := rulesFor(value.Type())
+	if err != nil {
+		return err
+	}
+	var fields []FieldError
+	for _, field := range rules {
+		if message := checkRules(value.Field(field.index), field.rules); message != "" {
+			fields = append(fields, FieldError{Field: field.name, Message: message})
+		}
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// fieldRules are the parsed validate rules of one struct field
+type fieldRules struct {
+	index int
+	name  string // JSON name
+	rules []rule
+}
+
+// rule is one parsed validate rule
+type rule struct {
+	name    string
+	arg     string
+	limit   float64  // max and min
+	options []string // oneof
+}
+
+// parsedType is a struct type's rules, or why its tags couldn't be parsed
+type parsedType struct {
+	fields []fieldRules
+	err    error
+}
+
+// parsedTypes caches a parsedType per struct type
+var parsedTypes sync.Map
+
+// rulesFor returns a struct type's rules, parsing its tags the first time the type is seen
+func rulesFor(typ reflect.Type) ([]fieldRules, error) {
+	if cached, ok := parsedTypes.Load(typ); ok {
+		parsed := cached.(parsedType)
+		return parsed.fields, parsed.err
+	}
+
+	var parsed parsedType
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" || !field.IsExported() {
+			continue
+		}
+		rules, err := parseRules(tag)
+		if err != nil {
+			parsed = parsedType{err: fmt.Errorf("validate: %s.%s: %w", typ, field.Name, err)}
+			break
+		}
+		parsed.fields = append(parsed.fields, fieldRules{index: i, name: jsonName(field), rules: rules})
+	}
+	parsedTypes.Store(typ, parsed)
+	return parsed.fields, parsed.err
+}
+
+// parseRules parses a validate tag
+func parseRules(tag string) ([]rule, error) {
+	var rules []rule
+	for _, part := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(part, "=")
+		r := rule{name: name, arg: arg}
+		switch name {
+		case "required", "email":
+		case "max", "min":
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, fmt.Errorf("bad %s rule %q", name, part)
+			}
+			r.limit = limit
+		case "oneof":
+			if r.options = strings.Fields(arg); len(r.options) == 0 {
+				return nil, fmt.Errorf("oneof rule %q has no options", part)
+			}
+		default:
+			return nil, fmt.Errorf("unknown rule %q", part)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// checkRules returns why a value breaks its rules, or "" if it doesn't
+func checkRules(value reflect.Value, rules []rule) string {
+	for _, r := range rules {
+		switch r.name {
+		case "required":
+			if value.IsZero() {
+				return "is required"
+			}
+		case "max", "min":
+			if message := checkBound(value, r.name, r.limit, r.arg); message != "" {
+				return message
+			}
+		case "oneof":
+			if value.Kind() == reflect.String && value.String() != "" && !slices.Contains(r.options, value.String()) {
+				return "must be one of " + strings.Join(r.options, ", ")
+			}
+		case "email":
+			if value.Kind() == reflect.String && value.String() != "" {
+				if address, err := mail.ParseAddress(value.String()); err != nil || address.Address != value.String() {
+					return "must be an email address"
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// checkBound applies a min or max rule to a string's length or a number's value
+func checkBound(value reflect.Value, rule string, limit float64, arg string) string {
+	var actual float64
+	var noun string
+	switch value.Kind() {
+	case reflect.String:
+		actual = float64(utf8.RuneCountInString(value.String()))
+		noun = " characters"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		actual = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		actual = value.Float()
+	case reflect.Slice, reflect.Map:
+		actual = float64(value.Len())
+		noun = " items"
+	default:
+		return ""
+	}
+
+	if rule == "max" && actual > limit {
+		return "must be at most " + arg + noun
+	}
+	if rule == "min" && actual < limit {
+		return "must be at least " + arg + noun
+	}
+	return ""
+}
+
+// jsonName returns the name a struct field has in JSON
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
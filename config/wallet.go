@@ -0,0 +1,161 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Coins. Every balance change writes a wallet_transactions row in the same transaction,
+// and the balance column's CHECK constraint keeps it from ever going negative.
+var (
+	ErrInsufficientFunds = errors.New("insufficient coins")
+	ErrInvalidAmount     = errors.New("amount must be positive")
+)
+
+const (
+	DefaultWalletHistoryLimit = 20
+	MaxWalletHistoryLimit     = 100
+
+	// pqCheckViolation is Postgres's check_violation error code
+	pqCheckViolation = "23514"
+)
+
+// WalletTransaction is one change to a player's balance
+type WalletTransaction struct {
+	ID           int64     `json:"id"`
+	Amount       int64     `json:"amount"` // Positive for credits, negative for debits
+	BalanceAfter int64     `json:"balance_after"`
+	Reason       string    `json:"reason"`              // e.g. "daily_reward", "shop_purchase", "admin"
+	Reference    string    `json:"reference,omitempty"` // What the change was for (item ID, order, ...)
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// GetBalance returns a player's coins (0 if they've never had any)
+func GetBalance(ctx context.Context, userID string) (int64, error) {
+	if DB == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+
+	var balance int64
+	err := Conn(ctx).QueryRowContext(ctx, `SELECT balance FROM wallets WHERE user_id = $1`, userID).Scan(&balance)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to get balance for user %s: %w", userID, err)
+	}
+	return balance, nil
+}
+
+// CreditCoins adds coins and returns the new balance
+func CreditCoins(ctx context.Context, userID string, amount int64, reason, reference string) (int64, error) {
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	return changeBalance(ctx, userID, amount, reason, reference)
+}
+
+// CreditCoinsOnce adds coins unless the user was already credited for the same reason and
+// reference, so a retried reward isn't paid twice. It reports whether it credited them.
+func CreditCoinsOnce(ctx context.Context, userID string, amount int64, reason, reference string) (int64, bool, error) {
+	if DB == nil {
+		return 0, false, fmt.Errorf("database not initialized")
+	}
+
+	var balance int64
+	credited := false
+	err := WithTx(ctx, func(ctx context.Context) error {
+		// Serialize credits for the same reward so two at once can't both see it unpaid
+		key := userID + ":" + reason + ":" + reference
+		if _, err := Conn(ctx).ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, key); err != nil {
+			return fmt.Errorf("failed to lock wallet credit: %w", err)
+		}
+		var paid bool
+		err := Conn(ctx).QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM wallet_transactions WHERE user_id = $1 AND reason = $2 AND reference = $3)
+		`, userID, reason, reference).Scan(&paid)
+		if err != nil {
+			return fmt.Errorf("failed to check wallet credit for user %s: %w", userID, err)
+		}
+		if paid {
+			balance, err = GetBalance(ctx, userID)
+			return err
+		}
+		balance, err = CreditCoins(ctx, userID, amount, reason, reference)
+		credited = err == nil
+		return err
+	})
+	return balance, credited, err
+}
+
+// DebitCoins removes coins and returns the new balance, or ErrInsufficientFunds
+func DebitCoins(ctx context.Context, userID string, amount int64, reason, reference string) (int64, error) {
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	return changeBalance(ctx, userID, -amount, reason, reference)
+}
+
+// changeBalance applies a signed change and records it
+func changeBalance(ctx context.Context, userID string, amount int64, reason, reference string) (int64, error) {
+	if DB == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+
+	var balance int64
+	err := WithTx(ctx, func(ctx context.Context) error {
+		err := Conn(ctx).QueryRowContext(ctx, `
+			INSERT INTO wallets (user_id, balance) VALUES ($1, $2)
+			ON CONFLICT (user_id) DO UPDATE SET balance = wallets.balance + EXCLUDED.balance, updated_at = NOW()
+			RETURNING balance
+		`, userID, amount).Scan(&balance)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == pqCheckViolation {
+			return ErrInsufficientFunds
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update balance for user %s: %w", userID, err)
+		}
+
+		_, err = Conn(ctx).ExecContext(ctx, `
+			INSERT INTO wallet_transactions (user_id, amount, balance_after, reason, reference)
+			VALUES ($1, $2, $3, $4, $5)
+		`, userID, amount, balance, reason, reference)
+		if err != nil {
+			return fmt.Errorf("failed to record wallet transaction for user %s: %w", userID, err)
+		}
+		return nil
+	})
+	return balance, err
+}
+
+// GetWalletTransactions returns up to limit of a player's transactions older than the
+// given ID (0 means the newest), newest first
+func GetWalletTransactions(ctx context.Context, userID string, before int64, limit int) ([]WalletTransaction, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := Conn(ctx).QueryContext(ctx, `
+		SELECT id, amount, balance_after, reason, reference, created_at FROM wallet_transactions
+		WHERE user_id = $1 AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`, userID, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet transactions for user %s: %w", userID, err)
+	}
+	defer rows.Close()
+
+	transactions := make([]WalletTransaction, 0, limit)
+	for rows.Next() {
+		var t WalletTransaction
+		if err := rows.Scan(&t.ID, &t.Amount, &t.BalanceAfter, &t.Reason, &t.Reference, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan wallet transaction: %w", err)
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
@@ -0,0 +1,267 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Webhook events
+const (
+	WebhookRoomCreated    = "room_created"
+	WebhookPlayerJoined   = "player_joined"
+	WebhookPlayerReported = "player_reported"
+)
+
+// Webhook request headers. The signature is hex HMAC-SHA256 of "<timestamp>.<body>" keyed
+// with WEBHOOK_SECRET, so receivers can reject forged or replayed deliveries.
+const (
+	WebhookEventHeader     = "X-Velvet-Event"
+	WebhookDeliveryHeader  = "X-Velvet-Delivery"
+	WebhookTimestampHeader = "X-Velvet-Timestamp"
+	WebhookSignatureHeader = "X-Velvet-Signature"
+)
+
+const (
+	webhookQueueSize      = 1000
+	webhookWorkers        = 4
+	webhookRequestTimeout = 10 * time.Second
+	webhookInitialBackoff = time.Second
+	webhookMaxBackoff     = time.Minute
+)
+
+// WebhookPayload is the JSON body POSTed to each webhook URL
+type WebhookPayload struct {
+	ID        string                 `json:"id"` // Same across retries, for deduplication
+	Event     string                 `json:"event"`
+	Timestamp int64                  `json:"timestamp"` // Unix ms when the event happened
+	Data      map[string]interface{} `json:"data"`
+}
+
+// webhookDelivery is one payload bound for one URL
+type webhookDelivery struct {
+	url     string
+	event   string
+	id      string
+	body    []byte
+	attempt int
+}
+
+// WebhookDispatcher signs and delivers event payloads to the configured URLs, retrying
+// failures with exponential backoff
+type WebhookDispatcher struct {
+	urls        []string
+	secret      []byte
+	events      []string // Events to send; empty sends all
+	maxAttempts int
+	client      *http.Client
+
+	queue  chan webhookDelivery
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var (
+	webhooks   *WebhookDispatcher
+	webhooksMu sync.RWMutex
+)
+
+// InitWebhooks starts the dispatcher when WEBHOOK_URLS (comma-separated) is set. Payloads
+// are signed with WEBHOOK_SECRET; WEBHOOK_EVENTS limits which events are sent and
+// WEBHOOK_MAX_ATTEMPTS (default 5) bounds retries.
+func InitWebhooks() error {
+	urls := GetEnvList("WEBHOOK_URLS")
+	if len(urls) == 0 {
+		slog.Info("Webhooks disabled (WEBHOOK_URLS not set)")
+		return nil
+	}
+	for _, raw := range urls {
+		parsed, err := url.Parse(raw)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid webhook URL %q", raw)
+		}
+	}
+	secret := os.Getenv("WEBHOOK_SECRET")
+	if secret == "" {
+		return fmt.Errorf("WEBHOOK_SECRET is required when WEBHOOK_URLS is set")
+	}
+	maxAttempts := GetEnvInt("WEBHOOK_MAX_ATTEMPTS", 5)
+	if maxAttempts < 1 {
+		return fmt.Errorf("WEBHOOK_MAX_ATTEMPTS must be at least 1")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dispatcher := &WebhookDispatcher{
+		urls:        urls,
+		secret:      []byte(secret),
+		events:      GetEnvList("WEBHOOK_EVENTS"),
+		maxAttempts: maxAttempts,
+		client:      &http.Client{Timeout: webhookRequestTimeout},
+		queue:       make(chan webhookDelivery, webhookQueueSize),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	for i := 0; i < webhookWorkers; i++ {
+		dispatcher.wg.Add(1)
+		go dispatcher.worker()
+	}
+
+	webhooksMu.Lock()
+	webhooks = dispatcher
+	webhooksMu.Unlock()
+	slog.Info("Webhooks initialized", "urls", len(urls), "events", dispatcher.events)
+	return nil
+}
+
+// CloseWebhooks stops the dispatcher; deliveries still queued or waiting to retry are dropped
+func CloseWebhooks() {
+	webhooksMu.Lock()
+	dispatcher := webhooks
+	webhooks = nil
+	webhooksMu.Unlock()
+
+	if dispatcher != nil {
+		dispatcher.cancel()
+		dispatcher.wg.Wait()
+	}
+}
+
+// EmitWebhook queues an event for every webhook URL without blocking. It's a no-op when
+// webhooks are disabled or the event is filtered out.
+func EmitWebhook(event string, data map[string]interface{}) {
+	webhooksMu.RLock()
+	dispatcher := webhooks
+	webhooksMu.RUnlock()
+	if dispatcher == nil {
+		return
+	}
+	if len(dispatcher.events) > 0 && !slices.Contains(dispatcher.events, event) {
+		return
+	}
+
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		slog.Warn("Could not generate webhook delivery ID", "error", err)
+		return
+	}
+	payload := WebhookPayload{
+		ID:        hex.EncodeToString(raw),
+		Event:     event,
+		Timestamp: time.Now().UnixMilli(),
+		Data:      data,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		slog.Warn("Could not encode webhook payload", "event", event, "error", err)
+		return
+	}
+
+	for _, target := range dispatcher.urls {
+		dispatcher.enqueue(webhookDelivery{url: target, event: event, id: payload.ID, body: body})
+	}
+}
+
+// enqueue adds a delivery to the queue, dropping it if the queue is full
+func (d *WebhookDispatcher) enqueue(delivery webhookDelivery) {
+	select {
+	case d.queue <- delivery:
+	default:
+		slog.Warn("Webhook queue full, dropping delivery", "event", delivery.event, "url", delivery.url)
+	}
+}
+
+// worker delivers queued payloads until the dispatcher closes
+func (d *WebhookDispatcher) worker() {
+	defer d.wg.Done()
+	for {
+		select {
+		case delivery := <-d.queue:
+			d.deliver(delivery)
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver POSTs one payload and schedules a retry for failures that may be transient
+func (d *WebhookDispatcher) deliver(delivery webhookDelivery) {
+	delivery.attempt++
+	retry, err := d.post(delivery)
+	if err == nil || d.ctx.Err() != nil {
+		return
+	}
+
+	logger := slog.With("event", delivery.event, "url", delivery.url, "delivery_id", delivery.id, "attempt", delivery.attempt)
+	if !retry || delivery.attempt >= d.maxAttempts {
+		logger.Warn("Webhook delivery failed, giving up", "error", err)
+		return
+	}
+
+	backoff := webhookInitialBackoff << (delivery.attempt - 1)
+	if backoff > webhookMaxBackoff {
+		backoff = webhookMaxBackoff
+	}
+	logger.Info("Webhook delivery failed, retrying", "error", err, "backoff", backoff.String())
+	time.AfterFunc(backoff, func() {
+		if d.ctx.Err() == nil {
+			d.enqueue(delivery)
+		}
+	})
+}
+
+// post sends the signed request; retry reports whether a failure is worth retrying
+func (d *WebhookDispatcher) post(delivery webhookDelivery) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(d.ctx, webhookRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.url, bytes.NewReader(delivery.body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.event)
+	req.Header.Set(WebhookDeliveryHeader, delivery.id)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+d.sign(timestamp, delivery.body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook returned %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+}
+
+// sign returns the hex HMAC-SHA256 of "<timestamp>.<body>"
+func (d *WebhookDispatcher) sign(timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, d.secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -2,19 +2,21 @@ package main
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata" // DAILY_REWARD_TIMEZONE works without system zoneinfo
 	"velvet/Player_Logic"
 	"velvet/Routing"
 
 	"velvet/config"
 
 	"github.com/joho/godotenv"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 // Note: WebSocket upgrader is defined in Player_Logic/websocket.go
@@ -22,68 +24,154 @@ import (
 func main() {
 	// Load environment variables
 	if err := godotenv.Load("config/config.env"); err != nil {
-		log.Fatal("Error loading config.env file:", err)
+		fatal("Error loading config.env file", err)
+	}
+
+	// Structured logging (LOG_LEVEL, LOG_FORMAT)
+	config.InitLogging()
+
+	// Server settings, validated up front so a bad value fails the deploy
+	appConfig, err := config.LoadAppConfig()
+	if err != nil {
+		fatal("Error loading configuration", err)
+	}
+	config.Configure(appConfig)
+	Player_Logic.Configure(appConfig)
+	Routing.Configure(appConfig)
+
+	// OpenTelemetry tracing (no-op unless an OTLP endpoint is configured)
+	if err := config.InitTracing(); err != nil {
+		fatal("Error initializing tracing", err)
 	}
 
 	// Initialize database
 	if err := config.InitDB(); err != nil {
-		log.Fatal("Error initializing database:", err)
+		fatal("Error initializing database", err)
 	}
+	config.RegisterDBMetrics()
+
+	// Accepted referrals pay both players
+	config.SetReferralRewardHook(Player_Logic.RewardReferral)
 
 	// Initialize room manager (starts cleanup routines)
 	roomManager := Player_Logic.GetRoomManager()
 
+	// Staff/moderators may use reserved room slots
+	roomManager.SetPrivilegedPlayers(appConfig.Rooms.PrivilegedPlayerIDs)
+	roomManager.AddPriorityResolver(Player_Logic.FriendsOfMembersPriority)
+	if err := roomManager.SetReservedSlots(roomManager.MainRoomID(), appConfig.Rooms.MainRoomReservedSlots); err != nil {
+		slog.Error("Error configuring main room reserved slots", "error", err)
+	}
+
+	// Optional chat translation provider
+	if url := appConfig.Chat.TranslationAPIURL; url != "" {
+		Player_Logic.SetTranslator(Player_Logic.NewHTTPTranslator(url, appConfig.Chat.TranslationAPIKey))
+		slog.Info("Chat translation enabled", "url", url)
+	}
+
+	// Storage for uploaded images (local disk or S3)
+	if err := config.InitStorage(); err != nil {
+		fatal("Error initializing upload storage", err)
+	}
+
+	// Push notifications (FCM), disabled unless credentials are configured
+	if err := config.InitPush(); err != nil {
+		fatal("Error initializing push notifications", err)
+	}
+
+	// Outbound webhooks for room and player events, disabled unless URLs are configured
+	if err := config.InitWebhooks(); err != nil {
+		fatal("Error initializing webhooks", err)
+	}
+
+	// Message broker (memory, redis, or nats) so several instances can share rooms
+	if err := config.InitBroker(); err != nil {
+		fatal("Error initializing message broker", err)
+	}
+	if err := Player_Logic.StartBackplane(); err != nil {
+		fatal("Error starting backplane", err)
+	}
+
 	// Set up graceful shutdown
 	defer func() {
-		log.Println("Starting graceful shutdown...")
+		slog.Info("Starting graceful shutdown")
+
+		// Stop relaying broadcasts between instances
+		if err := config.CloseBroker(); err != nil {
+			slog.Error("Error closing message broker", "error", err)
+		}
+
+		// Stop delivering webhooks
+		config.CloseWebhooks()
+
+		// Drop pending scheduled jobs
+		Player_Logic.GetScheduler().Shutdown()
 
 		// Shutdown room manager cleanup routines
 		roomManager.Shutdown()
 
+		// Queue unsaved XP so it's written before the database closes
+		Player_Logic.GetProgression().Flush()
+
 		// Close database connections
 		if err := config.CloseDB(); err != nil {
-			log.Printf("Error closing database: %v", err)
+			slog.Error("Error closing database", "error", err)
 		}
 
-		log.Println("Graceful shutdown completed")
-	}()
+		// Flush buffered spans
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := config.ShutdownTracing(flushCtx); err != nil {
+			slog.Error("Error shutting down tracing", "error", err)
+		}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	} else {
-		port = ":" + port
-	}
+		slog.Info("Graceful shutdown completed")
+	}()
 
 	// Create a new ServeMux
 	mux := http.NewServeMux()
 
-	// Create CORS middleware handler
-	corsHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
-		w.Header().Set("Access-Control-Expose-Headers", "*")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		mux.ServeHTTP(w, r)
-	})
-
 	// Setup routes
 	playerRouter := Routing.SetupPlayerRoutes()
 	mux.Handle("/player/", playerRouter)
 	authRouter := Routing.SetupAuthRoutes()
 	mux.Handle("/auth/", authRouter)
+	adminRouter := Routing.SetupAdminRoutes()
+	mux.Handle("/admin/", adminRouter)
+
+	// Read-only GraphQL over users, friends, rooms, and presence
+	mux.HandleFunc("/graphql", config.Recover(Routing.HandleGraphQL))
+
+	// Uploaded files, when they're kept on local disk
+	if prefix, handler := config.UploadsHandler(); handler != nil {
+		mux.Handle(prefix, handler)
+	}
+
+	// Prometheus scrape endpoint
+	if config.MetricsEnabled() {
+		mux.Handle("/metrics", config.MetricsHandler())
+	}
 
 	// Create HTTP server
 	server := &http.Server{
-		Addr:    port,
-		Handler: corsHandler,
+		Addr:    appConfig.Server.Addr,
+		Handler: tracedHandler(config.LogRequests(config.CORS(mux))),
+	}
+
+	// Optional HTTPS from certificate files or Let's Encrypt
+	tlsSettings, err := config.LoadTLSSettings()
+	if err != nil {
+		fatal("Error loading TLS settings", err)
+	}
+	var challengeServer *http.Server
+	if tlsSettings != nil {
+		challengeServer = tlsSettings.ChallengeServer()
+	}
+
+	// Internal gRPC API for other backend services (GRPC_ADDR)
+	grpcServer, err := Routing.StartGRPCServer()
+	if err != nil {
+		fatal("Error starting internal gRPC API", err)
 	}
 
 	// Channel to listen for interrupt signal to terminate server
@@ -92,24 +180,67 @@ func main() {
 
 	// Start server in a goroutine
 	go func() {
-		fmt.Printf("Server starting on port %s...\n", port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatal("Error starting server: ", err)
+		slog.Info("Server starting", "addr", server.Addr, "tls", tlsSettings != nil)
+		var err error
+		if tlsSettings != nil {
+			err = tlsSettings.ListenAndServe(server)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			fatal("Error starting server", err)
 		}
 	}()
 
+	// ACME HTTP-01 challenges and HTTP to HTTPS redirects
+	if challengeServer != nil {
+		go func() {
+			slog.Info("ACME challenge server starting", "addr", challengeServer.Addr)
+			if err := challengeServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fatal("Error starting ACME challenge server", err)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal
 	<-quit
-	log.Println("Shutting down server...")
+	slog.Info("Shutting down server")
 
 	// Create context with timeout for graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), appConfig.Server.ShutdownTimeout)
 	defer cancel()
 
+	// Warn WebSocket clients and close them cleanly (server.Shutdown leaves hijacked connections alone)
+	Player_Logic.DrainConnections(ctx, appConfig.Server.DrainCountdown)
+
 	// Gracefully shutdown the server
 	if err := server.Shutdown(ctx); err != nil {
-		log.Printf("Server forced to shutdown: %v", err)
+		slog.Error("Server forced to shutdown", "error", err)
 	}
+	if challengeServer != nil {
+		challengeServer.Shutdown(ctx)
+	}
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+
+	slog.Info("Server exited")
+}
+
+// fatal logs an error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
 
-	log.Println("Server exited")
+// tracedHandler gives each HTTP request a span; WebSocket upgrades trace their own setup
+// instead, since the request lasts as long as the connection
+func tracedHandler(handler http.Handler) http.Handler {
+	return otelhttp.NewHandler(handler, "http",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + r.URL.Path
+		}),
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return !strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+		}))
 }